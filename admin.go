@@ -0,0 +1,433 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/ziflex/rm-rf-production/pkg/accounts"
+	"github.com/ziflex/rm-rf-production/pkg/audit"
+	"github.com/ziflex/rm-rf-production/pkg/auth"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+	"github.com/ziflex/rm-rf-production/pkg/queue"
+	"github.com/ziflex/rm-rf-production/pkg/reconciliation"
+	"github.com/ziflex/rm-rf-production/pkg/scheduler"
+	"github.com/ziflex/rm-rf-production/pkg/tenants"
+)
+
+const adminUsage = `usage: rm-rf-production admin <command> [flags]
+
+commands:
+  create-tenant -id ID -name NAME
+  create-api-key -tenant ID -name NAME -scopes SCOPE[,SCOPE...]
+  revoke-api-key -id ID
+  block-account -tenant ID -id ACCOUNT_ID
+  unblock-account -tenant ID -id ACCOUNT_ID
+  run-job -name NAME       (without -name lists the jobs)
+  import-settlement -tenant ID -file PATH
+  retry-job -tenant ID -id JOB_ID   (queues a dead job again)
+`
+
+type (
+	admin struct {
+		out      io.Writer
+		keys     auth.Service
+		tenants  tenants.Service
+		accounts accounts.Service
+		audit    audit.Service
+		// jobs runs the maintenance jobs under the locks of the scheduler and records them in its history
+		jobs scheduler.Service
+		// queue holds the background jobs of the API, dead ones are retried by an operator
+		queue queue.Service
+
+		reconciliations reconciliation.Service
+	}
+)
+
+func (a *admin) run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(a.out, adminUsage)
+
+		return fmt.Errorf("missing admin command")
+	}
+
+	switch args[0] {
+	case "create-tenant":
+		return a.createTenant(ctx, args[1:])
+	case "create-api-key":
+		return a.createAPIKey(ctx, args[1:])
+	case "revoke-api-key":
+		return a.revokeAPIKey(ctx, args[1:])
+	case "block-account":
+		return a.setAccountBlocked(ctx, args[1:], true)
+	case "unblock-account":
+		return a.setAccountBlocked(ctx, args[1:], false)
+	case "run-job":
+		return a.runJob(ctx, args[1:])
+	case "import-settlement":
+		return a.importSettlement(ctx, args[1:])
+	case "retry-job":
+		return a.retryJob(ctx, args[1:])
+	default:
+		fmt.Fprint(a.out, adminUsage)
+
+		return fmt.Errorf("unknown admin command: %s", args[0])
+	}
+}
+
+func (a *admin) createTenant(ctx context.Context, args []string) error {
+	fset := flag.NewFlagSet("create-tenant", flag.ContinueOnError)
+	id := fset.String("id", "", "tenant id, lowercase letters, digits, '-' and '_'")
+	name := fset.String("name", "", "human readable tenant name")
+
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+
+	creation := tenants.TenantCreation{
+		ID:   *id,
+		Name: *name,
+	}
+
+	tenant, err := a.tenants.CreateTenant(ctx, creation)
+	a.record(ctx, "admin.createTenant", creation.ID, creation, err)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.out, "tenant %s (%s) created\n", tenant.ID, tenant.Name)
+
+	return nil
+}
+
+func (a *admin) createAPIKey(ctx context.Context, args []string) error {
+	fset := flag.NewFlagSet("create-api-key", flag.ContinueOnError)
+	tenant := fset.String("tenant", "", "tenant the key belongs to")
+	name := fset.String("name", "", "human readable key name")
+	scopes := fset.String("scopes", "", "comma separated list of scopes")
+
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+
+	creation := auth.APIKeyCreation{
+		TenantID: *tenant,
+		Name:     *name,
+	}
+
+	for _, s := range strings.Split(*scopes, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			creation.Scopes = append(creation.Scopes, auth.Scope(s))
+		}
+	}
+
+	key, err := a.keys.CreateAPIKey(ctx, creation)
+	a.record(ctx, "admin.createApiKey", creation.TenantID, creation, err)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.out, "id:     %d\n", key.ID)
+	fmt.Fprintf(a.out, "tenant: %s\n", key.TenantID)
+	fmt.Fprintf(a.out, "name:   %s\n", key.Name)
+	fmt.Fprintf(a.out, "scopes: %s\n", strings.Join(scopesToStrings(key.Scopes), ","))
+	fmt.Fprintf(a.out, "secret: %s\n", key.Secret)
+	fmt.Fprintln(a.out, "the secret is shown only once, store it securely")
+
+	return nil
+}
+
+func (a *admin) revokeAPIKey(ctx context.Context, args []string) error {
+	fset := flag.NewFlagSet("revoke-api-key", flag.ContinueOnError)
+	id := fset.Int64("id", 0, "api key id")
+
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+
+	if *id <= 0 {
+		return fmt.Errorf("invalid api key id: %d", *id)
+	}
+
+	err := a.keys.RevokeAPIKey(ctx, *id)
+	a.record(ctx, "admin.revokeApiKey", "", map[string]int64{"id": *id}, err)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.out, "api key %d revoked\n", *id)
+
+	return nil
+}
+
+func (a *admin) setAccountBlocked(ctx context.Context, args []string, blocked bool) error {
+	name := "block-account"
+	opID := "admin.blockAccount"
+
+	if !blocked {
+		name = "unblock-account"
+		opID = "admin.unblockAccount"
+	}
+
+	fset := flag.NewFlagSet(name, flag.ContinueOnError)
+	tenant := fset.String("tenant", "", "tenant the account belongs to")
+	id := fset.Int64("id", 0, "account id")
+
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+
+	if *tenant == "" {
+		return fmt.Errorf("tenant is required")
+	}
+
+	if *id <= 0 {
+		return fmt.Errorf("invalid account id: %d", *id)
+	}
+
+	ctx = common.WithTenant(ctx, *tenant)
+
+	var err error
+
+	if blocked {
+		err = a.accounts.BlockAccount(ctx, *id)
+	} else {
+		err = a.accounts.UnblockAccount(ctx, *id)
+	}
+
+	a.record(ctx, opID, *tenant, map[string]int64{"id": *id}, err)
+
+	if err != nil {
+		return err
+	}
+
+	if blocked {
+		fmt.Fprintf(a.out, "account %d blocked\n", *id)
+	} else {
+		fmt.Fprintf(a.out, "account %d unblocked\n", *id)
+	}
+
+	return nil
+}
+
+func (a *admin) runJob(ctx context.Context, args []string) error {
+	fset := flag.NewFlagSet("run-job", flag.ContinueOnError)
+	name := fset.String("name", "", "job name")
+
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+
+	if *name == "" {
+		for _, job := range a.jobs.Jobs() {
+			schedule := job.Schedule
+
+			if schedule == "" {
+				schedule = "on demand"
+			}
+
+			fmt.Fprintf(a.out, "%-20s %-15s %s\n", job.Name, schedule, job.Description)
+		}
+
+		return nil
+	}
+
+	run, err := a.jobs.Run(ctx, *name)
+	a.record(ctx, "admin.runJob", "", map[string]any{"name": *name, "run_id": run.ID}, err)
+
+	if errors.Is(err, scheduler.ErrLocked) {
+		return fmt.Errorf("job %s is running on another instance", *name)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.out, "%s: %s\n", *name, run.Result)
+
+	return nil
+}
+
+func (a *admin) importSettlement(ctx context.Context, args []string) error {
+	fset := flag.NewFlagSet("import-settlement", flag.ContinueOnError)
+	tenant := fset.String("tenant", "", "tenant the settlement file belongs to")
+	path := fset.String("file", "", "settlement file in the layout of RECONCILIATION_* settings")
+
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+
+	if *tenant == "" {
+		return fmt.Errorf("tenant is required")
+	}
+
+	if *path == "" {
+		return fmt.Errorf("file is required")
+	}
+
+	file, err := os.Open(*path)
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	ctx = common.WithTenant(ctx, *tenant)
+	name := filepath.Base(*path)
+
+	rec, err := a.reconciliations.Reconcile(ctx, reconciliation.SettlementFile{Name: name, Body: file})
+	a.record(ctx, "admin.importSettlement", *tenant, map[string]any{"file": name, "id": rec.ID}, err)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.out, "reconciliation:    %d\n", rec.ID)
+	fmt.Fprintf(a.out, "period:            %s - %s\n", rec.PeriodStart.Format(time.RFC3339), rec.PeriodEnd.Format(time.RFC3339))
+	fmt.Fprintf(a.out, "matched:           %d\n", rec.Summary.Matched)
+	fmt.Fprintf(a.out, "missing in ledger: %d\n", rec.Summary.MissingInLedger)
+	fmt.Fprintf(a.out, "missing in file:   %d\n", rec.Summary.MissingInFile)
+	fmt.Fprintf(a.out, "amount mismatch:   %d\n", rec.Summary.AmountMismatch)
+
+	return nil
+}
+
+func (a *admin) retryJob(ctx context.Context, args []string) error {
+	fset := flag.NewFlagSet("retry-job", flag.ContinueOnError)
+	tenant := fset.String("tenant", "", "tenant the job belongs to")
+	id := fset.Int64("id", 0, "dead job id")
+
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+
+	if *tenant == "" {
+		return fmt.Errorf("tenant is required")
+	}
+
+	ctx = common.WithTenant(ctx, *tenant)
+
+	job, err := a.queue.Retry(ctx, *id)
+	a.record(ctx, "admin.retryJob", *tenant, map[string]any{"id": *id}, err)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.out, "job %d (%s) queued, last error: %s\n", job.ID, job.Type, job.LastError)
+
+	return nil
+}
+
+// record writes an audit entry for the admin action.
+// Failing to do so does not undo the action, so the error is only logged.
+func (a *admin) record(ctx context.Context, opID, tenantID string, payload any, actionErr error) {
+	log := zerolog.Ctx(ctx)
+	data, err := json.Marshal(payload)
+
+	if err != nil {
+		log.Error().Err(err).Msg("failed to encode audit payload")
+	}
+
+	// entries reference existing tenants only, the ones of actions on a missing tenant
+	// are system wide and keep the requested tenant in the payload
+	if tenantID != "" && !a.tenantExists(ctx, tenantID) {
+		data = withTenant(data, tenantID)
+		tenantID = ""
+	}
+
+	entry := audit.EntryCreation{
+		TenantID:    tenantID,
+		Principal:   "admin:" + currentUser(),
+		RequestID:   newRequestID(),
+		OperationID: opID,
+		Payload:     audit.Redact(data, audit.SensitiveFields),
+		Outcome:     audit.OutcomeSuccess,
+	}
+
+	if actionErr != nil {
+		entry.Outcome = audit.OutcomeFailure
+	}
+
+	if _, err := a.audit.Record(ctx, entry); err != nil {
+		log.Error().Err(err).Str("operation_id", opID).Msg("failed to write audit entry")
+	}
+}
+
+// tenantExists reports whether the tenant exists, it is assumed to when the lookup fails.
+func (a *admin) tenantExists(ctx context.Context, id string) bool {
+	_, err := a.tenants.GetTenant(ctx, id)
+
+	if errors.Is(err, common.ErrNotFound) {
+		return false
+	}
+
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Str("tenant_id", id).Msg("failed to look up tenant")
+	}
+
+	return true
+}
+
+// withTenant adds the tenant to a JSON object payload, unless it already has one.
+func withTenant(payload []byte, tenantID string) []byte {
+	var doc map[string]any
+
+	if err := json.Unmarshal(payload, &doc); err != nil || doc == nil {
+		return payload
+	}
+
+	if _, ok := doc["tenant_id"]; ok {
+		return payload
+	}
+
+	doc["tenant_id"] = tenantID
+
+	out, err := json.Marshal(doc)
+
+	if err != nil {
+		return payload
+	}
+
+	return out
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+
+	return "unknown"
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+
+	return hex.EncodeToString(buf)
+}
+
+func scopesToStrings(scopes []auth.Scope) []string {
+	out := make([]string, 0, len(scopes))
+
+	for _, s := range scopes {
+		out = append(out, s.String())
+	}
+
+	return out
+}
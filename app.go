@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/internal/database"
+	"github.com/ziflex/rm-rf-production/internal/memory"
+	"github.com/ziflex/rm-rf-production/pkg/accounts"
+	"github.com/ziflex/rm-rf-production/pkg/audit"
+	"github.com/ziflex/rm-rf-production/pkg/auth"
+	"github.com/ziflex/rm-rf-production/pkg/disputes"
+	"github.com/ziflex/rm-rf-production/pkg/queue"
+	"github.com/ziflex/rm-rf-production/pkg/reconciliation"
+	"github.com/ziflex/rm-rf-production/pkg/scheduler"
+	"github.com/ziflex/rm-rf-production/pkg/tenants"
+	"github.com/ziflex/rm-rf-production/pkg/transactions"
+)
+
+// app holds the configuration, the database and the services shared by all commands.
+type app struct {
+	cfg    Config
+	logger zerolog.Logger
+	db     *sql.DB
+	// router serves reads from replicas, nil when none are configured.
+	router       *database.Router
+	keys         auth.Service
+	tenants      tenants.Service
+	accounts     accounts.Service
+	transactions transactions.Service
+	audit        audit.Service
+	// reconciliations is shared by the admin import and the API
+	reconciliations reconciliation.Service
+	// scheduler runs the periodic jobs of serve and the one-off ones of admin run-job
+	scheduler scheduler.Service
+	// queue runs the background jobs of the asynchronous API operations
+	queue queue.Service
+	// disputes credits the disputed purchases with transactions of its own
+	disputes disputes.Service
+}
+
+const (
+	driverPostgres = "postgres"
+	driverMemory   = "memory"
+)
+
+func newApp(ctx context.Context, cfg Config, logger zerolog.Logger) (*app, error) {
+	switch cfg.DbDriver {
+	case driverPostgres:
+		return newPostgresApp(ctx, cfg, logger)
+	case driverMemory:
+		return newMemoryApp(ctx, cfg, logger)
+	default:
+		return nil, fmt.Errorf("unknown database driver: %s", cfg.DbDriver)
+	}
+}
+
+func newPostgresApp(ctx context.Context, cfg Config, logger zerolog.Logger) (*app, error) {
+	opts := database.Options{
+		URL:              cfg.DbURL,
+		Name:             cfg.DbName,
+		Host:             cfg.DbHost,
+		Port:             cfg.DbPort,
+		User:             cfg.DbUser,
+		Pass:             cfg.DbPass,
+		SSLMode:          cfg.DbSSLMode,
+		SSLRootCert:      cfg.DbSSLRootCert,
+		SSLCert:          cfg.DbSSLCert,
+		SSLKey:           cfg.DbSSLKey,
+		ConnectTimeout:   cfg.DbConnectTimeout,
+		StatementTimeout: cfg.DbStatementTimeout,
+		ApplicationName:  cfg.DbApplicationName,
+		MaxOpenConns:     cfg.DbMaxOpenConns,
+		MaxIdleConns:     cfg.DbMaxIdleConns,
+		ConnMaxLifetime:  cfg.DbConnMaxLifetime,
+		ConnMaxIdleTime:  cfg.DbConnMaxIdleTime,
+
+		Replicas:             cfg.DbReplicaURLs,
+		ReplicaCheckInterval: cfg.DbReplicaCheckInterval,
+		ReplicaMaxLag:        cfg.DbReplicaMaxLag,
+		ReadYourWrites:       cfg.DbReadYourWrites,
+	}
+
+	db, err := database.New(opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.DbWaitTimeout > 0 {
+		logger.Info().Dur("timeout", cfg.DbWaitTimeout).Msg("waiting for database")
+
+		if err := database.WaitFor(ctx, db, cfg.DbWaitTimeout, time.Second); err != nil {
+			db.Close()
+
+			return nil, err
+		}
+	}
+
+	a := &app{
+		cfg:     cfg,
+		logger:  logger,
+		db:      db,
+		keys:    auth.NewService(db, database.NewAPIKeysRepository()),
+		tenants: tenants.NewService(db, database.NewTenantsRepository()),
+	}
+
+	// API keys are always looked up on the primary, so that a revocation takes effect immediately
+	var rw dbx.Database = db
+
+	if len(opts.Replicas) > 0 {
+		a.router, err = database.NewRouter(db, opts, logger)
+
+		if err != nil {
+			db.Close()
+
+			return nil, err
+		}
+
+		rw = a.router
+		logger.Info().Int("replicas", len(opts.Replicas)).Msg("routing reads to replicas")
+	}
+
+	a.accounts = accounts.NewService(rw, database.NewAccountsRepository())
+	a.transactions = transactions.NewService(rw, database.NewTransactions(), transactionsOptions(cfg))
+	a.audit = audit.NewService(rw, database.NewAuditRepository())
+	a.disputes = disputes.NewService(rw, database.NewDisputesRepository(), database.NewTransactions())
+
+	recOpts, err := reconciliationOptions(cfg)
+
+	if err != nil {
+		a.Close()
+
+		return nil, err
+	}
+
+	a.reconciliations = reconciliation.NewService(rw, database.NewReconciliationsRepository(), recOpts)
+
+	// replicas elect the instance running a job with advisory locks, which belong to a session of the primary
+	a.scheduler = scheduler.NewService(rw, database.NewAdvisoryLocker(db), database.NewSchedulerRepository(), schedulerOptions(cfg))
+
+	for _, job := range newJobs(cfg, db) {
+		if err := a.scheduler.Register(job); err != nil {
+			a.Close()
+
+			return nil, err
+		}
+	}
+
+	a.queue = queue.NewService(rw, database.NewJobsRepository(), queueOptions(cfg))
+
+	if err := a.registerHandlers(); err != nil {
+		a.Close()
+
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func transactionsOptions(cfg Config) transactions.Options {
+	return transactions.Options{
+		ExportTimeout: cfg.ExportTimeout,
+	}
+}
+
+func schedulerOptions(cfg Config) scheduler.Options {
+	return scheduler.Options{
+		Instance: cfg.SchedulerInstance,
+	}
+}
+
+func queueOptions(cfg Config) queue.Options {
+	return queue.Options{
+		Workers:           cfg.QueueWorkers,
+		PollInterval:      cfg.QueuePollInterval,
+		VisibilityTimeout: cfg.QueueVisibilityTimeout,
+		MaxAttempts:       cfg.QueueMaxAttempts,
+		RetryBase:         cfg.QueueRetryBase,
+		RetryMax:          cfg.QueueRetryMax,
+	}
+}
+
+// registerHandlers registers the handlers of the job types queued by the API.
+func (a *app) registerHandlers() error {
+	return a.queue.Register(reconciliation.JobType, reconciliation.NewImportHandler(a.reconciliations))
+}
+
+func reconciliationOptions(cfg Config) (reconciliation.Options, error) {
+	kind := reconciliation.Kind(cfg.ReconciliationFormat)
+	columns, err := reconciliation.ParseColumns(kind, cfg.ReconciliationColumns)
+
+	if err != nil {
+		return reconciliation.Options{}, err
+	}
+
+	layout := reconciliation.Layout{
+		Kind:       kind,
+		Columns:    columns,
+		SkipHeader: cfg.ReconciliationSkipHeader,
+		DateLayout: cfg.ReconciliationDateLayout,
+		MinorUnits: cfg.ReconciliationMinorUnits,
+		Negate:     cfg.ReconciliationNegate,
+	}
+
+	if delim := []rune(cfg.ReconciliationDelimiter); len(delim) == 1 {
+		layout.Delimiter = delim[0]
+	} else if len(delim) > 1 {
+		return reconciliation.Options{}, fmt.Errorf("%w: delimiter must be a single character", reconciliation.ErrInvalidLayout)
+	}
+
+	if err := layout.Validate(); err != nil {
+		return reconciliation.Options{}, err
+	}
+
+	return reconciliation.Options{
+		Layout:        layout,
+		DateTolerance: cfg.ReconciliationDateTolerance,
+	}, nil
+}
+
+// Close closes the database along with the replicas.
+func (a *app) Close() error {
+	if a.router != nil {
+		return a.router.Close()
+	}
+
+	return a.db.Close()
+}
+
+// newMemoryApp keeps all the data in the process, so the server runs without Postgres.
+// An API key with all the scopes is issued for the default tenant, since there is no other way to create one.
+func newMemoryApp(ctx context.Context, cfg Config, logger zerolog.Logger) (*app, error) {
+	store := memory.NewStore()
+	db := store.DB()
+
+	recOpts, err := reconciliationOptions(cfg)
+
+	if err != nil {
+		db.Close()
+
+		return nil, err
+	}
+
+	a := &app{
+		cfg:          cfg,
+		logger:       logger,
+		db:           db,
+		keys:         auth.NewService(db, memory.NewAPIKeysRepository(store)),
+		tenants:      tenants.NewService(db, memory.NewTenantsRepository(store)),
+		accounts:     accounts.NewService(db, memory.NewAccountsRepository(store)),
+		transactions: transactions.NewService(db, memory.NewTransactionsRepository(store), transactionsOptions(cfg)),
+		audit:        audit.NewService(db, memory.NewAuditRepository(store)),
+		disputes:     disputes.NewService(db, memory.NewDisputesRepository(store), memory.NewTransactionsRepository(store)),
+
+		reconciliations: reconciliation.NewService(db, memory.NewReconciliationsRepository(store), recOpts),
+		// the maintenance jobs need Postgres, none is registered
+		scheduler: scheduler.NewService(db, scheduler.NewLocalLocker(), memory.NewSchedulerRepository(store), schedulerOptions(cfg)),
+		queue:     queue.NewService(db, memory.NewJobsRepository(store), queueOptions(cfg)),
+	}
+
+	if err := a.registerHandlers(); err != nil {
+		db.Close()
+
+		return nil, err
+	}
+
+	key, err := a.keys.CreateAPIKey(ctx, auth.APIKeyCreation{
+		TenantID: memory.DefaultTenant,
+		Name:     "development",
+		Scopes:   auth.Scopes(),
+	})
+
+	if err != nil {
+		db.Close()
+
+		return nil, err
+	}
+
+	logger.Warn().
+		Str("api_key", key.Secret).
+		Str("tenant", key.TenantID).
+		Msg("using the in-memory database, data is lost on exit")
+
+	return a, nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+const configUsage = `usage: rm-rf-production config <command>
+
+commands:
+  print    print the effective configuration, secrets are redacted
+`
+
+const redacted = "[REDACTED]"
+
+func runConfig(out io.Writer, cfg Config, args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprint(out, configUsage)
+
+		if len(args) == 0 {
+			return fmt.Errorf("missing config command")
+		}
+
+		return fmt.Errorf("unknown config command: %s", args[0])
+	}
+
+	printConfig(out, cfg)
+
+	return nil
+}
+
+// printConfig writes the configuration in the env file format.
+// Values of the fields tagged with redact:"true" are replaced, unless they are empty.
+func printConfig(out io.Writer, cfg Config) {
+	v := reflect.ValueOf(cfg)
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("env")
+
+		if name == "" {
+			continue
+		}
+
+		value := formatConfigValue(v.Field(i))
+
+		if field.Tag.Get("redact") == "true" && value != "" {
+			value = redacted
+		}
+
+		fmt.Fprintf(out, "%s=%s\n", name, value)
+	}
+}
+
+func formatConfigValue(v reflect.Value) string {
+	if t, ok := v.Interface().(time.Time); ok {
+		// unset times are printed empty, as they are parsed
+		if t.IsZero() {
+			return ""
+		}
+
+		return t.Format(time.RFC3339)
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+	case reflect.Slice:
+		// slices are printed in the comma separated form they are parsed from
+		items := make([]string, 0, v.Len())
+
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, fmt.Sprint(v.Index(i).Interface()))
+		}
+
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+
+	// maps are printed in the same key:value,key:value form they are parsed from
+	pairs := make([]string, 0, v.Len())
+	iter := v.MapRange()
+
+	for iter.Next() {
+		pairs = append(pairs, fmt.Sprintf("%v:%v", iter.Key().Interface(), iter.Value().Interface()))
+	}
+
+	slices.Sort(pairs)
+
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS scheduler_runs;
+DROP TYPE IF EXISTS scheduler_run_status;
@@ -0,0 +1,18 @@
+CREATE TYPE scheduler_run_status AS ENUM ('running', 'succeeded', 'failed');
+
+-- run history of the scheduled jobs, shared by all instances
+CREATE TABLE IF NOT EXISTS scheduler_runs (
+    id BIGSERIAL PRIMARY KEY,
+    job_name VARCHAR(64) NOT NULL,
+    instance VARCHAR(255) NOT NULL,
+    status scheduler_run_status NOT NULL DEFAULT 'running',
+    scheduled_at TIMESTAMP NOT NULL,
+    started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP,
+    result TEXT,
+    error TEXT,
+    -- a job runs once per scheduled time, whichever instance gets there first
+    UNIQUE (job_name, scheduled_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduler_runs_job_name_id ON scheduler_runs(job_name, id);
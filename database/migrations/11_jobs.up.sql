@@ -0,0 +1,27 @@
+CREATE TYPE job_status AS ENUM ('queued', 'running', 'succeeded', 'dead');
+
+-- durable background jobs, claimed by the workers of all instances with FOR UPDATE SKIP LOCKED
+CREATE TABLE IF NOT EXISTS jobs (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL REFERENCES tenants(id),
+    type VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    status job_status NOT NULL DEFAULT 'queued',
+    priority INTEGER NOT NULL DEFAULT 0,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL,
+    -- when a queued job is due, the next retry for a failed one
+    run_at TIMESTAMP NOT NULL,
+    -- the worker holding a running job, until its visibility timeout expires
+    locked_by VARCHAR(255),
+    locked_until TIMESTAMP,
+    last_error TEXT,
+    result JSONB,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_queued ON jobs(priority DESC, run_at, id) WHERE status = 'queued';
+CREATE INDEX IF NOT EXISTS idx_jobs_running ON jobs(locked_until) WHERE status = 'running';
+CREATE INDEX IF NOT EXISTS idx_jobs_tenant_id_dead ON jobs(tenant_id, id) WHERE status = 'dead';
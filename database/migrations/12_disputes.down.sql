@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS dispute_events;
+DROP TABLE IF EXISTS disputes;
+DROP TYPE IF EXISTS dispute_status;
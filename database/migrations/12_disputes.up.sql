@@ -0,0 +1,36 @@
+CREATE TYPE dispute_status AS ENUM ('opened', 'under_review', 'won', 'lost');
+
+-- a customer dispute of a card purchase, credited provisionally until it is resolved
+CREATE TABLE IF NOT EXISTS disputes (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL REFERENCES tenants(id),
+    account_id INTEGER NOT NULL,
+    transaction_id INTEGER NOT NULL REFERENCES transactions(id),
+    -- the payment crediting the disputed amount when the dispute is opened
+    credit_transaction_id INTEGER NOT NULL REFERENCES transactions(id),
+    -- the transaction debiting the credit again when the dispute is lost
+    reversal_transaction_id INTEGER REFERENCES transactions(id),
+    status dispute_status NOT NULL DEFAULT 'opened',
+    reason TEXT NOT NULL,
+    amount NUMERIC(10, 2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    resolved_at TIMESTAMP,
+    FOREIGN KEY (tenant_id, account_id) REFERENCES accounts(tenant_id, id),
+    -- a purchase is disputed once
+    UNIQUE (tenant_id, transaction_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_disputes_tenant_id_account_id ON disputes(tenant_id, account_id, id);
+
+-- every state change of a dispute with the principal that made it, written along with the change
+CREATE TABLE IF NOT EXISTS dispute_events (
+    id BIGSERIAL PRIMARY KEY,
+    dispute_id BIGINT NOT NULL REFERENCES disputes(id),
+    status dispute_status NOT NULL,
+    principal VARCHAR(255) NOT NULL,
+    note TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_dispute_events_dispute_id ON dispute_events(dispute_id, id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_transactions_tenant_id_merchant_id;
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_tenant_id_merchant_id_fkey;
+ALTER TABLE transactions DROP COLUMN IF EXISTS merchant_id;
+DROP TABLE IF EXISTS merchants;
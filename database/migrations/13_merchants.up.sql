@@ -0,0 +1,21 @@
+-- merchants are identified by the ID assigned by the acquirer, inserted with the first transaction made there
+CREATE TABLE IF NOT EXISTS merchants (
+    tenant_id VARCHAR(64) NOT NULL REFERENCES tenants(id),
+    id VARCHAR(64) NOT NULL,
+    name VARCHAR(255),
+    city VARCHAR(100),
+    country CHAR(2),
+    mcc CHAR(4),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    PRIMARY KEY (tenant_id, id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_merchants_tenant_id_mcc ON merchants(tenant_id, mcc);
+
+-- set on purchases and withdrawals only, the foreign key is not checked while the merchant is null
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS merchant_id VARCHAR(64);
+ALTER TABLE transactions ADD CONSTRAINT transactions_tenant_id_merchant_id_fkey
+    FOREIGN KEY (tenant_id, merchant_id) REFERENCES merchants(tenant_id, id);
+CREATE INDEX IF NOT EXISTS idx_transactions_tenant_id_merchant_id ON transactions(tenant_id, merchant_id)
+    WHERE merchant_id IS NOT NULL;
@@ -0,0 +1,5 @@
+-- enum values cannot be dropped, the type is created again without it
+ALTER TYPE operation_type RENAME TO operation_type_old;
+CREATE TYPE operation_type AS ENUM ('purchase', 'installment_purchase', 'withdrawal', 'payment');
+ALTER TABLE transactions ALTER COLUMN operation_type TYPE operation_type USING operation_type::TEXT::operation_type;
+DROP TYPE operation_type_old;
@@ -0,0 +1,3 @@
+-- lost disputes are reversed with their own operation type, so a reversal never passes for a purchase.
+-- the value is used from the next migration on, Postgres does not allow it in the transaction adding it
+ALTER TYPE operation_type ADD VALUE IF NOT EXISTS 'dispute_reversal';
@@ -0,0 +1,3 @@
+UPDATE transactions t SET operation_type=p.operation_type
+FROM disputes d JOIN transactions p ON p.id=d.transaction_id
+WHERE t.id=d.reversal_transaction_id;
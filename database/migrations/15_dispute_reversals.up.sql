@@ -0,0 +1,4 @@
+-- reversals written before were given the operation type of the disputed purchase
+UPDATE transactions t SET operation_type='dispute_reversal'
+FROM disputes d
+WHERE t.id=d.reversal_transaction_id;
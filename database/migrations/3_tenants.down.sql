@@ -0,0 +1,15 @@
+ALTER TABLE api_keys DROP COLUMN IF EXISTS tenant_id;
+
+DROP INDEX IF EXISTS idx_transactions_tenant_id_account_id;
+CREATE INDEX IF NOT EXISTS idx_transactions_account_id ON transactions(account_id);
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_tenant_id_account_id_fkey;
+ALTER TABLE transactions ADD CONSTRAINT transactions_account_id_fkey FOREIGN KEY (account_id) REFERENCES accounts(id);
+ALTER TABLE transactions DROP COLUMN IF EXISTS tenant_id;
+
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_tenant_id_id_key;
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_tenant_id_document_number_key;
+ALTER TABLE accounts ADD CONSTRAINT accounts_document_number_key UNIQUE (document_number);
+ALTER TABLE accounts DROP COLUMN IF EXISTS tenant_id;
+
+DROP TABLE IF EXISTS tenants;
\ No newline at end of file
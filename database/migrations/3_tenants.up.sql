@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS tenants (
+    id VARCHAR(64) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+-- data created before multi-tenancy belongs to the default tenant
+INSERT INTO tenants (id, name) VALUES ('default', 'Default') ON CONFLICT DO NOTHING;
+
+ALTER TABLE accounts ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES tenants(id);
+ALTER TABLE accounts ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_document_number_key;
+ALTER TABLE accounts ADD CONSTRAINT accounts_tenant_id_document_number_key UNIQUE (tenant_id, document_number);
+ALTER TABLE accounts ADD CONSTRAINT accounts_tenant_id_id_key UNIQUE (tenant_id, id);
+
+ALTER TABLE transactions ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES tenants(id);
+ALTER TABLE transactions ALTER COLUMN tenant_id DROP DEFAULT;
+-- a transaction can only reference an account of the same tenant
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_account_id_fkey;
+ALTER TABLE transactions ADD CONSTRAINT transactions_tenant_id_account_id_fkey
+    FOREIGN KEY (tenant_id, account_id) REFERENCES accounts(tenant_id, id);
+
+DROP INDEX IF EXISTS idx_transactions_account_id;
+CREATE INDEX IF NOT EXISTS idx_transactions_tenant_id_account_id ON transactions(tenant_id, account_id);
+
+ALTER TABLE api_keys ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES tenants(id);
+ALTER TABLE api_keys ALTER COLUMN tenant_id DROP DEFAULT;
\ No newline at end of file
@@ -0,0 +1,33 @@
+CREATE TYPE audit_outcome AS ENUM ('success', 'failure');
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id VARCHAR(64) REFERENCES tenants(id),
+    principal VARCHAR(255) NOT NULL,
+    request_id VARCHAR(255) NOT NULL,
+    operation_id VARCHAR(255) NOT NULL,
+    payload JSONB,
+    outcome audit_outcome NOT NULL,
+    status INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_tenant_id_created_at ON audit_log(tenant_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_tenant_id_principal ON audit_log(tenant_id, principal);
+
+-- the audit log is append-only, rows can never be changed or removed
+CREATE OR REPLACE FUNCTION audit_log_immutable() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only: % is not allowed', TG_OP;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_no_update_delete
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_immutable();
+
+CREATE TRIGGER audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_immutable();
+
+REVOKE UPDATE, DELETE, TRUNCATE ON audit_log FROM PUBLIC;
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_transactions_tenant_id_account_id_id;
+CREATE INDEX IF NOT EXISTS idx_transactions_tenant_id_account_id ON transactions(tenant_id, account_id);
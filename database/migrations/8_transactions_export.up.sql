@@ -0,0 +1,3 @@
+-- exports read the transactions of an account in id order
+DROP INDEX IF EXISTS idx_transactions_tenant_id_account_id;
+CREATE INDEX IF NOT EXISTS idx_transactions_tenant_id_account_id_id ON transactions(tenant_id, account_id, id);
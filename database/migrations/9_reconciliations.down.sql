@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS reconciliation_items;
+DROP TABLE IF EXISTS reconciliations;
+DROP TYPE IF EXISTS reconciliation_category;
+DROP INDEX IF EXISTS idx_transactions_tenant_id_event_date;
+DROP INDEX IF EXISTS idx_transactions_tenant_id_external_ref;
+ALTER TABLE transactions DROP COLUMN IF EXISTS external_ref;
@@ -0,0 +1,38 @@
+-- the reference of a transaction at the card processor, settlement files are matched against it
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS external_ref VARCHAR(64);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_tenant_id_external_ref ON transactions(tenant_id, external_ref)
+    WHERE external_ref IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_transactions_tenant_id_event_date ON transactions(tenant_id, event_date);
+
+CREATE TYPE reconciliation_category AS ENUM ('matched', 'missing_in_ledger', 'missing_in_file', 'amount_mismatch');
+
+CREATE TABLE IF NOT EXISTS reconciliations (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL REFERENCES tenants(id),
+    file_name VARCHAR(255) NOT NULL,
+    period_start TIMESTAMP NOT NULL,
+    period_end TIMESTAMP NOT NULL,
+    matched INTEGER NOT NULL,
+    missing_in_ledger INTEGER NOT NULL,
+    missing_in_file INTEGER NOT NULL,
+    amount_mismatch INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconciliations_tenant_id ON reconciliations(tenant_id);
+
+-- the line is null for transactions missing in the file, the transaction for lines missing in the ledger
+CREATE TABLE IF NOT EXISTS reconciliation_items (
+    id BIGSERIAL PRIMARY KEY,
+    reconciliation_id BIGINT NOT NULL REFERENCES reconciliations(id) ON DELETE CASCADE,
+    category reconciliation_category NOT NULL,
+    line INTEGER,
+    reference VARCHAR(64),
+    file_amount NUMERIC(10, 2),
+    file_date TIMESTAMP,
+    transaction_id INTEGER,
+    ledger_amount NUMERIC(10, 2),
+    ledger_date TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconciliation_items_reconciliation_id ON reconciliation_items(reconciliation_id, category);
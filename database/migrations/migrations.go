@@ -0,0 +1,10 @@
+package migrations
+
+import (
+	"embed"
+)
+
+// FS holds the SQL migrations in the golang-migrate format: <version>_<name>.(up|down).sql.
+//
+//go:embed *.sql
+var FS embed.FS
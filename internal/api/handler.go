@@ -2,23 +2,49 @@ package api
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
 
+	"github.com/ziflex/rm-rf-production/internal/etag"
+	"github.com/ziflex/rm-rf-production/internal/export"
 	"github.com/ziflex/rm-rf-production/pkg/accounts"
+	"github.com/ziflex/rm-rf-production/pkg/audit"
+	"github.com/ziflex/rm-rf-production/pkg/disputes"
+	"github.com/ziflex/rm-rf-production/pkg/queue"
+	"github.com/ziflex/rm-rf-production/pkg/reconciliation"
+	"github.com/ziflex/rm-rf-production/pkg/scheduler"
 	"github.com/ziflex/rm-rf-production/pkg/transactions"
 )
 
 type Handler struct {
-	accounts     accounts.Service
-	transactions transactions.Service
+	accounts        accounts.Service
+	transactions    transactions.Service
+	audit           audit.Service
+	reconciliations reconciliation.Service
+	scheduler       scheduler.Service
+	queue           queue.Service
+	disputes        disputes.Service
 }
 
 func NewHandler(
 	accounts accounts.Service,
 	transactions transactions.Service,
+	audit audit.Service,
+	reconciliations reconciliation.Service,
+	scheduler scheduler.Service,
+	queue queue.Service,
+	disputes disputes.Service,
 ) StrictServerInterface {
 	return &Handler{
 		accounts,
 		transactions,
+		audit,
+		reconciliations,
+		scheduler,
+		queue,
+		disputes,
 	}
 }
 
@@ -32,8 +58,8 @@ func (r *Handler) CreateAccount(ctx context.Context, request CreateAccountReques
 	}
 
 	return CreateAccount201JSONResponse{
-		AccountId:      acc.ID,
-		DocumentNumber: acc.DocumentNumber,
+		Body:    toAccount(acc),
+		Headers: CreateAccount201ResponseHeaders{ETag: etag.Format(acc.Version)},
 	}, nil
 }
 
@@ -44,9 +70,38 @@ func (r *Handler) GetAccount(ctx context.Context, request GetAccountRequestObjec
 		return nil, err
 	}
 
+	tag := etag.Format(acc.Version)
+
+	if request.Params.IfNoneMatch != nil {
+		versions := etag.Parse(*request.Params.IfNoneMatch, true)
+
+		if versions == nil || slices.Contains(versions, acc.Version) {
+			return GetAccount304Response{Headers: GetAccount304ResponseHeaders{ETag: tag}}, nil
+		}
+	}
+
 	return GetAccount200JSONResponse{
-		AccountId:      acc.ID,
-		DocumentNumber: acc.DocumentNumber,
+		Body:    toAccount(acc),
+		Headers: GetAccount200ResponseHeaders{ETag: tag},
+	}, nil
+}
+
+func (r *Handler) UpdateAccount(ctx context.Context, request UpdateAccountRequestObject) (UpdateAccountResponseObject, error) {
+	if request.Params.IfMatch == nil {
+		return nil, fmt.Errorf("account %w: send its ETag in If-Match", accounts.ErrVersionRequired)
+	}
+
+	acc, err := r.accounts.UpdateAccount(ctx, request.AccountId, accounts.AccountUpdate{
+		Blocked: request.Body.Blocked,
+	}, etag.Parse(*request.Params.IfMatch, false))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return UpdateAccount200JSONResponse{
+		Body:    toAccount(acc),
+		Headers: UpdateAccount200ResponseHeaders{ETag: etag.Format(acc.Version)},
 	}, nil
 }
 
@@ -55,6 +110,8 @@ func (r *Handler) CreateTransaction(ctx context.Context, request CreateTransacti
 		AccountID:     request.Body.AccountId,
 		OperationType: transactions.NewOperationType(int(request.Body.OperationTypeId)),
 		Amount:        request.Body.Amount,
+		ExternalRef:   deref(request.Body.ExternalRef),
+		Merchant:      fromMerchant(request.Body.Merchant),
 	})
 
 	if err != nil {
@@ -67,5 +124,407 @@ func (r *Handler) CreateTransaction(ctx context.Context, request CreateTransacti
 		OperationTypeId: OperationType(tx.OperationType),
 		Amount:          tx.Amount,
 		EventDate:       tx.EventDate,
+		ExternalRef:     ref(tx.ExternalRef),
+		Merchant:        toMerchant(tx.Merchant),
+	}, nil
+}
+
+func (r *Handler) ExportTransactions(ctx context.Context, request ExportTransactionsRequestObject) (ExportTransactionsResponseObject, error) {
+	// the account is checked before the response starts, a missing one is still a 404
+	if _, err := r.accounts.GetAccountByID(ctx, request.AccountId); err != nil {
+		return nil, err
+	}
+
+	format := export.FormatCSV
+
+	if request.Params.Format != nil {
+		format = export.Format(*request.Params.Format)
+	}
+
+	filter := transactions.ExportFilter{
+		AccountID: request.AccountId,
+		From:      request.Params.From,
+		To:        request.Params.To,
+		MCC:       deref(request.Params.Mcc),
+	}
+
+	body := export.Pipe(format, export.Statement{
+		AccountID:   request.AccountId,
+		From:        request.Params.From,
+		To:          request.Params.To,
+		GeneratedAt: time.Now(),
+	}, func(fn func(transactions.Transaction) error) error {
+		return r.transactions.ExportTransactions(ctx, filter, fn)
+	})
+
+	headers := ExportTransactions200ResponseHeaders{
+		ContentDisposition: export.ContentDisposition(request.AccountId, format),
+	}
+
+	switch format {
+	case export.FormatNDJSON:
+		return ExportTransactions200ApplicationxNdjsonResponse{Body: body, Headers: headers}, nil
+	case export.FormatOFX:
+		return ExportTransactions200ApplicationxOfxResponse{Body: body, Headers: headers}, nil
+	default:
+		return ExportTransactions200TextcsvResponse{Body: body, Headers: headers}, nil
+	}
+}
+
+func (r *Handler) ImportSettlement(ctx context.Context, request ImportSettlementRequestObject) (ImportSettlementResponseObject, error) {
+	payload, err := json.Marshal(reconciliation.ImportPayload{
+		FileName: request.Body.FileName,
+		Content:  request.Body.Content,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := r.queue.Enqueue(ctx, queue.JobCreation{Type: reconciliation.JobType, Payload: payload})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ImportSettlement202JSONResponse{
+		Body: toJob(job),
+		// relative to the requested URL, so it keeps the API version of the request
+		Headers: ImportSettlement202ResponseHeaders{Location: fmt.Sprintf("jobs/%d", job.ID)},
 	}, nil
 }
+
+func (r *Handler) GetReconciliation(ctx context.Context, request GetReconciliationRequestObject) (GetReconciliationResponseObject, error) {
+	var filter reconciliation.Filter
+
+	if request.Params.Category != nil {
+		filter.Category = reconciliation.Category(*request.Params.Category)
+	}
+
+	rec, err := r.reconciliations.GetReconciliation(ctx, request.ReconciliationId, filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	res := GetReconciliation200JSONResponse{
+		Id:          rec.ID,
+		FileName:    rec.FileName,
+		PeriodStart: rec.PeriodStart,
+		PeriodEnd:   rec.PeriodEnd,
+		CreatedAt:   rec.CreatedAt,
+		Summary: ReconciliationSummary{
+			Matched:         rec.Summary.Matched,
+			MissingInLedger: rec.Summary.MissingInLedger,
+			MissingInFile:   rec.Summary.MissingInFile,
+			AmountMismatch:  rec.Summary.AmountMismatch,
+		},
+		Items: make([]ReconciliationItem, 0, len(rec.Items)),
+	}
+
+	for _, item := range rec.Items {
+		res.Items = append(res.Items, toReconciliationItem(item))
+	}
+
+	return res, nil
+}
+
+func (r *Handler) GetJob(ctx context.Context, request GetJobRequestObject) (GetJobResponseObject, error) {
+	job, err := r.queue.GetJob(ctx, request.JobId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return GetJob200JSONResponse(toJob(job)), nil
+}
+
+func (r *Handler) OpenDispute(ctx context.Context, request OpenDisputeRequestObject) (OpenDisputeResponseObject, error) {
+	d, err := r.disputes.OpenDispute(ctx, disputes.Opening{
+		AccountID:     request.AccountId,
+		TransactionID: request.Body.TransactionId,
+		Reason:        request.Body.Reason,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return OpenDispute201JSONResponse(toDispute(d)), nil
+}
+
+func (r *Handler) UpdateDispute(ctx context.Context, request UpdateDisputeRequestObject) (UpdateDisputeResponseObject, error) {
+	d, err := r.disputes.UpdateStatus(ctx, request.DisputeId, disputes.Change{
+		Status: disputes.Status(request.Body.Status),
+		Note:   deref(request.Body.Note),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return UpdateDispute200JSONResponse(toDispute(d)), nil
+}
+
+func (r *Handler) ListDisputes(ctx context.Context, request ListDisputesRequestObject) (ListDisputesResponseObject, error) {
+	filter := disputes.Filter{AccountID: request.AccountId}
+
+	if request.Params.Status != nil {
+		filter.Status = disputes.Status(*request.Params.Status)
+	}
+
+	list, err := r.disputes.ListDisputes(ctx, filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	res := ListDisputes200JSONResponse{
+		Disputes: make([]Dispute, 0, len(list)),
+	}
+
+	for _, d := range list {
+		res.Disputes = append(res.Disputes, toDispute(d))
+	}
+
+	return res, nil
+}
+
+func (r *Handler) ListAuditEntries(ctx context.Context, request ListAuditEntriesRequestObject) (ListAuditEntriesResponseObject, error) {
+	filter := audit.Filter{
+		From: request.Params.From,
+		To:   request.Params.To,
+	}
+
+	if request.Params.Principal != nil {
+		filter.Principal = *request.Params.Principal
+	}
+
+	if request.Params.OperationId != nil {
+		filter.OperationID = *request.Params.OperationId
+	}
+
+	if request.Params.Outcome != nil {
+		filter.Outcome = audit.Outcome(*request.Params.Outcome)
+	}
+
+	if request.Params.BeforeId != nil {
+		filter.BeforeID = *request.Params.BeforeId
+	}
+
+	if request.Params.Limit != nil {
+		filter.Limit = *request.Params.Limit
+	}
+
+	entries, err := r.audit.ListEntries(ctx, filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	res := ListAuditEntries200JSONResponse{
+		Entries: make([]AuditEntry, 0, len(entries)),
+	}
+
+	for _, e := range entries {
+		entry := AuditEntry{
+			Id:          e.ID,
+			Principal:   e.Principal,
+			RequestId:   e.RequestID,
+			OperationId: e.OperationID,
+			Outcome:     AuditOutcome(e.Outcome),
+			Status:      e.Status,
+			CreatedAt:   e.CreatedAt,
+		}
+
+		var payload map[string]any
+
+		if len(e.Payload) > 0 && json.Unmarshal(e.Payload, &payload) == nil {
+			entry.Payload = &payload
+		}
+
+		res.Entries = append(res.Entries, entry)
+	}
+
+	return res, nil
+}
+
+func (r *Handler) GetSchedulerStatus(ctx context.Context, _ GetSchedulerStatusRequestObject) (GetSchedulerStatusResponseObject, error) {
+	jobs, err := r.scheduler.Status(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	res := GetSchedulerStatus200JSONResponse{
+		Jobs: make([]SchedulerJob, 0, len(jobs)),
+	}
+
+	for _, job := range jobs {
+		item := SchedulerJob{
+			Name:        job.Name,
+			Description: job.Description,
+			Schedule:    ref(job.Schedule),
+			NextRunAt:   job.NextRunAt,
+			Runs:        make([]SchedulerRun, 0, len(job.Runs)),
+		}
+
+		for _, run := range job.Runs {
+			item.Runs = append(item.Runs, toSchedulerRun(run))
+		}
+
+		if job.LastFailure != nil {
+			failure := toSchedulerRun(*job.LastFailure)
+			item.LastFailure = &failure
+		}
+
+		res.Jobs = append(res.Jobs, item)
+	}
+
+	return res, nil
+}
+
+func toAccount(acc accounts.Account) Account {
+	return Account{
+		AccountId:      acc.ID,
+		DocumentNumber: acc.DocumentNumber,
+		Blocked:        acc.Blocked,
+	}
+}
+
+func toJob(job queue.Job) Job {
+	res := Job{
+		Id:          job.ID,
+		Type:        job.Type,
+		Status:      JobStatus(job.Status),
+		Attempts:    job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+		RunAt:       job.RunAt,
+		LastError:   ref(job.LastError),
+		CreatedAt:   job.CreatedAt,
+		UpdatedAt:   job.UpdatedAt,
+		FinishedAt:  job.FinishedAt,
+	}
+
+	var result map[string]any
+
+	if len(job.Result) > 0 && json.Unmarshal(job.Result, &result) == nil {
+		res.Result = &result
+	}
+
+	return res
+}
+
+func toDispute(d disputes.Dispute) Dispute {
+	res := Dispute{
+		Id:                  d.ID,
+		AccountId:           d.AccountID,
+		TransactionId:       d.TransactionID,
+		CreditTransactionId: d.CreditTransactionID,
+		Status:              DisputeStatus(d.Status),
+		Reason:              d.Reason,
+		Amount:              d.Amount,
+		CreatedAt:           d.CreatedAt,
+		UpdatedAt:           d.UpdatedAt,
+		ResolvedAt:          d.ResolvedAt,
+		Events:              make([]DisputeEvent, 0, len(d.Events)),
+	}
+
+	if d.ReversalTransactionID != 0 {
+		res.ReversalTransactionId = &d.ReversalTransactionID
+	}
+
+	for _, e := range d.Events {
+		res.Events = append(res.Events, DisputeEvent{
+			Id:        e.ID,
+			Status:    DisputeStatus(e.Status),
+			Principal: e.Principal,
+			Note:      ref(e.Note),
+			CreatedAt: e.CreatedAt,
+		})
+	}
+
+	return res
+}
+
+func toReconciliationItem(item reconciliation.Item) ReconciliationItem {
+	res := ReconciliationItem{Category: ReconciliationCategory(item.Category)}
+
+	if line := item.Line; line != nil {
+		res.Line = &line.Number
+		res.Reference = ref(line.Reference)
+		res.FileAmount = &line.Amount
+		res.FileDate = &line.Date
+	}
+
+	if tr := item.Transaction; tr != nil {
+		res.TransactionId = &tr.ID
+		res.LedgerAmount = &tr.Amount
+		res.LedgerDate = &tr.EventDate
+
+		if res.Reference == nil {
+			res.Reference = ref(tr.ExternalRef)
+		}
+	}
+
+	return res
+}
+
+func toSchedulerRun(run scheduler.Run) SchedulerRun {
+	return SchedulerRun{
+		Id:          run.ID,
+		Job:         run.Job,
+		Instance:    run.Instance,
+		Status:      SchedulerRunStatus(run.Status),
+		ScheduledAt: run.ScheduledAt,
+		StartedAt:   run.StartedAt,
+		FinishedAt:  run.FinishedAt,
+		Result:      ref(run.Result),
+		Error:       ref(run.Error),
+	}
+}
+
+func toMerchant(m *transactions.Merchant) *Merchant {
+	if m == nil {
+		return nil
+	}
+
+	return &Merchant{
+		Id:      m.ID,
+		Name:    ref(m.Name),
+		City:    ref(m.City),
+		Country: ref(m.Country),
+		Mcc:     ref(m.MCC),
+	}
+}
+
+func fromMerchant(m *Merchant) *transactions.Merchant {
+	if m == nil {
+		return nil
+	}
+
+	return &transactions.Merchant{
+		ID:      m.Id,
+		Name:    deref(m.Name),
+		City:    deref(m.City),
+		Country: deref(m.Country),
+		MCC:     deref(m.Mcc),
+	}
+}
+
+// ref returns nil for an empty string, optional strings are omitted rather than empty.
+func ref(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
@@ -4,21 +4,43 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/labstack/echo/v4"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/ziflex/rm-rf-production/internal/api"
+	"github.com/ziflex/rm-rf-production/internal/apiv2"
+	"github.com/ziflex/rm-rf-production/internal/health"
+	"github.com/ziflex/rm-rf-production/internal/metrics"
 	"github.com/ziflex/rm-rf-production/internal/server"
+	"github.com/ziflex/rm-rf-production/internal/tracing"
 	"github.com/ziflex/rm-rf-production/pkg/accounts"
+	"github.com/ziflex/rm-rf-production/pkg/audit"
+	"github.com/ziflex/rm-rf-production/pkg/auth"
 	"github.com/ziflex/rm-rf-production/pkg/common"
+	"github.com/ziflex/rm-rf-production/pkg/disputes"
+	"github.com/ziflex/rm-rf-production/pkg/queue"
+	"github.com/ziflex/rm-rf-production/pkg/ratelimit"
+	"github.com/ziflex/rm-rf-production/pkg/reconciliation"
+	"github.com/ziflex/rm-rf-production/pkg/scheduler"
 	"github.com/ziflex/rm-rf-production/pkg/transactions"
 	"github.com/ziflex/rm-rf-production/spec"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type mockAccountsService struct {
@@ -37,6 +59,25 @@ func (m *mockAccountsService) GetAccountByID(ctx context.Context, id int64) (acc
 	return args.Get(0).(accounts.Account), args.Error(1)
 }
 
+func (m *mockAccountsService) BlockAccount(ctx context.Context, id int64) error {
+	return m.Mock.Called(ctx, id).Error(0)
+}
+
+func (m *mockAccountsService) UnblockAccount(ctx context.Context, id int64) error {
+	return m.Mock.Called(ctx, id).Error(0)
+}
+
+func (m *mockAccountsService) UpdateAccount(
+	ctx context.Context,
+	id int64,
+	update accounts.AccountUpdate,
+	versions []int64,
+) (accounts.Account, error) {
+	args := m.Mock.Called(ctx, id, update, versions)
+
+	return args.Get(0).(accounts.Account), args.Error(1)
+}
+
 type mockTransactionsService struct {
 	mock.Mock
 }
@@ -47,16 +88,278 @@ func (m *mockTransactionsService) CreateTransaction(ctx context.Context, creatio
 	return args.Get(0).(transactions.Transaction), args.Error(1)
 }
 
-func createServer(accSvc accounts.Service, txSvc transactions.Service) (*server.Server, error) {
+// ExportTransactions passes the transactions returned by the mock to fn.
+func (m *mockTransactionsService) ExportTransactions(ctx context.Context, filter transactions.ExportFilter, fn func(transactions.Transaction) error) error {
+	args := m.Mock.Called(ctx, filter)
+
+	for _, tr := range args.Get(0).([]transactions.Transaction) {
+		if err := fn(tr); err != nil {
+			return err
+		}
+	}
+
+	return args.Error(1)
+}
+
+type mockReconciliationService struct {
+	mock.Mock
+}
+
+func (m *mockReconciliationService) Reconcile(ctx context.Context, file reconciliation.SettlementFile) (reconciliation.Reconciliation, error) {
+	args := m.Mock.Called(ctx, file)
+
+	return args.Get(0).(reconciliation.Reconciliation), args.Error(1)
+}
+
+func (m *mockReconciliationService) GetReconciliation(ctx context.Context, id int64, filter reconciliation.Filter) (reconciliation.Reconciliation, error) {
+	args := m.Mock.Called(ctx, id, filter)
+
+	return args.Get(0).(reconciliation.Reconciliation), args.Error(1)
+}
+
+type mockSchedulerService struct {
+	mock.Mock
+}
+
+func (m *mockSchedulerService) Register(job scheduler.Job) error {
+	return m.Mock.Called(job).Error(0)
+}
+
+func (m *mockSchedulerService) Jobs() []scheduler.Job {
+	return m.Mock.Called().Get(0).([]scheduler.Job)
+}
+
+func (m *mockSchedulerService) Start(ctx context.Context) {
+	m.Mock.Called(ctx)
+}
+
+func (m *mockSchedulerService) Stop(ctx context.Context) error {
+	return m.Mock.Called(ctx).Error(0)
+}
+
+func (m *mockSchedulerService) Run(ctx context.Context, name string) (scheduler.Run, error) {
+	args := m.Mock.Called(ctx, name)
+
+	return args.Get(0).(scheduler.Run), args.Error(1)
+}
+
+func (m *mockSchedulerService) Status(ctx context.Context) ([]scheduler.JobStatus, error) {
+	args := m.Mock.Called(ctx)
+
+	return args.Get(0).([]scheduler.JobStatus), args.Error(1)
+}
+
+type mockQueueService struct {
+	mock.Mock
+}
+
+func (m *mockQueueService) Register(jobType string, handler queue.Handler) error {
+	return m.Mock.Called(jobType, handler).Error(0)
+}
+
+func (m *mockQueueService) Enqueue(ctx context.Context, creation queue.JobCreation) (queue.Job, error) {
+	args := m.Mock.Called(ctx, creation)
+
+	return args.Get(0).(queue.Job), args.Error(1)
+}
+
+func (m *mockQueueService) GetJob(ctx context.Context, id int64) (queue.Job, error) {
+	args := m.Mock.Called(ctx, id)
+
+	return args.Get(0).(queue.Job), args.Error(1)
+}
+
+func (m *mockQueueService) Retry(ctx context.Context, id int64) (queue.Job, error) {
+	args := m.Mock.Called(ctx, id)
+
+	return args.Get(0).(queue.Job), args.Error(1)
+}
+
+func (m *mockQueueService) Start(ctx context.Context) {
+	m.Mock.Called(ctx)
+}
+
+func (m *mockQueueService) Stop(ctx context.Context) error {
+	return m.Mock.Called(ctx).Error(0)
+}
+
+type mockDisputesService struct {
+	mock.Mock
+}
+
+func (m *mockDisputesService) OpenDispute(ctx context.Context, opening disputes.Opening) (disputes.Dispute, error) {
+	args := m.Mock.Called(ctx, opening)
+
+	return args.Get(0).(disputes.Dispute), args.Error(1)
+}
+
+func (m *mockDisputesService) UpdateStatus(ctx context.Context, id int64, change disputes.Change) (disputes.Dispute, error) {
+	args := m.Mock.Called(ctx, id, change)
+
+	return args.Get(0).(disputes.Dispute), args.Error(1)
+}
+
+func (m *mockDisputesService) ListDisputes(ctx context.Context, filter disputes.Filter) ([]disputes.Dispute, error) {
+	args := m.Mock.Called(ctx, filter)
+
+	return args.Get(0).([]disputes.Dispute), args.Error(1)
+}
+
+type mockAuthService struct {
+	keys map[string]auth.Principal
+}
+
+func (m *mockAuthService) CreateAPIKey(_ context.Context, _ auth.APIKeyCreation) (auth.IssuedAPIKey, error) {
+	return auth.IssuedAPIKey{}, nil
+}
+
+func (m *mockAuthService) RevokeAPIKey(_ context.Context, _ int64) error {
+	return nil
+}
+
+func (m *mockAuthService) Authenticate(_ context.Context, secret string) (auth.Principal, error) {
+	p, ok := m.keys[secret]
+
+	if !ok {
+		return auth.Principal{}, auth.ErrUnauthorized
+	}
+
+	return p, nil
+}
+
+type mockTokenVerifier struct {
+	tokens map[string]auth.Principal
+}
+
+func (m *mockTokenVerifier) Verify(_ context.Context, token string) (auth.Principal, error) {
+	p, ok := m.tokens[token]
+
+	if !ok {
+		return auth.Principal{}, auth.ErrUnauthorized
+	}
+
+	return p, nil
+}
+
+const (
+	testBearerToken    = "header.payload.signature"
+	testAPIKey         = "rrp_test"
+	testReadOnlyAPIKey = "rrp_test_read_only"
+)
+
+type apiKeyTransport struct {
+	key string
+}
+
+func (t apiKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+
+	if t.key != "" {
+		req.Header.Set(server.HeaderAPIKey, t.key)
+	}
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+var client = &http.Client{Transport: apiKeyTransport{key: testAPIKey}}
+
+type mockAuditService struct {
+	mu      sync.Mutex
+	entries []audit.EntryCreation
+	filters []audit.Filter
+	list    []audit.Entry
+}
+
+func (m *mockAuditService) Record(_ context.Context, entry audit.EntryCreation) (audit.Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries = append(m.entries, entry)
+
+	return audit.Entry{ID: int64(len(m.entries))}, nil
+}
+
+func (m *mockAuditService) ListEntries(_ context.Context, filter audit.Filter) ([]audit.Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.filters = append(m.filters, filter)
+
+	return m.list, nil
+}
+
+func (m *mockAuditService) Entries() []audit.EntryCreation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]audit.EntryCreation(nil), m.entries...)
+}
+
+func createServer(accSvc accounts.Service, txSvc transactions.Service, setters ...func(opts *server.Options)) (*server.Server, error) {
+	return createServerWithAudit(accSvc, txSvc, &mockAuditService{}, setters...)
+}
+
+func createServerWithAudit(accSvc accounts.Service, txSvc transactions.Service, auditSvc audit.Service, setters ...func(opts *server.Options)) (*server.Server, error) {
+	return createServerWithServices(accSvc, txSvc, auditSvc, &mockReconciliationService{}, &mockSchedulerService{}, &mockQueueService{}, &mockDisputesService{}, setters...)
+}
+
+func createServerWithServices(
+	accSvc accounts.Service,
+	txSvc transactions.Service,
+	auditSvc audit.Service,
+	recSvc reconciliation.Service,
+	schedSvc scheduler.Service,
+	queueSvc queue.Service,
+	disputesSvc disputes.Service,
+	setters ...func(opts *server.Options),
+) (*server.Server, error) {
 	logger := zerolog.New(io.Discard).With().Timestamp().Logger()
 
+	opts := server.Options{
+		Logger: logger,
+		Spec:   spec.File,
+		Audit:  auditSvc,
+		Auth: &mockAuthService{
+			keys: map[string]auth.Principal{
+				testAPIKey: {
+					ID:       "apikey:1",
+					Name:     "test",
+					TenantID: "acme",
+					Scopes:   auth.Scopes(),
+				},
+				testReadOnlyAPIKey: {
+					ID:       "apikey:2",
+					Name:     "test-read-only",
+					TenantID: "acme",
+					Scopes:   []auth.Scope{auth.ScopeAccountsRead},
+				},
+			},
+		},
+		Tokens: &mockTokenVerifier{
+			tokens: map[string]auth.Principal{
+				testBearerToken: {
+					ID:       "jwt:gateway",
+					Name:     "gateway",
+					TenantID: "acme",
+					Scopes:   []auth.Scope{auth.ScopeAccountsRead},
+				},
+			},
+		},
+	}
+
+	for _, setter := range setters {
+		setter(&opts)
+	}
+
 	return server.NewServer(api.NewHandler(
 		accSvc,
 		txSvc,
-	), server.Options{
-		Logger: logger,
-		Spec:   spec.File,
-	})
+		auditSvc,
+		recSvc,
+		schedSvc,
+		queueSvc,
+		disputesSvc,
+	), opts)
 }
 
 func toJSON(t *testing.T, v any) io.Reader {
@@ -80,7 +383,7 @@ func TestCreateAccount_Success(t *testing.T) {
 	time.Sleep(1 * time.Second)
 
 	defer func() {
-		if err := svr.Shutdown(); err != nil {
+		if err := svr.Shutdown(context.Background()); err != nil {
 			t.Errorf("shutdown error: %v", err)
 		}
 	}()
@@ -91,12 +394,13 @@ func TestCreateAccount_Success(t *testing.T) {
 	mockAccSvc.On("CreateAccount", mock.Anything, creation).Return(accounts.Account{
 		ID:             1,
 		DocumentNumber: creation.DocumentNumber,
+		Version:        1,
 	}, nil)
 
 	payload := toJSON(t, api.AccountCreateRequest{
 		DocumentNumber: creation.DocumentNumber,
 	})
-	resp, err := http.Post("http://localhost:8080/accounts", "application/json", payload)
+	resp, err := client.Post("http://localhost:8080/accounts", "application/json", payload)
 
 	assert.NoError(t, err)
 
@@ -106,8 +410,9 @@ func TestCreateAccount_Success(t *testing.T) {
 	defer resp.Body.Close()
 
 	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, `"1"`, resp.Header.Get("ETag"))
 
-	var result api.CreateAccount201JSONResponse
+	var result api.Account
 
 	err = json.Unmarshal(body, &result)
 	assert.NoError(t, err)
@@ -130,7 +435,7 @@ func TestCreateAccount_Error_Validation(t *testing.T) {
 	time.Sleep(1 * time.Second)
 
 	defer func() {
-		if err := svr.Shutdown(); err != nil {
+		if err := svr.Shutdown(context.Background()); err != nil {
 			t.Errorf("shutdown error: %v", err)
 		}
 	}()
@@ -140,7 +445,7 @@ func TestCreateAccount_Error_Validation(t *testing.T) {
 	}
 
 	payload := toJSON(t, creation)
-	resp, err := http.Post("http://localhost:8080/accounts", "application/json", payload)
+	resp, err := client.Post("http://localhost:8080/accounts", "application/json", payload)
 
 	assert.NoError(t, err)
 
@@ -149,18 +454,32 @@ func TestCreateAccount_Error_Validation(t *testing.T) {
 	assert.NoError(t, err)
 	defer resp.Body.Close()
 	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "application/problem+json", resp.Header.Get("Content-Type"))
 
-	var result api.Error
+	var result api.Problem
 
 	err = json.Unmarshal(body, &result)
 	assert.NoError(t, err)
 	assert.Equal(t, "badRequest", result.Code)
+	assert.Equal(t, "urn:rm-rf-production:problem:badRequest", result.Type)
+	assert.Equal(t, "Bad Request", result.Title)
+	assert.Equal(t, http.StatusBadRequest, result.Status)
+
+	if assert.NotNil(t, result.Instance) {
+		assert.Equal(t, resp.Header.Get("X-Correlation-ID"), *result.Instance)
+	}
+
+	if assert.NotNil(t, result.Errors) && assert.Len(t, *result.Errors, 1) {
+		assert.Equal(t, "document_number", (*result.Errors)[0].Field)
+		assert.Equal(t, "minLength", (*result.Errors)[0].Rule)
+	}
+
 	mockAccSvc.AssertExpectations(t)
 }
 
-func TestGetAccountByID_Success(t *testing.T) {
-	mockAccSvc := new(mockAccountsService)
-	svr, err := createServer(mockAccSvc, &mockTransactionsService{})
+func TestCreateTransaction_Error_FieldErrors(t *testing.T) {
+	mockTxSvc := new(mockTransactionsService)
+	svr, err := createServer(&mockAccountsService{}, mockTxSvc)
 	assert.NoError(t, err)
 
 	go func() {
@@ -172,39 +491,107 @@ func TestGetAccountByID_Success(t *testing.T) {
 	time.Sleep(1 * time.Second)
 
 	defer func() {
-		if err := svr.Shutdown(); err != nil {
+		if err := svr.Shutdown(context.Background()); err != nil {
 			t.Errorf("shutdown error: %v", err)
 		}
 	}()
 
-	expected := accounts.Account{
-		ID:             1,
-		DocumentNumber: "12345678900",
+	type testCase struct {
+		name    string
+		payload string
+		errors  []api.FieldError
 	}
 
-	mockAccSvc.On("GetAccountByID", mock.Anything, expected.ID).Return(expected, nil)
+	tsdata := []testCase{
+		{
+			"All invalid fields are reported",
+			`{"account_id": 0, "operation_type_id": 9, "amount": -1}`,
+			[]api.FieldError{
+				{Field: "account_id", Rule: "minimum"},
+				{Field: "operation_type_id", Rule: "enum"},
+				{Field: "amount", Rule: "minimum"},
+			},
+		},
+		{
+			"Missing field",
+			`{"account_id": 1, "operation_type_id": 1}`,
+			[]api.FieldError{
+				{Field: "amount", Rule: "required"},
+			},
+		},
+		{
+			"Malformed body",
+			`{"account_id": `,
+			[]api.FieldError{
+				{Field: "body", Rule: "format"},
+			},
+		},
+	}
+
+	for _, tc := range tsdata {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := client.Post("http://localhost:8080/transactions", "application/json", strings.NewReader(tc.payload))
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+			var result api.Problem
+
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+			assert.Equal(t, "badRequest", result.Code)
+
+			if !assert.NotNil(t, result.Errors) {
+				return
+			}
 
-	resp, err := http.Get(fmt.Sprintf("http://localhost:8080/accounts/%d", expected.ID))
+			actual := make([]api.FieldError, 0, len(*result.Errors))
 
+			for _, e := range *result.Errors {
+				assert.NotEmpty(t, e.Message)
+				actual = append(actual, api.FieldError{Field: e.Field, Rule: e.Rule})
+			}
+
+			assert.ElementsMatch(t, tc.errors, actual)
+		})
+	}
+
+	mockTxSvc.AssertExpectations(t)
+}
+
+func TestCreateAccount_Error_PayloadTooLarge(t *testing.T) {
+	svr, err := createServer(&mockAccountsService{}, &mockTransactionsService{})
 	assert.NoError(t, err)
 
-	body, err := io.ReadAll(resp.Body)
+	go func() {
+		if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+			t.Errorf("server error: %v", err)
+		}
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer func() {
+		if err := svr.Shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown error: %v", err)
+		}
+	}()
 
+	payload := fmt.Sprintf(`{"document_number": "%s"}`, strings.Repeat("1", 2<<20))
+	resp, err := client.Post("http://localhost:8080/accounts", "application/json", strings.NewReader(payload))
 	assert.NoError(t, err)
 	defer resp.Body.Close()
 
-	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
 
-	var result api.GetAccount200JSONResponse
+	var result api.Problem
 
-	err = json.Unmarshal(body, &result)
-	assert.NoError(t, err)
-	assert.Equal(t, expected.ID, result.AccountId)
-	assert.Equal(t, expected.DocumentNumber, result.DocumentNumber)
-	mockAccSvc.AssertExpectations(t)
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "payloadTooLarge", result.Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, result.Status)
 }
 
-func TestGetAccountByID_Error_NotFound(t *testing.T) {
+func TestGetAccountByID_Success(t *testing.T) {
 	mockAccSvc := new(mockAccountsService)
 	svr, err := createServer(mockAccSvc, &mockTransactionsService{})
 	assert.NoError(t, err)
@@ -218,7 +605,7 @@ func TestGetAccountByID_Error_NotFound(t *testing.T) {
 	time.Sleep(1 * time.Second)
 
 	defer func() {
-		if err := svr.Shutdown(); err != nil {
+		if err := svr.Shutdown(context.Background()); err != nil {
 			t.Errorf("shutdown error: %v", err)
 		}
 	}()
@@ -226,11 +613,19 @@ func TestGetAccountByID_Error_NotFound(t *testing.T) {
 	expected := accounts.Account{
 		ID:             1,
 		DocumentNumber: "12345678900",
+		Version:        3,
 	}
 
-	mockAccSvc.On("GetAccountByID", mock.Anything, expected.ID).Return(accounts.Account{}, common.ErrNotFound)
+	// the tenant of the authenticated principal must reach the service layer
+	withTenant := mock.MatchedBy(func(ctx context.Context) bool {
+		tenantID, err := common.TenantFromContext(ctx)
+
+		return err == nil && tenantID == "acme"
+	})
+
+	mockAccSvc.On("GetAccountByID", withTenant, expected.ID).Return(expected, nil)
 
-	resp, err := http.Get(fmt.Sprintf("http://localhost:8080/accounts/%d", expected.ID))
+	resp, err := client.Get(fmt.Sprintf("http://localhost:8080/accounts/%d", expected.ID))
 
 	assert.NoError(t, err)
 
@@ -239,17 +634,19 @@ func TestGetAccountByID_Error_NotFound(t *testing.T) {
 	assert.NoError(t, err)
 	defer resp.Body.Close()
 
-	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"3"`, resp.Header.Get("ETag"))
 
-	var result api.Error
+	var result api.Account
 
 	err = json.Unmarshal(body, &result)
 	assert.NoError(t, err)
-	assert.Equal(t, "notFound", result.Code)
+	assert.Equal(t, expected.ID, result.AccountId)
+	assert.Equal(t, expected.DocumentNumber, result.DocumentNumber)
 	mockAccSvc.AssertExpectations(t)
 }
 
-func TestGetAccountByID_Error_Validation(t *testing.T) {
+func TestGetAccountByID_IfNoneMatch(t *testing.T) {
 	mockAccSvc := new(mockAccountsService)
 	svr, err := createServer(mockAccSvc, &mockTransactionsService{})
 	assert.NoError(t, err)
@@ -263,33 +660,60 @@ func TestGetAccountByID_Error_Validation(t *testing.T) {
 	time.Sleep(1 * time.Second)
 
 	defer func() {
-		if err := svr.Shutdown(); err != nil {
+		if err := svr.Shutdown(context.Background()); err != nil {
 			t.Errorf("shutdown error: %v", err)
 		}
 	}()
 
-	resp, err := http.Get("http://localhost:8080/accounts/foobar")
+	expected := accounts.Account{
+		ID:             1,
+		DocumentNumber: "12345678900",
+		Version:        3,
+	}
+
+	mockAccSvc.On("GetAccountByID", mock.Anything, expected.ID).Return(expected, nil)
 
-	assert.NoError(t, err)
+	type testCase struct {
+		name   string
+		header string
+		status int
+	}
 
-	body, err := io.ReadAll(resp.Body)
+	tsdata := []testCase{
+		{"Current version", `"3"`, http.StatusNotModified},
+		{"Weak tag", `W/"3"`, http.StatusNotModified},
+		{"One of the tags", `"2", "3"`, http.StatusNotModified},
+		{"Any version", `*`, http.StatusNotModified},
+		{"Stale version", `"2"`, http.StatusOK},
+		{"Unknown tag", `"abc"`, http.StatusOK},
+	}
 
-	assert.NoError(t, err)
-	defer resp.Body.Close()
+	for _, tc := range tsdata {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost:8080/accounts/%d", expected.ID), nil)
+			assert.NoError(t, err)
+			req.Header.Set("If-None-Match", tc.header)
 
-	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			resp, err := client.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
 
-	var result api.Error
+			body, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
 
-	err = json.Unmarshal(body, &result)
-	assert.NoError(t, err)
-	assert.Equal(t, "badRequest", result.Code)
-	mockAccSvc.AssertExpectations(t)
+			assert.Equal(t, tc.status, resp.StatusCode)
+			assert.Equal(t, `"3"`, resp.Header.Get("ETag"))
+
+			if tc.status == http.StatusNotModified {
+				assert.Empty(t, body)
+			}
+		})
+	}
 }
 
-func TestCreateTransaction_Success(t *testing.T) {
-	mockTxSvc := new(mockTransactionsService)
-	svr, err := createServer(&mockAccountsService{}, mockTxSvc)
+func TestUpdateAccount(t *testing.T) {
+	mockAccSvc := new(mockAccountsService)
+	svr, err := createServer(mockAccSvc, &mockTransactionsService{})
 	assert.NoError(t, err)
 
 	go func() {
@@ -301,20 +725,192 @@ func TestCreateTransaction_Success(t *testing.T) {
 	time.Sleep(1 * time.Second)
 
 	defer func() {
-		if err := svr.Shutdown(); err != nil {
+		if err := svr.Shutdown(context.Background()); err != nil {
 			t.Errorf("shutdown error: %v", err)
 		}
 	}()
 
-	expected := transactions.Transaction{
-		ID:            1,
-		AccountID:     1,
-		OperationType: transactions.OperationTypePurchase,
-		Amount:        100.0,
-		EventDate:     time.Now(),
+	blocked := true
+	update := accounts.AccountUpdate{Blocked: &blocked}
+
+	mockAccSvc.On("UpdateAccount", mock.Anything, int64(1), update, []int64{3}).Return(accounts.Account{
+		ID:             1,
+		DocumentNumber: "12345678900",
+		Blocked:        true,
+		Version:        4,
+	}, nil)
+	mockAccSvc.On("UpdateAccount", mock.Anything, int64(1), update, []int64{2}).
+		Return(accounts.Account{}, fmt.Errorf("account %w: 1", accounts.ErrVersionMismatch))
+	mockAccSvc.On("UpdateAccount", mock.Anything, int64(1), update, []int64(nil)).Return(accounts.Account{
+		ID:             1,
+		DocumentNumber: "12345678900",
+		Blocked:        true,
+		Version:        5,
+	}, nil)
+	mockAccSvc.On("UpdateAccount", mock.Anything, int64(1), update, []int64{}).
+		Return(accounts.Account{}, fmt.Errorf("account %w: 1", accounts.ErrVersionMismatch))
+
+	type testCase struct {
+		name   string
+		header string
+		status int
+		code   string
+		etag   string
 	}
 
-	input := transactions.TransactionCreation{
+	tsdata := []testCase{
+		{"Current version", `"3"`, http.StatusOK, "", `"4"`},
+		{"Any version", `*`, http.StatusOK, "", `"5"`},
+		{"Stale version", `"2"`, http.StatusPreconditionFailed, "versionMismatch", ""},
+		{"Weak tag", `W/"3"`, http.StatusPreconditionFailed, "versionMismatch", ""},
+		{"Missing header", "", http.StatusPreconditionRequired, "preconditionRequired", ""},
+	}
+
+	for _, tc := range tsdata {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPatch, "http://localhost:8080/accounts/1", toJSON(t, api.AccountUpdateRequest{
+				Blocked: &blocked,
+			}))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			if tc.header != "" {
+				req.Header.Set("If-Match", tc.header)
+			}
+
+			resp, err := client.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.status, resp.StatusCode)
+			assert.Equal(t, tc.etag, resp.Header.Get("ETag"))
+
+			if tc.code != "" {
+				var result api.Problem
+
+				assert.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+				assert.Equal(t, tc.code, result.Code)
+			} else {
+				var result api.Account
+
+				assert.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+				assert.True(t, result.Blocked)
+			}
+		})
+	}
+}
+
+func TestGetAccountByID_Error_NotFound(t *testing.T) {
+	mockAccSvc := new(mockAccountsService)
+	svr, err := createServer(mockAccSvc, &mockTransactionsService{})
+	assert.NoError(t, err)
+
+	go func() {
+		if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+			t.Errorf("server error: %v", err)
+		}
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer func() {
+		if err := svr.Shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown error: %v", err)
+		}
+	}()
+
+	expected := accounts.Account{
+		ID:             1,
+		DocumentNumber: "12345678900",
+	}
+
+	mockAccSvc.On("GetAccountByID", mock.Anything, expected.ID).Return(accounts.Account{}, common.ErrNotFound)
+
+	resp, err := client.Get(fmt.Sprintf("http://localhost:8080/accounts/%d", expected.ID))
+
+	assert.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	var result api.Problem
+
+	err = json.Unmarshal(body, &result)
+	assert.NoError(t, err)
+	assert.Equal(t, "notFound", result.Code)
+	mockAccSvc.AssertExpectations(t)
+}
+
+func TestGetAccountByID_Error_Validation(t *testing.T) {
+	mockAccSvc := new(mockAccountsService)
+	svr, err := createServer(mockAccSvc, &mockTransactionsService{})
+	assert.NoError(t, err)
+
+	go func() {
+		if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+			t.Errorf("server error: %v", err)
+		}
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer func() {
+		if err := svr.Shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown error: %v", err)
+		}
+	}()
+
+	resp, err := client.Get("http://localhost:8080/accounts/foobar")
+
+	assert.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var result api.Problem
+
+	err = json.Unmarshal(body, &result)
+	assert.NoError(t, err)
+	assert.Equal(t, "badRequest", result.Code)
+	mockAccSvc.AssertExpectations(t)
+}
+
+func TestCreateTransaction_Success(t *testing.T) {
+	mockTxSvc := new(mockTransactionsService)
+	svr, err := createServer(&mockAccountsService{}, mockTxSvc)
+	assert.NoError(t, err)
+
+	go func() {
+		if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+			t.Errorf("server error: %v", err)
+		}
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer func() {
+		if err := svr.Shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown error: %v", err)
+		}
+	}()
+
+	expected := transactions.Transaction{
+		ID:            1,
+		AccountID:     1,
+		OperationType: transactions.OperationTypePurchase,
+		Amount:        100.0,
+		EventDate:     time.Now(),
+	}
+
+	input := transactions.TransactionCreation{
 		AccountID:     expected.AccountID,
 		OperationType: expected.OperationType,
 		Amount:        expected.Amount,
@@ -327,7 +923,7 @@ func TestCreateTransaction_Success(t *testing.T) {
 		OperationTypeId: api.OperationType(input.OperationType),
 		Amount:          input.Amount,
 	})
-	resp, err := http.Post("http://localhost:8080/transactions", "application/json", payload)
+	resp, err := client.Post("http://localhost:8080/transactions", "application/json", payload)
 	assert.NoError(t, err)
 
 	body, err := io.ReadAll(resp.Body)
@@ -347,6 +943,220 @@ func TestCreateTransaction_Success(t *testing.T) {
 	mockTxSvc.AssertExpectations(t)
 }
 
+func TestCreateTransaction_Merchant(t *testing.T) {
+	mockTxSvc := new(mockTransactionsService)
+	svr, err := createServer(&mockAccountsService{}, mockTxSvc)
+	assert.NoError(t, err)
+
+	go func() {
+		if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+			t.Errorf("server error: %v", err)
+		}
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer func() {
+		if err := svr.Shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown error: %v", err)
+		}
+	}()
+
+	input := transactions.TransactionCreation{
+		AccountID:     1,
+		OperationType: transactions.OperationTypePurchase,
+		Amount:        4.5,
+		Merchant:      &transactions.Merchant{ID: "M-1", Name: "Corner Cafe", MCC: "5814"},
+	}
+
+	// the merchant is known, the response carries the details stored before
+	mockTxSvc.On("CreateTransaction", mock.Anything, input).Return(transactions.Transaction{
+		ID:            1,
+		AccountID:     1,
+		OperationType: transactions.OperationTypePurchase,
+		Amount:        -4.5,
+		EventDate:     time.Now(),
+		Merchant:      &transactions.Merchant{ID: "M-1", Name: "Corner Cafe", City: "Lisbon", Country: "PT", MCC: "5814"},
+	}, nil)
+
+	payload := toJSON(t, map[string]any{
+		"account_id":        1,
+		"operation_type_id": 1,
+		"amount":            4.5,
+		"merchant":          map[string]any{"id": "M-1", "name": "Corner Cafe", "mcc": "5814"},
+	})
+	resp, err := client.Post("http://localhost:8080/transactions", "application/json", payload)
+	assert.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var creationResult api.CreateTransaction201JSONResponse
+	assert.NoError(t, json.Unmarshal(body, &creationResult))
+	assert.NotNil(t, creationResult.Merchant)
+	assert.Equal(t, "M-1", creationResult.Merchant.Id)
+	assert.Equal(t, "Lisbon", *creationResult.Merchant.City)
+	assert.Equal(t, "5814", *creationResult.Merchant.Mcc)
+	mockTxSvc.AssertExpectations(t)
+}
+
+func TestExportTransactions_Success(t *testing.T) {
+	mockAccSvc := new(mockAccountsService)
+	mockTxSvc := new(mockTransactionsService)
+	svr, err := createServer(mockAccSvc, mockTxSvc)
+	assert.NoError(t, err)
+
+	go func() {
+		if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+			t.Errorf("server error: %v", err)
+		}
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer func() {
+		if err := svr.Shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown error: %v", err)
+		}
+	}()
+
+	from := time.Date(2025, time.August, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, time.September, 1, 0, 0, 0, 0, time.UTC)
+	eventDate := time.Date(2025, time.August, 30, 12, 0, 0, 0, time.UTC)
+
+	mockAccSvc.On("GetAccountByID", mock.Anything, int64(1)).Return(accounts.Account{ID: 1}, nil)
+	mockTxSvc.On("ExportTransactions", mock.Anything, transactions.ExportFilter{
+		AccountID: 1,
+		From:      &from,
+		To:        &to,
+	}).Return([]transactions.Transaction{
+		{ID: 1, AccountID: 1, OperationType: transactions.OperationTypePurchase, Amount: -50, EventDate: eventDate},
+		{ID: 2, AccountID: 1, OperationType: transactions.OperationTypePayment, Amount: 60.5, EventDate: eventDate},
+	}, nil)
+
+	type testCase struct {
+		format      string
+		contentType string
+		expected    []string
+	}
+
+	tsdata := []testCase{
+		{"csv", "text/csv", []string{
+			"transaction_id,account_id,operation_type_id,operation_type,amount,event_date,merchant_id,merchant_name,merchant_city,merchant_country,mcc\n",
+			"1,1,1,purchase,-50.00,2025-08-30T12:00:00Z,,,,,\n",
+			"2,1,4,payment,60.50,2025-08-30T12:00:00Z,,,,,\n",
+		}},
+		{"ndjson", "application/x-ndjson", []string{
+			`{"transaction_id":1,"account_id":1,"operation_type_id":1,"operation_type":"purchase","amount":-50,"event_date":"2025-08-30T12:00:00Z"}` + "\n",
+			`{"transaction_id":2,"account_id":1,"operation_type_id":4,"operation_type":"payment","amount":60.5,"event_date":"2025-08-30T12:00:00Z"}` + "\n",
+		}},
+		{"ofx", "application/x-ofx", []string{
+			"<TRNTYPE>POS</TRNTYPE>\n<DTPOSTED>20250830120000.000[0:GMT]</DTPOSTED>\n<TRNAMT>-50.00</TRNAMT>\n<FITID>1</FITID>",
+			"<TRNTYPE>CREDIT</TRNTYPE>\n<DTPOSTED>20250830120000.000[0:GMT]</DTPOSTED>\n<TRNAMT>60.50</TRNAMT>\n<FITID>2</FITID>",
+			"<DTSTART>20250801000000.000[0:GMT]</DTSTART>\n<DTEND>20250901000000.000[0:GMT]</DTEND>",
+			"<BALAMT>10.50</BALAMT>",
+			"</OFX>\n",
+		}},
+	}
+
+	for _, tc := range tsdata {
+		t.Run(tc.format, func(t *testing.T) {
+			resp, err := client.Get("http://localhost:8080/accounts/1/transactions/export?format=" + tc.format +
+				"&from=2025-08-01T00:00:00Z&to=2025-09-01T00:00:00Z")
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, tc.contentType, resp.Header.Get("Content-Type"))
+			assert.Equal(t, `attachment; filename="account-1-transactions.`+tc.format+`"`, resp.Header.Get("Content-Disposition"))
+
+			for _, expected := range tc.expected {
+				assert.Contains(t, string(body), expected)
+			}
+		})
+	}
+
+	mockAccSvc.AssertExpectations(t)
+	mockTxSvc.AssertExpectations(t)
+}
+
+func TestExportTransactions_Error_AccountNotFound(t *testing.T) {
+	mockAccSvc := new(mockAccountsService)
+	mockTxSvc := new(mockTransactionsService)
+	svr, err := createServer(mockAccSvc, mockTxSvc)
+	assert.NoError(t, err)
+
+	go func() {
+		if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+			t.Errorf("server error: %v", err)
+		}
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer func() {
+		if err := svr.Shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown error: %v", err)
+		}
+	}()
+
+	mockAccSvc.On("GetAccountByID", mock.Anything, int64(1)).
+		Return(accounts.Account{}, fmt.Errorf("account %w: %d", common.ErrNotFound, 1))
+
+	resp, err := client.Get("http://localhost:8080/accounts/1/transactions/export?format=ndjson")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	var problem api.Problem
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "notFound", problem.Code)
+	mockTxSvc.AssertNotCalled(t, "ExportTransactions", mock.Anything, mock.Anything)
+}
+
+func TestCreateTransaction_Error_DuplicateExternalRef(t *testing.T) {
+	mockTxSvc := new(mockTransactionsService)
+	svr, err := createServer(&mockAccountsService{}, mockTxSvc)
+	assert.NoError(t, err)
+
+	go func() {
+		if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+			t.Errorf("server error: %v", err)
+		}
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer func() {
+		if err := svr.Shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown error: %v", err)
+		}
+	}()
+
+	mockTxSvc.On("CreateTransaction", mock.Anything, transactions.TransactionCreation{
+		AccountID:     1,
+		OperationType: transactions.OperationTypePurchase,
+		Amount:        10,
+		ExternalRef:   "PRC-1",
+	}).Return(transactions.Transaction{}, fmt.Errorf("transaction external_ref %w: PRC-1", common.ErrDuplicate))
+
+	resp, err := client.Post("http://localhost:8080/transactions", "application/json",
+		strings.NewReader(`{"account_id": 1, "operation_type_id": 1, "amount": 10, "external_ref": "PRC-1"}`))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	var problem api.Problem
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "duplicate", problem.Code)
+	mockTxSvc.AssertExpectations(t)
+}
+
 func TestCreateTransaction_Error_AccountIDNotFound(t *testing.T) {
 	mockTxSvc := new(mockTransactionsService)
 	svr, err := createServer(&mockAccountsService{}, mockTxSvc)
@@ -361,7 +1171,7 @@ func TestCreateTransaction_Error_AccountIDNotFound(t *testing.T) {
 	time.Sleep(1 * time.Second)
 
 	defer func() {
-		if err := svr.Shutdown(); err != nil {
+		if err := svr.Shutdown(context.Background()); err != nil {
 			t.Errorf("shutdown error: %v", err)
 		}
 	}()
@@ -379,7 +1189,7 @@ func TestCreateTransaction_Error_AccountIDNotFound(t *testing.T) {
 		OperationTypeId: api.OperationType(input.OperationType),
 		Amount:          input.Amount,
 	})
-	resp, err := http.Post("http://localhost:8080/transactions", "application/json", payload)
+	resp, err := client.Post("http://localhost:8080/transactions", "application/json", payload)
 	assert.NoError(t, err)
 
 	body, err := io.ReadAll(resp.Body)
@@ -388,7 +1198,7 @@ func TestCreateTransaction_Error_AccountIDNotFound(t *testing.T) {
 
 	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
 
-	var creationResult api.Error
+	var creationResult api.Problem
 	err = json.Unmarshal(body, &creationResult)
 	assert.NoError(t, err)
 	assert.Equal(t, "notFound", creationResult.Code)
@@ -404,6 +1214,8 @@ func TestCreateTransaction_Error_Validation(t *testing.T) {
 		payload api.TransactionCreateRequest
 	}
 
+	invalidMCC := "58a4"
+
 	tsdata := []testCase{
 		{
 			name: "Missing account ID",
@@ -434,9 +1246,18 @@ func TestCreateTransaction_Error_Validation(t *testing.T) {
 				Amount:          100.0,
 			},
 		},
-	}
-
-	go func() {
+		{
+			name: "Invalid merchant MCC",
+			payload: api.TransactionCreateRequest{
+				AccountId:       1,
+				OperationTypeId: api.OperationType(transactions.OperationTypePurchase),
+				Amount:          100.0,
+				Merchant:        &api.Merchant{Id: "M-1", Mcc: &invalidMCC},
+			},
+		},
+	}
+
+	go func() {
 		if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
 			t.Errorf("server error: %v", err)
 		}
@@ -445,7 +1266,7 @@ func TestCreateTransaction_Error_Validation(t *testing.T) {
 	time.Sleep(1 * time.Second)
 
 	defer func() {
-		if err := svr.Shutdown(); err != nil {
+		if err := svr.Shutdown(context.Background()); err != nil {
 			t.Errorf("shutdown error: %v", err)
 		}
 	}()
@@ -453,7 +1274,7 @@ func TestCreateTransaction_Error_Validation(t *testing.T) {
 	for _, tc := range tsdata {
 		t.Run(tc.name, func(t *testing.T) {
 			payload := toJSON(t, tc.payload)
-			resp, err := http.Post("http://localhost:8080/transactions", "application/json", payload)
+			resp, err := client.Post("http://localhost:8080/transactions", "application/json", payload)
 			assert.NoError(t, err)
 
 			body, err := io.ReadAll(resp.Body)
@@ -462,10 +1283,1416 @@ func TestCreateTransaction_Error_Validation(t *testing.T) {
 
 			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 
-			var creationResult api.Error
+			var creationResult api.Problem
 			err = json.Unmarshal(body, &creationResult)
 			assert.NoError(t, err)
 			assert.Equal(t, "badRequest", creationResult.Code)
 		})
 	}
 }
+
+// panickingTokenVerifier fails the authentication middleware with a panic.
+type panickingTokenVerifier struct{}
+
+func (panickingTokenVerifier) Verify(context.Context, string) (auth.Principal, error) {
+	panic("verifier is broken")
+}
+
+func TestCreateAccount_Error_PanicInMiddleware(t *testing.T) {
+	mockAccSvc := new(mockAccountsService)
+	svr, err := createServer(mockAccSvc, &mockTransactionsService{}, func(opts *server.Options) {
+		opts.Tokens = panickingTokenVerifier{}
+	})
+	assert.NoError(t, err)
+
+	go func() {
+		if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+			t.Errorf("server error: %v", err)
+		}
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer func() {
+		if err := svr.Shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown error: %v", err)
+		}
+	}()
+
+	req, err := http.NewRequest(http.MethodPost, "http://localhost:8080/accounts", toJSON(t, api.AccountCreateRequest{
+		DocumentNumber: "12345678900",
+	}))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+testBearerToken)
+
+	// the panic is recovered before the connection is dropped
+	resp, err := http.DefaultClient.Do(req)
+
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		assert.NotEmpty(t, resp.Header.Get(echo.HeaderXCorrelationID))
+	}
+
+	mockAccSvc.AssertNotCalled(t, "CreateAccount", mock.Anything, mock.Anything)
+}
+
+func TestCreateAccount_Error_Unauthorized(t *testing.T) {
+	mockAccSvc := new(mockAccountsService)
+	svr, err := createServer(mockAccSvc, &mockTransactionsService{})
+	assert.NoError(t, err)
+
+	go func() {
+		if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+			t.Errorf("server error: %v", err)
+		}
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer func() {
+		if err := svr.Shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown error: %v", err)
+		}
+	}()
+
+	type testCase struct {
+		name string
+		key  string
+	}
+
+	tsdata := []testCase{
+		{"Missing key", ""},
+		{"Unknown key", "rrp_unknown"},
+	}
+
+	for _, tc := range tsdata {
+		t.Run(tc.name, func(t *testing.T) {
+			cl := &http.Client{Transport: apiKeyTransport{key: tc.key}}
+			payload := toJSON(t, api.AccountCreateRequest{
+				DocumentNumber: "12345678900",
+			})
+			resp, err := cl.Post("http://localhost:8080/accounts", "application/json", payload)
+			assert.NoError(t, err)
+
+			body, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+			var result api.Problem
+			err = json.Unmarshal(body, &result)
+			assert.NoError(t, err)
+			assert.Equal(t, "unauthorized", result.Code)
+		})
+	}
+
+	mockAccSvc.AssertExpectations(t)
+}
+
+func TestCreateAccount_Error_Forbidden(t *testing.T) {
+	mockAccSvc := new(mockAccountsService)
+	svr, err := createServer(mockAccSvc, &mockTransactionsService{})
+	assert.NoError(t, err)
+
+	go func() {
+		if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+			t.Errorf("server error: %v", err)
+		}
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer func() {
+		if err := svr.Shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown error: %v", err)
+		}
+	}()
+
+	cl := &http.Client{Transport: apiKeyTransport{key: testReadOnlyAPIKey}}
+	payload := toJSON(t, api.AccountCreateRequest{
+		DocumentNumber: "12345678900",
+	})
+	resp, err := cl.Post("http://localhost:8080/accounts", "application/json", payload)
+	assert.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	var result api.Problem
+	err = json.Unmarshal(body, &result)
+	assert.NoError(t, err)
+	assert.Equal(t, "forbidden", result.Code)
+	if assert.NotNil(t, result.Detail) {
+		assert.Contains(t, *result.Detail, string(auth.ScopeAccountsWrite))
+	}
+	mockAccSvc.AssertExpectations(t)
+}
+
+func TestGetAccountByID_BearerToken(t *testing.T) {
+	mockAccSvc := new(mockAccountsService)
+	svr, err := createServer(mockAccSvc, &mockTransactionsService{})
+	assert.NoError(t, err)
+
+	go func() {
+		if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+			t.Errorf("server error: %v", err)
+		}
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer func() {
+		if err := svr.Shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown error: %v", err)
+		}
+	}()
+
+	expected := accounts.Account{
+		ID:             1,
+		DocumentNumber: "12345678900",
+	}
+
+	mockAccSvc.On("GetAccountByID", mock.Anything, expected.ID).Return(expected, nil)
+
+	type testCase struct {
+		name   string
+		token  string
+		status int
+	}
+
+	tsdata := []testCase{
+		{"Valid token", testBearerToken, http.StatusOK},
+		{"Invalid token", "invalid", http.StatusUnauthorized},
+	}
+
+	for _, tc := range tsdata {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost:8080/accounts/%d", expected.ID), nil)
+			assert.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.status, resp.StatusCode)
+		})
+	}
+}
+
+func TestGetAccountByID_Error_RateLimited(t *testing.T) {
+	mockAccSvc := new(mockAccountsService)
+	svr, err := createServer(mockAccSvc, &mockTransactionsService{}, func(opts *server.Options) {
+		opts.RateLimit = &ratelimit.Policy{
+			Limiter: ratelimit.NewMemoryLimiter(ratelimit.MemoryOptions{}),
+			Default: ratelimit.Rule{Limit: 100, Period: time.Minute},
+			Operations: map[string]ratelimit.Rule{
+				"getAccount": {Limit: 2, Period: time.Minute},
+			},
+		}
+	})
+	assert.NoError(t, err)
+
+	go func() {
+		if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+			t.Errorf("server error: %v", err)
+		}
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer func() {
+		if err := svr.Shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown error: %v", err)
+		}
+	}()
+
+	expected := accounts.Account{
+		ID:             1,
+		DocumentNumber: "12345678900",
+	}
+
+	mockAccSvc.On("GetAccountByID", mock.Anything, expected.ID).Return(expected, nil).Times(2)
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(fmt.Sprintf("http://localhost:8080/accounts/%d", expected.ID))
+		assert.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "2", resp.Header.Get(server.HeaderRateLimitLimit))
+		assert.Equal(t, fmt.Sprint(1-i), resp.Header.Get(server.HeaderRateLimitRemaining))
+	}
+
+	resp, err := client.Get(fmt.Sprintf("http://localhost:8080/accounts/%d", expected.ID))
+	assert.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "0", resp.Header.Get(server.HeaderRateLimitRemaining))
+	assert.NotEmpty(t, resp.Header.Get(server.HeaderRetryAfter))
+
+	var result api.Problem
+	err = json.Unmarshal(body, &result)
+	assert.NoError(t, err)
+	assert.Equal(t, "rateLimited", result.Code)
+	mockAccSvc.AssertExpectations(t)
+}
+
+func TestRateLimit_ForwardedFor(t *testing.T) {
+	_, loopback, err := net.ParseCIDR("127.0.0.1/32")
+	assert.NoError(t, err)
+
+	type testCase struct {
+		name    string
+		proxies []*net.IPNet
+		// expected is the status of the third request, the limit is 2
+		expected int
+	}
+
+	tsdata := []testCase{
+		// the header is set by the client, rotating it does not reset the budget
+		{"Spoofed", nil, http.StatusTooManyRequests},
+		// the header is set by a trusted proxy, each address has its own budget
+		{"TrustedProxy", []*net.IPNet{loopback}, http.StatusUnauthorized},
+	}
+
+	anonymous := &http.Client{}
+
+	for _, tc := range tsdata {
+		t.Run(tc.name, func(t *testing.T) {
+			svr, err := createServer(&mockAccountsService{}, &mockTransactionsService{}, func(opts *server.Options) {
+				opts.TrustedProxies = tc.proxies
+				opts.RateLimit = &ratelimit.Policy{
+					Limiter: ratelimit.NewMemoryLimiter(ratelimit.MemoryOptions{}),
+					Operations: map[string]ratelimit.Rule{
+						"getAccount": {Limit: 2, Period: time.Minute},
+					},
+				}
+			})
+			assert.NoError(t, err)
+
+			go func() {
+				if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+					t.Errorf("server error: %v", err)
+				}
+			}()
+
+			time.Sleep(1 * time.Second)
+
+			defer func() {
+				if err := svr.Shutdown(context.Background()); err != nil {
+					t.Errorf("shutdown error: %v", err)
+				}
+			}()
+
+			statuses := make([]int, 0, 3)
+
+			for i := 1; i <= 3; i++ {
+				req, err := http.NewRequest(http.MethodGet, "http://localhost:8080/accounts/1", nil)
+				assert.NoError(t, err)
+				req.Header.Set(echo.HeaderXForwardedFor, fmt.Sprintf("203.0.113.%d", i))
+				req.Header.Set(echo.HeaderXRealIP, fmt.Sprintf("203.0.113.%d", i))
+
+				resp, err := anonymous.Do(req)
+				assert.NoError(t, err)
+				resp.Body.Close()
+
+				statuses = append(statuses, resp.StatusCode)
+			}
+
+			assert.Equal(t, []int{http.StatusUnauthorized, http.StatusUnauthorized, tc.expected}, statuses)
+		})
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	mockAccSvc := new(mockAccountsService)
+	m := metrics.New()
+	svr, err := createServer(metrics.NewAccountsService(mockAccSvc, m), &mockTransactionsService{}, func(opts *server.Options) {
+		opts.Metrics = m
+	})
+	assert.NoError(t, err)
+
+	go func() {
+		if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+			t.Errorf("server error: %v", err)
+		}
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer func() {
+		if err := svr.Shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown error: %v", err)
+		}
+	}()
+
+	created := accounts.Account{
+		ID:             1,
+		DocumentNumber: "12345678900",
+	}
+
+	mockAccSvc.On("CreateAccount", mock.Anything, accounts.AccountCreation{DocumentNumber: created.DocumentNumber}).Return(created, nil)
+	mockAccSvc.On("GetAccountByID", mock.Anything, int64(2)).Return(accounts.Account{}, common.ErrNotFound)
+
+	resp, err := client.Post("http://localhost:8080/accounts", "application/json", toJSON(t, api.AccountCreateRequest{
+		DocumentNumber: created.DocumentNumber,
+	}))
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, err = client.Get("http://localhost:8080/accounts/2")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = client.Get("http://localhost:8080/health")
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	resp, err = http.Get("http://localhost:8080/metrics")
+	assert.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	out := string(body)
+	assert.Contains(t, out, `rmrf_http_requests_total{method="POST",operation="createAccount",status="201"} 1`)
+	assert.Contains(t, out, `rmrf_http_requests_total{method="GET",operation="getAccount",status="404"} 1`)
+	assert.Contains(t, out, `rmrf_http_request_errors_total{method="GET",operation="getAccount",status="404"} 1`)
+	assert.Contains(t, out, `rmrf_http_request_duration_seconds_count{method="POST",operation="createAccount"} 1`)
+	assert.Contains(t, out, "rmrf_accounts_created_total 1")
+	assert.False(t, strings.Contains(out, `status="200"`), "service routes must not be measured")
+	mockAccSvc.AssertExpectations(t)
+}
+
+func TestProbes(t *testing.T) {
+	svr, err := createServer(&mockAccountsService{}, &mockTransactionsService{}, func(opts *server.Options) {
+		opts.Readiness = health.NewChecker(time.Second,
+			health.Check{Name: "database", Func: func(context.Context) error { return nil }},
+			health.Check{Name: "schema", Func: func(context.Context) error { return errors.New("schema version 4 is behind expected version 5") }},
+		)
+	})
+	assert.NoError(t, err)
+
+	go func() {
+		if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+			t.Errorf("server error: %v", err)
+		}
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer func() {
+		if err := svr.Shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown error: %v", err)
+		}
+	}()
+
+	resp, err := http.Get("http://localhost:8080/livez")
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Get("http://localhost:8080/readyz")
+	assert.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	var report health.Report
+	err = json.Unmarshal(body, &report)
+	assert.NoError(t, err)
+	assert.Equal(t, health.StatusFailing, report.Status)
+	assert.Equal(t, health.StatusOK, report.Checks["database"].Status)
+	assert.Equal(t, health.StatusFailing, report.Checks["schema"].Status)
+	assert.Equal(t, "schema version 4 is behind expected version 5", report.Checks["schema"].Error)
+}
+
+func TestShutdown_DrainsInFlightRequests(t *testing.T) {
+	mockAccSvc := new(mockAccountsService)
+	svr, err := createServer(mockAccSvc, &mockTransactionsService{})
+	assert.NoError(t, err)
+
+	go func() {
+		if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+			t.Errorf("server error: %v", err)
+		}
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	expected := accounts.Account{
+		ID:             1,
+		DocumentNumber: "12345678900",
+	}
+
+	started := make(chan struct{})
+
+	mockAccSvc.On("GetAccountByID", mock.Anything, expected.ID).Run(func(_ mock.Arguments) {
+		close(started)
+		time.Sleep(500 * time.Millisecond)
+	}).Return(expected, nil)
+
+	inFlight := make(chan int, 1)
+
+	go func() {
+		resp, err := client.Get(fmt.Sprintf("http://localhost:8080/accounts/%d", expected.ID))
+
+		if err != nil {
+			t.Errorf("in-flight request failed: %v", err)
+			inFlight <- 0
+
+			return
+		}
+
+		resp.Body.Close()
+		inFlight <- resp.StatusCode
+	}()
+
+	<-started
+
+	svr.Drain()
+
+	resp, err := http.Get("http://localhost:8080/readyz")
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	assert.NoError(t, svr.Shutdown(ctx))
+	assert.Equal(t, http.StatusOK, <-inFlight)
+	mockAccSvc.AssertExpectations(t)
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func TestGetAccountByID_Tracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	logs := new(syncBuffer)
+	mockAccSvc := new(mockAccountsService)
+	svr, err := createServer(tracing.NewAccountsService(mockAccSvc), &mockTransactionsService{}, func(opts *server.Options) {
+		opts.Logger = zerolog.New(logs)
+	})
+	assert.NoError(t, err)
+
+	go func() {
+		if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+			t.Errorf("server error: %v", err)
+		}
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer func() {
+		if err := svr.Shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown error: %v", err)
+		}
+	}()
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	expected := accounts.Account{
+		ID:             1,
+		DocumentNumber: "12345678900",
+	}
+
+	// the trace of the caller must reach the service layer and its logger
+	withTrace := mock.MatchedBy(func(ctx context.Context) bool {
+		return trace.SpanContextFromContext(ctx).TraceID().String() == traceID
+	})
+
+	mockAccSvc.On("GetAccountByID", withTrace, expected.ID).Run(func(args mock.Arguments) {
+		zerolog.Ctx(args.Get(0).(context.Context)).Info().Msg("service called")
+	}).Return(expected, nil)
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost:8080/accounts/%d", expected.ID), nil)
+	assert.NoError(t, err)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, logs.String(), `"trace_id":"`+traceID+`"`)
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+
+	serverSpan, ok := spans["getAccount"]
+	assert.True(t, ok, "server span is missing")
+	assert.Equal(t, traceID, serverSpan.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", serverSpan.Parent().SpanID().String())
+
+	serviceSpan, ok := spans["accounts.GetAccountByID"]
+	assert.True(t, ok, "service span is missing")
+	assert.Equal(t, serverSpan.SpanContext().SpanID(), serviceSpan.Parent().SpanID())
+	mockAccSvc.AssertExpectations(t)
+}
+
+func TestCreateAccount_Audit(t *testing.T) {
+	mockAccSvc := new(mockAccountsService)
+	auditSvc := &mockAuditService{}
+	svr, err := createServerWithAudit(mockAccSvc, &mockTransactionsService{}, auditSvc)
+	assert.NoError(t, err)
+
+	go func() {
+		if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+			t.Errorf("server error: %v", err)
+		}
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer func() {
+		if err := svr.Shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown error: %v", err)
+		}
+	}()
+
+	creation := accounts.AccountCreation{
+		DocumentNumber: "12345678900",
+	}
+	mockAccSvc.On("CreateAccount", mock.Anything, creation).Return(accounts.Account{
+		ID:             1,
+		DocumentNumber: creation.DocumentNumber,
+		Version:        1,
+	}, nil).Once()
+	mockAccSvc.On("CreateAccount", mock.Anything, creation).Return(accounts.Account{}, common.ErrDuplicate).Once()
+
+	for _, status := range []int{http.StatusCreated, http.StatusConflict} {
+		resp, err := client.Post("http://localhost:8080/accounts", "application/json", toJSON(t, api.AccountCreateRequest{
+			DocumentNumber: creation.DocumentNumber,
+		}))
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, status, resp.StatusCode)
+	}
+
+	// reads are not audited
+	mockAccSvc.On("GetAccountByID", mock.Anything, int64(1)).Return(accounts.Account{ID: 1}, nil)
+	resp, err := client.Get("http://localhost:8080/accounts/1")
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	entries := auditSvc.Entries()
+
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, "acme", entries[0].TenantID)
+		assert.Equal(t, "apikey:1", entries[0].Principal)
+		assert.Equal(t, "createAccount", entries[0].OperationID)
+		assert.NotEmpty(t, entries[0].RequestID)
+		assert.Equal(t, audit.OutcomeSuccess, entries[0].Outcome)
+		assert.Equal(t, http.StatusCreated, entries[0].Status)
+		assert.JSONEq(t, `{"document_number":"[REDACTED]"}`, string(entries[0].Payload))
+
+		assert.Equal(t, audit.OutcomeFailure, entries[1].Outcome)
+		assert.Equal(t, http.StatusConflict, entries[1].Status)
+	}
+
+	mockAccSvc.AssertExpectations(t)
+}
+
+func TestListAuditEntries_Success(t *testing.T) {
+	auditSvc := &mockAuditService{
+		list: []audit.Entry{
+			{
+				ID:          2,
+				TenantID:    "acme",
+				Principal:   "apikey:1",
+				RequestID:   "req-2",
+				OperationID: "createTransaction",
+				Payload:     []byte(`{"account_id":1}`),
+				Outcome:     audit.OutcomeSuccess,
+				Status:      http.StatusCreated,
+				CreatedAt:   time.Now().UTC(),
+			},
+		},
+	}
+	svr, err := createServerWithAudit(&mockAccountsService{}, &mockTransactionsService{}, auditSvc)
+	assert.NoError(t, err)
+
+	go func() {
+		if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+			t.Errorf("server error: %v", err)
+		}
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer func() {
+		if err := svr.Shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown error: %v", err)
+		}
+	}()
+
+	resp, err := client.Get("http://localhost:8080/audit?operation_id=createTransaction&outcome=success&limit=10")
+	assert.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result api.AuditEntryList
+	err = json.Unmarshal(body, &result)
+	assert.NoError(t, err)
+
+	if assert.Len(t, result.Entries, 1) {
+		assert.Equal(t, int64(2), result.Entries[0].Id)
+		assert.Equal(t, "createTransaction", result.Entries[0].OperationId)
+		assert.Equal(t, map[string]any{"account_id": float64(1)}, *result.Entries[0].Payload)
+	}
+
+	if assert.Len(t, auditSvc.filters, 1) {
+		assert.Equal(t, audit.Filter{
+			OperationID: "createTransaction",
+			Outcome:     audit.OutcomeSuccess,
+			Limit:       10,
+		}, auditSvc.filters[0])
+	}
+
+	// the read-only key is not allowed to read the audit log
+	cl := &http.Client{Transport: apiKeyTransport{key: testReadOnlyAPIKey}}
+	resp, err = cl.Get("http://localhost:8080/audit")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestVersions(t *testing.T) {
+	mockAccSvc := new(mockAccountsService)
+	mockTxSvc := new(mockTransactionsService)
+	deprecatedAt := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	sunsetAt := time.Date(2026, time.July, 1, 0, 0, 0, 0, time.UTC)
+	svr, err := createServer(mockAccSvc, mockTxSvc, func(opts *server.Options) {
+		opts.V2 = &server.V2Options{
+			Handler: apiv2.NewHandler(api.NewHandler(mockAccSvc, mockTxSvc, &mockAuditService{}, &mockReconciliationService{}, &mockSchedulerService{}, &mockQueueService{}, &mockDisputesService{})),
+			Spec:    spec.FileV2,
+		}
+		opts.Deprecation = &server.Deprecation{
+			At:     deprecatedAt,
+			Sunset: sunsetAt,
+			Link:   "https://example.com/migration",
+		}
+	})
+	assert.NoError(t, err)
+
+	go func() {
+		if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+			t.Errorf("server error: %v", err)
+		}
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer func() {
+		if err := svr.Shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown error: %v", err)
+		}
+	}()
+
+	eventDate := time.Date(2025, time.August, 30, 12, 0, 0, 0, time.UTC)
+	creation := transactions.TransactionCreation{
+		AccountID:     1,
+		OperationType: transactions.OperationTypePayment,
+		Amount:        10,
+	}
+
+	mockTxSvc.On("CreateTransaction", mock.Anything, creation).Return(transactions.Transaction{
+		ID:            1,
+		AccountID:     1,
+		OperationType: transactions.OperationTypePayment,
+		Amount:        10,
+		EventDate:     eventDate,
+	}, nil)
+
+	type testCase struct {
+		name       string
+		path       string
+		payload    string
+		status     int
+		deprecated bool
+		expected   string
+	}
+
+	tsdata := []testCase{
+		{"v1", "/v1/transactions", `{"account_id": 1, "operation_type_id": 4, "amount": 10}`, http.StatusCreated, true, `"operation_type_id":4`},
+		{"Unversioned alias", "/transactions", `{"account_id": 1, "operation_type_id": 4, "amount": 10}`, http.StatusCreated, true, `"operation_type_id":4`},
+		{"v2", "/v2/transactions", `{"account_id": 1, "operation_type": "payment", "amount": 10}`, http.StatusCreated, false, `"operation_type":"payment"`},
+		{"v2 rejects the v1 contract", "/v2/transactions", `{"account_id": 1, "operation_type_id": 4, "amount": 10}`, http.StatusBadRequest, false, `"field":"operation_type"`},
+		{"v1 rejects the v2 contract", "/v1/transactions", `{"account_id": 1, "operation_type": "payment", "amount": 10}`, http.StatusBadRequest, true, `"field":"operation_type_id"`},
+		{"Unknown version", "/v3/transactions", `{}`, http.StatusNotFound, false, `"code":"notFound"`},
+	}
+
+	for _, tc := range tsdata {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := client.Post("http://localhost:8080"+tc.path, "application/json", strings.NewReader(tc.payload))
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tc.status, resp.StatusCode)
+			assert.Contains(t, string(body), tc.expected)
+
+			if tc.deprecated {
+				assert.Equal(t, fmt.Sprintf("@%d", deprecatedAt.Unix()), resp.Header.Get("Deprecation"))
+				assert.Equal(t, "Wed, 01 Jul 2026 00:00:00 GMT", resp.Header.Get("Sunset"))
+				assert.Equal(t, `<https://example.com/migration>; rel="deprecation"`, resp.Header.Get("Link"))
+			} else {
+				assert.Empty(t, resp.Header.Get("Deprecation"))
+				assert.Empty(t, resp.Header.Get("Sunset"))
+			}
+		})
+	}
+
+	for _, path := range []string{"/openapi.yaml", "/v1/openapi.yaml", "/v2/openapi.yaml"} {
+		resp, err := client.Get("http://localhost:8080" + path)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+	}
+}
+
+func TestUnmatchedRoutes(t *testing.T) {
+	mockAccSvc := new(mockAccountsService)
+	mockTxSvc := new(mockTransactionsService)
+	svr, err := createServer(mockAccSvc, mockTxSvc, func(opts *server.Options) {
+		opts.V2 = &server.V2Options{
+			Handler: apiv2.NewHandler(api.NewHandler(mockAccSvc, mockTxSvc, &mockAuditService{}, &mockReconciliationService{}, &mockSchedulerService{}, &mockQueueService{}, &mockDisputesService{})),
+			Spec:    spec.FileV2,
+		}
+		opts.Deprecation = &server.Deprecation{At: time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)}
+	})
+	assert.NoError(t, err)
+
+	go func() {
+		if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+			t.Errorf("server error: %v", err)
+		}
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer func() {
+		if err := svr.Shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown error: %v", err)
+		}
+	}()
+
+	type testCase struct {
+		name   string
+		method string
+		path   string
+		status int
+	}
+
+	// the requests that match no operation are answered by the router, neither deprecated nor validated
+	tsdata := []testCase{
+		{"Unknown path", http.MethodGet, "/unknown", http.StatusNotFound},
+		{"Unknown nested path", http.MethodPost, "/accounts/1/unknown", http.StatusNotFound},
+		{"Unknown v1 path", http.MethodGet, "/v1/unknown", http.StatusNotFound},
+		{"Unknown v2 path", http.MethodGet, "/v2/unknown", http.StatusNotFound},
+		{"Unmapped method", http.MethodDelete, "/accounts/1", http.StatusMethodNotAllowed},
+		{"Unmapped v1 method", http.MethodDelete, "/v1/accounts/1", http.StatusMethodNotAllowed},
+		{"Unmapped v2 method", http.MethodPut, "/v2/transactions", http.StatusMethodNotAllowed},
+		{"Health", http.MethodGet, "/health", http.StatusOK},
+		{"Liveness", http.MethodGet, "/livez", http.StatusOK},
+		{"Unmapped health method", http.MethodPost, "/health", http.StatusMethodNotAllowed},
+		{"Unmapped spec method", http.MethodDelete, "/openapi.yaml", http.StatusMethodNotAllowed},
+	}
+
+	for _, tc := range tsdata {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, "http://localhost:8080"+tc.path, nil)
+			assert.NoError(t, err)
+
+			resp, err := client.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.status, resp.StatusCode)
+			assert.Empty(t, resp.Header.Get("Deprecation"))
+		})
+	}
+
+	mockAccSvc.AssertNotCalled(t, "GetAccount", mock.Anything, mock.Anything)
+}
+
+func TestGetReconciliation_Success(t *testing.T) {
+	mockRecSvc := new(mockReconciliationService)
+	svr, err := createServerWithServices(&mockAccountsService{}, &mockTransactionsService{}, &mockAuditService{}, mockRecSvc, &mockSchedulerService{}, &mockQueueService{}, &mockDisputesService{})
+	assert.NoError(t, err)
+
+	go func() {
+		if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+			t.Errorf("server error: %v", err)
+		}
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer func() {
+		if err := svr.Shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown error: %v", err)
+		}
+	}()
+
+	date := time.Date(2025, time.August, 30, 0, 0, 0, 0, time.UTC)
+	eventDate := time.Date(2025, time.August, 30, 12, 0, 0, 0, time.UTC)
+
+	mockRecSvc.On("GetReconciliation", mock.Anything, int64(1), reconciliation.Filter{}).Return(reconciliation.Reconciliation{
+		ID:          1,
+		FileName:    "settlement.csv",
+		PeriodStart: date.Add(-24 * time.Hour),
+		PeriodEnd:   date.Add(48 * time.Hour),
+		Summary:     reconciliation.Summary{Matched: 1, MissingInFile: 1},
+		Items: []reconciliation.Item{
+			{
+				Category:    reconciliation.CategoryMatched,
+				Line:        &reconciliation.Line{Number: 2, Reference: "PRC-1", Amount: -10, Date: date},
+				Transaction: &transactions.Transaction{ID: 7, Amount: -10, EventDate: eventDate, ExternalRef: "PRC-1"},
+			},
+			{
+				Category:    reconciliation.CategoryMissingInFile,
+				Transaction: &transactions.Transaction{ID: 8, Amount: 20, EventDate: eventDate, ExternalRef: "PRC-2"},
+			},
+		},
+		CreatedAt: eventDate,
+	}, nil)
+	mockRecSvc.On("GetReconciliation", mock.Anything, int64(2), reconciliation.Filter{Category: reconciliation.CategoryAmountMismatch}).
+		Return(reconciliation.Reconciliation{}, fmt.Errorf("reconciliation %w: %d", common.ErrNotFound, 2))
+
+	resp, err := client.Get("http://localhost:8080/reconciliations/1")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	var res api.Reconciliation
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, api.ReconciliationSummary{Matched: 1, MissingInFile: 1}, res.Summary)
+	assert.Len(t, res.Items, 2)
+
+	matched := res.Items[0]
+	assert.Equal(t, api.ReconciliationCategory("matched"), matched.Category)
+	assert.Equal(t, 2, *matched.Line)
+	assert.Equal(t, "PRC-1", *matched.Reference)
+	assert.Equal(t, -10.0, *matched.FileAmount)
+	assert.Equal(t, int64(7), *matched.TransactionId)
+
+	missing := res.Items[1]
+	assert.Equal(t, api.ReconciliationCategory("missing_in_file"), missing.Category)
+	assert.Nil(t, missing.Line)
+	assert.Nil(t, missing.FileAmount)
+	assert.Equal(t, "PRC-2", *missing.Reference)
+	assert.Equal(t, 20.0, *missing.LedgerAmount)
+
+	resp, err = client.Get("http://localhost:8080/reconciliations/2?category=amount_mismatch")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = client.Get("http://localhost:8080/reconciliations/1?category=unknown")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	mockRecSvc.AssertExpectations(t)
+}
+
+func TestImportSettlement_Success(t *testing.T) {
+	mockQueueSvc := new(mockQueueService)
+	svr, err := createServerWithServices(&mockAccountsService{}, &mockTransactionsService{}, &mockAuditService{}, &mockReconciliationService{}, &mockSchedulerService{}, mockQueueSvc, &mockDisputesService{})
+	assert.NoError(t, err)
+
+	go func() {
+		if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+			t.Errorf("server error: %v", err)
+		}
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer func() {
+		if err := svr.Shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown error: %v", err)
+		}
+	}()
+
+	at := time.Date(2025, time.August, 31, 3, 0, 0, 0, time.UTC)
+	content := "reference,amount,date\nPRC-1,-10.00,2025-08-30\n"
+
+	mockQueueSvc.On("Enqueue", mock.Anything, mock.MatchedBy(func(creation queue.JobCreation) bool {
+		var payload reconciliation.ImportPayload
+
+		return creation.Type == reconciliation.JobType &&
+			json.Unmarshal(creation.Payload, &payload) == nil &&
+			payload == reconciliation.ImportPayload{FileName: "settlement.csv", Content: content}
+	})).Return(queue.Job{
+		ID:          3,
+		Type:        reconciliation.JobType,
+		Status:      queue.StatusQueued,
+		MaxAttempts: 5,
+		RunAt:       at,
+		CreatedAt:   at,
+		UpdatedAt:   at,
+	}, nil)
+
+	body, err := json.Marshal(map[string]string{"file_name": "settlement.csv", "content": content})
+	require.NoError(t, err)
+
+	resp, err := client.Post("http://localhost:8080/reconciliations", "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	var res api.Job
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, "jobs/3", resp.Header.Get("Location"))
+	assert.Equal(t, int64(3), res.Id)
+	assert.Equal(t, api.JobStatus("queued"), res.Status)
+	assert.Nil(t, res.Result)
+
+	resp, err = client.Post("http://localhost:8080/reconciliations", "application/json", strings.NewReader(`{"file_name":"settlement.csv"}`))
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "missing content")
+
+	mockQueueSvc.AssertExpectations(t)
+}
+
+func TestGetJob_Success(t *testing.T) {
+	mockQueueSvc := new(mockQueueService)
+	svr, err := createServerWithServices(&mockAccountsService{}, &mockTransactionsService{}, &mockAuditService{}, &mockReconciliationService{}, &mockSchedulerService{}, mockQueueSvc, &mockDisputesService{})
+	assert.NoError(t, err)
+
+	go func() {
+		if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+			t.Errorf("server error: %v", err)
+		}
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer func() {
+		if err := svr.Shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown error: %v", err)
+		}
+	}()
+
+	at := time.Date(2025, time.August, 31, 3, 0, 0, 0, time.UTC)
+	finishedAt := at.Add(2 * time.Second)
+
+	mockQueueSvc.On("GetJob", mock.Anything, int64(3)).Return(queue.Job{
+		ID:          3,
+		Type:        reconciliation.JobType,
+		Status:      queue.StatusSucceeded,
+		Attempts:    2,
+		MaxAttempts: 5,
+		RunAt:       at,
+		LastError:   "connection refused",
+		Result:      json.RawMessage(`{"reconciliation_id":7}`),
+		CreatedAt:   at,
+		UpdatedAt:   finishedAt,
+		FinishedAt:  &finishedAt,
+	}, nil)
+	mockQueueSvc.On("GetJob", mock.Anything, int64(4)).Return(queue.Job{}, fmt.Errorf("job %w: %d", common.ErrNotFound, 4))
+
+	resp, err := client.Get("http://localhost:8080/jobs/3")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	var res api.Job
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, api.JobStatus("succeeded"), res.Status)
+	assert.Equal(t, 2, res.Attempts)
+	assert.Equal(t, "connection refused", *res.LastError)
+	require.NotNil(t, res.Result)
+	assert.Equal(t, map[string]any{"reconciliation_id": 7.0}, *res.Result)
+	assert.True(t, finishedAt.Equal(*res.FinishedAt))
+
+	resp, err = client.Get("http://localhost:8080/jobs/4")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	mockQueueSvc.AssertExpectations(t)
+}
+
+func TestOpenDispute_Success(t *testing.T) {
+	mockDisputesSvc := new(mockDisputesService)
+	svr, err := createServerWithServices(&mockAccountsService{}, &mockTransactionsService{}, &mockAuditService{}, &mockReconciliationService{}, &mockSchedulerService{}, &mockQueueService{}, mockDisputesSvc)
+	assert.NoError(t, err)
+
+	go func() {
+		if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+			t.Errorf("server error: %v", err)
+		}
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer func() {
+		if err := svr.Shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown error: %v", err)
+		}
+	}()
+
+	at := time.Date(2025, time.August, 31, 3, 0, 0, 0, time.UTC)
+
+	mockDisputesSvc.On("OpenDispute", mock.Anything, disputes.Opening{AccountID: 1, TransactionID: 5, Reason: "not received"}).Return(disputes.Dispute{
+		ID:                  3,
+		AccountID:           1,
+		TransactionID:       5,
+		CreditTransactionID: 6,
+		Status:              disputes.StatusOpened,
+		Reason:              "not received",
+		Amount:              12.5,
+		CreatedAt:           at,
+		UpdatedAt:           at,
+		Events: []disputes.Event{
+			{ID: 1, Status: disputes.StatusOpened, Principal: "apikey:1", Note: "not received", CreatedAt: at},
+		},
+	}, nil)
+	mockDisputesSvc.On("OpenDispute", mock.Anything, disputes.Opening{AccountID: 1, TransactionID: 7, Reason: "fraud"}).
+		Return(disputes.Dispute{}, fmt.Errorf("%w: payment 7", disputes.ErrNotDisputable))
+	mockDisputesSvc.On("OpenDispute", mock.Anything, disputes.Opening{AccountID: 1, TransactionID: 8, Reason: "fraud"}).
+		Return(disputes.Dispute{}, fmt.Errorf("dispute of transaction %w: 8", common.ErrDuplicate))
+
+	resp, err := client.Post("http://localhost:8080/accounts/1/disputes", "application/json",
+		strings.NewReader(`{"transaction_id":5,"reason":"not received"}`))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	var res api.Dispute
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, int64(3), res.Id)
+	assert.Equal(t, int64(6), res.CreditTransactionId)
+	assert.Nil(t, res.ReversalTransactionId)
+	assert.Equal(t, api.DisputeStatus("opened"), res.Status)
+	assert.Equal(t, 12.5, res.Amount)
+	require.Len(t, res.Events, 1)
+	assert.Equal(t, "apikey:1", res.Events[0].Principal)
+
+	for body, status := range map[string]int{
+		`{"transaction_id":7,"reason":"fraud"}`: http.StatusUnprocessableEntity,
+		`{"transaction_id":8,"reason":"fraud"}`: http.StatusConflict,
+		`{"transaction_id":5}`:                  http.StatusBadRequest,
+	} {
+		resp, err := client.Post("http://localhost:8080/accounts/1/disputes", "application/json", strings.NewReader(body))
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, status, resp.StatusCode, body)
+	}
+
+	mockDisputesSvc.AssertExpectations(t)
+}
+
+func TestUpdateDispute_Success(t *testing.T) {
+	mockDisputesSvc := new(mockDisputesService)
+	svr, err := createServerWithServices(&mockAccountsService{}, &mockTransactionsService{}, &mockAuditService{}, &mockReconciliationService{}, &mockSchedulerService{}, &mockQueueService{}, mockDisputesSvc)
+	assert.NoError(t, err)
+
+	go func() {
+		if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+			t.Errorf("server error: %v", err)
+		}
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer func() {
+		if err := svr.Shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown error: %v", err)
+		}
+	}()
+
+	at := time.Date(2025, time.August, 31, 3, 0, 0, 0, time.UTC)
+	resolvedAt := at.Add(48 * time.Hour)
+
+	mockDisputesSvc.On("UpdateStatus", mock.Anything, int64(3), disputes.Change{Status: disputes.StatusLost, Note: "proof of delivery"}).Return(disputes.Dispute{
+		ID:                    3,
+		AccountID:             1,
+		TransactionID:         5,
+		CreditTransactionID:   6,
+		ReversalTransactionID: 9,
+		Status:                disputes.StatusLost,
+		Reason:                "not received",
+		Amount:                12.5,
+		CreatedAt:             at,
+		UpdatedAt:             resolvedAt,
+		ResolvedAt:            &resolvedAt,
+	}, nil)
+	mockDisputesSvc.On("UpdateStatus", mock.Anything, int64(4), disputes.Change{Status: disputes.StatusWon}).
+		Return(disputes.Dispute{}, fmt.Errorf("%w: opened to won", disputes.ErrInvalidTransition))
+
+	patch := func(id int, body string) *http.Response {
+		req, err := http.NewRequest(http.MethodPatch, fmt.Sprintf("http://localhost:8080/disputes/%d", id), strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+
+		return resp
+	}
+
+	resp := patch(3, `{"status":"lost","note":"proof of delivery"}`)
+	defer resp.Body.Close()
+
+	var res api.Dispute
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, api.DisputeStatus("lost"), res.Status)
+	require.NotNil(t, res.ReversalTransactionId)
+	assert.Equal(t, int64(9), *res.ReversalTransactionId)
+	assert.True(t, resolvedAt.Equal(*res.ResolvedAt))
+	assert.NotNil(t, res.Events, "events are always listed")
+
+	resp = patch(4, `{"status":"won"}`)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp = patch(3, `{"status":"closed"}`)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	mockDisputesSvc.AssertExpectations(t)
+}
+
+func TestListDisputes_Success(t *testing.T) {
+	mockDisputesSvc := new(mockDisputesService)
+	svr, err := createServerWithServices(&mockAccountsService{}, &mockTransactionsService{}, &mockAuditService{}, &mockReconciliationService{}, &mockSchedulerService{}, &mockQueueService{}, mockDisputesSvc)
+	assert.NoError(t, err)
+
+	go func() {
+		if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+			t.Errorf("server error: %v", err)
+		}
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer func() {
+		if err := svr.Shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown error: %v", err)
+		}
+	}()
+
+	at := time.Date(2025, time.August, 31, 3, 0, 0, 0, time.UTC)
+
+	mockDisputesSvc.On("ListDisputes", mock.Anything, disputes.Filter{AccountID: 1, Status: disputes.StatusUnderReview}).Return([]disputes.Dispute{
+		{
+			ID:                  3,
+			AccountID:           1,
+			TransactionID:       5,
+			CreditTransactionID: 6,
+			Status:              disputes.StatusUnderReview,
+			Reason:              "not received",
+			Amount:              12.5,
+			CreatedAt:           at,
+			UpdatedAt:           at,
+			Events: []disputes.Event{
+				{ID: 1, Status: disputes.StatusOpened, Principal: "apikey:1", Note: "not received", CreatedAt: at},
+				{ID: 2, Status: disputes.StatusUnderReview, Principal: "apikey:1", CreatedAt: at},
+			},
+		},
+	}, nil)
+	mockDisputesSvc.On("ListDisputes", mock.Anything, disputes.Filter{AccountID: 2}).Return([]disputes.Dispute(nil), nil)
+
+	resp, err := client.Get("http://localhost:8080/accounts/1/disputes?status=under_review")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	var res api.DisputeList
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, res.Disputes, 1)
+	require.Len(t, res.Disputes[0].Events, 2)
+	assert.Equal(t, "not received", *res.Disputes[0].Events[0].Note)
+	assert.Nil(t, res.Disputes[0].Events[1].Note)
+
+	resp, err = client.Get("http://localhost:8080/accounts/2/disputes")
+	assert.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"disputes":[]}`, string(body))
+
+	resp, err = client.Get("http://localhost:8080/accounts/1/disputes?status=closed")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	mockDisputesSvc.AssertExpectations(t)
+}
+
+func TestGetSchedulerStatus_Success(t *testing.T) {
+	mockSchedSvc := new(mockSchedulerService)
+	svr, err := createServerWithServices(&mockAccountsService{}, &mockTransactionsService{}, &mockAuditService{}, &mockReconciliationService{}, mockSchedSvc, &mockQueueService{}, &mockDisputesService{}, func(opts *server.Options) {
+		opts.OperatorTenant = "acme"
+	})
+	assert.NoError(t, err)
+
+	go func() {
+		if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+			t.Errorf("server error: %v", err)
+		}
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	defer func() {
+		if err := svr.Shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown error: %v", err)
+		}
+	}()
+
+	at := time.Date(2025, time.August, 30, 12, 0, 0, 0, time.UTC)
+	next := at.Add(time.Hour)
+	finishedAt := at.Add(2 * time.Second)
+	failure := scheduler.Run{
+		ID:          1,
+		Job:         "purge-rate-limits",
+		Instance:    "api-1",
+		Status:      scheduler.RunStatusFailed,
+		ScheduledAt: at.Add(-time.Hour),
+		StartedAt:   at.Add(-time.Hour),
+		FinishedAt:  &finishedAt,
+		Error:       "connection refused",
+	}
+
+	mockSchedSvc.On("Status", mock.Anything).Return([]scheduler.JobStatus{
+		{
+			Name:        "purge-rate-limits",
+			Description: "delete expired rate limit counters",
+			Schedule:    "0 * * * *",
+			NextRunAt:   &next,
+			Runs: []scheduler.Run{
+				{
+					ID:          2,
+					Job:         "purge-rate-limits",
+					Instance:    "api-2",
+					Status:      scheduler.RunStatusSucceeded,
+					ScheduledAt: at,
+					StartedAt:   at,
+					FinishedAt:  &finishedAt,
+					Result:      "3 expired counters deleted",
+				},
+				failure,
+			},
+			LastFailure: &failure,
+		},
+		{Name: "statements", Description: "generate statements"},
+	}, nil)
+
+	resp, err := client.Get("http://localhost:8080/admin/scheduler")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	var res api.SchedulerStatus
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, res.Jobs, 2)
+
+	job := res.Jobs[0]
+	assert.Equal(t, "0 * * * *", *job.Schedule)
+	assert.True(t, next.Equal(*job.NextRunAt))
+	require.Len(t, job.Runs, 2)
+	assert.Equal(t, api.SchedulerRunStatus("succeeded"), job.Runs[0].Status)
+	assert.Equal(t, "3 expired counters deleted", *job.Runs[0].Result)
+	assert.Nil(t, job.Runs[0].Error)
+	require.NotNil(t, job.LastFailure)
+	assert.Equal(t, "connection refused", *job.LastFailure.Error)
+
+	// jobs run on demand only have no schedule
+	assert.Nil(t, res.Jobs[1].Schedule)
+	assert.Nil(t, res.Jobs[1].NextRunAt)
+	assert.Empty(t, res.Jobs[1].Runs)
+
+	mockSchedSvc.AssertExpectations(t)
+}
+
+func TestGetSchedulerStatus_NotOperator(t *testing.T) {
+	for name, operator := range map[string]string{"Unset": "", "OtherTenant": "ops"} {
+		t.Run(name, func(t *testing.T) {
+			mockSchedSvc := new(mockSchedulerService)
+			svr, err := createServerWithServices(&mockAccountsService{}, &mockTransactionsService{}, &mockAuditService{}, &mockReconciliationService{}, mockSchedSvc, &mockQueueService{}, &mockDisputesService{}, func(opts *server.Options) {
+				opts.OperatorTenant = operator
+			})
+			assert.NoError(t, err)
+
+			go func() {
+				if err := svr.Run(8080); err != nil && err != http.ErrServerClosed {
+					t.Errorf("server error: %v", err)
+				}
+			}()
+
+			time.Sleep(1 * time.Second)
+
+			defer func() {
+				if err := svr.Shutdown(context.Background()); err != nil {
+					t.Errorf("shutdown error: %v", err)
+				}
+			}()
+
+			// the key has the scheduler:read scope but belongs to a regular tenant
+			resp, err := client.Get("http://localhost:8080/admin/scheduler")
+			assert.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+			mockSchedSvc.AssertNotCalled(t, "Status", mock.Anything)
+		})
+	}
+}
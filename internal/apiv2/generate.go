@@ -0,0 +1,4 @@
+// Package apiv2 serves the second version of the REST API, see spec/v2/openapi.yaml.
+//
+//go:generate oapi-codegen -config ../../spec/v2/oapi-codegen.yaml -o api.gen.go ../../spec/v2/openapi.yaml
+package apiv2
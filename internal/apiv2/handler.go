@@ -0,0 +1,61 @@
+package apiv2
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ziflex/rm-rf-production/internal/api"
+	"github.com/ziflex/rm-rf-production/pkg/transactions"
+)
+
+// Handler serves the operations that changed in the second version.
+// It delegates to the handler of the first version and only maps the operation types between their names and identifiers.
+type Handler struct {
+	v1 api.StrictServerInterface
+}
+
+func NewHandler(v1 api.StrictServerInterface) StrictServerInterface {
+	return &Handler{v1}
+}
+
+func (r *Handler) CreateTransaction(ctx context.Context, request CreateTransactionRequestObject) (CreateTransactionResponseObject, error) {
+	res, err := r.v1.CreateTransaction(ctx, api.CreateTransactionRequestObject{
+		Body: &api.TransactionCreateRequest{
+			AccountId:       request.Body.AccountId,
+			OperationTypeId: toOperationTypeID(request.Body.OperationType),
+			Amount:          request.Body.Amount,
+			ExternalRef:     request.Body.ExternalRef,
+			Merchant:        request.Body.Merchant,
+		},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	tx, ok := res.(api.CreateTransaction201JSONResponse)
+
+	if !ok {
+		return nil, fmt.Errorf("unexpected response of the first version: %T", res)
+	}
+
+	return CreateTransaction201JSONResponse{
+		TransactionId: tx.TransactionId,
+		AccountId:     tx.AccountId,
+		OperationType: toOperationType(tx.OperationTypeId),
+		Amount:        tx.Amount,
+		EventDate:     tx.EventDate,
+		ExternalRef:   tx.ExternalRef,
+		Merchant:      tx.Merchant,
+	}, nil
+}
+
+// toOperationTypeID maps the name of an operation type to its identifier in the first version.
+// Unknown names map to an unknown identifier, which the first version rejects.
+func toOperationTypeID(name OperationType) api.OperationType {
+	return api.OperationType(transactions.NewOperationTypeFromString(string(name)))
+}
+
+func toOperationType(id api.OperationType) OperationType {
+	return OperationType(transactions.NewOperationType(int(id)).String())
+}
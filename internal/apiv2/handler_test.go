@@ -0,0 +1,141 @@
+package apiv2_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/ziflex/rm-rf-production/internal/api"
+	"github.com/ziflex/rm-rf-production/internal/apiv2"
+)
+
+// mockV1Handler stubs the operations of the first version the second one delegates to.
+type mockV1Handler struct {
+	api.StrictServerInterface
+	mock.Mock
+}
+
+func (m *mockV1Handler) CreateTransaction(ctx context.Context, request api.CreateTransactionRequestObject) (api.CreateTransactionResponseObject, error) {
+	args := m.Called(ctx, request)
+	res, _ := args.Get(0).(api.CreateTransactionResponseObject)
+
+	return res, args.Error(1)
+}
+
+// stubServer records the operations routed to it.
+type stubServer struct {
+	api.ServerInterface
+	calls []string
+}
+
+func (s *stubServer) CreateTransaction(ctx echo.Context) error {
+	s.calls = append(s.calls, "CreateTransaction")
+
+	return ctx.NoContent(http.StatusCreated)
+}
+
+func (s *stubServer) GetAccount(ctx echo.Context, _ int64, _ api.GetAccountParams) error {
+	s.calls = append(s.calls, "GetAccount")
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+func TestHandler_CreateTransaction(t *testing.T) {
+	type testCase struct {
+		Name string
+		Type apiv2.OperationType
+		ID   api.OperationType
+	}
+
+	tsdata := []testCase{
+		{"Purchase", apiv2.Purchase, 1},
+		{"InstallmentPurchase", apiv2.InstallmentPurchase, 2},
+		{"Withdrawal", apiv2.Withdrawal, 3},
+		{"Payment", apiv2.Payment, 4},
+	}
+
+	eventDate := time.Date(2025, time.August, 30, 12, 0, 0, 0, time.UTC)
+	externalRef := "PRC-000123"
+	merchant := &api.Merchant{Id: "m-1"}
+
+	for _, tc := range tsdata {
+		t.Run(tc.Name, func(t *testing.T) {
+			v1 := new(mockV1Handler)
+			v1.On("CreateTransaction", mock.Anything, api.CreateTransactionRequestObject{
+				Body: &api.TransactionCreateRequest{
+					AccountId:       1,
+					OperationTypeId: tc.ID,
+					Amount:          10,
+					ExternalRef:     &externalRef,
+					Merchant:        merchant,
+				},
+			}).Return(api.CreateTransaction201JSONResponse{
+				TransactionId:   7,
+				AccountId:       1,
+				OperationTypeId: tc.ID,
+				Amount:          -10,
+				EventDate:       eventDate,
+				ExternalRef:     &externalRef,
+				Merchant:        merchant,
+			}, nil)
+
+			res, err := apiv2.NewHandler(v1).CreateTransaction(context.Background(), apiv2.CreateTransactionRequestObject{
+				Body: &apiv2.TransactionCreateRequest{
+					AccountId:     1,
+					OperationType: tc.Type,
+					Amount:        10,
+					ExternalRef:   &externalRef,
+					Merchant:      merchant,
+				},
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, apiv2.CreateTransaction201JSONResponse{
+				TransactionId: 7,
+				AccountId:     1,
+				OperationType: tc.Type,
+				Amount:        -10,
+				EventDate:     eventDate,
+				ExternalRef:   &externalRef,
+				Merchant:      merchant,
+			}, res)
+			v1.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_CreateTransaction_Error(t *testing.T) {
+	v1 := new(mockV1Handler)
+	v1.On("CreateTransaction", mock.Anything, mock.Anything).Return(nil, errors.New("account is blocked"))
+
+	res, err := apiv2.NewHandler(v1).CreateTransaction(context.Background(), apiv2.CreateTransactionRequestObject{
+		Body: &apiv2.TransactionCreateRequest{AccountId: 1, OperationType: apiv2.Payment, Amount: 10},
+	})
+
+	assert.EqualError(t, err, "account is blocked")
+	assert.Nil(t, res)
+}
+
+func TestNewServer(t *testing.T) {
+	v1 := &stubServer{}
+	v2 := &stubServer{}
+	e := echo.New()
+	api.RegisterHandlers(e, apiv2.NewServer(v1, v2))
+
+	req := httptest.NewRequest(http.MethodPost, "/transactions", strings.NewReader(`{}`))
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest(http.MethodGet, "/accounts/1", nil)
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	// the operations of the second version are routed to it, the others to the first version
+	assert.Equal(t, []string{"CreateTransaction"}, v2.calls)
+	assert.Equal(t, []string{"GetAccount"}, v1.calls)
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 
+	"github.com/lib/pq"
 	"github.com/ziflex/dbx"
 	"github.com/ziflex/rm-rf-production/pkg/accounts"
 	"github.com/ziflex/rm-rf-production/pkg/common"
@@ -17,10 +18,16 @@ func NewAccountsRepository() accounts.Repository {
 }
 
 func (a *Accounts) CreateAccount(ctx dbx.Context, acc accounts.AccountCreation) (accounts.Account, error) {
-	row := ctx.Executor().QueryRow(`
-		INSERT INTO accounts (document_number) VALUES ($1)
-		RETURNING id
-	`, acc.DocumentNumber)
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return accounts.Account{}, err
+	}
+
+	row := executor(ctx).QueryRow(`
+		INSERT INTO accounts (tenant_id, document_number) VALUES ($1, $2)
+		RETURNING id, version
+	`, tenantID, acc.DocumentNumber)
 
 	if err := row.Err(); err != nil {
 		if pgErr, ok := IsPgErr(err); ok {
@@ -32,21 +39,26 @@ func (a *Accounts) CreateAccount(ctx dbx.Context, acc accounts.AccountCreation)
 		return accounts.Account{}, err
 	}
 
-	var id int64
-	err := row.Scan(&id)
+	created := accounts.Account{DocumentNumber: acc.DocumentNumber}
+	err = row.Scan(&created.ID, &created.Version)
 
 	if err != nil {
 		return accounts.Account{}, err
 	}
 
-	return accounts.Account{
-		ID:             id,
-		DocumentNumber: acc.DocumentNumber,
-	}, nil
+	return created, nil
 }
 
 func (a *Accounts) GetAccountByID(ctx dbx.Context, id int64) (accounts.Account, error) {
-	rows, err := ctx.Executor().Query("SELECT * FROM accounts WHERE id=$1", id)
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return accounts.Account{}, err
+	}
+
+	rows, err := executor(ctx).Query(`
+		SELECT id, document_number, blocked_at IS NOT NULL, version FROM accounts WHERE tenant_id=$1 AND id=$2
+	`, tenantID, id)
 
 	if err != nil {
 		return accounts.Account{}, err
@@ -61,9 +73,89 @@ func (a *Accounts) GetAccountByID(ctx dbx.Context, id int64) (accounts.Account,
 	return a.scanAccount(rows)
 }
 
+func (a *Accounts) SetAccountBlocked(ctx dbx.Context, id int64, blocked bool) error {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	// blocking an already blocked account keeps the original time
+	res, err := executor(ctx).Exec(`
+		UPDATE accounts SET
+			blocked_at = CASE WHEN $3 THEN COALESCE(blocked_at, now()) ELSE NULL END,
+			version = version + 1
+		WHERE tenant_id=$1 AND id=$2
+	`, tenantID, id, blocked)
+
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("account %w: %d", common.ErrNotFound, id)
+	}
+
+	return nil
+}
+
+func (a *Accounts) UpdateAccount(ctx dbx.Context, id int64, update accounts.AccountUpdate, versions []int64) (accounts.Account, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return accounts.Account{}, err
+	}
+
+	// the version condition is checked again after the row is locked,
+	// so of two concurrent updates with the same version only one succeeds
+	rows, err := executor(ctx).Query(`
+		UPDATE accounts SET
+			blocked_at = CASE
+				WHEN $3::boolean IS NULL THEN blocked_at
+				WHEN $3 THEN COALESCE(blocked_at, now())
+				ELSE NULL
+			END,
+			version = version + 1
+		WHERE tenant_id=$1 AND id=$2 AND ($4::bigint[] IS NULL OR version = ANY($4))
+		RETURNING id, document_number, blocked_at IS NOT NULL, version
+	`, tenantID, id, update.Blocked, pq.Array(versions))
+
+	if err != nil {
+		return accounts.Account{}, err
+	}
+
+	if rows.Next() {
+		defer rows.Close()
+
+		return a.scanAccount(rows)
+	}
+
+	// the connection is busy until the rows are closed
+	if err := rows.Close(); err != nil {
+		return accounts.Account{}, err
+	}
+
+	if err := rows.Err(); err != nil {
+		return accounts.Account{}, err
+	}
+
+	// nothing was updated, either the account does not exist or its version differs
+	if _, err := a.GetAccountByID(ctx, id); err != nil {
+		return accounts.Account{}, err
+	}
+
+	return accounts.Account{}, fmt.Errorf("account %w: %d", accounts.ErrVersionMismatch, id)
+}
+
 func (a *Accounts) scanAccount(rows *sql.Rows) (accounts.Account, error) {
 	var acc accounts.Account
-	err := rows.Scan(&acc.ID, &acc.DocumentNumber)
+	err := rows.Scan(&acc.ID, &acc.DocumentNumber, &acc.Blocked, &acc.Version)
 
 	if err != nil {
 		return accounts.Account{}, err
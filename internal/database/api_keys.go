@@ -0,0 +1,110 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/auth"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+)
+
+type APIKeysRepository struct {
+}
+
+func NewAPIKeysRepository() auth.Repository {
+	return &APIKeysRepository{}
+}
+
+func (r *APIKeysRepository) CreateAPIKey(ctx dbx.Context, key auth.APIKeyCreation, hash string) (auth.APIKey, error) {
+	row := executor(ctx).QueryRow(`
+		INSERT INTO api_keys (tenant_id, name, key_hash, scopes) VALUES ($1, $2, $3, $4)
+		RETURNING id, tenant_id, name, scopes, created_at, revoked_at
+	`, key.TenantID, key.Name, hash, pq.Array(scopesToStrings(key.Scopes)))
+
+	if err := row.Err(); err != nil {
+		if pgErr, ok := IsPgErr(err); ok {
+			if IsDbUniqueViolation(pgErr) {
+				return auth.APIKey{}, fmt.Errorf("api key %w", common.ErrDuplicate)
+			}
+
+			if IsDbForeignKeyViolation(pgErr) {
+				return auth.APIKey{}, fmt.Errorf("tenant %w: %s", common.ErrNotFound, key.TenantID)
+			}
+		}
+
+		return auth.APIKey{}, err
+	}
+
+	return r.scanAPIKey(row)
+}
+
+func (r *APIKeysRepository) GetAPIKeyByHash(ctx dbx.Context, hash string) (auth.APIKey, error) {
+	row := executor(ctx).QueryRow(`
+		SELECT id, tenant_id, name, scopes, created_at, revoked_at FROM api_keys WHERE key_hash=$1
+	`, hash)
+
+	key, err := r.scanAPIKey(row)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return auth.APIKey{}, fmt.Errorf("api key %w", common.ErrNotFound)
+		}
+
+		return auth.APIKey{}, err
+	}
+
+	return key, nil
+}
+
+func (r *APIKeysRepository) RevokeAPIKey(ctx dbx.Context, id int64) error {
+	res, err := executor(ctx).Exec(`
+		UPDATE api_keys SET revoked_at=CURRENT_TIMESTAMP WHERE id=$1 AND revoked_at IS NULL
+	`, id)
+
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("api key %w: %d", common.ErrNotFound, id)
+	}
+
+	return nil
+}
+
+func (r *APIKeysRepository) scanAPIKey(row *sql.Row) (auth.APIKey, error) {
+	var key auth.APIKey
+	var scopes pq.StringArray
+
+	err := row.Scan(&key.ID, &key.TenantID, &key.Name, &scopes, &key.CreatedAt, &key.RevokedAt)
+
+	if err != nil {
+		return auth.APIKey{}, err
+	}
+
+	key.Scopes = make([]auth.Scope, 0, len(scopes))
+
+	for _, s := range scopes {
+		key.Scopes = append(key.Scopes, auth.Scope(s))
+	}
+
+	return key, nil
+}
+
+func scopesToStrings(scopes []auth.Scope) []string {
+	out := make([]string, 0, len(scopes))
+
+	for _, s := range scopes {
+		out = append(out, s.String())
+	}
+
+	return out
+}
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/audit"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+)
+
+type AuditRepository struct {
+}
+
+func NewAuditRepository() audit.Repository {
+	return &AuditRepository{}
+}
+
+func (r *AuditRepository) CreateEntry(ctx dbx.Context, entry audit.EntryCreation) (audit.Entry, error) {
+	var payload any
+
+	if len(entry.Payload) > 0 {
+		// lib/pq sends []byte as bytea, JSONB needs text
+		payload = string(entry.Payload)
+	}
+
+	row := executor(ctx).QueryRow(`
+		INSERT INTO audit_log (tenant_id, principal, request_id, operation_id, payload, outcome, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, tenant_id, principal, request_id, operation_id, payload, outcome, status, created_at
+	`, nullString(entry.TenantID), entry.Principal, entry.RequestID, entry.OperationID, payload, entry.Outcome.String(), entry.Status)
+
+	return r.scanEntry(row)
+}
+
+func (r *AuditRepository) ListEntries(ctx dbx.Context, filter audit.Filter) ([]audit.Entry, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	where := []string{"tenant_id=$1"}
+	args := []any{tenantID}
+
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, strings.ReplaceAll(cond, "?", "$"+strconv.Itoa(len(args))))
+	}
+
+	if filter.Principal != "" {
+		add("principal=?", filter.Principal)
+	}
+
+	if filter.OperationID != "" {
+		add("operation_id=?", filter.OperationID)
+	}
+
+	if filter.Outcome != "" {
+		add("outcome=?", filter.Outcome.String())
+	}
+
+	if filter.From != nil {
+		add("created_at>=?", filter.From.UTC())
+	}
+
+	if filter.To != nil {
+		add("created_at<?", filter.To.UTC())
+	}
+
+	if filter.BeforeID > 0 {
+		add("id<?", filter.BeforeID)
+	}
+
+	args = append(args, filter.Limit)
+
+	rows, err := executor(ctx).Query(`
+		SELECT id, tenant_id, principal, request_id, operation_id, payload, outcome, status, created_at
+		FROM audit_log WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY id DESC LIMIT $`+strconv.Itoa(len(args)), args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	entries := make([]audit.Entry, 0, filter.Limit)
+
+	for rows.Next() {
+		entry, err := r.scanEntry(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+func (r *AuditRepository) scanEntry(row interface{ Scan(dest ...any) error }) (audit.Entry, error) {
+	var entry audit.Entry
+	var tenantID sql.NullString
+	var payload []byte
+	var outcome string
+
+	err := row.Scan(
+		&entry.ID,
+		&tenantID,
+		&entry.Principal,
+		&entry.RequestID,
+		&entry.OperationID,
+		&payload,
+		&outcome,
+		&entry.Status,
+		&entry.CreatedAt,
+	)
+
+	if err != nil {
+		return audit.Entry{}, err
+	}
+
+	entry.TenantID = tenantID.String
+	entry.Payload = payload
+	entry.Outcome = audit.Outcome(outcome)
+
+	return entry, nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
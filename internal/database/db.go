@@ -4,15 +4,34 @@ import (
 	"database/sql"
 
 	_ "github.com/lib/pq"
-	"github.com/ziflex/dbx"
 )
 
-func New(opts Options) (dbx.Database, error) {
+func New(opts Options) (*sql.DB, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
 	db, err := sql.Open("postgres", toConnectionString(opts))
 
 	if err != nil {
 		return nil, err
 	}
 
+	if opts.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(opts.MaxOpenConns)
+	}
+
+	if opts.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(opts.MaxIdleConns)
+	}
+
+	if opts.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	}
+
+	if opts.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
+	}
+
 	return db, nil
 }
@@ -0,0 +1,297 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+	"github.com/ziflex/rm-rf-production/pkg/disputes"
+	"github.com/ziflex/rm-rf-production/pkg/transactions"
+)
+
+const disputeColumns = `id, account_id, transaction_id, credit_transaction_id, COALESCE(reversal_transaction_id, 0),
+	status, reason, amount, created_at, updated_at, resolved_at`
+
+type DisputesRepository struct {
+}
+
+func NewDisputesRepository() disputes.Repository {
+	return &DisputesRepository{}
+}
+
+func (r *DisputesRepository) GetTransaction(ctx dbx.Context, accountID, transactionID int64) (transactions.Transaction, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return transactions.Transaction{}, err
+	}
+
+	var tr transactions.Transaction
+	var optype string
+
+	err = executor(ctx).QueryRow(`
+		SELECT id, account_id, operation_type, amount, event_date, COALESCE(external_ref, '')
+		FROM transactions WHERE tenant_id=$1 AND account_id=$2 AND id=$3
+	`, tenantID, accountID, transactionID).Scan(&tr.ID, &tr.AccountID, &optype, &tr.Amount, &tr.EventDate, &tr.ExternalRef)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return transactions.Transaction{}, fmt.Errorf("transaction %w: %d", common.ErrNotFound, transactionID)
+	}
+
+	if err != nil {
+		return transactions.Transaction{}, err
+	}
+
+	tr.OperationType = transactions.NewOperationTypeFromString(optype)
+
+	return tr, nil
+}
+
+func (r *DisputesRepository) IsDisputeTransaction(ctx dbx.Context, transactionID int64) (bool, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	var found bool
+
+	err = executor(ctx).QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM disputes
+			WHERE tenant_id=$1 AND (credit_transaction_id=$2 OR reversal_transaction_id=$2)
+		)
+	`, tenantID, transactionID).Scan(&found)
+
+	return found, err
+}
+
+func (r *DisputesRepository) CreateDispute(ctx dbx.Context, creation disputes.DisputeCreation) (disputes.Dispute, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return disputes.Dispute{}, err
+	}
+
+	row := executor(ctx).QueryRow(`
+		INSERT INTO disputes (tenant_id, account_id, transaction_id, credit_transaction_id, reason, amount)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+disputeColumns,
+		tenantID, creation.AccountID, creation.TransactionID, creation.CreditTransactionID, creation.Reason, creation.Amount)
+
+	if err := row.Err(); err != nil {
+		if pgErr, ok := IsPgErr(err); ok && IsDbUniqueViolation(pgErr) {
+			return disputes.Dispute{}, fmt.Errorf("dispute of transaction %w: %d", common.ErrDuplicate, creation.TransactionID)
+		}
+
+		return disputes.Dispute{}, err
+	}
+
+	return r.scanDispute(row)
+}
+
+func (r *DisputesRepository) GetDispute(ctx dbx.Context, id int64) (disputes.Dispute, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return disputes.Dispute{}, err
+	}
+
+	row := executor(ctx).QueryRow(`SELECT `+disputeColumns+` FROM disputes WHERE tenant_id=$1 AND id=$2`, tenantID, id)
+
+	res, err := r.scanDispute(row)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return disputes.Dispute{}, fmt.Errorf("dispute %w: %d", common.ErrNotFound, id)
+	}
+
+	if err != nil {
+		return disputes.Dispute{}, err
+	}
+
+	events, err := r.listEvents(ctx, []int64{id})
+
+	if err != nil {
+		return disputes.Dispute{}, err
+	}
+
+	res.Events = events[id]
+
+	return res, nil
+}
+
+func (r *DisputesRepository) ResolveDispute(ctx dbx.Context, id int64, from disputes.Status, resolution disputes.Resolution) (disputes.Dispute, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return disputes.Dispute{}, err
+	}
+
+	row := executor(ctx).QueryRow(`
+		UPDATE disputes SET status=$4, reversal_transaction_id=NULLIF($5, 0), updated_at=CURRENT_TIMESTAMP,
+			resolved_at=CASE WHEN $6 THEN CURRENT_TIMESTAMP END
+		WHERE tenant_id=$1 AND id=$2 AND status=$3
+		RETURNING `+disputeColumns,
+		tenantID, id, from.String(), resolution.Status.String(), resolution.ReversalTransactionID, resolution.Status.IsResolved())
+
+	res, err := r.scanDispute(row)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return disputes.Dispute{}, fmt.Errorf("%w: dispute %d is not %s", disputes.ErrInvalidTransition, id, from)
+	}
+
+	return res, err
+}
+
+func (r *DisputesRepository) AddEvent(ctx dbx.Context, creation disputes.EventCreation) (disputes.Event, error) {
+	event := disputes.Event{
+		Status:    creation.Status,
+		Principal: creation.Principal,
+		Note:      creation.Note,
+	}
+
+	err := executor(ctx).QueryRow(`
+		INSERT INTO dispute_events (dispute_id, status, principal, note)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, creation.DisputeID, creation.Status.String(), creation.Principal, nullString(creation.Note)).Scan(&event.ID, &event.CreatedAt)
+
+	if err != nil {
+		return disputes.Event{}, err
+	}
+
+	return event, nil
+}
+
+func (r *DisputesRepository) ListDisputes(ctx dbx.Context, filter disputes.Filter) ([]disputes.Dispute, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	where := []string{"tenant_id=$1", "account_id=$2"}
+	args := []any{tenantID, filter.AccountID}
+
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, strings.ReplaceAll(cond, "?", "$"+strconv.Itoa(len(args))))
+	}
+
+	if filter.Status != "" {
+		add("status=?", filter.Status.String())
+	}
+
+	rows, err := executor(ctx).Query(`
+		SELECT `+disputeColumns+` FROM disputes WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY id DESC`, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	res := make([]disputes.Dispute, 0)
+	ids := make([]int64, 0)
+
+	for rows.Next() {
+		d, err := r.scanDispute(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, d)
+		ids = append(ids, d.ID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return res, nil
+	}
+
+	events, err := r.listEvents(ctx, ids)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range res {
+		res[i].Events = events[res[i].ID]
+	}
+
+	return res, nil
+}
+
+// listEvents returns the events of the disputes by dispute, oldest first.
+func (r *DisputesRepository) listEvents(ctx dbx.Context, ids []int64) (map[int64][]disputes.Event, error) {
+	rows, err := executor(ctx).Query(`
+		SELECT dispute_id, id, status, principal, COALESCE(note, ''), created_at
+		FROM dispute_events WHERE dispute_id=ANY($1)
+		ORDER BY dispute_id, id
+	`, pq.Array(ids))
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	res := make(map[int64][]disputes.Event, len(ids))
+
+	for rows.Next() {
+		var disputeID int64
+		var event disputes.Event
+		var status string
+
+		if err := rows.Scan(&disputeID, &event.ID, &status, &event.Principal, &event.Note, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		event.Status = disputes.Status(status)
+		res[disputeID] = append(res[disputeID], event)
+	}
+
+	return res, rows.Err()
+}
+
+func (r *DisputesRepository) scanDispute(row interface{ Scan(dest ...any) error }) (disputes.Dispute, error) {
+	var d disputes.Dispute
+	var status string
+	var resolvedAt sql.NullTime
+
+	err := row.Scan(
+		&d.ID,
+		&d.AccountID,
+		&d.TransactionID,
+		&d.CreditTransactionID,
+		&d.ReversalTransactionID,
+		&status,
+		&d.Reason,
+		&d.Amount,
+		&d.CreatedAt,
+		&d.UpdatedAt,
+		&resolvedAt,
+	)
+
+	if err != nil {
+		return disputes.Dispute{}, err
+	}
+
+	d.Status = disputes.Status(status)
+
+	if resolvedAt.Valid {
+		d.ResolvedAt = &resolvedAt.Time
+	}
+
+	return d, nil
+}
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ziflex/rm-rf-production/internal/health"
+)
+
+// Ping checks that the database is reachable.
+func Ping(db *sql.DB) health.CheckFunc {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
+
+// CheckSchema checks that all migrations have been applied cleanly and the schema is at least at the expected version.
+// A newer schema is accepted so the pods of the previous release stay ready while a rollout migrates the database.
+func CheckSchema(db *sql.DB, expected int) health.CheckFunc {
+	return func(ctx context.Context) error {
+		var version int
+		var dirty bool
+
+		err := db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("no migrations applied, expected version %d", expected)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if dirty {
+			return fmt.Errorf("migration %d failed and left the schema dirty", version)
+		}
+
+		if version < expected {
+			return fmt.Errorf("schema version %d is behind expected version %d", version, expected)
+		}
+
+		return nil
+	}
+}
+
+// WaitFor blocks until the database answers a ping or the timeout expires.
+func WaitFor(ctx context.Context, db *sql.DB, timeout time.Duration, interval time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := db.PingContext(ctx)
+
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database is not reachable after %s: %w", timeout, err)
+		case <-ticker.C:
+		}
+	}
+}
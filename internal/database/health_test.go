@@ -0,0 +1,58 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/ziflex/rm-rf-production/internal/database"
+)
+
+func TestCheckSchema(t *testing.T) {
+	testCases := []struct {
+		Name    string
+		Version int
+		Dirty   bool
+		Error   string
+	}{
+		{Name: "matching version", Version: 5},
+		{Name: "newer version", Version: 6},
+		{Name: "outdated version", Version: 4, Error: "schema version 4 is behind expected version 5"},
+		{Name: "dirty newer migration", Version: 6, Dirty: true, Error: "migration 6 failed and left the schema dirty"},
+		{Name: "dirty migration", Version: 5, Dirty: true, Error: "migration 5 failed and left the schema dirty"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer mockDB.Close()
+
+			mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+				WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(tc.Version, tc.Dirty))
+
+			err = database.CheckSchema(mockDB, 5)(context.Background())
+
+			if tc.Error == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.Error)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCheckSchema_NoMigrations(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+
+	mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}))
+
+	err = database.CheckSchema(mockDB, 5)(context.Background())
+	assert.EqualError(t, err, "no migrations applied, expected version 5")
+}
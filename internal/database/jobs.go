@@ -0,0 +1,216 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+	"github.com/ziflex/rm-rf-production/pkg/queue"
+)
+
+const jobColumns = `id, tenant_id, type, payload, status, priority, attempts, max_attempts, run_at,
+	COALESCE(locked_by, ''), locked_until, COALESCE(last_error, ''), result, created_at, updated_at, finished_at`
+
+type JobsRepository struct {
+}
+
+func NewJobsRepository() queue.Repository {
+	return &JobsRepository{}
+}
+
+func (r *JobsRepository) CreateJob(ctx dbx.Context, creation queue.JobCreation) (queue.Job, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return queue.Job{}, err
+	}
+
+	// lib/pq sends []byte as bytea, JSONB needs text
+	row := executor(ctx).QueryRow(`
+		INSERT INTO jobs (tenant_id, type, payload, priority, max_attempts, run_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+jobColumns,
+		tenantID, creation.Type, string(creation.Payload), creation.Priority, creation.MaxAttempts, creation.RunAt.UTC())
+
+	return r.scanJob(row)
+}
+
+func (r *JobsRepository) GetJob(ctx dbx.Context, id int64) (queue.Job, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return queue.Job{}, err
+	}
+
+	row := executor(ctx).QueryRow(`SELECT `+jobColumns+` FROM jobs WHERE tenant_id=$1 AND id=$2`, tenantID, id)
+
+	job, err := r.scanJob(row)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return queue.Job{}, fmt.Errorf("job %w: %d", common.ErrNotFound, id)
+	}
+
+	return job, err
+}
+
+func (r *JobsRepository) ClaimJob(ctx dbx.Context, claim queue.Claim) (queue.Job, bool, error) {
+	row := executor(ctx).QueryRow(`
+		UPDATE jobs SET status='running', attempts=attempts+1, locked_by=$2, locked_until=$3, updated_at=$4
+		WHERE id=(
+			SELECT id FROM jobs
+			WHERE type=ANY($1) AND (
+				(status='queued' AND run_at<=$4) OR
+				(status='running' AND locked_until<=$4)
+			)
+			ORDER BY priority DESC, run_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+jobColumns,
+		pq.Array(claim.Types), claim.Worker, claim.LockedUntil.UTC(), claim.Now.UTC())
+
+	job, err := r.scanJob(row)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return queue.Job{}, false, nil
+	}
+
+	if err != nil {
+		return queue.Job{}, false, err
+	}
+
+	return job, true, nil
+}
+
+func (r *JobsRepository) ExtendJob(ctx dbx.Context, id int64, lock queue.Lock, until time.Time) error {
+	res, err := executor(ctx).Exec(`
+		UPDATE jobs SET locked_until=$4
+		WHERE id=$1 AND status='running' AND locked_by=$2 AND attempts=$3
+	`, id, lock.Worker, lock.Attempt, until.UTC())
+
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("%w: %d", queue.ErrLockLost, id)
+	}
+
+	return nil
+}
+
+func (r *JobsRepository) CompleteJob(ctx dbx.Context, id int64, lock queue.Lock, completion queue.Completion) (queue.Job, error) {
+	var result sql.NullString
+
+	if len(completion.Result) > 0 {
+		result = sql.NullString{String: string(completion.Result), Valid: true}
+	}
+
+	var finishedAt sql.NullTime
+	runAt := sql.NullTime{Time: completion.RunAt.UTC(), Valid: completion.Status == queue.StatusQueued}
+
+	if completion.Status != queue.StatusQueued {
+		finishedAt = sql.NullTime{Time: completion.Now.UTC(), Valid: true}
+	}
+
+	row := executor(ctx).QueryRow(`
+		UPDATE jobs SET status=$4, result=$5, last_error=COALESCE($6, last_error), run_at=COALESCE($7, run_at),
+			locked_by=NULL, locked_until=NULL, updated_at=$8, finished_at=$9
+		WHERE id=$1 AND status='running' AND locked_by=$2 AND attempts=$3
+		RETURNING `+jobColumns,
+		id, lock.Worker, lock.Attempt, completion.Status.String(), result, nullString(completion.Error),
+		runAt, completion.Now.UTC(), finishedAt)
+
+	job, err := r.scanJob(row)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return queue.Job{}, fmt.Errorf("%w: %d", queue.ErrLockLost, id)
+	}
+
+	return job, err
+}
+
+func (r *JobsRepository) RetryJob(ctx dbx.Context, id int64, now time.Time) (queue.Job, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return queue.Job{}, err
+	}
+
+	row := executor(ctx).QueryRow(`
+		UPDATE jobs SET status='queued', attempts=0, run_at=$3, updated_at=$3, finished_at=NULL
+		WHERE tenant_id=$1 AND id=$2 AND status='dead'
+		RETURNING `+jobColumns,
+		tenantID, id, now.UTC())
+
+	job, err := r.scanJob(row)
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return job, err
+	}
+
+	// tells a missing job from one that is not dead
+	if _, err := r.GetJob(ctx, id); err != nil {
+		return queue.Job{}, err
+	}
+
+	return queue.Job{}, fmt.Errorf("%w: %d", queue.ErrNotDead, id)
+}
+
+func (r *JobsRepository) scanJob(row interface{ Scan(dest ...any) error }) (queue.Job, error) {
+	var job queue.Job
+	var status string
+	var payload []byte
+	var result []byte
+	var lockedUntil, finishedAt sql.NullTime
+
+	err := row.Scan(
+		&job.ID,
+		&job.TenantID,
+		&job.Type,
+		&payload,
+		&status,
+		&job.Priority,
+		&job.Attempts,
+		&job.MaxAttempts,
+		&job.RunAt,
+		&job.LockedBy,
+		&lockedUntil,
+		&job.LastError,
+		&result,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&finishedAt,
+	)
+
+	if err != nil {
+		return queue.Job{}, err
+	}
+
+	job.Status = queue.Status(status)
+	job.Payload = payload
+
+	if result != nil {
+		job.Result = result
+	}
+
+	if lockedUntil.Valid {
+		job.LockedUntil = &lockedUntil.Time
+	}
+
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+
+	return job, nil
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"hash/fnv"
+)
+
+// AdvisoryLocker locks the scheduled jobs with Postgres advisory locks, so that a job runs on one instance at a time.
+// A lock belongs to the session holding it, the instance keeps a connection of the pool until it unlocks.
+// When the instance dies, the connection closes and the lock is released.
+type AdvisoryLocker struct {
+	db *sql.DB
+}
+
+func NewAdvisoryLocker(db *sql.DB) *AdvisoryLocker {
+	return &AdvisoryLocker{db}
+}
+
+func (l *AdvisoryLocker) TryLock(ctx context.Context, job string) (func(), bool, error) {
+	conn, err := l.db.Conn(ctx)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	key := lockKey("scheduler:" + job)
+
+	var acquired bool
+
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired); err != nil {
+		conn.Close()
+
+		return nil, false, err
+	}
+
+	if !acquired {
+		conn.Close()
+
+		return nil, false, nil
+	}
+
+	return func() {
+		// the job context may be cancelled by now
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key); err != nil {
+			// a connection still holding the lock must not go back to the pool
+			conn.Raw(func(any) error {
+				return driver.ErrBadConn
+			})
+		}
+
+		conn.Close()
+	}, true, nil
+}
+
+// lockKey maps a name to the 64-bit key space of the advisory locks.
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+
+	return int64(h.Sum64())
+}
@@ -0,0 +1,50 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/ziflex/rm-rf-production/internal/database"
+)
+
+func TestAdvisoryLocker_TryLock(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+
+	locker := database.NewAdvisoryLocker(mockDB)
+
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock\(\$1\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(true))
+	mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1\)`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	unlock, acquired, err := locker.TryLock(context.Background(), "purge-rate-limits")
+
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	unlock()
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAdvisoryLocker_TryLock_Locked(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+
+	locker := database.NewAdvisoryLocker(mockDB)
+
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock\(\$1\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(false))
+
+	unlock, acquired, err := locker.TryLock(context.Background(), "purge-rate-limits")
+
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+	assert.Nil(t, unlock)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,213 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/rs/zerolog"
+	"github.com/ziflex/rm-rf-production/database/migrations"
+)
+
+type (
+	MigrationStatus struct {
+		// Version is the last applied migration, 0 when none has been applied yet.
+		Version uint
+		// Dirty is set when the last migration failed half way and the schema must be fixed manually.
+		Dirty bool
+		// Latest is the newest migration embedded into the binary.
+		Latest uint
+		// Pending lists the embedded migrations that are not applied yet.
+		Pending []uint
+	}
+
+	// Migrator applies the migrations embedded into the binary.
+	// Every schema change is made under a Postgres advisory lock, so concurrent replicas cannot race.
+	Migrator struct {
+		migrate *migrate.Migrate
+		conn    *sql.Conn
+	}
+
+	migrateLogger struct {
+		log zerolog.Logger
+	}
+)
+
+// NewMigrator creates a migrator on a dedicated connection of the pool. The pool itself is not closed by the migrator.
+func NewMigrator(ctx context.Context, db *sql.DB, log zerolog.Logger) (*Migrator, error) {
+	src, err := iofs.New(migrations.FS, ".")
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	conn, err := db.Conn(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the timeouts of the pool are meant for requests: a replica waits for the migration lock as long as another one migrates
+	// and a long schema change must not be cancelled half way, leaving the schema dirty
+	if _, err := conn.ExecContext(ctx, "SET statement_timeout = 0; SET lock_timeout = 0"); err != nil {
+		conn.Close()
+
+		return nil, fmt.Errorf("failed to disable the timeouts of the migration connection: %w", err)
+	}
+
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{})
+
+	if err != nil {
+		release(conn)
+
+		return nil, fmt.Errorf("failed to create migration driver: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, "postgres", driver)
+
+	if err != nil {
+		release(conn)
+
+		return nil, fmt.Errorf("failed to create migrator: %w", err)
+	}
+
+	m.Log = &migrateLogger{log}
+
+	return &Migrator{m, conn}, nil
+}
+
+// Up applies all pending migrations.
+func (m *Migrator) Up() error {
+	return ignoreNoChange(m.migrate.Up())
+}
+
+// Down rolls back the last n migrations.
+func (m *Migrator) Down(n int) error {
+	if n <= 0 {
+		return fmt.Errorf("number of migrations to roll back must be positive")
+	}
+
+	return ignoreNoChange(m.migrate.Steps(-n))
+}
+
+// Force sets the schema version and clears the dirty flag without running any migration.
+func (m *Migrator) Force(version int) error {
+	return m.migrate.Force(version)
+}
+
+// Status reports the applied and the embedded migration versions.
+func (m *Migrator) Status() (MigrationStatus, error) {
+	var status MigrationStatus
+
+	version, dirty, err := m.migrate.Version()
+
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return status, err
+	}
+
+	status.Version = version
+	status.Dirty = dirty
+
+	versions, err := MigrationVersions()
+
+	if err != nil {
+		return status, err
+	}
+
+	for _, v := range versions {
+		if v > status.Version {
+			status.Pending = append(status.Pending, v)
+		}
+
+		status.Latest = max(status.Latest, v)
+	}
+
+	return status, nil
+}
+
+// Close releases the connection of the migrator.
+func (m *Migrator) Close() error {
+	resetErr := resetTimeouts(m.conn)
+	srcErr, dbErr := m.migrate.Close()
+
+	return errors.Join(resetErr, srcErr, dbErr)
+}
+
+// release returns the connection to the pool with the timeouts of the pool.
+func release(conn *sql.Conn) {
+	resetTimeouts(conn)
+	conn.Close()
+}
+
+func resetTimeouts(conn *sql.Conn) error {
+	_, err := conn.ExecContext(context.Background(), "RESET statement_timeout; RESET lock_timeout")
+
+	return err
+}
+
+// MigrationVersions returns the sorted versions of the embedded migrations.
+func MigrationVersions() ([]uint, error) {
+	entries, err := fs.ReadDir(migrations.FS, ".")
+
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]uint, 0, len(entries)/2)
+
+	for _, entry := range entries {
+		m, err := source.DefaultParse(entry.Name())
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name %s: %w", entry.Name(), err)
+		}
+
+		if m.Direction == source.Up {
+			versions = append(versions, m.Version)
+		}
+	}
+
+	// file names are sorted lexically, so 10_* would precede 2_*
+	slices.Sort(versions)
+
+	return versions, nil
+}
+
+// SchemaVersion returns the latest embedded migration, the version the binary is built against.
+func SchemaVersion() (int, error) {
+	versions, err := MigrationVersions()
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(versions) == 0 {
+		return 0, fmt.Errorf("no migrations embedded")
+	}
+
+	return int(versions[len(versions)-1]), nil
+}
+
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+
+	return err
+}
+
+func (l *migrateLogger) Printf(format string, v ...interface{}) {
+	l.log.Info().Msg(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (l *migrateLogger) Verbose() bool {
+	return false
+}
@@ -0,0 +1,61 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/ziflex/rm-rf-production/database/migrations"
+	"github.com/ziflex/rm-rf-production/internal/database"
+)
+
+func TestMigrationVersions(t *testing.T) {
+	versions, err := database.MigrationVersions()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, versions)
+
+	for i, v := range versions {
+		assert.Equal(t, uint(i+1), v, "migration versions must be sequential")
+	}
+
+	latest, err := database.SchemaVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, int(versions[len(versions)-1]), latest)
+}
+
+func TestMigrations_HaveDownMigrations(t *testing.T) {
+	ups, err := fs.Glob(migrations.FS, "*.up.sql")
+	assert.NoError(t, err)
+
+	for _, up := range ups {
+		down := strings.TrimSuffix(up, ".up.sql") + ".down.sql"
+
+		_, err := fs.Stat(migrations.FS, down)
+		assert.NoError(t, err, "%s has no down migration", up)
+	}
+}
+
+func TestNewMigrator_DisablesTimeouts(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+
+	// the timeouts are disabled on the connection before the driver takes the migration lock on it
+	mock.ExpectExec(`SET statement_timeout = 0; SET lock_timeout = 0`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT CURRENT_DATABASE\(\)`).
+		WillReturnError(errors.New("connection reset"))
+	// and restored before the connection returns to the pool
+	mock.ExpectExec(`RESET statement_timeout; RESET lock_timeout`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	_, err = database.NewMigrator(context.Background(), mockDB, zerolog.Nop())
+
+	assert.ErrorContains(t, err, "connection reset")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
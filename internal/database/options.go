@@ -1,32 +1,140 @@
 package database
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
+	"time"
+)
+
+const (
+	SSLModeDisable    = "disable"
+	SSLModeRequire    = "require"
+	SSLModeVerifyCA   = "verify-ca"
+	SSLModeVerifyFull = "verify-full"
 )
 
 type Options struct {
+	// URL is a complete connection string (URL or key=value form) that overrides all the connection settings below.
+	URL string
+
 	Name string
 	Host string
 	Port int
 	User string
 	Pass string
+
+	// SSLMode is one of disable, require, verify-ca or verify-full. Defaults to disable.
+	SSLMode string
+	// SSLRootCert is the path to the CA certificate used to verify the server.
+	SSLRootCert string
+	// SSLCert and SSLKey are the paths to the client certificate and its key.
+	SSLCert string
+	SSLKey  string
+
+	// ConnectTimeout bounds establishing a connection. Zero waits indefinitely.
+	ConnectTimeout time.Duration
+	// StatementTimeout aborts statements running longer than that. Zero disables the limit.
+	StatementTimeout time.Duration
+	// ApplicationName is reported in pg_stat_activity.
+	ApplicationName string
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// Replicas are complete connection strings of read replicas, see Router.
+	// Replica pools use the pool settings above.
+	Replicas []string
+	// ReplicaCheckInterval defines how often replicas are checked. Defaults to 5s.
+	ReplicaCheckInterval time.Duration
+	// ReplicaMaxLag takes a replica out of rotation while its replay lag exceeds it. Zero disables the lag check.
+	ReplicaMaxLag time.Duration
+	// ReadYourWrites routes the reads of a client to the primary for that long after its last write. Zero disables it.
+	ReadYourWrites time.Duration
+}
+
+func (opts Options) validate() error {
+	switch opts.SSLMode {
+	case "", SSLModeDisable, SSLModeRequire, SSLModeVerifyCA, SSLModeVerifyFull:
+	default:
+		return fmt.Errorf("unknown ssl mode: %s", opts.SSLMode)
+	}
+
+	if (opts.SSLCert == "") != (opts.SSLKey == "") {
+		return fmt.Errorf("ssl cert and key must be set together")
+	}
+
+	return nil
 }
 
 func toConnectionString(cfg Options) string {
+	if cfg.URL != "" {
+		return cfg.URL
+	}
+
+	sslMode := cfg.SSLMode
+
+	if sslMode == "" {
+		sslMode = SSLModeDisable
+	}
+
 	sb := new(strings.Builder)
 
-	sb.WriteString("host=")
-	sb.WriteString(cfg.Host)
-	sb.WriteString(" port=")
-	sb.WriteString(strconv.Itoa(cfg.Port))
-	sb.WriteString(" dbname=")
-	sb.WriteString(cfg.Name)
-	sb.WriteString(" user=")
-	sb.WriteString(cfg.User)
-	sb.WriteString(" password=")
-	sb.WriteString(cfg.Pass)
-	sb.WriteString(" sslmode=disable")
+	writeParam(sb, "host", cfg.Host)
+	writeParam(sb, "port", strconv.Itoa(cfg.Port))
+	writeParam(sb, "dbname", cfg.Name)
+	writeParam(sb, "user", cfg.User)
+	writeParam(sb, "password", cfg.Pass)
+	writeParam(sb, "sslmode", sslMode)
+	writeParam(sb, "sslrootcert", cfg.SSLRootCert)
+	writeParam(sb, "sslcert", cfg.SSLCert)
+	writeParam(sb, "sslkey", cfg.SSLKey)
+	writeParam(sb, "application_name", cfg.ApplicationName)
+
+	if cfg.ConnectTimeout > 0 {
+		// the driver accepts whole seconds only
+		writeParam(sb, "connect_timeout", strconv.Itoa(int(max(cfg.ConnectTimeout.Round(time.Second), time.Second).Seconds())))
+	}
+
+	if cfg.StatementTimeout > 0 {
+		// unknown parameters are sent to the server as session settings
+		writeParam(sb, "statement_timeout", strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10))
+	}
 
 	return sb.String()
 }
+
+// writeParam appends a key=value pair, quoting the value when it is empty or contains
+// spaces, quotes or backslashes, so that passwords with special characters are passed verbatim.
+func writeParam(sb *strings.Builder, key, value string) {
+	if value == "" && key != "password" {
+		return
+	}
+
+	if sb.Len() > 0 {
+		sb.WriteByte(' ')
+	}
+
+	sb.WriteString(key)
+	sb.WriteByte('=')
+
+	if value != "" && !strings.ContainsAny(value, " \t\n\r'\\") {
+		sb.WriteString(value)
+
+		return
+	}
+
+	sb.WriteByte('\'')
+
+	for _, r := range value {
+		if r == '\'' || r == '\\' {
+			sb.WriteByte('\\')
+		}
+
+		sb.WriteRune(r)
+	}
+
+	sb.WriteByte('\'')
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToConnectionString(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Options  Options
+		Expected string
+	}{
+		{
+			Name:     "defaults",
+			Options:  Options{Host: "localhost", Port: 5432, Name: "app", User: "app", Pass: "secret"},
+			Expected: "host=localhost port=5432 dbname=app user=app password=secret sslmode=disable",
+		},
+		{
+			Name:     "password with special characters",
+			Options:  Options{Host: "localhost", Port: 5432, Name: "app", User: "app", Pass: `p@ss w'rd\`},
+			Expected: `host=localhost port=5432 dbname=app user=app password='p@ss w\'rd\\' sslmode=disable`,
+		},
+		{
+			Name:     "empty password",
+			Options:  Options{Host: "localhost", Port: 5432, Name: "app", User: "app"},
+			Expected: "host=localhost port=5432 dbname=app user=app password='' sslmode=disable",
+		},
+		{
+			Name: "tls and timeouts",
+			Options: Options{
+				Host:             "db.internal",
+				Port:             5432,
+				Name:             "app",
+				User:             "app",
+				Pass:             "secret",
+				SSLMode:          SSLModeVerifyFull,
+				SSLRootCert:      "/etc/ssl/ca.pem",
+				SSLCert:          "/etc/ssl/client.pem",
+				SSLKey:           "/etc/ssl/client.key",
+				ApplicationName:  "rm-rf-production",
+				ConnectTimeout:   1500 * time.Millisecond,
+				StatementTimeout: 30 * time.Second,
+			},
+			Expected: "host=db.internal port=5432 dbname=app user=app password=secret sslmode=verify-full " +
+				"sslrootcert=/etc/ssl/ca.pem sslcert=/etc/ssl/client.pem sslkey=/etc/ssl/client.key " +
+				"application_name=rm-rf-production connect_timeout=2 statement_timeout=30000",
+		},
+		{
+			Name:     "url override",
+			Options:  Options{URL: "postgres://app:secret@db/app?sslmode=require", Host: "ignored"},
+			Expected: "postgres://app:secret@db/app?sslmode=require",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			dsn := toConnectionString(tc.Options)
+
+			assert.Equal(t, tc.Expected, dsn)
+
+			_, err := pq.NewConnector(dsn)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestOptions_Validate(t *testing.T) {
+	assert.NoError(t, Options{}.validate())
+	assert.NoError(t, Options{SSLMode: SSLModeVerifyCA, SSLCert: "cert", SSLKey: "key"}.validate())
+	assert.EqualError(t, Options{SSLMode: "prefer-ish"}.validate(), "unknown ssl mode: prefer-ish")
+	assert.EqualError(t, Options{SSLCert: "cert"}.validate(), "ssl cert and key must be set together")
+}
@@ -0,0 +1,41 @@
+package database
+
+import (
+	"time"
+
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/ratelimit"
+)
+
+type RateLimitsRepository struct {
+}
+
+func NewRateLimitsRepository() ratelimit.Repository {
+	return &RateLimitsRepository{}
+}
+
+func (r *RateLimitsRepository) Increment(ctx dbx.Context, key string, window time.Time, expiresAt time.Time) (int, error) {
+	row := executor(ctx).QueryRow(`
+		INSERT INTO rate_limits (key, window_start, hits, expires_at) VALUES ($1, $2, 1, $3)
+		ON CONFLICT (key, window_start) DO UPDATE SET hits = rate_limits.hits + 1
+		RETURNING hits
+	`, key, window.UTC(), expiresAt.UTC())
+
+	var hits int
+
+	if err := row.Scan(&hits); err != nil {
+		return 0, err
+	}
+
+	return hits, nil
+}
+
+func (r *RateLimitsRepository) DeleteExpired(ctx dbx.Context, before time.Time) (int64, error) {
+	res, err := executor(ctx).Exec("DELETE FROM rate_limits WHERE expires_at < $1", before.UTC())
+
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
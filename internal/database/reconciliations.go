@@ -0,0 +1,293 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+	"github.com/ziflex/rm-rf-production/pkg/reconciliation"
+	"github.com/ziflex/rm-rf-production/pkg/transactions"
+)
+
+type ReconciliationsRepository struct {
+}
+
+func NewReconciliationsRepository() reconciliation.Repository {
+	return &ReconciliationsRepository{}
+}
+
+func (r *ReconciliationsRepository) CreateReconciliation(ctx dbx.Context, creation reconciliation.ReconciliationCreation) (reconciliation.Reconciliation, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return reconciliation.Reconciliation{}, err
+	}
+
+	res := reconciliation.Reconciliation{
+		FileName:    creation.FileName,
+		PeriodStart: creation.PeriodStart.UTC(),
+		PeriodEnd:   creation.PeriodEnd.UTC(),
+		Summary:     creation.Summary,
+		Items:       creation.Items,
+	}
+
+	err = executor(ctx).QueryRow(`
+		INSERT INTO reconciliations (tenant_id, file_name, period_start, period_end, matched, missing_in_ledger, missing_in_file, amount_mismatch)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`, tenantID, res.FileName, res.PeriodStart, res.PeriodEnd,
+		res.Summary.Matched, res.Summary.MissingInLedger, res.Summary.MissingInFile, res.Summary.AmountMismatch,
+	).Scan(&res.ID, &res.CreatedAt)
+
+	if err != nil {
+		return reconciliation.Reconciliation{}, err
+	}
+
+	if len(creation.Items) == 0 {
+		return res, nil
+	}
+
+	// the items are inserted with a single statement, a settlement file has thousands of lines
+	cols := newItemColumns(len(creation.Items))
+
+	for _, item := range creation.Items {
+		cols.add(item)
+	}
+
+	_, err = executor(ctx).Exec(`
+		INSERT INTO reconciliation_items
+			(reconciliation_id, category, line, reference, file_amount, file_date, transaction_id, ledger_amount, ledger_date)
+		SELECT $1, category::reconciliation_category, line, reference, file_amount, file_date, transaction_id, ledger_amount, ledger_date
+		FROM unnest($2::text[], $3::integer[], $4::text[], $5::numeric[], $6::timestamp[], $7::integer[], $8::numeric[], $9::timestamp[])
+			WITH ORDINALITY AS items(category, line, reference, file_amount, file_date, transaction_id, ledger_amount, ledger_date, n)
+		ORDER BY n
+	`, res.ID, pq.Array(cols.category), pq.Array(cols.line), pq.Array(cols.reference), pq.Array(cols.fileAmount),
+		pq.Array(cols.fileDate), pq.Array(cols.transactionID), pq.Array(cols.ledgerAmount), pq.Array(cols.ledgerDate))
+
+	if err != nil {
+		return reconciliation.Reconciliation{}, err
+	}
+
+	return res, nil
+}
+
+func (r *ReconciliationsRepository) GetReconciliation(ctx dbx.Context, id int64, filter reconciliation.Filter) (reconciliation.Reconciliation, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return reconciliation.Reconciliation{}, err
+	}
+
+	var res reconciliation.Reconciliation
+
+	err = executor(ctx).QueryRow(`
+		SELECT id, file_name, period_start, period_end, matched, missing_in_ledger, missing_in_file, amount_mismatch, created_at
+		FROM reconciliations WHERE tenant_id=$1 AND id=$2
+	`, tenantID, id).Scan(
+		&res.ID,
+		&res.FileName,
+		&res.PeriodStart,
+		&res.PeriodEnd,
+		&res.Summary.Matched,
+		&res.Summary.MissingInLedger,
+		&res.Summary.MissingInFile,
+		&res.Summary.AmountMismatch,
+		&res.CreatedAt,
+	)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return reconciliation.Reconciliation{}, fmt.Errorf("reconciliation %w: %d", common.ErrNotFound, id)
+	}
+
+	if err != nil {
+		return reconciliation.Reconciliation{}, err
+	}
+
+	rows, err := executor(ctx).Query(`
+		SELECT i.category, i.line, i.reference, i.file_amount, i.file_date,
+			i.transaction_id, t.account_id, t.operation_type, i.ledger_amount, i.ledger_date, COALESCE(t.external_ref, '')
+		FROM reconciliation_items i
+		LEFT JOIN transactions t ON t.tenant_id=$1 AND t.id=i.transaction_id
+		WHERE i.reconciliation_id=$2 AND ($3='' OR i.category::text=$3)
+		ORDER BY i.id
+	`, tenantID, id, filter.Category.String())
+
+	if err != nil {
+		return reconciliation.Reconciliation{}, err
+	}
+
+	defer rows.Close()
+
+	res.Items = make([]reconciliation.Item, 0)
+
+	for rows.Next() {
+		item, err := r.scanItem(rows)
+
+		if err != nil {
+			return reconciliation.Reconciliation{}, err
+		}
+
+		res.Items = append(res.Items, item)
+	}
+
+	return res, rows.Err()
+}
+
+func (r *ReconciliationsRepository) ListLedger(ctx dbx.Context, from, to time.Time, fn func(transactions.Transaction) error) error {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := executor(ctx).Query(`
+		SELECT id, account_id, operation_type, amount, event_date, COALESCE(external_ref, '')
+		FROM transactions WHERE tenant_id=$1 AND event_date>=$2 AND event_date<$3
+			AND operation_type IN ('purchase', 'installment_purchase', 'withdrawal')
+		ORDER BY id
+	`, tenantID, from.UTC(), to.UTC())
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var tr transactions.Transaction
+		var optype string
+
+		if err := rows.Scan(&tr.ID, &tr.AccountID, &optype, &tr.Amount, &tr.EventDate, &tr.ExternalRef); err != nil {
+			return err
+		}
+
+		tr.OperationType = transactions.NewOperationTypeFromString(optype)
+
+		if err := fn(tr); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *ReconciliationsRepository) scanItem(row interface{ Scan(dest ...any) error }) (reconciliation.Item, error) {
+	var (
+		category      string
+		line          sql.NullInt64
+		reference     sql.NullString
+		fileAmount    sql.NullFloat64
+		fileDate      sql.NullTime
+		transactionID sql.NullInt64
+		accountID     sql.NullInt64
+		optype        sql.NullString
+		ledgerAmount  sql.NullFloat64
+		ledgerDate    sql.NullTime
+		externalRef   string
+	)
+
+	err := row.Scan(&category, &line, &reference, &fileAmount, &fileDate,
+		&transactionID, &accountID, &optype, &ledgerAmount, &ledgerDate, &externalRef)
+
+	if err != nil {
+		return reconciliation.Item{}, err
+	}
+
+	item := reconciliation.Item{Category: reconciliation.Category(category)}
+
+	if line.Valid {
+		item.Line = &reconciliation.Line{
+			Number:    int(line.Int64),
+			Reference: reference.String,
+			Amount:    fileAmount.Float64,
+			Date:      fileDate.Time,
+		}
+	}
+
+	// the transaction keeps the amount and date it was matched with
+	if transactionID.Valid {
+		item.Transaction = &transactions.Transaction{
+			ID:            transactionID.Int64,
+			AccountID:     accountID.Int64,
+			OperationType: transactions.NewOperationTypeFromString(optype.String),
+			Amount:        ledgerAmount.Float64,
+			EventDate:     ledgerDate.Time,
+			ExternalRef:   externalRef,
+		}
+	}
+
+	return item, nil
+}
+
+// itemColumns holds the items column by column, to be sent as arrays.
+type itemColumns struct {
+	category      []string
+	line          []sql.NullInt64
+	reference     []sql.NullString
+	fileAmount    []sql.NullFloat64
+	fileDate      []sql.NullString
+	transactionID []sql.NullInt64
+	ledgerAmount  []sql.NullFloat64
+	ledgerDate    []sql.NullString
+}
+
+func newItemColumns(n int) *itemColumns {
+	return &itemColumns{
+		category:      make([]string, 0, n),
+		line:          make([]sql.NullInt64, 0, n),
+		reference:     make([]sql.NullString, 0, n),
+		fileAmount:    make([]sql.NullFloat64, 0, n),
+		fileDate:      make([]sql.NullString, 0, n),
+		transactionID: make([]sql.NullInt64, 0, n),
+		ledgerAmount:  make([]sql.NullFloat64, 0, n),
+		ledgerDate:    make([]sql.NullString, 0, n),
+	}
+}
+
+func (c *itemColumns) add(item reconciliation.Item) {
+	var (
+		line          sql.NullInt64
+		reference     sql.NullString
+		fileAmount    sql.NullFloat64
+		fileDate      sql.NullString
+		transactionID sql.NullInt64
+		ledgerAmount  sql.NullFloat64
+		ledgerDate    sql.NullString
+	)
+
+	if l := item.Line; l != nil {
+		line = sql.NullInt64{Int64: int64(l.Number), Valid: true}
+		reference = nullString(l.Reference)
+		fileAmount = sql.NullFloat64{Float64: l.Amount, Valid: true}
+		fileDate = nullTimestamp(l.Date)
+	}
+
+	if tr := item.Transaction; tr != nil {
+		transactionID = sql.NullInt64{Int64: tr.ID, Valid: true}
+		ledgerAmount = sql.NullFloat64{Float64: tr.Amount, Valid: true}
+		ledgerDate = nullTimestamp(tr.EventDate)
+
+		// transactions missing in the file are reported with their own reference
+		if !reference.Valid {
+			reference = nullString(tr.ExternalRef)
+		}
+	}
+
+	c.category = append(c.category, item.Category.String())
+	c.line = append(c.line, line)
+	c.reference = append(c.reference, reference)
+	c.fileAmount = append(c.fileAmount, fileAmount)
+	c.fileDate = append(c.fileDate, fileDate)
+	c.transactionID = append(c.transactionID, transactionID)
+	c.ledgerAmount = append(c.ledgerAmount, ledgerAmount)
+	c.ledgerDate = append(c.ledgerDate, ledgerDate)
+}
+
+// nullTimestamp formats a time for a timestamp array, the columns hold UTC times.
+func nullTimestamp(t time.Time) sql.NullString {
+	return sql.NullString{String: t.UTC().Format("2006-01-02 15:04:05.999999"), Valid: true}
+}
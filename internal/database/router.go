@@ -0,0 +1,291 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/auth"
+)
+
+const defaultReplicaCheckInterval = 5 * time.Second
+
+type (
+	// Router sends read-only queries to healthy replicas and everything else to the primary.
+	// It is a dbx.Database on its own: statements and transactions always run on the primary,
+	// only the database returned by ForRead may be a replica.
+	Router struct {
+		*sql.DB
+		replicas []*replica
+		next     atomic.Uint64
+		opts     Options
+		logger   zerolog.Logger
+		now      func() time.Time
+		mu       sync.Mutex
+		// pins holds the time until which a client reads from the primary.
+		pins map[string]time.Time
+		stop context.CancelFunc
+		done chan struct{}
+	}
+
+	replica struct {
+		db      *sql.DB
+		name    string
+		healthy atomic.Bool
+	}
+)
+
+// NewRouter opens a pool per replica from opts.Replicas and starts checking them in the background.
+// Replicas are out of rotation until their first successful check.
+func NewRouter(primary *sql.DB, opts Options, logger zerolog.Logger) (*Router, error) {
+	replicas := make([]*sql.DB, 0, len(opts.Replicas))
+
+	for i, dsn := range opts.Replicas {
+		replicaOpts := opts
+		replicaOpts.URL = dsn
+		replicaOpts.Replicas = nil
+
+		db, err := New(replicaOpts)
+
+		if err != nil {
+			for _, r := range replicas {
+				r.Close()
+			}
+
+			return nil, fmt.Errorf("replica %d: %w", i, err)
+		}
+
+		replicas = append(replicas, db)
+	}
+
+	r := newRouter(primary, replicas, opts, logger)
+	r.start()
+
+	return r, nil
+}
+
+func newRouter(primary *sql.DB, replicas []*sql.DB, opts Options, logger zerolog.Logger) *Router {
+	if opts.ReplicaCheckInterval <= 0 {
+		opts.ReplicaCheckInterval = defaultReplicaCheckInterval
+	}
+
+	r := &Router{
+		DB:     primary,
+		opts:   opts,
+		logger: logger,
+		now:    time.Now,
+		pins:   make(map[string]time.Time),
+		stop:   func() {},
+		done:   make(chan struct{}),
+	}
+
+	for i, db := range replicas {
+		r.replicas = append(r.replicas, &replica{db: db, name: fmt.Sprintf("replica-%d", i)})
+	}
+
+	close(r.done)
+
+	return r
+}
+
+func (r *Router) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.stop = cancel
+	r.done = make(chan struct{})
+
+	go r.watch(ctx)
+}
+
+// ForRead returns a healthy replica for read-only queries. It returns the primary when there are no healthy
+// replicas or when the client from the context has written recently and read-your-writes is enabled.
+func (r *Router) ForRead(ctx context.Context) dbx.Database {
+	if r.isPinned(ctx) {
+		return r.DB
+	}
+
+	n := len(r.replicas)
+
+	for range n {
+		rep := r.replicas[r.next.Add(1)%uint64(n)]
+
+		if rep.healthy.Load() {
+			return rep.db
+		}
+	}
+
+	return r.DB
+}
+
+func (r *Router) Begin() (*sql.Tx, error) {
+	return r.BeginTx(context.Background(), nil)
+}
+
+func (r *Router) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	if opts == nil || !opts.ReadOnly {
+		r.pin(ctx)
+	}
+
+	return r.DB.BeginTx(ctx, opts)
+}
+
+func (r *Router) Exec(query string, args ...any) (sql.Result, error) {
+	return r.ExecContext(context.Background(), query, args...)
+}
+
+func (r *Router) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	r.pin(ctx)
+
+	return r.DB.ExecContext(ctx, query, args...)
+}
+
+// Close stops the checks and closes the replica pools and the primary.
+func (r *Router) Close() error {
+	r.stop()
+	<-r.done
+
+	return errors.Join(r.closeReplicas(), r.DB.Close())
+}
+
+// Replicas returns the replica pools, e.g. to export their stats.
+func (r *Router) Replicas() map[string]*sql.DB {
+	out := make(map[string]*sql.DB, len(r.replicas))
+
+	for _, rep := range r.replicas {
+		out[rep.name] = rep.db
+	}
+
+	return out
+}
+
+// pin routes the reads of the client to the primary for the read-your-writes window.
+// Clients are identified by their principal, anonymous calls are never pinned.
+func (r *Router) pin(ctx context.Context) {
+	if r.opts.ReadYourWrites <= 0 || len(r.replicas) == 0 {
+		return
+	}
+
+	principal, ok := auth.PrincipalFromContext(ctx)
+
+	if !ok {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pins[principal.ID] = r.now().Add(r.opts.ReadYourWrites)
+}
+
+func (r *Router) isPinned(ctx context.Context) bool {
+	if r.opts.ReadYourWrites <= 0 {
+		return false
+	}
+
+	principal, ok := auth.PrincipalFromContext(ctx)
+
+	if !ok {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	until, ok := r.pins[principal.ID]
+
+	return ok && r.now().Before(until)
+}
+
+func (r *Router) watch(ctx context.Context) {
+	defer close(r.done)
+
+	if len(r.replicas) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(r.opts.ReplicaCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		r.checkReplicas(ctx)
+		r.sweepPins()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkReplicas takes replicas that are unreachable or lag behind out of rotation and returns recovered ones.
+func (r *Router) checkReplicas(ctx context.Context) {
+	for _, rep := range r.replicas {
+		checkCtx, cancel := context.WithTimeout(ctx, r.opts.ReplicaCheckInterval)
+		err := r.checkReplica(checkCtx, rep.db)
+		cancel()
+
+		healthy := err == nil
+
+		if rep.healthy.Swap(healthy) != healthy {
+			if healthy {
+				r.logger.Info().Str("replica", rep.name).Msg("replica is back in rotation")
+			} else {
+				r.logger.Warn().Err(err).Str("replica", rep.name).Msg("replica is out of rotation")
+			}
+		}
+	}
+}
+
+func (r *Router) checkReplica(ctx context.Context, db *sql.DB) error {
+	if r.opts.ReplicaMaxLag <= 0 {
+		return db.PingContext(ctx)
+	}
+
+	// a replica that replayed everything it received is not lagging, however old the last transaction is;
+	// the functions return NULL on a primary
+	var lag float64
+
+	err := db.QueryRowContext(ctx, `
+		SELECT CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0) END
+	`).Scan(&lag)
+
+	if err != nil {
+		return err
+	}
+
+	if d := time.Duration(lag * float64(time.Second)); d > r.opts.ReplicaMaxLag {
+		return fmt.Errorf("replication lag %s exceeds %s", d.Round(time.Millisecond), r.opts.ReplicaMaxLag)
+	}
+
+	return nil
+}
+
+func (r *Router) sweepPins() {
+	now := r.now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, until := range r.pins {
+		if !now.Before(until) {
+			delete(r.pins, id)
+		}
+	}
+}
+
+func (r *Router) closeReplicas() error {
+	var errs []error
+
+	for _, rep := range r.replicas {
+		errs = append(errs, rep.db.Close())
+	}
+
+	return errors.Join(errs...)
+}
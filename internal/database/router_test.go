@@ -0,0 +1,130 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziflex/rm-rf-production/pkg/auth"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+)
+
+const lagQuery = "SELECT CASE WHEN pg_last_wal_receive_lsn"
+
+func newMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return db, mock
+}
+
+func principalCtx(id string) context.Context {
+	return auth.WithPrincipal(context.Background(), auth.Principal{ID: id, TenantID: "acme"})
+}
+
+func TestRouter_ForRead_RoundRobin(t *testing.T) {
+	primary, _ := newMockDB(t)
+	first, firstMock := newMockDB(t)
+	second, secondMock := newMockDB(t)
+	r := newRouter(primary, []*sql.DB{first, second}, Options{}, zerolog.Nop())
+
+	// replicas are out of rotation until checked
+	assert.Same(t, primary, r.ForRead(context.Background()))
+
+	firstMock.ExpectPing()
+	secondMock.ExpectPing()
+	r.checkReplicas(context.Background())
+
+	seen := map[any]int{}
+
+	for range 4 {
+		seen[r.ForRead(context.Background())]++
+	}
+
+	assert.Equal(t, map[any]int{first: 2, second: 2}, seen)
+	assert.NoError(t, firstMock.ExpectationsWereMet())
+	assert.NoError(t, secondMock.ExpectationsWereMet())
+}
+
+func TestRouter_ForRead_FallsBackToPrimary(t *testing.T) {
+	primary, _ := newMockDB(t)
+	replica, mock := newMockDB(t)
+	r := newRouter(primary, []*sql.DB{replica}, Options{ReplicaMaxLag: 10 * time.Second}, zerolog.Nop())
+
+	mock.ExpectQuery(lagQuery).WillReturnRows(sqlmock.NewRows([]string{"lag"}).AddRow(0.5))
+	r.checkReplicas(context.Background())
+	assert.Same(t, replica, r.ForRead(context.Background()))
+
+	mock.ExpectQuery(lagQuery).WillReturnRows(sqlmock.NewRows([]string{"lag"}).AddRow(30.0))
+	r.checkReplicas(context.Background())
+	assert.Same(t, primary, r.ForRead(context.Background()), "lagging replica")
+
+	mock.ExpectQuery(lagQuery).WillReturnRows(sqlmock.NewRows([]string{"lag"}).AddRow(0.0))
+	r.checkReplicas(context.Background())
+	assert.Same(t, replica, r.ForRead(context.Background()), "recovered replica")
+
+	mock.ExpectQuery(lagQuery).WillReturnError(errors.New("connection refused"))
+	r.checkReplicas(context.Background())
+	assert.Same(t, primary, r.ForRead(context.Background()), "unreachable replica")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRouter_ReadYourWrites(t *testing.T) {
+	primary, primaryMock := newMockDB(t)
+	replica, replicaMock := newMockDB(t)
+	r := newRouter(primary, []*sql.DB{replica}, Options{ReadYourWrites: 5 * time.Second}, zerolog.Nop())
+
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	replicaMock.ExpectPing()
+	r.checkReplicas(context.Background())
+
+	writer := principalCtx("apikey:1")
+	reader := principalCtx("apikey:2")
+
+	primaryMock.ExpectBegin()
+	primaryMock.ExpectCommit()
+
+	tx, err := r.BeginTx(writer, nil)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	assert.Same(t, primary, r.ForRead(writer), "writer is pinned to the primary")
+	assert.Same(t, replica, r.ForRead(reader), "other clients are not pinned")
+	assert.Same(t, replica, r.ForRead(context.Background()), "anonymous calls are not pinned")
+
+	now = now.Add(5 * time.Second)
+	r.sweepPins()
+
+	assert.Same(t, replica, r.ForRead(writer), "the pin expires")
+	assert.Empty(t, r.pins)
+
+	primaryMock.ExpectExec("UPDATE accounts").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	_, err = r.ExecContext(writer, "UPDATE accounts SET blocked_at = now()")
+	require.NoError(t, err)
+
+	assert.Same(t, primary, r.ForRead(writer), "statements pin too")
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+}
+
+func TestForRead(t *testing.T) {
+	primary, _ := newMockDB(t)
+	replica, mock := newMockDB(t)
+	r := newRouter(primary, []*sql.DB{replica}, Options{}, zerolog.Nop())
+
+	mock.ExpectPing()
+	r.checkReplicas(context.Background())
+
+	assert.Same(t, replica, common.ForRead(context.Background(), r))
+	assert.Same(t, primary, common.ForRead(context.Background(), primary), "plain databases are used as is")
+}
@@ -0,0 +1,146 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+	"github.com/ziflex/rm-rf-production/pkg/scheduler"
+)
+
+const runColumns = "id, job_name, instance, status, scheduled_at, started_at, finished_at, COALESCE(result, ''), COALESCE(error, '')"
+
+type SchedulerRepository struct {
+}
+
+func NewSchedulerRepository() scheduler.Repository {
+	return &SchedulerRepository{}
+}
+
+func (r *SchedulerRepository) CreateRun(ctx dbx.Context, creation scheduler.RunCreation) (scheduler.Run, error) {
+	row := executor(ctx).QueryRow(`
+		INSERT INTO scheduler_runs (job_name, instance, scheduled_at)
+		VALUES ($1, $2, $3)
+		RETURNING `+runColumns,
+		creation.Job, creation.Instance, creation.ScheduledAt.UTC())
+
+	run, err := r.scanRun(row)
+
+	if err != nil {
+		if pgErr, ok := IsPgErr(err); ok && IsDbUniqueViolation(pgErr) {
+			return scheduler.Run{}, fmt.Errorf("run of %s %w: %s", creation.Job, common.ErrDuplicate, creation.ScheduledAt.UTC())
+		}
+
+		return scheduler.Run{}, err
+	}
+
+	return run, nil
+}
+
+func (r *SchedulerRepository) FinishRun(ctx dbx.Context, id int64, completion scheduler.RunCompletion) (scheduler.Run, error) {
+	row := executor(ctx).QueryRow(`
+		UPDATE scheduler_runs SET status=$2, result=$3, error=$4, finished_at=CURRENT_TIMESTAMP
+		WHERE id=$1
+		RETURNING `+runColumns,
+		id, completion.Status.String(), nullString(completion.Result), nullString(completion.Error))
+
+	run, err := r.scanRun(row)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return scheduler.Run{}, fmt.Errorf("run %w: %d", common.ErrNotFound, id)
+	}
+
+	return run, err
+}
+
+func (r *SchedulerRepository) InterruptRuns(ctx dbx.Context, job string, reason string) (int64, error) {
+	res, err := executor(ctx).Exec(`
+		UPDATE scheduler_runs SET status='failed', error=$2, finished_at=CURRENT_TIMESTAMP
+		WHERE job_name=$1 AND status='running'
+	`, job, reason)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (r *SchedulerRepository) ListRuns(ctx dbx.Context, filter scheduler.RunFilter) ([]scheduler.Run, error) {
+	where := []string{"TRUE"}
+	var args []any
+
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, strings.ReplaceAll(cond, "?", "$"+strconv.Itoa(len(args))))
+	}
+
+	if filter.Job != "" {
+		add("job_name=?", filter.Job)
+	}
+
+	if filter.Status != "" {
+		add("status=?", filter.Status.String())
+	}
+
+	args = append(args, filter.Limit)
+
+	rows, err := executor(ctx).Query(`
+		SELECT `+runColumns+`
+		FROM scheduler_runs WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY id DESC LIMIT $`+strconv.Itoa(len(args)), args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	runs := make([]scheduler.Run, 0, filter.Limit)
+
+	for rows.Next() {
+		run, err := r.scanRun(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
+
+func (r *SchedulerRepository) scanRun(row interface{ Scan(dest ...any) error }) (scheduler.Run, error) {
+	var run scheduler.Run
+	var status string
+	var finishedAt sql.NullTime
+
+	err := row.Scan(
+		&run.ID,
+		&run.Job,
+		&run.Instance,
+		&status,
+		&run.ScheduledAt,
+		&run.StartedAt,
+		&finishedAt,
+		&run.Result,
+		&run.Error,
+	)
+
+	if err != nil {
+		return scheduler.Run{}, err
+	}
+
+	run.Status = scheduler.RunStatus(status)
+
+	if finishedAt.Valid {
+		run.FinishedAt = &finishedAt.Time
+	}
+
+	return run, nil
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+	"github.com/ziflex/rm-rf-production/pkg/tenants"
+)
+
+type TenantsRepository struct {
+}
+
+func NewTenantsRepository() tenants.Repository {
+	return &TenantsRepository{}
+}
+
+func (r *TenantsRepository) CreateTenant(ctx dbx.Context, creation tenants.TenantCreation) (tenants.Tenant, error) {
+	row := executor(ctx).QueryRow(`
+		INSERT INTO tenants (id, name) VALUES ($1, $2)
+		RETURNING id, name, created_at
+	`, creation.ID, creation.Name)
+
+	if err := row.Err(); err != nil {
+		if pgErr, ok := IsPgErr(err); ok {
+			if IsDbUniqueViolation(pgErr) {
+				return tenants.Tenant{}, fmt.Errorf("tenant %w: %s", common.ErrDuplicate, creation.ID)
+			}
+		}
+
+		return tenants.Tenant{}, err
+	}
+
+	var tenant tenants.Tenant
+
+	if err := row.Scan(&tenant.ID, &tenant.Name, &tenant.CreatedAt); err != nil {
+		return tenants.Tenant{}, err
+	}
+
+	return tenant, nil
+}
+
+func (r *TenantsRepository) GetTenant(ctx dbx.Context, id string) (tenants.Tenant, error) {
+	var tenant tenants.Tenant
+
+	err := executor(ctx).QueryRow(`
+		SELECT id, name, created_at FROM tenants WHERE id=$1
+	`, id).Scan(&tenant.ID, &tenant.Name, &tenant.CreatedAt)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return tenants.Tenant{}, fmt.Errorf("tenant %w: %s", common.ErrNotFound, id)
+	}
+
+	return tenant, err
+}
@@ -0,0 +1,96 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/internal/tracing"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedExecutor creates a client span around every SQL statement.
+// Statements without an explicit context run with the context of the repository call,
+// so their spans are attached to the current trace.
+type tracedExecutor struct {
+	ctx  context.Context
+	next dbx.Executor
+}
+
+// executor returns the executor of the context instrumented with tracing.
+// Repositories must use it instead of calling ctx.Executor() directly.
+func executor(ctx dbx.Context) dbx.Executor {
+	return &tracedExecutor{ctx, ctx.Executor()}
+}
+
+func (e *tracedExecutor) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return e.ExecContext(e.ctx, query, args...)
+}
+
+func (e *tracedExecutor) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return e.QueryContext(e.ctx, query, args...)
+}
+
+func (e *tracedExecutor) QueryRow(query string, args ...interface{}) *sql.Row {
+	return e.QueryRowContext(e.ctx, query, args...)
+}
+
+func (e *tracedExecutor) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startQuery(ctx, query)
+	defer span.End()
+
+	res, err := e.next.ExecContext(ctx, query, args...)
+	endQuery(span, err)
+
+	return res, err
+}
+
+func (e *tracedExecutor) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startQuery(ctx, query)
+	defer span.End()
+
+	rows, err := e.next.QueryContext(ctx, query, args...)
+	endQuery(span, err)
+
+	return rows, err
+}
+
+func (e *tracedExecutor) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := startQuery(ctx, query)
+	defer span.End()
+
+	row := e.next.QueryRowContext(ctx, query, args...)
+	endQuery(span, row.Err())
+
+	return row
+}
+
+func startQuery(ctx context.Context, query string) (context.Context, trace.Span) {
+	query = strings.TrimSpace(query)
+	operation := queryOperation(query)
+
+	return tracing.Tracer().Start(ctx, operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		semconv.DBSystemNamePostgreSQL,
+		semconv.DBOperationName(operation),
+		semconv.DBQueryText(query),
+	))
+}
+
+func endQuery(span trace.Span, err error) {
+	if err != nil && err != sql.ErrNoRows {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// queryOperation returns the leading SQL keyword of the query, e.g. SELECT or INSERT.
+func queryOperation(query string) string {
+	if i := strings.IndexAny(query, " \t\n"); i > 0 {
+		return strings.ToUpper(query[:i])
+	}
+
+	return strings.ToUpper(query)
+}
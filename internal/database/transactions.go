@@ -2,13 +2,20 @@ package database
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/ziflex/dbx"
 	"github.com/ziflex/rm-rf-production/pkg/common"
 	"github.com/ziflex/rm-rf-production/pkg/transactions"
 )
 
+// transactionColumns selects a transaction t with its merchant m, which is joined with a LEFT JOIN.
+const transactionColumns = `t.id, t.account_id, t.operation_type, t.amount, t.event_date, COALESCE(t.external_ref, ''),
+	COALESCE(m.id, ''), COALESCE(m.name, ''), COALESCE(m.city, ''), COALESCE(m.country, ''), COALESCE(m.mcc, '')`
+
 type TransactionsRepository struct {
 }
 
@@ -17,16 +24,50 @@ func NewTransactions() transactions.Repository {
 }
 
 func (t *TransactionsRepository) CreateTransaction(ctx dbx.Context, tr transactions.TransactionCreation) (transactions.Transaction, error) {
-	row := ctx.Executor().QueryRow(`
-		INSERT INTO transactions (account_id, operation_type, amount) VALUES ($1, $2, $3)
-		RETURNING id, account_id, operation_type, amount, event_date
-	`, tr.AccountID, tr.OperationType.String(), tr.Amount)
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return transactions.Transaction{}, err
+	}
+
+	var merchantID string
+
+	if tr.Merchant != nil {
+		merchantID = tr.Merchant.ID
+
+		if err := t.upsertMerchant(ctx, tenantID, *tr.Merchant); err != nil {
+			return transactions.Transaction{}, err
+		}
+	}
+
+	// nothing is inserted for blocked accounts, unless the system issues the transaction
+	guard := `WHERE NOT EXISTS (SELECT 1 FROM accounts WHERE tenant_id=$1 AND id=$2 AND blocked_at IS NOT NULL)`
+
+	if tr.AllowBlocked {
+		guard = ""
+	}
+
+	// the composite foreign key on (tenant_id, account_id) rejects accounts of other tenants
+	row := executor(ctx).QueryRow(`
+		WITH t AS (
+			INSERT INTO transactions (tenant_id, account_id, operation_type, amount, external_ref, merchant_id)
+			SELECT $1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, '')
+			`+guard+`
+			RETURNING id, account_id, operation_type, amount, event_date, external_ref, merchant_id
+		)
+		SELECT `+transactionColumns+`
+		FROM t LEFT JOIN merchants m ON m.tenant_id=$1 AND m.id=t.merchant_id
+	`, tenantID, tr.AccountID, tr.OperationType.String(), tr.Amount, tr.ExternalRef, merchantID)
 
 	if err := row.Err(); err != nil {
 		if pgErr, ok := IsPgErr(err); ok {
 			if IsDbForeignKeyViolation(pgErr) {
 				return transactions.Transaction{}, fmt.Errorf("account %w: %d", common.ErrNotFound, tr.AccountID)
 			}
+
+			if IsDbUniqueViolation(pgErr) {
+				return transactions.Transaction{}, fmt.Errorf("transaction external_ref %w: %s", common.ErrDuplicate, tr.ExternalRef)
+			}
 		}
 
 		return transactions.Transaction{}, err
@@ -34,6 +75,10 @@ func (t *TransactionsRepository) CreateTransaction(ctx dbx.Context, tr transacti
 
 	res, err := t.scanTransaction(row)
 
+	if errors.Is(err, sql.ErrNoRows) {
+		return transactions.Transaction{}, fmt.Errorf("%w: %d", transactions.ErrAccountBlocked, tr.AccountID)
+	}
+
 	if err != nil {
 		return transactions.Transaction{}, err
 	}
@@ -41,11 +86,72 @@ func (t *TransactionsRepository) CreateTransaction(ctx dbx.Context, tr transacti
 	return res, nil
 }
 
-func (t *TransactionsRepository) scanTransaction(row *sql.Row) (transactions.Transaction, error) {
+func (t *TransactionsRepository) ExportTransactions(ctx dbx.Context, filter transactions.ExportFilter, fn func(transactions.Transaction) error) error {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	where := []string{"t.tenant_id=$1", "t.account_id=$2"}
+	args := []any{tenantID, filter.AccountID}
+
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, strings.ReplaceAll(cond, "?", "$"+strconv.Itoa(len(args))))
+	}
+
+	if filter.AfterID > 0 {
+		add("t.id>?", filter.AfterID)
+	}
+
+	if filter.From != nil {
+		add("t.event_date>=?", filter.From.UTC())
+	}
+
+	if filter.To != nil {
+		add("t.event_date<?", filter.To.UTC())
+	}
+
+	if filter.MCC != "" {
+		add("m.mcc=?", filter.MCC)
+	}
+
+	// the rows are read from the cursor as they arrive, the result is never held in memory
+	rows, err := executor(ctx).Query(`
+		SELECT `+transactionColumns+`
+		FROM transactions t LEFT JOIN merchants m ON m.tenant_id=t.tenant_id AND m.id=t.merchant_id
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY t.id`, args...)
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		tr, err := t.scanTransaction(rows)
+
+		if err != nil {
+			return err
+		}
+
+		if err := fn(tr); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (t *TransactionsRepository) scanTransaction(row interface{ Scan(dest ...any) error }) (transactions.Transaction, error) {
 	var tr transactions.Transaction
 	var optype string
+	var merchant transactions.Merchant
 
-	err := row.Scan(&tr.ID, &tr.AccountID, &optype, &tr.Amount, &tr.EventDate)
+	err := row.Scan(&tr.ID, &tr.AccountID, &optype, &tr.Amount, &tr.EventDate, &tr.ExternalRef,
+		&merchant.ID, &merchant.Name, &merchant.City, &merchant.Country, &merchant.MCC)
 
 	if err != nil {
 		return transactions.Transaction{}, err
@@ -53,5 +159,47 @@ func (t *TransactionsRepository) scanTransaction(row *sql.Row) (transactions.Tra
 
 	tr.OperationType = transactions.NewOperationTypeFromString(optype)
 
+	if merchant.ID != "" {
+		tr.Merchant = &merchant
+	}
+
 	return tr, nil
 }
+
+// upsertMerchant inserts a merchant seen for the first time and updates the details of a known one that changed.
+// The row of a known merchant is locked only when its details change, so that the transactions of a merchant
+// do not wait for each other.
+func (t *TransactionsRepository) upsertMerchant(ctx dbx.Context, tenantID string, merchant transactions.Merchant) error {
+	res, err := executor(ctx).Exec(`
+		INSERT INTO merchants (tenant_id, id, name, city, country, mcc)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''))
+		ON CONFLICT (tenant_id, id) DO NOTHING
+	`, tenantID, merchant.ID, merchant.Name, merchant.City, merchant.Country, merchant.MCC)
+
+	if err != nil {
+		return err
+	}
+
+	inserted, err := res.RowsAffected()
+
+	if err != nil || inserted > 0 {
+		return err
+	}
+
+	_, err = executor(ctx).Exec(`
+		UPDATE merchants SET
+			name=COALESCE(NULLIF($3, ''), name),
+			city=COALESCE(NULLIF($4, ''), city),
+			country=COALESCE(NULLIF($5, ''), country),
+			mcc=COALESCE(NULLIF($6, ''), mcc),
+			updated_at=CURRENT_TIMESTAMP
+		WHERE tenant_id=$1 AND id=$2 AND (
+			(NULLIF($3, '') IS NOT NULL AND name IS DISTINCT FROM $3) OR
+			(NULLIF($4, '') IS NOT NULL AND city IS DISTINCT FROM $4) OR
+			(NULLIF($5, '') IS NOT NULL AND country IS DISTINCT FROM $5) OR
+			(NULLIF($6, '') IS NOT NULL AND mcc IS DISTINCT FROM $6)
+		)
+	`, tenantID, merchant.ID, merchant.Name, merchant.City, merchant.Country, merchant.MCC)
+
+	return err
+}
@@ -0,0 +1,52 @@
+// Package etag formats and parses the entity tags of versioned resources.
+package etag
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Format returns the strong entity tag of a version.
+func Format(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// Parse parses the list of entity tags of If-Match or If-None-Match.
+// The versions are nil if the list is "*". Weak tags are skipped unless weak is set,
+// If-Match uses the strong comparison and If-None-Match the weak one (RFC 9110, section 8.8.3.2).
+// Tags the server never issued are skipped as well, so the result may be empty but not nil.
+func Parse(header string, weak bool) []int64 {
+	if strings.TrimSpace(header) == "*" {
+		return nil
+	}
+
+	versions := make([]int64, 0, 1)
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				continue
+			}
+
+			tag = tag[2:]
+		}
+
+		value, err := strconv.Unquote(tag)
+
+		if err != nil || !strings.HasPrefix(tag, `"`) {
+			continue
+		}
+
+		version, err := strconv.ParseInt(value, 10, 64)
+
+		if err != nil {
+			continue
+		}
+
+		versions = append(versions, version)
+	}
+
+	return versions
+}
@@ -0,0 +1,359 @@
+// Package export encodes the transactions of an account as a file, one transaction at a time.
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ziflex/rm-rf-production/pkg/transactions"
+)
+
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+	FormatOFX    Format = "ofx"
+)
+
+const (
+	// ofxTime is the OFX datetime format, always sent in UTC.
+	ofxTime       = "20060102150405.000[0:GMT]"
+	ofxNameLength = 32
+)
+
+type (
+	// Statement describes the exported transactions, it is used by the formats with a header.
+	Statement struct {
+		AccountID   int64
+		From        *time.Time
+		To          *time.Time
+		Currency    string
+		GeneratedAt time.Time
+	}
+
+	Encoder interface {
+		Encode(tr transactions.Transaction) error
+		// Close writes the end of the file, it does not close the underlying writer.
+		Close() error
+	}
+
+	// Record is a transaction as it is written to CSV and NDJSON files.
+	Record struct {
+		TransactionID   int64     `json:"transaction_id"`
+		AccountID       int64     `json:"account_id"`
+		OperationTypeID int       `json:"operation_type_id"`
+		OperationType   string    `json:"operation_type"`
+		Amount          float64   `json:"amount"`
+		EventDate       time.Time `json:"event_date"`
+		MerchantID      string    `json:"merchant_id,omitempty"`
+		MerchantName    string    `json:"merchant_name,omitempty"`
+		MerchantCity    string    `json:"merchant_city,omitempty"`
+		MerchantCountry string    `json:"merchant_country,omitempty"`
+		MCC             string    `json:"mcc,omitempty"`
+	}
+)
+
+var csvHeader = []string{
+	"transaction_id", "account_id", "operation_type_id", "operation_type", "amount", "event_date",
+	"merchant_id", "merchant_name", "merchant_city", "merchant_country", "mcc",
+}
+
+func NewEncoder(w io.Writer, format Format, stmt Statement) (Encoder, error) {
+	switch format {
+	case FormatCSV:
+		return &csvEncoder{w: csv.NewWriter(w)}, nil
+	case FormatNDJSON:
+		return &ndjsonEncoder{enc: json.NewEncoder(w)}, nil
+	case FormatOFX:
+		if stmt.Currency == "" {
+			stmt.Currency = "USD"
+		}
+
+		return &ofxEncoder{w: bufio.NewWriter(w), stmt: stmt}, nil
+	default:
+		return nil, fmt.Errorf("unknown export format: %s", format)
+	}
+}
+
+// ContentDisposition returns the Content-Disposition header of the file of an account.
+func ContentDisposition(accountID int64, format Format) string {
+	return fmt.Sprintf(`attachment; filename="account-%d-transactions.%s"`, accountID, format)
+}
+
+// Pipe returns a reader of the file written by export, which passes the transactions to the encoder.
+// The file is written while it is read, closing the reader makes the encoder fail and stops the export.
+func Pipe(format Format, stmt Statement, export func(fn func(transactions.Transaction) error) error) io.ReadCloser {
+	pr, pw := io.Pipe()
+
+	go func() {
+		enc, err := NewEncoder(pw, format, stmt)
+
+		if err == nil {
+			err = export(enc.Encode)
+		}
+
+		if err == nil {
+			err = enc.Close()
+		}
+
+		pw.CloseWithError(err)
+	}()
+
+	return pr
+}
+
+func NewRecord(tr transactions.Transaction) Record {
+	r := Record{
+		TransactionID:   tr.ID,
+		AccountID:       tr.AccountID,
+		OperationTypeID: int(tr.OperationType),
+		OperationType:   tr.OperationType.String(),
+		Amount:          tr.Amount,
+		EventDate:       tr.EventDate.UTC(),
+	}
+
+	if m := tr.Merchant; m != nil {
+		r.MerchantID = m.ID
+		r.MerchantName = m.Name
+		r.MerchantCity = m.City
+		r.MerchantCountry = m.Country
+		r.MCC = m.MCC
+	}
+
+	return r
+}
+
+type csvEncoder struct {
+	w       *csv.Writer
+	started bool
+}
+
+func (e *csvEncoder) Encode(tr transactions.Transaction) error {
+	if err := e.start(); err != nil {
+		return err
+	}
+
+	r := NewRecord(tr)
+
+	return e.w.Write([]string{
+		strconv.FormatInt(r.TransactionID, 10),
+		strconv.FormatInt(r.AccountID, 10),
+		strconv.Itoa(r.OperationTypeID),
+		r.OperationType,
+		formatAmount(r.Amount),
+		r.EventDate.Format(time.RFC3339Nano),
+		r.MerchantID,
+		r.MerchantName,
+		r.MerchantCity,
+		r.MerchantCountry,
+		r.MCC,
+	})
+}
+
+func (e *csvEncoder) Close() error {
+	// an empty export still has the header
+	if err := e.start(); err != nil {
+		return err
+	}
+
+	e.w.Flush()
+
+	return e.w.Error()
+}
+
+func (e *csvEncoder) start() error {
+	if e.started {
+		return nil
+	}
+
+	e.started = true
+
+	return e.w.Write(csvHeader)
+}
+
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonEncoder) Encode(tr transactions.Transaction) error {
+	return e.enc.Encode(NewRecord(tr))
+}
+
+func (e *ndjsonEncoder) Close() error {
+	return nil
+}
+
+// ofxEncoder writes an OFX 2.2 credit card statement.
+// The header is written with the first transaction, so that the start of an unbounded statement is known.
+type ofxEncoder struct {
+	w       *bufio.Writer
+	stmt    Statement
+	started bool
+	// net is the sum of the amounts of the exported transactions
+	net float64
+}
+
+func (e *ofxEncoder) Encode(tr transactions.Transaction) error {
+	if !e.started {
+		e.start(tr.EventDate)
+	}
+
+	e.net += tr.Amount
+
+	// the buffer is flushed whenever it fills up, the statement is never held in memory
+	fmt.Fprintf(e.w, `<STMTTRN>
+<TRNTYPE>%s</TRNTYPE>
+<DTPOSTED>%s</DTPOSTED>
+<TRNAMT>%s</TRNAMT>
+<FITID>%d</FITID>
+`, TransactionType(tr.OperationType), tr.EventDate.UTC().Format(ofxTime), formatAmount(tr.Amount), tr.ID)
+
+	name := tr.OperationType.String()
+
+	if m := tr.Merchant; m != nil {
+		if m.MCC != "" {
+			fmt.Fprintf(e.w, "<SIC>%s</SIC>\n", m.MCC)
+		}
+
+		if m.Name != "" {
+			name = m.Name
+		}
+	}
+
+	_, err := fmt.Fprintf(e.w, "<NAME>%s</NAME>\n</STMTTRN>\n", ofxName(name))
+
+	return err
+}
+
+func (e *ofxEncoder) Close() error {
+	if !e.started {
+		e.start(e.stmt.GeneratedAt)
+	}
+
+	// LEDGERBAL is required. Accounts have no balance, so it is the net of the exported transactions,
+	// which is the balance only when the statement has every transaction of the account up to DTASOF.
+	fmt.Fprintf(e.w, `</BANKTRANLIST>
+<LEDGERBAL>
+<BALAMT>%s</BALAMT>
+<DTASOF>%s</DTASOF>
+</LEDGERBAL>
+</CCSTMTRS>
+</CCSTMTTRNRS>
+</CREDITCARDMSGSRSV1>
+</OFX>
+`, formatAmount(e.net), e.end().Format(ofxTime))
+
+	return e.w.Flush()
+}
+
+func (e *ofxEncoder) start(first time.Time) {
+	e.started = true
+
+	start := first
+
+	if e.stmt.From != nil {
+		start = *e.stmt.From
+	}
+
+	generated := e.stmt.GeneratedAt.UTC().Format(ofxTime)
+
+	fmt.Fprintf(e.w, `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+<OFX>
+<SIGNONMSGSRSV1>
+<SONRS>
+<STATUS>
+<CODE>0</CODE>
+<SEVERITY>INFO</SEVERITY>
+</STATUS>
+<DTSERVER>%s</DTSERVER>
+<LANGUAGE>ENG</LANGUAGE>
+</SONRS>
+</SIGNONMSGSRSV1>
+<CREDITCARDMSGSRSV1>
+<CCSTMTTRNRS>
+<TRNUID>0</TRNUID>
+<STATUS>
+<CODE>0</CODE>
+<SEVERITY>INFO</SEVERITY>
+</STATUS>
+<CCSTMTRS>
+<CURDEF>%s</CURDEF>
+<CCACCTFROM>
+<ACCTID>%d</ACCTID>
+</CCACCTFROM>
+<BANKTRANLIST>
+<DTSTART>%s</DTSTART>
+<DTEND>%s</DTEND>
+`, generated, e.stmt.Currency, e.stmt.AccountID, start.UTC().Format(ofxTime), e.end().Format(ofxTime))
+}
+
+func (e *ofxEncoder) end() time.Time {
+	if e.stmt.To != nil {
+		return e.stmt.To.UTC()
+	}
+
+	return e.stmt.GeneratedAt.UTC()
+}
+
+// TransactionType maps an operation type to the OFX TRNTYPE of its transactions.
+func TransactionType(op transactions.OperationType) string {
+	switch op {
+	case transactions.OperationTypePurchase:
+		return "POS"
+	case transactions.OperationTypeInstallmentPurchase:
+		return "DEBIT"
+	case transactions.OperationTypeWithdrawal:
+		return "ATM"
+	case transactions.OperationTypePayment:
+		return "CREDIT"
+	case transactions.OperationTypeDisputeReversal:
+		return "DEBIT"
+	default:
+		return "OTHER"
+	}
+}
+
+// ofxName escapes a payee name, OFX limits it to 32 characters.
+// The limit applies to the escaped name, which is cut before the first character or entity that does not fit.
+func ofxName(name string) string {
+	var escaped, buf strings.Builder
+
+	_ = xml.EscapeText(&escaped, []byte(name))
+
+	count := 0
+
+	for rest := escaped.String(); rest != ""; {
+		_, size := utf8.DecodeRuneInString(rest)
+		token := rest[:size]
+
+		if end := strings.IndexByte(rest, ';'); rest[0] == '&' && end > 0 {
+			token = rest[:end+1]
+		}
+
+		count += utf8.RuneCountInString(token)
+
+		if count > ofxNameLength {
+			break
+		}
+
+		buf.WriteString(token)
+		rest = rest[len(token):]
+	}
+
+	return buf.String()
+}
+
+// formatAmount formats a signed amount with two decimals, as it is stored.
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
@@ -0,0 +1,161 @@
+package export_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ziflex/rm-rf-production/internal/export"
+	"github.com/ziflex/rm-rf-production/pkg/transactions"
+)
+
+func TestEncoder_Empty(t *testing.T) {
+	generated := time.Date(2025, time.September, 1, 0, 0, 0, 0, time.UTC)
+
+	type testCase struct {
+		format   export.Format
+		expected string
+	}
+
+	tsdata := []testCase{
+		{export.FormatCSV, "transaction_id,account_id,operation_type_id,operation_type,amount,event_date,merchant_id,merchant_name,merchant_city,merchant_country,mcc\n"},
+		{export.FormatNDJSON, ""},
+		{export.FormatOFX, "<DTSTART>20250901000000.000[0:GMT]</DTSTART>\n<DTEND>20250901000000.000[0:GMT]</DTEND>\n</BANKTRANLIST>"},
+	}
+
+	for _, tc := range tsdata {
+		t.Run(string(tc.format), func(t *testing.T) {
+			var buf bytes.Buffer
+
+			enc, err := export.NewEncoder(&buf, tc.format, export.Statement{AccountID: 1, GeneratedAt: generated})
+			assert.NoError(t, err)
+			assert.NoError(t, enc.Close())
+
+			if tc.expected == "" {
+				assert.Empty(t, buf.String())
+			} else {
+				assert.Contains(t, buf.String(), tc.expected)
+			}
+		})
+	}
+}
+
+func TestEncoder_Merchant(t *testing.T) {
+	date := time.Date(2025, time.August, 30, 12, 0, 0, 0, time.UTC)
+	merchant := &transactions.Merchant{ID: "M-1", Name: "Café & Bar, the finest in all of Lisbon", City: "Lisbon", Country: "PT", MCC: "5814"}
+
+	type testCase struct {
+		format   export.Format
+		expected []string
+	}
+
+	tsdata := []testCase{
+		{export.FormatCSV, []string{
+			"1,1,1,purchase,-4.50,2025-08-30T12:00:00Z,M-1,\"Café & Bar, the finest in all of Lisbon\",Lisbon,PT,5814\n",
+			"2,1,4,payment,60.50,2025-08-30T12:00:00Z,,,,,\n",
+		}},
+		{export.FormatNDJSON, []string{
+			`"merchant_id":"M-1","merchant_name":"Café \u0026 Bar, the finest in all of Lisbon","merchant_city":"Lisbon","merchant_country":"PT","mcc":"5814"}`,
+			`"event_date":"2025-08-30T12:00:00Z"}`,
+		}},
+		{export.FormatOFX, []string{
+			"<FITID>1</FITID>\n<SIC>5814</SIC>\n<NAME>Café &amp; Bar, the finest in al</NAME>\n",
+			"<FITID>2</FITID>\n<NAME>payment</NAME>\n",
+		}},
+	}
+
+	for _, tc := range tsdata {
+		t.Run(string(tc.format), func(t *testing.T) {
+			var buf bytes.Buffer
+
+			enc, err := export.NewEncoder(&buf, tc.format, export.Statement{AccountID: 1, GeneratedAt: date})
+			assert.NoError(t, err)
+			assert.NoError(t, enc.Encode(transactions.Transaction{
+				ID: 1, AccountID: 1, OperationType: transactions.OperationTypePurchase, Amount: -4.5, EventDate: date, Merchant: merchant,
+			}))
+			assert.NoError(t, enc.Encode(transactions.Transaction{
+				ID: 2, AccountID: 1, OperationType: transactions.OperationTypePayment, Amount: 60.5, EventDate: date,
+			}))
+			assert.NoError(t, enc.Close())
+
+			for _, expected := range tc.expected {
+				assert.Contains(t, buf.String(), expected)
+			}
+		})
+	}
+}
+
+func TestEncoder_OFXName(t *testing.T) {
+	date := time.Date(2025, time.August, 30, 12, 0, 0, 0, time.UTC)
+
+	type testCase struct {
+		Name     string
+		Merchant string
+		Expected string
+	}
+
+	tsdata := []testCase{
+		{"Short", "Corner Cafe", "Corner Cafe"},
+		{"Exact", strings.Repeat("a", 27) + "&", strings.Repeat("a", 27) + "&amp;"},
+		{"EntityAtLimit", strings.Repeat("a", 30) + "&b", strings.Repeat("a", 30)},
+		{"Runes", strings.Repeat("é", 40), strings.Repeat("é", 32)},
+	}
+
+	for _, tc := range tsdata {
+		t.Run(tc.Name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			enc, err := export.NewEncoder(&buf, export.FormatOFX, export.Statement{AccountID: 1, GeneratedAt: date})
+			assert.NoError(t, err)
+			assert.NoError(t, enc.Encode(transactions.Transaction{
+				ID: 1, AccountID: 1, OperationType: transactions.OperationTypePurchase, Amount: -4.5, EventDate: date,
+				Merchant: &transactions.Merchant{ID: "M-1", Name: tc.Merchant},
+			}))
+			assert.NoError(t, enc.Close())
+
+			assert.Contains(t, buf.String(), "<NAME>"+tc.Expected+"</NAME>\n")
+		})
+	}
+}
+
+func TestEncoder_Error_UnknownFormat(t *testing.T) {
+	_, err := export.NewEncoder(io.Discard, "xlsx", export.Statement{})
+	assert.Error(t, err)
+}
+
+func TestTransactionType(t *testing.T) {
+	assert.Equal(t, "POS", export.TransactionType(transactions.OperationTypePurchase))
+	assert.Equal(t, "DEBIT", export.TransactionType(transactions.OperationTypeInstallmentPurchase))
+	assert.Equal(t, "ATM", export.TransactionType(transactions.OperationTypeWithdrawal))
+	assert.Equal(t, "CREDIT", export.TransactionType(transactions.OperationTypePayment))
+	assert.Equal(t, "DEBIT", export.TransactionType(transactions.OperationTypeDisputeReversal))
+}
+
+func TestPipe_ClosedReaderStopsExport(t *testing.T) {
+	done := make(chan error, 1)
+
+	body := export.Pipe(export.FormatNDJSON, export.Statement{}, func(fn func(transactions.Transaction) error) error {
+		for i := int64(1); ; i++ {
+			if err := fn(transactions.Transaction{ID: i}); err != nil {
+				done <- err
+
+				return err
+			}
+		}
+	})
+
+	_, err := io.ReadFull(body, make([]byte, 10))
+	assert.NoError(t, err)
+	assert.NoError(t, body.Close())
+
+	select {
+	case err := <-done:
+		assert.True(t, errors.Is(err, io.ErrClosedPipe))
+	case <-time.After(time.Second):
+		t.Fatal("export did not stop")
+	}
+}
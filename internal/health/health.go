@@ -0,0 +1,106 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK      Status = "ok"
+	StatusFailing Status = "failing"
+)
+
+const DefaultTimeout = 2 * time.Second
+
+type (
+	Status string
+
+	// CheckFunc reports whether a dependency is usable.
+	CheckFunc func(ctx context.Context) error
+
+	Check struct {
+		Name string
+		Func CheckFunc
+	}
+
+	CheckResult struct {
+		Status     Status `json:"status"`
+		Error      string `json:"error,omitempty"`
+		DurationMs int64  `json:"duration_ms"`
+	}
+
+	Report struct {
+		Status Status                 `json:"status"`
+		Checks map[string]CheckResult `json:"checks,omitempty"`
+	}
+
+	// Checker runs the readiness checks of the service.
+	Checker struct {
+		checks  []Check
+		timeout time.Duration
+	}
+)
+
+// NewChecker creates a checker that runs every check concurrently, each bounded by the timeout.
+func NewChecker(timeout time.Duration, checks ...Check) *Checker {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	return &Checker{checks, timeout}
+}
+
+// Run executes all the checks. The report is failing when at least one check fails.
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{
+		Status: StatusOK,
+		Checks: make(map[string]CheckResult, len(c.checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, check := range c.checks {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			res := c.run(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			report.Checks[check.Name] = res
+
+			if res.Status != StatusOK {
+				report.Status = StatusFailing
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Func(ctx)
+
+	res := CheckResult{
+		Status:     StatusOK,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+
+	if err != nil {
+		res.Status = StatusFailing
+		res.Error = err.Error()
+	}
+
+	return res
+}
@@ -0,0 +1,58 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ziflex/rm-rf-production/internal/health"
+)
+
+func TestChecker_Run(t *testing.T) {
+	t.Run("should report ok when all checks pass", func(t *testing.T) {
+		checker := health.NewChecker(time.Second,
+			health.Check{Name: "a", Func: func(context.Context) error { return nil }},
+			health.Check{Name: "b", Func: func(context.Context) error { return nil }},
+		)
+
+		report := checker.Run(context.Background())
+
+		assert.Equal(t, health.StatusOK, report.Status)
+		assert.Len(t, report.Checks, 2)
+		assert.Equal(t, health.StatusOK, report.Checks["a"].Status)
+		assert.Equal(t, health.StatusOK, report.Checks["b"].Status)
+	})
+
+	t.Run("should report failing when any check fails", func(t *testing.T) {
+		checker := health.NewChecker(time.Second,
+			health.Check{Name: "a", Func: func(context.Context) error { return nil }},
+			health.Check{Name: "b", Func: func(context.Context) error { return errors.New("boom") }},
+		)
+
+		report := checker.Run(context.Background())
+
+		assert.Equal(t, health.StatusFailing, report.Status)
+		assert.Equal(t, health.StatusOK, report.Checks["a"].Status)
+		assert.Equal(t, health.StatusFailing, report.Checks["b"].Status)
+		assert.Equal(t, "boom", report.Checks["b"].Error)
+	})
+
+	t.Run("should bound every check by the timeout", func(t *testing.T) {
+		checker := health.NewChecker(50*time.Millisecond,
+			health.Check{Name: "slow", Func: func(ctx context.Context) error {
+				<-ctx.Done()
+
+				return ctx.Err()
+			}},
+		)
+
+		start := time.Now()
+		report := checker.Run(context.Background())
+
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, health.StatusFailing, report.Status)
+		assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["slow"].Error)
+	})
+}
@@ -0,0 +1,185 @@
+package memory
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/accounts"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+)
+
+type AccountsRepository struct {
+	store *Store
+}
+
+func NewAccountsRepository(store *Store) accounts.Repository {
+	return &AccountsRepository{store}
+}
+
+func (r *AccountsRepository) CreateAccount(ctx dbx.Context, acc accounts.AccountCreation) (accounts.Account, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return accounts.Account{}, err
+	}
+
+	var created accounts.Account
+
+	err = r.store.run(ctx, func() error {
+		if _, exists := r.store.tenants[tenantID]; !exists {
+			return fmt.Errorf("tenant %w: %s", errForeignKey, tenantID)
+		}
+
+		key := documentKey{tenantID, acc.DocumentNumber}
+
+		if _, exists := r.store.documents[key]; exists {
+			return fmt.Errorf("document number %w: %s", common.ErrDuplicate, acc.DocumentNumber)
+		}
+
+		id := r.store.nextID("accounts")
+
+		r.store.accounts[id] = &account{tenantID: tenantID, id: id, documentNumber: acc.DocumentNumber, version: 1}
+		r.store.documents[key] = id
+		r.store.onRollback(func() {
+			delete(r.store.accounts, id)
+			delete(r.store.documents, key)
+		})
+
+		created = accounts.Account{ID: id, DocumentNumber: acc.DocumentNumber, Version: 1}
+
+		return nil
+	})
+
+	return created, err
+}
+
+func (r *AccountsRepository) GetAccountByID(ctx dbx.Context, id int64) (accounts.Account, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return accounts.Account{}, err
+	}
+
+	var found accounts.Account
+
+	err = r.store.run(ctx, func() error {
+		acc, ok := r.store.account(tenantID, id)
+
+		if !ok {
+			return fmt.Errorf("account %w: %d", common.ErrNotFound, id)
+		}
+
+		found = acc.model()
+
+		return nil
+	})
+
+	return found, err
+}
+
+func (r *AccountsRepository) SetAccountBlocked(ctx dbx.Context, id int64, blocked bool) error {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	return r.store.run(ctx, func() error {
+		acc, ok := r.store.account(tenantID, id)
+
+		if !ok {
+			return fmt.Errorf("account %w: %d", common.ErrNotFound, id)
+		}
+
+		acc.setBlocked(r.store, blocked)
+
+		return nil
+	})
+}
+
+func (r *AccountsRepository) UpdateAccount(
+	ctx dbx.Context,
+	id int64,
+	update accounts.AccountUpdate,
+	versions []int64,
+) (accounts.Account, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return accounts.Account{}, err
+	}
+
+	var updated accounts.Account
+
+	err = r.store.run(ctx, func() error {
+		acc, ok := r.store.account(tenantID, id)
+
+		if !ok {
+			return fmt.Errorf("account %w: %d", common.ErrNotFound, id)
+		}
+
+		if versions != nil && !slices.Contains(versions, acc.version) {
+			return fmt.Errorf("account %w: %d", accounts.ErrVersionMismatch, id)
+		}
+
+		if update.Blocked != nil {
+			acc.setBlocked(r.store, *update.Blocked)
+		} else {
+			acc.bump(r.store)
+		}
+
+		updated = acc.model()
+
+		return nil
+	})
+
+	return updated, err
+}
+
+// setBlocked changes the block and the version, blocking an already blocked account keeps the original time.
+func (acc *account) setBlocked(s *Store, blocked bool) {
+	prev := acc.blockedAt
+
+	switch {
+	case !blocked:
+		acc.blockedAt = nil
+	case acc.blockedAt == nil:
+		now := s.now()
+		acc.blockedAt = &now
+	}
+
+	s.onRollback(func() {
+		acc.blockedAt = prev
+	})
+
+	acc.bump(s)
+}
+
+func (acc *account) bump(s *Store) {
+	acc.version++
+
+	s.onRollback(func() {
+		acc.version--
+	})
+}
+
+func (acc *account) model() accounts.Account {
+	return accounts.Account{
+		ID:             acc.id,
+		DocumentNumber: acc.documentNumber,
+		Blocked:        acc.blockedAt != nil,
+		Version:        acc.version,
+	}
+}
+
+// account returns the account of the tenant, accounts of other tenants do not exist for it.
+func (s *Store) account(tenantID string, id int64) (*account, bool) {
+	acc, ok := s.accounts[id]
+
+	if !ok || acc.tenantID != tenantID {
+		return nil, false
+	}
+
+	return acc, true
+}
@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/auth"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+)
+
+type APIKeysRepository struct {
+	store *Store
+}
+
+func NewAPIKeysRepository(store *Store) auth.Repository {
+	return &APIKeysRepository{store}
+}
+
+func (r *APIKeysRepository) CreateAPIKey(ctx dbx.Context, key auth.APIKeyCreation, hash string) (auth.APIKey, error) {
+	var created auth.APIKey
+
+	err := r.store.run(ctx, func() error {
+		if _, exists := r.store.keyHashes[hash]; exists {
+			return fmt.Errorf("api key %w", common.ErrDuplicate)
+		}
+
+		if _, exists := r.store.tenants[key.TenantID]; !exists {
+			return fmt.Errorf("tenant %w: %s", common.ErrNotFound, key.TenantID)
+		}
+
+		created = auth.APIKey{
+			ID:        r.store.nextID("api_keys"),
+			TenantID:  key.TenantID,
+			Name:      key.Name,
+			Scopes:    slices.Clone(key.Scopes),
+			CreatedAt: r.store.now(),
+		}
+
+		r.store.apiKeys[created.ID] = &apiKey{created, hash}
+		r.store.keyHashes[hash] = created.ID
+		r.store.onRollback(func() {
+			delete(r.store.apiKeys, created.ID)
+			delete(r.store.keyHashes, hash)
+		})
+
+		return nil
+	})
+
+	return created, err
+}
+
+func (r *APIKeysRepository) GetAPIKeyByHash(ctx dbx.Context, hash string) (auth.APIKey, error) {
+	var found auth.APIKey
+
+	err := r.store.run(ctx, func() error {
+		id, ok := r.store.keyHashes[hash]
+
+		if !ok {
+			return fmt.Errorf("api key %w", common.ErrNotFound)
+		}
+
+		found = r.store.apiKeys[id].APIKey
+		found.Scopes = slices.Clone(found.Scopes)
+
+		return nil
+	})
+
+	return found, err
+}
+
+func (r *APIKeysRepository) RevokeAPIKey(ctx dbx.Context, id int64) error {
+	return r.store.run(ctx, func() error {
+		key, ok := r.store.apiKeys[id]
+
+		if !ok || key.RevokedAt != nil {
+			return fmt.Errorf("api key %w: %d", common.ErrNotFound, id)
+		}
+
+		now := r.store.now()
+		key.RevokedAt = &now
+		r.store.onRollback(func() {
+			key.RevokedAt = nil
+		})
+
+		return nil
+	})
+}
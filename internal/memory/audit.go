@@ -0,0 +1,94 @@
+package memory
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/audit"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+)
+
+type AuditRepository struct {
+	store *Store
+}
+
+func NewAuditRepository(store *Store) audit.Repository {
+	return &AuditRepository{store}
+}
+
+func (r *AuditRepository) CreateEntry(ctx dbx.Context, entry audit.EntryCreation) (audit.Entry, error) {
+	var created audit.Entry
+
+	err := r.store.run(ctx, func() error {
+		if _, exists := r.store.tenants[entry.TenantID]; entry.TenantID != "" && !exists {
+			return fmt.Errorf("tenant %w: %s", errForeignKey, entry.TenantID)
+		}
+
+		created = audit.Entry{
+			ID:          r.store.nextID("audit_log"),
+			TenantID:    entry.TenantID,
+			Principal:   entry.Principal,
+			RequestID:   entry.RequestID,
+			OperationID: entry.OperationID,
+			Payload:     slices.Clone(entry.Payload),
+			Outcome:     entry.Outcome,
+			Status:      entry.Status,
+			CreatedAt:   r.store.now(),
+		}
+
+		n := len(r.store.audit)
+		r.store.audit = append(r.store.audit, created)
+		r.store.onRollback(func() {
+			r.store.audit = r.store.audit[:n]
+		})
+
+		return nil
+	})
+
+	return created, err
+}
+
+func (r *AuditRepository) ListEntries(ctx dbx.Context, filter audit.Filter) ([]audit.Entry, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]audit.Entry, 0, filter.Limit)
+
+	err = r.store.run(ctx, func() error {
+		// entries are appended in id order
+		for i := len(r.store.audit) - 1; i >= 0 && len(entries) < filter.Limit; i-- {
+			entry := r.store.audit[i]
+
+			if entry.TenantID == tenantID && matches(entry, filter) {
+				entries = append(entries, entry)
+			}
+		}
+
+		return nil
+	})
+
+	return entries, err
+}
+
+func matches(entry audit.Entry, filter audit.Filter) bool {
+	switch {
+	case filter.Principal != "" && entry.Principal != filter.Principal:
+		return false
+	case filter.OperationID != "" && entry.OperationID != filter.OperationID:
+		return false
+	case filter.Outcome != "" && entry.Outcome != filter.Outcome:
+		return false
+	case filter.From != nil && entry.CreatedAt.Before(*filter.From):
+		return false
+	case filter.To != nil && !entry.CreatedAt.Before(*filter.To):
+		return false
+	case filter.BeforeID > 0 && entry.ID >= filter.BeforeID:
+		return false
+	default:
+		return true
+	}
+}
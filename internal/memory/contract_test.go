@@ -0,0 +1,25 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/ziflex/rm-rf-production/internal/memory"
+	"github.com/ziflex/rm-rf-production/internal/repotest"
+)
+
+func TestRepositoryContract(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Fixture {
+		store := memory.NewStore()
+
+		return repotest.Fixture{
+			DB:              store.DB(),
+			Tenants:         memory.NewTenantsRepository(store),
+			Accounts:        memory.NewAccountsRepository(store),
+			Transactions:    memory.NewTransactionsRepository(store),
+			Reconciliations: memory.NewReconciliationsRepository(store),
+			Scheduler:       memory.NewSchedulerRepository(store),
+			Jobs:            memory.NewJobsRepository(store),
+			Disputes:        memory.NewDisputesRepository(store),
+		}
+	})
+}
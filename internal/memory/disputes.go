@@ -0,0 +1,297 @@
+package memory
+
+import (
+	"fmt"
+
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+	"github.com/ziflex/rm-rf-production/pkg/disputes"
+	"github.com/ziflex/rm-rf-production/pkg/transactions"
+)
+
+type DisputesRepository struct {
+	store *Store
+}
+
+func NewDisputesRepository(store *Store) disputes.Repository {
+	return &DisputesRepository{store}
+}
+
+func (r *DisputesRepository) GetTransaction(ctx dbx.Context, accountID, transactionID int64) (transactions.Transaction, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return transactions.Transaction{}, err
+	}
+
+	var found transactions.Transaction
+
+	err = r.store.run(ctx, func() error {
+		for _, tr := range r.store.transactions {
+			if tr.tenantID == tenantID && tr.AccountID == accountID && tr.ID == transactionID {
+				found = tr.Transaction
+
+				return nil
+			}
+		}
+
+		return fmt.Errorf("transaction %w: %d", common.ErrNotFound, transactionID)
+	})
+
+	if err != nil {
+		return transactions.Transaction{}, err
+	}
+
+	return found, nil
+}
+
+func (r *DisputesRepository) IsDisputeTransaction(ctx dbx.Context, transactionID int64) (bool, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	var found bool
+
+	err = r.store.run(ctx, func() error {
+		for _, d := range r.store.disputes {
+			if d.tenantID == tenantID && (d.CreditTransactionID == transactionID || d.ReversalTransactionID == transactionID) {
+				found = true
+
+				return nil
+			}
+		}
+
+		return nil
+	})
+
+	return found, err
+}
+
+func (r *DisputesRepository) CreateDispute(ctx dbx.Context, creation disputes.DisputeCreation) (disputes.Dispute, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return disputes.Dispute{}, err
+	}
+
+	var created disputes.Dispute
+
+	err = r.store.run(ctx, func() error {
+		if _, ok := r.store.account(tenantID, creation.AccountID); !ok {
+			return fmt.Errorf("account %w: %d", errForeignKey, creation.AccountID)
+		}
+
+		for _, d := range r.store.disputes {
+			if d.tenantID == tenantID && d.TransactionID == creation.TransactionID {
+				return fmt.Errorf("dispute of transaction %w: %d", common.ErrDuplicate, creation.TransactionID)
+			}
+		}
+
+		now := r.store.now()
+		created = disputes.Dispute{
+			ID:                  r.store.nextID("disputes"),
+			AccountID:           creation.AccountID,
+			TransactionID:       creation.TransactionID,
+			CreditTransactionID: creation.CreditTransactionID,
+			Status:              disputes.StatusOpened,
+			Reason:              creation.Reason,
+			Amount:              roundCents(creation.Amount),
+			CreatedAt:           now,
+			UpdatedAt:           now,
+		}
+
+		n := len(r.store.disputes)
+		r.store.disputes = append(r.store.disputes, dispute{tenantID, created})
+		r.store.onRollback(func() {
+			r.store.disputes = r.store.disputes[:n]
+		})
+
+		return nil
+	})
+
+	if err != nil {
+		return disputes.Dispute{}, err
+	}
+
+	return created, nil
+}
+
+func (r *DisputesRepository) GetDispute(ctx dbx.Context, id int64) (disputes.Dispute, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return disputes.Dispute{}, err
+	}
+
+	var found disputes.Dispute
+
+	err = r.store.run(ctx, func() error {
+		d, ok := r.store.dispute(tenantID, id)
+
+		if !ok {
+			return fmt.Errorf("dispute %w: %d", common.ErrNotFound, id)
+		}
+
+		found = d.Dispute
+		found.Events = r.store.disputeEvents(id)
+
+		return nil
+	})
+
+	if err != nil {
+		return disputes.Dispute{}, err
+	}
+
+	return found, nil
+}
+
+func (r *DisputesRepository) ResolveDispute(ctx dbx.Context, id int64, from disputes.Status, resolution disputes.Resolution) (disputes.Dispute, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return disputes.Dispute{}, err
+	}
+
+	if !resolution.Status.IsValid() {
+		return disputes.Dispute{}, fmt.Errorf("status %w: %s", errInvalidEnum, resolution.Status)
+	}
+
+	var updated disputes.Dispute
+
+	err = r.store.run(ctx, func() error {
+		d, ok := r.store.dispute(tenantID, id)
+
+		if !ok || d.Status != from {
+			return fmt.Errorf("%w: dispute %d is not %s", disputes.ErrInvalidTransition, id, from)
+		}
+
+		prev := d.Dispute
+		now := r.store.now()
+
+		d.Status = resolution.Status
+		d.ReversalTransactionID = resolution.ReversalTransactionID
+		d.UpdatedAt = now
+		d.ResolvedAt = nil
+
+		if resolution.Status.IsResolved() {
+			d.ResolvedAt = &now
+		}
+
+		updated = d.Dispute
+		// the slice may grow before a rollback, the dispute is looked up again
+		r.store.onRollback(func() {
+			if d, ok := r.store.dispute(tenantID, id); ok {
+				d.Dispute = prev
+			}
+		})
+
+		return nil
+	})
+
+	if err != nil {
+		return disputes.Dispute{}, err
+	}
+
+	return updated, nil
+}
+
+func (r *DisputesRepository) AddEvent(ctx dbx.Context, creation disputes.EventCreation) (disputes.Event, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return disputes.Event{}, err
+	}
+
+	var created disputes.Event
+
+	err = r.store.run(ctx, func() error {
+		if _, ok := r.store.dispute(tenantID, creation.DisputeID); !ok {
+			return fmt.Errorf("dispute %w: %d", errForeignKey, creation.DisputeID)
+		}
+
+		created = disputes.Event{
+			ID:        r.store.nextID("dispute_events"),
+			Status:    creation.Status,
+			Principal: creation.Principal,
+			Note:      creation.Note,
+			CreatedAt: r.store.now(),
+		}
+
+		n := len(r.store.events)
+		r.store.events = append(r.store.events, disputeEvent{creation.DisputeID, created})
+		r.store.onRollback(func() {
+			r.store.events = r.store.events[:n]
+		})
+
+		return nil
+	})
+
+	if err != nil {
+		return disputes.Event{}, err
+	}
+
+	return created, nil
+}
+
+func (r *DisputesRepository) ListDisputes(ctx dbx.Context, filter disputes.Filter) ([]disputes.Dispute, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	found := make([]disputes.Dispute, 0)
+
+	err = r.store.run(ctx, func() error {
+		// disputes are appended in id order, the newest are listed first
+		for i := len(r.store.disputes) - 1; i >= 0; i-- {
+			d := r.store.disputes[i]
+
+			if d.tenantID != tenantID || d.AccountID != filter.AccountID {
+				continue
+			}
+
+			if filter.Status != "" && d.Status != filter.Status {
+				continue
+			}
+
+			res := d.Dispute
+			res.Events = r.store.disputeEvents(d.ID)
+			found = append(found, res)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// dispute returns the stored dispute to update it in place, it must be called with the store held.
+func (s *Store) dispute(tenantID string, id int64) (*dispute, bool) {
+	for i := range s.disputes {
+		if d := &s.disputes[i]; d.tenantID == tenantID && d.ID == id {
+			return d, true
+		}
+	}
+
+	return nil, false
+}
+
+// disputeEvents returns a copy of the events of a dispute, oldest first.
+func (s *Store) disputeEvents(id int64) []disputes.Event {
+	var res []disputes.Event
+
+	for _, e := range s.events {
+		if e.disputeID == id {
+			res = append(res, e.Event)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,269 @@
+package memory
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+	"github.com/ziflex/rm-rf-production/pkg/queue"
+)
+
+type JobsRepository struct {
+	store *Store
+}
+
+func NewJobsRepository(store *Store) queue.Repository {
+	return &JobsRepository{store}
+}
+
+func (r *JobsRepository) CreateJob(ctx dbx.Context, creation queue.JobCreation) (queue.Job, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return queue.Job{}, err
+	}
+
+	var created queue.Job
+
+	err = r.store.run(ctx, func() error {
+		if _, exists := r.store.tenants[tenantID]; !exists {
+			return fmt.Errorf("tenant %w: %s", errForeignKey, tenantID)
+		}
+
+		now := r.store.now()
+		created = queue.Job{
+			ID:          r.store.nextID("jobs"),
+			TenantID:    tenantID,
+			Type:        creation.Type,
+			Payload:     slices.Clone(creation.Payload),
+			Status:      queue.StatusQueued,
+			Priority:    creation.Priority,
+			MaxAttempts: creation.MaxAttempts,
+			RunAt:       creation.RunAt.UTC(),
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+
+		n := len(r.store.jobs)
+		r.store.jobs = append(r.store.jobs, created)
+		r.store.onRollback(func() {
+			r.store.jobs = r.store.jobs[:n]
+		})
+
+		return nil
+	})
+
+	return created, err
+}
+
+func (r *JobsRepository) GetJob(ctx dbx.Context, id int64) (queue.Job, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return queue.Job{}, err
+	}
+
+	var found queue.Job
+
+	err = r.store.run(ctx, func() error {
+		i := r.find(id)
+
+		if i < 0 || r.store.jobs[i].TenantID != tenantID {
+			return fmt.Errorf("job %w: %d", common.ErrNotFound, id)
+		}
+
+		found = r.store.jobs[i]
+
+		return nil
+	})
+
+	return found, err
+}
+
+func (r *JobsRepository) ClaimJob(ctx dbx.Context, claim queue.Claim) (queue.Job, bool, error) {
+	var claimed queue.Job
+	var ok bool
+
+	err := r.store.run(ctx, func() error {
+		next := -1
+
+		for i, job := range r.store.jobs {
+			if !slices.Contains(claim.Types, job.Type) || !due(job, claim.Now) {
+				continue
+			}
+
+			if next < 0 || before(job, r.store.jobs[next]) {
+				next = i
+			}
+		}
+
+		if next < 0 {
+			return nil
+		}
+
+		prev := r.store.jobs[next]
+		until := claim.LockedUntil.UTC()
+		job := &r.store.jobs[next]
+		job.Status = queue.StatusRunning
+		job.Attempts++
+		job.LockedBy = claim.Worker
+		job.LockedUntil = &until
+		job.UpdatedAt = claim.Now.UTC()
+		r.store.onRollback(func() {
+			r.store.jobs[next] = prev
+		})
+
+		claimed, ok = *job, true
+
+		return nil
+	})
+
+	return claimed, ok, err
+}
+
+func (r *JobsRepository) ExtendJob(ctx dbx.Context, id int64, lock queue.Lock, until time.Time) error {
+	return r.store.run(ctx, func() error {
+		i := r.find(id)
+
+		if i < 0 || !holds(r.store.jobs[i], lock) {
+			return fmt.Errorf("%w: %d", queue.ErrLockLost, id)
+		}
+
+		prev := r.store.jobs[i]
+		until = until.UTC()
+		r.store.jobs[i].LockedUntil = &until
+		r.store.onRollback(func() {
+			r.store.jobs[i] = prev
+		})
+
+		return nil
+	})
+}
+
+func (r *JobsRepository) CompleteJob(ctx dbx.Context, id int64, lock queue.Lock, completion queue.Completion) (queue.Job, error) {
+	var completed queue.Job
+
+	err := r.store.run(ctx, func() error {
+		i := r.find(id)
+
+		if i < 0 || !holds(r.store.jobs[i], lock) {
+			return fmt.Errorf("%w: %d", queue.ErrLockLost, id)
+		}
+
+		prev := r.store.jobs[i]
+		now := completion.Now.UTC()
+		job := &r.store.jobs[i]
+		job.Status = completion.Status
+		job.Result = slices.Clone(completion.Result)
+
+		// the error of the last failed attempt is kept
+		if completion.Error != "" {
+			job.LastError = completion.Error
+		}
+
+		job.LockedBy = ""
+		job.LockedUntil = nil
+		job.UpdatedAt = now
+		job.FinishedAt = nil
+
+		if completion.Status == queue.StatusQueued {
+			job.RunAt = completion.RunAt.UTC()
+		} else {
+			job.FinishedAt = &now
+		}
+
+		r.store.onRollback(func() {
+			r.store.jobs[i] = prev
+		})
+
+		completed = *job
+
+		return nil
+	})
+
+	return completed, err
+}
+
+func (r *JobsRepository) RetryJob(ctx dbx.Context, id int64, now time.Time) (queue.Job, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return queue.Job{}, err
+	}
+
+	var retried queue.Job
+
+	err = r.store.run(ctx, func() error {
+		i := r.find(id)
+
+		if i < 0 || r.store.jobs[i].TenantID != tenantID {
+			return fmt.Errorf("job %w: %d", common.ErrNotFound, id)
+		}
+
+		if r.store.jobs[i].Status != queue.StatusDead {
+			return fmt.Errorf("%w: %d", queue.ErrNotDead, id)
+		}
+
+		prev := r.store.jobs[i]
+		job := &r.store.jobs[i]
+		job.Status = queue.StatusQueued
+		job.Attempts = 0
+		job.RunAt = now.UTC()
+		job.UpdatedAt = now.UTC()
+		job.FinishedAt = nil
+		r.store.onRollback(func() {
+			r.store.jobs[i] = prev
+		})
+
+		retried = *job
+
+		return nil
+	})
+
+	return retried, err
+}
+
+// find returns the index of a job, jobs are appended in id order.
+func (r *JobsRepository) find(id int64) int {
+	i, found := slices.BinarySearchFunc(r.store.jobs, id, func(job queue.Job, id int64) int {
+		return cmp.Compare(job.ID, id)
+	})
+
+	if !found {
+		return -1
+	}
+
+	return i
+}
+
+// due reports whether a job can be claimed: queued and due, or running with an expired lock.
+func due(job queue.Job, now time.Time) bool {
+	switch job.Status {
+	case queue.StatusQueued:
+		return !job.RunAt.After(now)
+	case queue.StatusRunning:
+		return job.LockedUntil != nil && !job.LockedUntil.After(now)
+	default:
+		return false
+	}
+}
+
+// before orders the due jobs by priority, then by due time.
+func before(a, b queue.Job) bool {
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+
+	if !a.RunAt.Equal(b.RunAt) {
+		return a.RunAt.Before(b.RunAt)
+	}
+
+	return a.ID < b.ID
+}
+
+func holds(job queue.Job, lock queue.Lock) bool {
+	return job.Status == queue.StatusRunning && job.LockedBy == lock.Worker && job.Attempts == lock.Attempt
+}
@@ -0,0 +1,143 @@
+package memory
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+	"github.com/ziflex/rm-rf-production/pkg/reconciliation"
+	"github.com/ziflex/rm-rf-production/pkg/transactions"
+)
+
+type ReconciliationsRepository struct {
+	store *Store
+}
+
+func NewReconciliationsRepository(store *Store) reconciliation.Repository {
+	return &ReconciliationsRepository{store}
+}
+
+func (r *ReconciliationsRepository) CreateReconciliation(ctx dbx.Context, creation reconciliation.ReconciliationCreation) (reconciliation.Reconciliation, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return reconciliation.Reconciliation{}, err
+	}
+
+	var created reconciliation.Reconciliation
+
+	err = r.store.run(ctx, func() error {
+		if _, exists := r.store.tenants[tenantID]; !exists {
+			return fmt.Errorf("tenant %w: %s", errForeignKey, tenantID)
+		}
+
+		created = reconciliation.Reconciliation{
+			ID:          r.store.nextID("reconciliations"),
+			FileName:    creation.FileName,
+			PeriodStart: creation.PeriodStart.UTC(),
+			PeriodEnd:   creation.PeriodEnd.UTC(),
+			Summary:     creation.Summary,
+			Items:       cloneItems(creation.Items),
+			CreatedAt:   r.store.now(),
+		}
+
+		id := created.ID
+		r.store.reconciled[id] = &reconciliationRun{tenantID, created}
+		r.store.onRollback(func() {
+			delete(r.store.reconciled, id)
+		})
+
+		return nil
+	})
+
+	if err != nil {
+		return reconciliation.Reconciliation{}, err
+	}
+
+	return created, nil
+}
+
+func (r *ReconciliationsRepository) GetReconciliation(ctx dbx.Context, id int64, filter reconciliation.Filter) (reconciliation.Reconciliation, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return reconciliation.Reconciliation{}, err
+	}
+
+	var found reconciliation.Reconciliation
+
+	err = r.store.run(ctx, func() error {
+		run, exists := r.store.reconciled[id]
+
+		if !exists || run.tenantID != tenantID {
+			return fmt.Errorf("reconciliation %w: %d", common.ErrNotFound, id)
+		}
+
+		found = run.Reconciliation
+		found.Items = make([]reconciliation.Item, 0, len(run.Items))
+
+		for _, item := range cloneItems(run.Items) {
+			if filter.Category == "" || item.Category == filter.Category {
+				found.Items = append(found.Items, item)
+			}
+		}
+
+		return nil
+	})
+
+	return found, err
+}
+
+func (r *ReconciliationsRepository) ListLedger(ctx dbx.Context, from, to time.Time, fn func(transactions.Transaction) error) error {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	var found []transactions.Transaction
+
+	err = r.store.run(ctx, func() error {
+		// transactions are appended in id order
+		for _, tr := range r.store.transactions {
+			if tr.tenantID == tenantID && tr.OperationType.IsSettled() && !tr.EventDate.Before(from) && tr.EventDate.Before(to) {
+				found = append(found, tr.Transaction)
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, tr := range found {
+		if err := fn(tr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cloneItems copies the items, so that the stored ones are not shared with the callers.
+func cloneItems(items []reconciliation.Item) []reconciliation.Item {
+	res := slices.Clone(items)
+
+	for i := range res {
+		if res[i].Line != nil {
+			line := *res[i].Line
+			res[i].Line = &line
+		}
+
+		if res[i].Transaction != nil {
+			tr := *res[i].Transaction
+			res[i].Transaction = &tr
+		}
+	}
+
+	return res
+}
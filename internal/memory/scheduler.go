@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"fmt"
+
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+	"github.com/ziflex/rm-rf-production/pkg/scheduler"
+)
+
+type SchedulerRepository struct {
+	store *Store
+}
+
+func NewSchedulerRepository(store *Store) scheduler.Repository {
+	return &SchedulerRepository{store}
+}
+
+func (r *SchedulerRepository) CreateRun(ctx dbx.Context, creation scheduler.RunCreation) (scheduler.Run, error) {
+	var created scheduler.Run
+
+	err := r.store.run(ctx, func() error {
+		slot := runSlot{creation.Job, creation.ScheduledAt.UTC()}
+
+		if _, exists := r.store.runSlots[slot]; exists {
+			return fmt.Errorf("run of %s %w: %s", creation.Job, common.ErrDuplicate, slot.scheduledAt)
+		}
+
+		created = scheduler.Run{
+			ID:          r.store.nextID("scheduler_runs"),
+			Job:         creation.Job,
+			Instance:    creation.Instance,
+			Status:      scheduler.RunStatusRunning,
+			ScheduledAt: slot.scheduledAt,
+			StartedAt:   r.store.now(),
+		}
+
+		n := len(r.store.jobRuns)
+		r.store.jobRuns = append(r.store.jobRuns, created)
+		r.store.runSlots[slot] = created.ID
+		r.store.onRollback(func() {
+			r.store.jobRuns = r.store.jobRuns[:n]
+			delete(r.store.runSlots, slot)
+		})
+
+		return nil
+	})
+
+	return created, err
+}
+
+func (r *SchedulerRepository) FinishRun(ctx dbx.Context, id int64, completion scheduler.RunCompletion) (scheduler.Run, error) {
+	var finished scheduler.Run
+
+	err := r.store.run(ctx, func() error {
+		for i := len(r.store.jobRuns) - 1; i >= 0; i-- {
+			if r.store.jobRuns[i].ID == id {
+				prev := r.store.jobRuns[i]
+				r.finish(i, completion)
+				finished = r.store.jobRuns[i]
+				r.store.onRollback(func() {
+					r.store.jobRuns[i] = prev
+				})
+
+				return nil
+			}
+		}
+
+		return fmt.Errorf("run %w: %d", common.ErrNotFound, id)
+	})
+
+	return finished, err
+}
+
+func (r *SchedulerRepository) InterruptRuns(ctx dbx.Context, job string, reason string) (int64, error) {
+	var count int64
+
+	err := r.store.run(ctx, func() error {
+		for i, run := range r.store.jobRuns {
+			if run.Job != job || run.Status != scheduler.RunStatusRunning {
+				continue
+			}
+
+			r.finish(i, scheduler.RunCompletion{Status: scheduler.RunStatusFailed, Error: reason})
+			r.store.onRollback(func() {
+				r.store.jobRuns[i] = run
+			})
+
+			count++
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+func (r *SchedulerRepository) ListRuns(ctx dbx.Context, filter scheduler.RunFilter) ([]scheduler.Run, error) {
+	runs := make([]scheduler.Run, 0, filter.Limit)
+
+	err := r.store.run(ctx, func() error {
+		// runs are appended in id order
+		for i := len(r.store.jobRuns) - 1; i >= 0 && len(runs) < filter.Limit; i-- {
+			run := r.store.jobRuns[i]
+
+			if (filter.Job == "" || run.Job == filter.Job) && (filter.Status == "" || run.Status == filter.Status) {
+				runs = append(runs, run)
+			}
+		}
+
+		return nil
+	})
+
+	return runs, err
+}
+
+func (r *SchedulerRepository) finish(i int, completion scheduler.RunCompletion) {
+	now := r.store.now()
+	run := &r.store.jobRuns[i]
+	run.Status = completion.Status
+	run.Result = completion.Result
+	run.Error = completion.Error
+	run.FinishedAt = &now
+}
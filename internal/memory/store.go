@@ -0,0 +1,275 @@
+// Package memory implements the repositories on top of process memory.
+// It is meant for local development and tests, nothing survives a restart.
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/audit"
+	"github.com/ziflex/rm-rf-production/pkg/auth"
+	"github.com/ziflex/rm-rf-production/pkg/disputes"
+	"github.com/ziflex/rm-rf-production/pkg/queue"
+	"github.com/ziflex/rm-rf-production/pkg/reconciliation"
+	"github.com/ziflex/rm-rf-production/pkg/scheduler"
+	"github.com/ziflex/rm-rf-production/pkg/tenants"
+	"github.com/ziflex/rm-rf-production/pkg/transactions"
+)
+
+// DefaultTenant mirrors the tenant created by the migrations.
+const DefaultTenant = "default"
+
+var errNoSQL = errors.New("memory database does not execute sql")
+
+type (
+	// Store holds the tables shared by the repositories.
+	// Transactions are serialized: a transaction holds the store until it commits or rolls back,
+	// statements outside of a transaction hold it for their own duration.
+	Store struct {
+		db    *sql.DB
+		sem   chan struct{}
+		mu    sync.Mutex
+		data  sync.Mutex
+		undo  []func()
+		clock func() time.Time
+
+		tenants      map[string]tenants.Tenant
+		accounts     map[int64]*account
+		documents    map[documentKey]int64
+		transactions []transaction
+		externalRefs map[refKey]int64
+		merchants    map[merchantKey]transactions.Merchant
+		apiKeys      map[int64]*apiKey
+		keyHashes    map[string]int64
+		audit        []audit.Entry
+		reconciled   map[int64]*reconciliationRun
+		jobRuns      []scheduler.Run
+		runSlots     map[runSlot]int64
+		jobs         []queue.Job
+		disputes     []dispute
+		events       []disputeEvent
+		seq          map[string]int64
+	}
+
+	account struct {
+		tenantID       string
+		id             int64
+		documentNumber string
+		blockedAt      *time.Time
+		version        int64
+	}
+
+	documentKey struct {
+		tenantID       string
+		documentNumber string
+	}
+
+	refKey struct {
+		tenantID    string
+		externalRef string
+	}
+
+	// transaction references its merchant like the merchant_id column, so that the details stay normalized.
+	transaction struct {
+		tenantID string
+		transactions.Transaction
+		merchantID string
+	}
+
+	merchantKey struct {
+		tenantID string
+		id       string
+	}
+
+	reconciliationRun struct {
+		tenantID string
+		reconciliation.Reconciliation
+	}
+
+	dispute struct {
+		tenantID string
+		disputes.Dispute
+	}
+
+	disputeEvent struct {
+		disputeID int64
+		disputes.Event
+	}
+
+	runSlot struct {
+		job         string
+		scheduledAt time.Time
+	}
+
+	apiKey struct {
+		auth.APIKey
+		hash string
+	}
+
+	connector struct {
+		store *Store
+	}
+
+	conn struct {
+		store *Store
+	}
+
+	tx struct {
+		store *Store
+	}
+)
+
+// NewStore returns an empty store with the default tenant.
+func NewStore() *Store {
+	s := &Store{
+		sem:          make(chan struct{}, 1),
+		clock:        time.Now,
+		tenants:      make(map[string]tenants.Tenant),
+		accounts:     make(map[int64]*account),
+		documents:    make(map[documentKey]int64),
+		externalRefs: make(map[refKey]int64),
+		merchants:    make(map[merchantKey]transactions.Merchant),
+		reconciled:   make(map[int64]*reconciliationRun),
+		runSlots:     make(map[runSlot]int64),
+		apiKeys:      make(map[int64]*apiKey),
+		keyHashes:    make(map[string]int64),
+		seq:          make(map[string]int64),
+	}
+
+	s.tenants[DefaultTenant] = tenants.Tenant{ID: DefaultTenant, Name: "Default", CreatedAt: s.now()}
+	s.db = sql.OpenDB(&connector{s})
+
+	return s
+}
+
+// DB returns the fake database the services run their transactions on.
+// It supports transactions and ping only, any SQL statement fails.
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+// run executes fn with exclusive access to the tables.
+// Inside a transaction the access is already granted and the changes made by fn are undone on rollback.
+func (s *Store) run(ctx dbx.Context, fn func() error) error {
+	if _, ok := ctx.Executor().(*sql.Tx); ok {
+		// database/sql may roll back a cancelled transaction concurrently
+		s.data.Lock()
+		defer s.data.Unlock()
+
+		return fn()
+	}
+
+	if err := s.acquire(ctx); err != nil {
+		return err
+	}
+
+	s.data.Lock()
+	err := fn()
+	s.data.Unlock()
+
+	if err != nil {
+		s.rollback()
+	} else {
+		s.commit()
+	}
+
+	return err
+}
+
+// onRollback registers a function reverting a change.
+func (s *Store) onRollback(fn func()) {
+	s.undo = append(s.undo, fn)
+}
+
+func (s *Store) acquire(ctx context.Context) error {
+	select {
+	case s.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Store) commit() {
+	s.undo = nil
+	<-s.sem
+}
+
+func (s *Store) rollback() {
+	s.data.Lock()
+	defer s.data.Unlock()
+
+	for i := len(s.undo) - 1; i >= 0; i-- {
+		s.undo[i]()
+	}
+
+	s.undo = nil
+	<-s.sem
+}
+
+// nextID works like a sequence: ids are never reused, even after a rollback.
+func (s *Store) nextID(table string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq[table]++
+
+	return s.seq[table]
+}
+
+func (s *Store) now() time.Time {
+	return s.clock().UTC()
+}
+
+func (c *connector) Connect(_ context.Context) (driver.Conn, error) {
+	return &conn{c.store}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c
+}
+
+func (c *connector) Open(_ string) (driver.Conn, error) {
+	return &conn{c.store}, nil
+}
+
+func (c *conn) Prepare(_ string) (driver.Stmt, error) {
+	return nil, errNoSQL
+}
+
+func (c *conn) Close() error {
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, _ driver.TxOptions) (driver.Tx, error) {
+	if err := c.store.acquire(ctx); err != nil {
+		return nil, err
+	}
+
+	return &tx{c.store}, nil
+}
+
+func (c *conn) Ping(_ context.Context) error {
+	return nil
+}
+
+func (t *tx) Commit() error {
+	t.store.commit()
+
+	return nil
+}
+
+func (t *tx) Rollback() error {
+	t.store.rollback()
+
+	return nil
+}
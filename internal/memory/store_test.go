@@ -0,0 +1,149 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/internal/memory"
+	"github.com/ziflex/rm-rf-production/pkg/accounts"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+	"github.com/ziflex/rm-rf-production/pkg/transactions"
+)
+
+const testTenant = memory.DefaultTenant
+
+func tenantCtx() context.Context {
+	return common.WithTenant(context.Background(), testTenant)
+}
+
+func TestStore_Transaction_Commit(t *testing.T) {
+	store := memory.NewStore()
+	repo := memory.NewAccountsRepository(store)
+
+	created, err := dbx.TransactionWithResult[accounts.Account](tenantCtx(), store.DB(), func(tx dbx.Context) (accounts.Account, error) {
+		acc, err := repo.CreateAccount(tx, accounts.AccountCreation{DocumentNumber: "12345678900"})
+
+		if err != nil {
+			return accounts.Account{}, err
+		}
+
+		// changes are visible inside the transaction
+		return repo.GetAccountByID(tx, acc.ID)
+	})
+
+	assert.NoError(t, err)
+
+	found, err := repo.GetAccountByID(dbx.NewContextFrom(tenantCtx(), store.DB()), created.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, created, found)
+}
+
+func TestStore_Transaction_Rollback(t *testing.T) {
+	store := memory.NewStore()
+	repo := memory.NewAccountsRepository(store)
+	failure := errors.New("failure")
+	var id int64
+
+	err := dbx.Transaction(tenantCtx(), store.DB(), func(tx dbx.Context) error {
+		acc, err := repo.CreateAccount(tx, accounts.AccountCreation{DocumentNumber: "12345678900"})
+
+		if err != nil {
+			return err
+		}
+
+		id = acc.ID
+
+		return failure
+	})
+
+	assert.ErrorIs(t, err, failure)
+
+	ctx := dbx.NewContextFrom(tenantCtx(), store.DB())
+
+	_, err = repo.GetAccountByID(ctx, id)
+	assert.ErrorIs(t, err, common.ErrNotFound)
+
+	// the document number is free again, the id is not reused
+	acc, err := repo.CreateAccount(ctx, accounts.AccountCreation{DocumentNumber: "12345678900"})
+	assert.NoError(t, err)
+	assert.Greater(t, acc.ID, id)
+}
+
+func TestStore_Transaction_Cancelled(t *testing.T) {
+	store := memory.NewStore()
+
+	tx, err := store.DB().Begin()
+	assert.NoError(t, err)
+	defer tx.Rollback()
+
+	// the store is held by the open transaction
+	ctx, cancel := context.WithCancel(tenantCtx())
+	cancel()
+
+	_, err = memory.NewAccountsRepository(store).CreateAccount(dbx.NewContextFrom(ctx, store.DB()), accounts.AccountCreation{DocumentNumber: "1"})
+
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestStore_DB_RejectsSQL(t *testing.T) {
+	store := memory.NewStore()
+
+	assert.NoError(t, store.DB().Ping())
+
+	_, err := store.DB().Exec("SELECT 1")
+	assert.Error(t, err)
+}
+
+func TestTransactionsRepository_CreateTransaction(t *testing.T) {
+	store := memory.NewStore()
+	ctx := dbx.NewContextFrom(tenantCtx(), store.DB())
+	accs := memory.NewAccountsRepository(store)
+	repo := memory.NewTransactionsRepository(store)
+
+	acc, err := accs.CreateAccount(ctx, accounts.AccountCreation{DocumentNumber: "12345678900"})
+	assert.NoError(t, err)
+
+	tr, err := repo.CreateTransaction(ctx, transactions.TransactionCreation{
+		AccountID:     acc.ID,
+		OperationType: transactions.OperationTypePurchase,
+		Amount:        -10.005,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, -10.01, tr.Amount)
+	assert.Equal(t, transactions.OperationTypePurchase, tr.OperationType)
+	assert.False(t, tr.EventDate.IsZero())
+
+	_, err = repo.CreateTransaction(ctx, transactions.TransactionCreation{
+		AccountID:     acc.ID + 1,
+		OperationType: transactions.OperationTypePurchase,
+		Amount:        -10,
+	})
+
+	assert.ErrorIs(t, err, common.ErrNotFound)
+
+	// accounts of other tenants do not exist
+	other := dbx.NewContextFrom(common.WithTenant(context.Background(), "other"), store.DB())
+
+	_, err = repo.CreateTransaction(other, transactions.TransactionCreation{
+		AccountID:     acc.ID,
+		OperationType: transactions.OperationTypePayment,
+		Amount:        10,
+	})
+
+	assert.ErrorIs(t, err, common.ErrNotFound)
+
+	assert.NoError(t, accs.SetAccountBlocked(ctx, acc.ID, true))
+
+	_, err = repo.CreateTransaction(ctx, transactions.TransactionCreation{
+		AccountID:     acc.ID,
+		OperationType: transactions.OperationTypePayment,
+		Amount:        10,
+	})
+
+	assert.ErrorIs(t, err, transactions.ErrAccountBlocked)
+}
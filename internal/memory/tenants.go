@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"fmt"
+
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+	"github.com/ziflex/rm-rf-production/pkg/tenants"
+)
+
+type TenantsRepository struct {
+	store *Store
+}
+
+func NewTenantsRepository(store *Store) tenants.Repository {
+	return &TenantsRepository{store}
+}
+
+func (r *TenantsRepository) CreateTenant(ctx dbx.Context, creation tenants.TenantCreation) (tenants.Tenant, error) {
+	var created tenants.Tenant
+
+	err := r.store.run(ctx, func() error {
+		if _, exists := r.store.tenants[creation.ID]; exists {
+			return fmt.Errorf("tenant %w: %s", common.ErrDuplicate, creation.ID)
+		}
+
+		created = tenants.Tenant{ID: creation.ID, Name: creation.Name, CreatedAt: r.store.now()}
+
+		r.store.tenants[created.ID] = created
+		r.store.onRollback(func() {
+			delete(r.store.tenants, created.ID)
+		})
+
+		return nil
+	})
+
+	return created, err
+}
+
+func (r *TenantsRepository) GetTenant(ctx dbx.Context, id string) (tenants.Tenant, error) {
+	var found tenants.Tenant
+
+	err := r.store.run(ctx, func() error {
+		tenant, exists := r.store.tenants[id]
+
+		if !exists {
+			return fmt.Errorf("tenant %w: %s", common.ErrNotFound, id)
+		}
+
+		found = tenant
+
+		return nil
+	})
+
+	return found, err
+}
@@ -0,0 +1,233 @@
+package memory
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+	"github.com/ziflex/rm-rf-production/pkg/transactions"
+)
+
+// maxAmount is the bound of the NUMERIC(10, 2) amount column.
+const maxAmount = 1e8
+
+var (
+	errForeignKey      = errors.New("foreign key violation")
+	errNumericOverflow = errors.New("numeric field overflow")
+	errInvalidEnum     = errors.New("invalid input value for enum")
+)
+
+type TransactionsRepository struct {
+	store *Store
+}
+
+func NewTransactionsRepository(store *Store) transactions.Repository {
+	return &TransactionsRepository{store}
+}
+
+func (r *TransactionsRepository) CreateTransaction(ctx dbx.Context, tr transactions.TransactionCreation) (transactions.Transaction, error) {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return transactions.Transaction{}, err
+	}
+
+	amount := roundCents(tr.Amount)
+
+	if math.Abs(amount) >= maxAmount {
+		return transactions.Transaction{}, fmt.Errorf("amount %w: %v", errNumericOverflow, tr.Amount)
+	}
+
+	// the operation type is stored by name, as in the operation_type enum
+	optype := tr.OperationType.String()
+
+	if optype == "" {
+		return transactions.Transaction{}, fmt.Errorf("operation_type %w: %d", errInvalidEnum, tr.OperationType)
+	}
+
+	var created transactions.Transaction
+
+	err = r.store.run(ctx, func() error {
+		acc, ok := r.store.account(tenantID, tr.AccountID)
+
+		if !ok {
+			return fmt.Errorf("account %w: %d", common.ErrNotFound, tr.AccountID)
+		}
+
+		if acc.blockedAt != nil && !tr.AllowBlocked {
+			return fmt.Errorf("%w: %d", transactions.ErrAccountBlocked, tr.AccountID)
+		}
+
+		key := refKey{tenantID, tr.ExternalRef}
+
+		if _, exists := r.store.externalRefs[key]; tr.ExternalRef != "" && exists {
+			return fmt.Errorf("transaction external_ref %w: %s", common.ErrDuplicate, tr.ExternalRef)
+		}
+
+		var merchantID string
+
+		if tr.Merchant != nil {
+			merchantID = tr.Merchant.ID
+			r.store.upsertMerchant(tenantID, *tr.Merchant)
+		}
+
+		created = transactions.Transaction{
+			ID:            r.store.nextID("transactions"),
+			AccountID:     tr.AccountID,
+			OperationType: transactions.NewOperationTypeFromString(optype),
+			Amount:        amount,
+			EventDate:     r.store.now(),
+			ExternalRef:   tr.ExternalRef,
+		}
+
+		n := len(r.store.transactions)
+		r.store.transactions = append(r.store.transactions, transaction{tenantID, created, merchantID})
+		created.Merchant = r.store.merchant(tenantID, merchantID)
+
+		if tr.ExternalRef != "" {
+			r.store.externalRefs[key] = created.ID
+		}
+
+		r.store.onRollback(func() {
+			r.store.transactions = r.store.transactions[:n]
+			delete(r.store.externalRefs, key)
+		})
+
+		return nil
+	})
+
+	if err != nil {
+		return transactions.Transaction{}, err
+	}
+
+	return created, nil
+}
+
+func (r *TransactionsRepository) ExportTransactions(ctx dbx.Context, filter transactions.ExportFilter, fn func(transactions.Transaction) error) error {
+	tenantID, err := common.TenantFromContext(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	var found []transactions.Transaction
+
+	err = r.store.run(ctx, func() error {
+		// transactions are appended in id order
+		for _, tr := range r.store.transactions {
+			if tr.tenantID != tenantID {
+				continue
+			}
+
+			res := tr.Transaction
+			res.Merchant = r.store.merchant(tenantID, tr.merchantID)
+
+			if exported(res, filter) {
+				found = append(found, res)
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// fn is called outside of the store, like a cursor it may be slow
+	for _, tr := range found {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := fn(tr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func exported(tr transactions.Transaction, filter transactions.ExportFilter) bool {
+	switch {
+	case tr.AccountID != filter.AccountID:
+		return false
+	case tr.ID <= filter.AfterID:
+		return false
+	case filter.From != nil && tr.EventDate.Before(*filter.From):
+		return false
+	case filter.To != nil && !tr.EventDate.Before(*filter.To):
+		return false
+	case filter.MCC != "" && (tr.Merchant == nil || tr.Merchant.MCC != filter.MCC):
+		return false
+	default:
+		return true
+	}
+}
+
+// upsertMerchant stores a merchant seen for the first time, the details sent for a known one replace the stored ones.
+func (s *Store) upsertMerchant(tenantID string, merchant transactions.Merchant) {
+	key := merchantKey{tenantID, merchant.ID}
+	prev, exists := s.merchants[key]
+	next := merchant
+
+	if exists {
+		next = prev
+		next.Name = cmp.Or(merchant.Name, prev.Name)
+		next.City = cmp.Or(merchant.City, prev.City)
+		next.Country = cmp.Or(merchant.Country, prev.Country)
+		next.MCC = cmp.Or(merchant.MCC, prev.MCC)
+	}
+
+	s.merchants[key] = next
+	s.onRollback(func() {
+		if exists {
+			s.merchants[key] = prev
+		} else {
+			delete(s.merchants, key)
+		}
+	})
+}
+
+// merchant returns a copy of a stored merchant, nil when the id is empty.
+func (s *Store) merchant(tenantID, id string) *transactions.Merchant {
+	m, ok := s.merchants[merchantKey{tenantID, id}]
+
+	if !ok {
+		return nil
+	}
+
+	return &m
+}
+
+// roundCents rounds half away from zero to cents, like the NUMERIC(10, 2) column does.
+// lib/pq sends the shortest decimal representation of the float, so that is what gets rounded,
+// e.g. 1.005 becomes 1.01 although its binary value is slightly below it.
+func roundCents(v float64) float64 {
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(v, 'f', -1, 64))
+
+	if !ok {
+		return v
+	}
+
+	r.Mul(r, big.NewRat(100, 1))
+
+	// (2|num| + den) / 2den truncates to the rounded magnitude
+	num := new(big.Int).Abs(r.Num())
+	num.Mul(num, big.NewInt(2)).Add(num, r.Denom())
+	den := new(big.Int).Mul(r.Denom(), big.NewInt(2))
+	cents := num.Quo(num, den)
+
+	if r.Sign() < 0 {
+		cents.Neg(cents)
+	}
+
+	res, _ := new(big.Rat).SetFrac(cents, big.NewInt(100)).Float64()
+
+	return res
+}
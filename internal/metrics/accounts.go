@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/ziflex/rm-rf-production/pkg/accounts"
+)
+
+type accountsService struct {
+	accounts.Service
+	metrics *Metrics
+}
+
+// NewAccountsService decorates the service with business metrics.
+func NewAccountsService(next accounts.Service, m *Metrics) accounts.Service {
+	return &accountsService{next, m}
+}
+
+func (s *accountsService) CreateAccount(ctx context.Context, creation accounts.AccountCreation) (accounts.Account, error) {
+	acc, err := s.Service.CreateAccount(ctx, creation)
+
+	if err == nil {
+		s.metrics.AccountsCreated.Inc()
+	}
+
+	return acc, err
+}
@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/ziflex/rm-rf-production/pkg/disputes"
+	"github.com/ziflex/rm-rf-production/pkg/transactions"
+)
+
+type disputesService struct {
+	disputes.Service
+	metrics *Metrics
+}
+
+// NewDisputesService decorates the service with business metrics. The credits and the reversals of disputes
+// are counted with the other transactions once the dispute change is committed.
+func NewDisputesService(next disputes.Service, m *Metrics) disputes.Service {
+	return &disputesService{next, m}
+}
+
+func (s *disputesService) OpenDispute(ctx context.Context, opening disputes.Opening) (disputes.Dispute, error) {
+	dispute, err := s.Service.OpenDispute(ctx, opening)
+
+	if err == nil {
+		s.metrics.countTransaction(transactions.OperationTypePayment, dispute.Amount)
+	}
+
+	return dispute, err
+}
+
+func (s *disputesService) UpdateStatus(ctx context.Context, id int64, change disputes.Change) (disputes.Dispute, error) {
+	dispute, err := s.Service.UpdateStatus(ctx, id, change)
+
+	if err == nil && dispute.ReversalTransactionID != 0 {
+		s.metrics.countTransaction(transactions.OperationTypeDisputeReversal, dispute.Amount)
+	}
+
+	return dispute, err
+}
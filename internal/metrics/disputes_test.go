@@ -0,0 +1,55 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/ziflex/rm-rf-production/internal/metrics"
+	"github.com/ziflex/rm-rf-production/pkg/disputes"
+)
+
+type stubDisputes struct {
+	disputes.Service
+	dispute disputes.Dispute
+	err     error
+}
+
+func (s *stubDisputes) OpenDispute(context.Context, disputes.Opening) (disputes.Dispute, error) {
+	return s.dispute, s.err
+}
+
+func (s *stubDisputes) UpdateStatus(context.Context, int64, disputes.Change) (disputes.Dispute, error) {
+	return s.dispute, s.err
+}
+
+func TestDisputesService(t *testing.T) {
+	m := metrics.New()
+	next := &stubDisputes{dispute: disputes.Dispute{ID: 1, CreditTransactionID: 10, Amount: 25, Status: disputes.StatusOpened}}
+	svc := metrics.NewDisputesService(next, m)
+
+	_, err := svc.OpenDispute(context.Background(), disputes.Opening{})
+	assert.NoError(t, err)
+
+	// only the lost disputes are reversed
+	next.dispute.Status = disputes.StatusUnderReview
+	_, err = svc.UpdateStatus(context.Background(), 1, disputes.Change{Status: disputes.StatusUnderReview})
+	assert.NoError(t, err)
+
+	next.dispute.Status = disputes.StatusLost
+	next.dispute.ReversalTransactionID = 11
+	_, err = svc.UpdateStatus(context.Background(), 1, disputes.Change{Status: disputes.StatusLost})
+	assert.NoError(t, err)
+
+	// failed changes are rolled back, nothing is created
+	next.err = errors.New("dispute is not open")
+	_, err = svc.OpenDispute(context.Background(), disputes.Opening{})
+	assert.Error(t, err)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.TransactionsCreated.WithLabelValues("payment")))
+	assert.Equal(t, 25.0, testutil.ToFloat64(m.TransactionsAmount.WithLabelValues("payment")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.TransactionsCreated.WithLabelValues("dispute_reversal")))
+	assert.Equal(t, 25.0, testutil.ToFloat64(m.TransactionsAmount.WithLabelValues("dispute_reversal")))
+}
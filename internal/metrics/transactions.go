@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"context"
+	"math"
+
+	"github.com/ziflex/rm-rf-production/pkg/transactions"
+)
+
+type transactionsService struct {
+	transactions.Service
+	metrics *Metrics
+}
+
+// NewTransactionsService decorates the service with business metrics.
+func NewTransactionsService(next transactions.Service, m *Metrics) transactions.Service {
+	return &transactionsService{next, m}
+}
+
+func (s *transactionsService) CreateTransaction(ctx context.Context, creation transactions.TransactionCreation) (transactions.Transaction, error) {
+	tx, err := s.Service.CreateTransaction(ctx, creation)
+
+	if err == nil {
+		s.metrics.countTransaction(tx.OperationType, tx.Amount)
+	}
+
+	return tx, err
+}
+
+func (m *Metrics) countTransaction(op transactions.OperationType, amount float64) {
+	m.TransactionsCreated.WithLabelValues(op.String()).Inc()
+	m.TransactionsAmount.WithLabelValues(op.String()).Add(math.Abs(amount))
+}
@@ -0,0 +1,1090 @@
+// Package repotest is a conformance suite for the accounts, transactions, reconciliations, scheduler, jobs
+// and disputes repositories.
+// Every implementation runs it, so they all behave like the Postgres one.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/accounts"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+	"github.com/ziflex/rm-rf-production/pkg/disputes"
+	"github.com/ziflex/rm-rf-production/pkg/queue"
+	"github.com/ziflex/rm-rf-production/pkg/reconciliation"
+	"github.com/ziflex/rm-rf-production/pkg/scheduler"
+	"github.com/ziflex/rm-rf-production/pkg/tenants"
+	"github.com/ziflex/rm-rf-production/pkg/transactions"
+)
+
+const concurrency = 16
+
+type (
+	// Fixture is the implementation under test.
+	Fixture struct {
+		DB              dbx.Database
+		Tenants         tenants.Repository
+		Accounts        accounts.Repository
+		Transactions    transactions.Repository
+		Reconciliations reconciliation.Repository
+		Scheduler       scheduler.Repository
+		Jobs            queue.Repository
+		Disputes        disputes.Repository
+	}
+
+	// Factory returns a fixture, the data it holds may be shared with other runs.
+	Factory func(t *testing.T) Fixture
+
+	suite struct {
+		Fixture
+		t *testing.T
+	}
+)
+
+// Run runs the suite. Each test works in its own tenant, so a fixture may point at a database that is not empty.
+func Run(t *testing.T, factory Factory) {
+	tests := []struct {
+		Name string
+		Func func(s *suite)
+	}{
+		{"Tenants", testTenants},
+		{"DuplicateDocumentNumber", testDuplicateDocumentNumber},
+		{"AccountNotFound", testAccountNotFound},
+		{"AccountBlocked", testAccountBlocked},
+		{"AccountVersions", testAccountVersions},
+		{"OperationTypes", testOperationTypes},
+		{"AmountPrecision", testAmountPrecision},
+		{"ExportTransactions", testExportTransactions},
+		{"ExternalRefs", testExternalRefs},
+		{"Merchants", testMerchants},
+		{"Reconciliations", testReconciliations},
+		{"SchedulerRuns", testSchedulerRuns},
+		{"Jobs", testJobs},
+		{"Disputes", testDisputes},
+		{"ConcurrentAccounts", testConcurrentAccounts},
+		{"ConcurrentDuplicates", testConcurrentDuplicates},
+		{"ConcurrentTransactions", testConcurrentTransactions},
+		{"ConcurrentUpdates", testConcurrentUpdates},
+		{"ConcurrentClaims", testConcurrentClaims},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			tc.Func(&suite{factory(t), t})
+		})
+	}
+}
+
+// tenant creates a tenant and returns a context scoped to it.
+func (s *suite) tenant() context.Context {
+	id := fmt.Sprintf("repotest-%016x", rand.Uint64())
+
+	_, err := s.Tenants.CreateTenant(dbx.NewContextFrom(context.Background(), s.DB), tenants.TenantCreation{ID: id, Name: id})
+	require.NoError(s.t, err)
+
+	return common.WithTenant(context.Background(), id)
+}
+
+// createAccount inserts an account in its own transaction, as the service does.
+func (s *suite) createAccount(ctx context.Context, doc string) (accounts.Account, error) {
+	return dbx.TransactionWithResult[accounts.Account](ctx, s.DB, func(tx dbx.Context) (accounts.Account, error) {
+		return s.Accounts.CreateAccount(tx, accounts.AccountCreation{DocumentNumber: doc})
+	})
+}
+
+func (s *suite) updateAccount(ctx context.Context, id int64, update accounts.AccountUpdate, versions []int64) (accounts.Account, error) {
+	return dbx.TransactionWithResult[accounts.Account](ctx, s.DB, func(tx dbx.Context) (accounts.Account, error) {
+		return s.Accounts.UpdateAccount(tx, id, update, versions)
+	})
+}
+
+func (s *suite) createTransaction(ctx context.Context, tr transactions.TransactionCreation) (transactions.Transaction, error) {
+	return dbx.TransactionWithResult[transactions.Transaction](ctx, s.DB, func(tx dbx.Context) (transactions.Transaction, error) {
+		return s.Transactions.CreateTransaction(tx, tr)
+	})
+}
+
+func (s *suite) mustCreateAccount(ctx context.Context, doc string) accounts.Account {
+	acc, err := s.createAccount(ctx, doc)
+	require.NoError(s.t, err)
+
+	return acc
+}
+
+func testDuplicateDocumentNumber(s *suite) {
+	ctx := s.tenant()
+	acc := s.mustCreateAccount(ctx, "12345678900")
+
+	_, err := s.createAccount(ctx, "12345678900")
+	assert.ErrorIs(s.t, err, common.ErrDuplicate)
+
+	// the document number is unique per tenant
+	other, err := s.createAccount(s.tenant(), "12345678900")
+	assert.NoError(s.t, err)
+	assert.NotEqual(s.t, acc.ID, other.ID)
+
+	found, err := s.Accounts.GetAccountByID(dbx.NewContextFrom(ctx, s.DB), acc.ID)
+	assert.NoError(s.t, err)
+	assert.Equal(s.t, acc, found)
+}
+
+func testTenants(s *suite) {
+	id, err := common.TenantFromContext(s.tenant())
+	require.NoError(s.t, err)
+
+	ctx := dbx.NewContextFrom(context.Background(), s.DB)
+
+	tenant, err := s.Tenants.GetTenant(ctx, id)
+	require.NoError(s.t, err)
+	assert.Equal(s.t, id, tenant.ID)
+	assert.Equal(s.t, id, tenant.Name)
+	assert.False(s.t, tenant.CreatedAt.IsZero())
+
+	_, err = s.Tenants.GetTenant(ctx, id+"-missing")
+	assert.ErrorIs(s.t, err, common.ErrNotFound)
+}
+
+func testAccountNotFound(s *suite) {
+	ctx := s.tenant()
+	acc := s.mustCreateAccount(ctx, "12345678900")
+	other := s.tenant()
+
+	_, err := s.Accounts.GetAccountByID(dbx.NewContextFrom(other, s.DB), acc.ID)
+	assert.ErrorIs(s.t, err, common.ErrNotFound)
+
+	assert.ErrorIs(s.t, s.Accounts.SetAccountBlocked(dbx.NewContextFrom(other, s.DB), acc.ID, true), common.ErrNotFound)
+
+	_, err = s.updateAccount(other, acc.ID, accounts.AccountUpdate{}, []int64{acc.Version})
+	assert.ErrorIs(s.t, err, common.ErrNotFound)
+
+	// the foreign key rejects missing accounts and accounts of other tenants
+	for _, tc := range []struct {
+		Name      string
+		Ctx       context.Context
+		AccountID int64
+	}{
+		{"missing", ctx, acc.ID + 1_000_000},
+		{"other tenant", other, acc.ID},
+	} {
+		_, err := s.createTransaction(tc.Ctx, transactions.TransactionCreation{
+			AccountID:     tc.AccountID,
+			OperationType: transactions.OperationTypePayment,
+			Amount:        10,
+		})
+
+		assert.ErrorIs(s.t, err, common.ErrNotFound, tc.Name)
+	}
+}
+
+func testAccountBlocked(s *suite) {
+	ctx := s.tenant()
+	db := dbx.NewContextFrom(ctx, s.DB)
+	acc := s.mustCreateAccount(ctx, "12345678900")
+	creation := transactions.TransactionCreation{
+		AccountID:     acc.ID,
+		OperationType: transactions.OperationTypePayment,
+		Amount:        10,
+	}
+
+	require.NoError(s.t, s.Accounts.SetAccountBlocked(db, acc.ID, true))
+	// blocking twice is not an error
+	require.NoError(s.t, s.Accounts.SetAccountBlocked(db, acc.ID, true))
+
+	_, err := s.createTransaction(ctx, creation)
+	assert.ErrorIs(s.t, err, transactions.ErrAccountBlocked)
+
+	// transactions issued by the system skip the check
+	system := creation
+	system.AllowBlocked = true
+
+	_, err = s.createTransaction(ctx, system)
+	assert.NoError(s.t, err)
+
+	require.NoError(s.t, s.Accounts.SetAccountBlocked(db, acc.ID, false))
+
+	_, err = s.createTransaction(ctx, creation)
+	assert.NoError(s.t, err)
+}
+
+func testAccountVersions(s *suite) {
+	ctx := s.tenant()
+	db := dbx.NewContextFrom(ctx, s.DB)
+	acc := s.mustCreateAccount(ctx, "12345678900")
+	blocked, unblocked := true, false
+
+	assert.Equal(s.t, int64(1), acc.Version)
+
+	updated, err := s.updateAccount(ctx, acc.ID, accounts.AccountUpdate{Blocked: &blocked}, []int64{acc.Version})
+	require.NoError(s.t, err)
+	assert.True(s.t, updated.Blocked)
+	assert.Equal(s.t, acc.Version+1, updated.Version)
+
+	found, err := s.Accounts.GetAccountByID(db, acc.ID)
+	require.NoError(s.t, err)
+	assert.Equal(s.t, updated, found)
+
+	// a stale version does not change the account
+	_, err = s.updateAccount(ctx, acc.ID, accounts.AccountUpdate{Blocked: &unblocked}, []int64{acc.Version})
+	assert.ErrorIs(s.t, err, accounts.ErrVersionMismatch)
+
+	// an empty list matches nothing, nil matches any version
+	_, err = s.updateAccount(ctx, acc.ID, accounts.AccountUpdate{Blocked: &unblocked}, []int64{})
+	assert.ErrorIs(s.t, err, accounts.ErrVersionMismatch)
+
+	found, err = s.Accounts.GetAccountByID(db, acc.ID)
+	require.NoError(s.t, err)
+	assert.Equal(s.t, updated, found)
+
+	updated, err = s.updateAccount(ctx, acc.ID, accounts.AccountUpdate{Blocked: &unblocked}, nil)
+	require.NoError(s.t, err)
+	assert.False(s.t, updated.Blocked)
+	assert.Equal(s.t, found.Version+1, updated.Version)
+
+	// blocking changes the version as well
+	require.NoError(s.t, s.Accounts.SetAccountBlocked(db, acc.ID, true))
+
+	found, err = s.Accounts.GetAccountByID(db, acc.ID)
+	require.NoError(s.t, err)
+	assert.True(s.t, found.Blocked)
+	assert.Equal(s.t, updated.Version+1, found.Version)
+
+	_, err = s.updateAccount(ctx, acc.ID+1_000_000, accounts.AccountUpdate{}, nil)
+	assert.ErrorIs(s.t, err, common.ErrNotFound)
+}
+
+func testOperationTypes(s *suite) {
+	ctx := s.tenant()
+	acc := s.mustCreateAccount(ctx, "12345678900")
+
+	for _, op := range []transactions.OperationType{
+		transactions.OperationTypePurchase,
+		transactions.OperationTypeInstallmentPurchase,
+		transactions.OperationTypeWithdrawal,
+		transactions.OperationTypePayment,
+		transactions.OperationTypeDisputeReversal,
+	} {
+		tr, err := s.createTransaction(ctx, transactions.TransactionCreation{
+			AccountID:     acc.ID,
+			OperationType: op,
+			Amount:        1,
+		})
+
+		if assert.NoError(s.t, err, op.String()) {
+			assert.Equal(s.t, op, tr.OperationType)
+			assert.Equal(s.t, op, transactions.NewOperationTypeFromString(tr.OperationType.String()))
+			assert.Equal(s.t, acc.ID, tr.AccountID)
+			assert.False(s.t, tr.EventDate.IsZero())
+		}
+	}
+
+	_, err := s.createTransaction(ctx, transactions.TransactionCreation{
+		AccountID:     acc.ID,
+		OperationType: transactions.OperationTypeUnknown,
+		Amount:        1,
+	})
+
+	assert.Error(s.t, err)
+}
+
+func testAmountPrecision(s *suite) {
+	ctx := s.tenant()
+	acc := s.mustCreateAccount(ctx, "12345678900")
+
+	// amounts are stored as NUMERIC(10, 2)
+	for _, tc := range []struct {
+		In  float64
+		Out float64
+	}{
+		{0.01, 0.01},
+		{-123.45, -123.45},
+		{0.1 + 0.2, 0.3},
+		{10.005, 10.01},
+		{-10.005, -10.01},
+		{1.005, 1.01},
+		{1.004, 1},
+		{99999999.99, 99999999.99},
+	} {
+		tr, err := s.createTransaction(ctx, transactions.TransactionCreation{
+			AccountID:     acc.ID,
+			OperationType: transactions.OperationTypePayment,
+			Amount:        tc.In,
+		})
+
+		if assert.NoError(s.t, err, tc.In) {
+			assert.Equal(s.t, tc.Out, tr.Amount, tc.In)
+		}
+	}
+
+	_, err := s.createTransaction(ctx, transactions.TransactionCreation{
+		AccountID:     acc.ID,
+		OperationType: transactions.OperationTypePayment,
+		Amount:        100000000,
+	})
+
+	assert.Error(s.t, err)
+}
+
+func testExportTransactions(s *suite) {
+	ctx := s.tenant()
+	acc := s.mustCreateAccount(ctx, "12345678900")
+	other := s.mustCreateAccount(ctx, "12345678901")
+
+	var created []transactions.Transaction
+
+	for i, accountID := range []int64{acc.ID, other.ID, acc.ID, acc.ID} {
+		tr, err := s.createTransaction(ctx, transactions.TransactionCreation{
+			AccountID:     accountID,
+			OperationType: transactions.OperationTypePayment,
+			Amount:        float64(i + 1),
+		})
+		require.NoError(s.t, err)
+
+		created = append(created, tr)
+	}
+
+	export := func(ctx context.Context, filter transactions.ExportFilter) []transactions.Transaction {
+		var exported []transactions.Transaction
+
+		err := s.Transactions.ExportTransactions(dbx.NewContextFrom(ctx, s.DB), filter, func(tr transactions.Transaction) error {
+			exported = append(exported, tr)
+
+			return nil
+		})
+		require.NoError(s.t, err)
+
+		return exported
+	}
+
+	ids := func(trs []transactions.Transaction) []int64 {
+		res := make([]int64, 0, len(trs))
+
+		for _, tr := range trs {
+			res = append(res, tr.ID)
+		}
+
+		return res
+	}
+
+	assert.Equal(s.t, []int64{created[0].ID, created[2].ID, created[3].ID}, ids(export(ctx, transactions.ExportFilter{AccountID: acc.ID})))
+	assert.Equal(s.t, []int64{created[2].ID, created[3].ID}, ids(export(ctx, transactions.ExportFilter{AccountID: acc.ID, AfterID: created[0].ID})))
+	assert.Empty(s.t, export(s.tenant(), transactions.ExportFilter{AccountID: acc.ID}), "other tenant")
+
+	// the bounds are inclusive and exclusive
+	from := created[0].EventDate
+	assert.Len(s.t, export(ctx, transactions.ExportFilter{AccountID: acc.ID, From: &from}), 3)
+	assert.Empty(s.t, export(ctx, transactions.ExportFilter{AccountID: acc.ID, To: &from}))
+
+	// the export stops at the first error of fn
+	stop := errors.New("stop")
+	count := 0
+	err := s.Transactions.ExportTransactions(dbx.NewContextFrom(ctx, s.DB), transactions.ExportFilter{AccountID: acc.ID}, func(transactions.Transaction) error {
+		count++
+
+		return stop
+	})
+	assert.ErrorIs(s.t, err, stop)
+	assert.Equal(s.t, 1, count)
+}
+
+func testConcurrentAccounts(s *suite) {
+	ctx := s.tenant()
+	ids := make([]int64, concurrency)
+	errs := make([]error, concurrency)
+
+	parallel(concurrency, func(i int) {
+		var acc accounts.Account
+		acc, errs[i] = s.createAccount(ctx, fmt.Sprintf("%011d", i))
+		ids[i] = acc.ID
+	})
+
+	seen := make(map[int64]bool, concurrency)
+
+	for i := range concurrency {
+		require.NoError(s.t, errs[i])
+		assert.False(s.t, seen[ids[i]], "id %d is assigned twice", ids[i])
+		seen[ids[i]] = true
+	}
+}
+
+func testConcurrentDuplicates(s *suite) {
+	ctx := s.tenant()
+	errs := make([]error, concurrency)
+
+	parallel(concurrency, func(i int) {
+		_, errs[i] = s.createAccount(ctx, "12345678900")
+	})
+
+	created := 0
+
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, common.ErrDuplicate):
+			s.t.Errorf("unexpected error: %v", err)
+		}
+	}
+
+	assert.Equal(s.t, 1, created)
+}
+
+func testConcurrentTransactions(s *suite) {
+	ctx := s.tenant()
+	acc := s.mustCreateAccount(ctx, "12345678900")
+	ids := make([]int64, concurrency)
+	errs := make([]error, concurrency)
+
+	parallel(concurrency, func(i int) {
+		var tr transactions.Transaction
+		tr, errs[i] = s.createTransaction(ctx, transactions.TransactionCreation{
+			AccountID:     acc.ID,
+			OperationType: transactions.OperationTypePayment,
+			Amount:        float64(i + 1),
+		})
+		ids[i] = tr.ID
+	})
+
+	seen := make(map[int64]bool, concurrency)
+
+	for i := range concurrency {
+		require.NoError(s.t, errs[i])
+		assert.False(s.t, seen[ids[i]], "id %d is assigned twice", ids[i])
+		seen[ids[i]] = true
+	}
+}
+
+func testConcurrentUpdates(s *suite) {
+	ctx := s.tenant()
+	acc := s.mustCreateAccount(ctx, "12345678900")
+	errs := make([]error, concurrency)
+
+	parallel(concurrency, func(i int) {
+		blocked := i%2 == 0
+		_, errs[i] = s.updateAccount(ctx, acc.ID, accounts.AccountUpdate{Blocked: &blocked}, []int64{acc.Version})
+	})
+
+	updated := 0
+
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			updated++
+		case !errors.Is(err, accounts.ErrVersionMismatch):
+			s.t.Errorf("unexpected error: %v", err)
+		}
+	}
+
+	assert.Equal(s.t, 1, updated)
+}
+
+func parallel(n int, fn func(i int)) {
+	var wg sync.WaitGroup
+
+	for i := range n {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			fn(i)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func testExternalRefs(s *suite) {
+	ctx := s.tenant()
+	acc := s.mustCreateAccount(ctx, "12345678900")
+
+	creation := transactions.TransactionCreation{
+		AccountID:     acc.ID,
+		OperationType: transactions.OperationTypePayment,
+		Amount:        10,
+		ExternalRef:   "PRC-1",
+	}
+
+	tr, err := s.createTransaction(ctx, creation)
+	require.NoError(s.t, err)
+	assert.Equal(s.t, "PRC-1", tr.ExternalRef)
+
+	_, err = s.createTransaction(ctx, creation)
+	assert.ErrorIs(s.t, err, common.ErrDuplicate)
+
+	// the reference is unique per tenant
+	other := s.tenant()
+	creation.AccountID = s.mustCreateAccount(other, "12345678900").ID
+	_, err = s.createTransaction(other, creation)
+	assert.NoError(s.t, err)
+
+	// transactions without a reference do not conflict
+	for range 2 {
+		tr, err := s.createTransaction(ctx, transactions.TransactionCreation{
+			AccountID:     acc.ID,
+			OperationType: transactions.OperationTypePayment,
+			Amount:        10,
+		})
+		require.NoError(s.t, err)
+		assert.Empty(s.t, tr.ExternalRef)
+	}
+}
+
+func testMerchants(s *suite) {
+	ctx := s.tenant()
+	acc := s.mustCreateAccount(ctx, "12345678900")
+
+	create := func(ctx context.Context, merchant *transactions.Merchant) transactions.Transaction {
+		op := transactions.OperationTypePurchase
+
+		if merchant == nil {
+			op = transactions.OperationTypePayment
+		}
+
+		tr, err := s.createTransaction(ctx, transactions.TransactionCreation{
+			AccountID:     acc.ID,
+			OperationType: op,
+			Amount:        -10,
+			Merchant:      merchant,
+		})
+		require.NoError(s.t, err)
+
+		return tr
+	}
+
+	first := create(ctx, &transactions.Merchant{ID: "M-1", Name: "Corner Cafe", City: "Lisbon", Country: "PT", MCC: "5814"})
+	assert.Equal(s.t, &transactions.Merchant{ID: "M-1", Name: "Corner Cafe", City: "Lisbon", Country: "PT", MCC: "5814"}, first.Merchant)
+
+	// known merchants keep the details a transaction leaves empty
+	second := create(ctx, &transactions.Merchant{ID: "M-1", Name: "Corner Cafe & Bar"})
+	assert.Equal(s.t, &transactions.Merchant{ID: "M-1", Name: "Corner Cafe & Bar", City: "Lisbon", Country: "PT", MCC: "5814"}, second.Merchant)
+
+	atm := create(ctx, &transactions.Merchant{ID: "ATM-7", MCC: "6011"})
+	payment := create(ctx, nil)
+	assert.Nil(s.t, payment.Merchant)
+
+	// merchants are scoped to the tenant
+	otherCtx := s.tenant()
+	otherAcc := s.mustCreateAccount(otherCtx, "12345678900")
+	other, err := s.createTransaction(otherCtx, transactions.TransactionCreation{
+		AccountID:     otherAcc.ID,
+		OperationType: transactions.OperationTypePurchase,
+		Amount:        -1,
+		Merchant:      &transactions.Merchant{ID: "M-1"},
+	})
+	require.NoError(s.t, err)
+	assert.Equal(s.t, &transactions.Merchant{ID: "M-1"}, other.Merchant)
+
+	export := func(filter transactions.ExportFilter) []transactions.Transaction {
+		var exported []transactions.Transaction
+
+		err := s.Transactions.ExportTransactions(dbx.NewContextFrom(ctx, s.DB), filter, func(tr transactions.Transaction) error {
+			exported = append(exported, tr)
+
+			return nil
+		})
+		require.NoError(s.t, err)
+
+		return exported
+	}
+
+	all := export(transactions.ExportFilter{AccountID: acc.ID})
+	require.Len(s.t, all, 4)
+	assert.Equal(s.t, "Corner Cafe & Bar", all[0].Merchant.Name, "details are stored once per merchant")
+	assert.Nil(s.t, all[3].Merchant)
+
+	cafe := export(transactions.ExportFilter{AccountID: acc.ID, MCC: "5814"})
+	require.Len(s.t, cafe, 2)
+	assert.Equal(s.t, []int64{first.ID, second.ID}, []int64{cafe[0].ID, cafe[1].ID})
+
+	cash := export(transactions.ExportFilter{AccountID: acc.ID, MCC: "6011"})
+	require.Len(s.t, cash, 1)
+	assert.Equal(s.t, atm.ID, cash[0].ID)
+
+	assert.Empty(s.t, export(transactions.ExportFilter{AccountID: acc.ID, MCC: "5411"}))
+}
+
+func testReconciliations(s *suite) {
+	ctx := s.tenant()
+	acc := s.mustCreateAccount(ctx, "12345678900")
+
+	tr, err := s.createTransaction(ctx, transactions.TransactionCreation{
+		AccountID:     acc.ID,
+		OperationType: transactions.OperationTypePurchase,
+		Amount:        10,
+		ExternalRef:   "PRC-1",
+	})
+	require.NoError(s.t, err)
+
+	// payments are not settled by the processor, they are left out of the ledger
+	_, err = s.createTransaction(ctx, transactions.TransactionCreation{
+		AccountID:     acc.ID,
+		OperationType: transactions.OperationTypePayment,
+		Amount:        10,
+		ExternalRef:   "PAY-1",
+	})
+	require.NoError(s.t, err)
+
+	// the ledger window is inclusive and exclusive
+	ledger := func(from, to time.Time) []int64 {
+		var ids []int64
+
+		err := s.Reconciliations.ListLedger(dbx.NewContextFrom(ctx, s.DB), from, to, func(tr transactions.Transaction) error {
+			assert.Equal(s.t, "PRC-1", tr.ExternalRef)
+			ids = append(ids, tr.ID)
+
+			return nil
+		})
+		require.NoError(s.t, err)
+
+		return ids
+	}
+
+	assert.Equal(s.t, []int64{tr.ID}, ledger(tr.EventDate, tr.EventDate.Add(time.Hour)))
+	assert.Empty(s.t, ledger(tr.EventDate.Add(-time.Hour), tr.EventDate))
+
+	day := tr.EventDate.UTC().Truncate(24 * time.Hour)
+	lines := []reconciliation.Line{
+		{Number: 1, Reference: "PRC-1", Amount: tr.Amount, Date: day},
+		{Number: 2, Reference: "PRC-2", Amount: -5, Date: day},
+	}
+	items, summary := reconciliation.Match(lines, []transactions.Transaction{tr})
+
+	created, err := dbx.TransactionWithResult[reconciliation.Reconciliation](ctx, s.DB, func(tx dbx.Context) (reconciliation.Reconciliation, error) {
+		return s.Reconciliations.CreateReconciliation(tx, reconciliation.ReconciliationCreation{
+			FileName:    "settlement.csv",
+			PeriodStart: day,
+			PeriodEnd:   day.Add(24 * time.Hour),
+			Summary:     summary,
+			Items:       items,
+		})
+	})
+	require.NoError(s.t, err)
+	assert.NotZero(s.t, created.ID)
+
+	get := func(ctx context.Context, filter reconciliation.Filter) (reconciliation.Reconciliation, error) {
+		return s.Reconciliations.GetReconciliation(dbx.NewContextFrom(ctx, s.DB), created.ID, filter)
+	}
+
+	found, err := get(ctx, reconciliation.Filter{})
+	require.NoError(s.t, err)
+	assert.Equal(s.t, "settlement.csv", found.FileName)
+	assert.True(s.t, day.Equal(found.PeriodStart))
+	assert.Equal(s.t, reconciliation.Summary{Matched: 1, MissingInLedger: 1}, found.Summary)
+	require.Len(s.t, found.Items, 2)
+
+	matched := found.Items[0]
+	assert.Equal(s.t, reconciliation.CategoryMatched, matched.Category)
+	require.NotNil(s.t, matched.Line)
+	assert.Equal(s.t, 1, matched.Line.Number)
+	require.NotNil(s.t, matched.Transaction)
+	assert.Equal(s.t, tr.ID, matched.Transaction.ID)
+	assert.Equal(s.t, acc.ID, matched.Transaction.AccountID)
+	assert.Equal(s.t, tr.Amount, matched.Transaction.Amount)
+
+	assert.Equal(s.t, reconciliation.CategoryMissingInLedger, found.Items[1].Category)
+	assert.Nil(s.t, found.Items[1].Transaction)
+
+	found, err = get(ctx, reconciliation.Filter{Category: reconciliation.CategoryMissingInLedger})
+	require.NoError(s.t, err)
+	require.Len(s.t, found.Items, 1)
+	assert.Equal(s.t, "PRC-2", found.Items[0].Line.Reference)
+
+	found, err = get(ctx, reconciliation.Filter{Category: reconciliation.CategoryAmountMismatch})
+	require.NoError(s.t, err)
+	assert.Empty(s.t, found.Items)
+
+	_, err = get(s.tenant(), reconciliation.Filter{})
+	assert.ErrorIs(s.t, err, common.ErrNotFound, "other tenant")
+}
+
+func testSchedulerRuns(s *suite) {
+	// runs are not scoped to a tenant, the job name keeps them apart from other runs of the suite
+	job := fmt.Sprintf("repotest-%016x", rand.Uint64())
+	ctx := dbx.NewContextFrom(context.Background(), s.DB)
+	at := time.Date(2025, time.August, 30, 10, 0, 0, 0, time.UTC)
+
+	first, err := s.Scheduler.CreateRun(ctx, scheduler.RunCreation{Job: job, Instance: "a", ScheduledAt: at})
+	require.NoError(s.t, err)
+	assert.Equal(s.t, scheduler.RunStatusRunning, first.Status)
+	assert.True(s.t, at.Equal(first.ScheduledAt))
+	assert.Nil(s.t, first.FinishedAt)
+
+	// a job runs once per scheduled time
+	_, err = s.Scheduler.CreateRun(ctx, scheduler.RunCreation{Job: job, Instance: "b", ScheduledAt: at})
+	assert.ErrorIs(s.t, err, common.ErrDuplicate)
+
+	finished, err := s.Scheduler.FinishRun(ctx, first.ID, scheduler.RunCompletion{Status: scheduler.RunStatusSucceeded, Result: "done"})
+	require.NoError(s.t, err)
+	assert.Equal(s.t, scheduler.RunStatusSucceeded, finished.Status)
+	assert.Equal(s.t, "done", finished.Result)
+	assert.NotNil(s.t, finished.FinishedAt)
+
+	second, err := s.Scheduler.CreateRun(ctx, scheduler.RunCreation{Job: job, Instance: "b", ScheduledAt: at.Add(time.Hour)})
+	require.NoError(s.t, err)
+
+	// only the runs still running are interrupted
+	interrupted, err := s.Scheduler.InterruptRuns(ctx, job, "interrupted")
+	require.NoError(s.t, err)
+	assert.Equal(s.t, int64(1), interrupted)
+
+	list := func(filter scheduler.RunFilter) []int64 {
+		runs, err := s.Scheduler.ListRuns(ctx, filter)
+		require.NoError(s.t, err)
+
+		ids := make([]int64, 0, len(runs))
+
+		for _, run := range runs {
+			ids = append(ids, run.ID)
+		}
+
+		return ids
+	}
+
+	assert.Equal(s.t, []int64{second.ID, first.ID}, list(scheduler.RunFilter{Job: job, Limit: 10}))
+	assert.Equal(s.t, []int64{second.ID}, list(scheduler.RunFilter{Job: job, Limit: 1}))
+	assert.Equal(s.t, []int64{second.ID}, list(scheduler.RunFilter{Job: job, Status: scheduler.RunStatusFailed, Limit: 10}))
+	assert.Empty(s.t, list(scheduler.RunFilter{Job: job + "-other", Limit: 10}))
+
+	runs, err := s.Scheduler.ListRuns(ctx, scheduler.RunFilter{Job: job, Limit: 1})
+	require.NoError(s.t, err)
+	assert.Equal(s.t, "interrupted", runs[0].Error)
+
+	_, err = s.Scheduler.FinishRun(ctx, second.ID+1000000, scheduler.RunCompletion{Status: scheduler.RunStatusSucceeded})
+	assert.ErrorIs(s.t, err, common.ErrNotFound)
+}
+
+// jobType returns a job type of its own, jobs of any tenant are claimed by type.
+func jobType() string {
+	return fmt.Sprintf("repotest-%016x", rand.Uint64())
+}
+
+func testJobs(s *suite) {
+	tenant := s.tenant()
+	ctx := dbx.NewContextFrom(tenant, s.DB)
+	typ := jobType()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	later := now.Add(time.Hour)
+
+	create := func(priority int, runAt time.Time) queue.Job {
+		job, err := s.Jobs.CreateJob(ctx, queue.JobCreation{
+			Type:        typ,
+			Payload:     []byte(`{"n":1}`),
+			Priority:    priority,
+			MaxAttempts: 3,
+			RunAt:       &runAt,
+		})
+		require.NoError(s.t, err)
+
+		return job
+	}
+
+	low := create(0, now.Add(-time.Minute))
+	high := create(5, now)
+	delayed := create(10, later)
+
+	assert.Equal(s.t, queue.StatusQueued, low.Status)
+	assert.JSONEq(s.t, `{"n":1}`, string(low.Payload))
+	assert.Zero(s.t, low.Attempts)
+	assert.Nil(s.t, low.LockedUntil)
+
+	claim := func(worker string, at time.Time) (queue.Job, bool) {
+		job, ok, err := s.Jobs.ClaimJob(ctx, queue.Claim{
+			Types:       []string{typ},
+			Worker:      worker,
+			Now:         at,
+			LockedUntil: at.Add(time.Minute),
+		})
+		require.NoError(s.t, err)
+
+		return job, ok
+	}
+
+	// the highest priority first, the delayed job is not due yet
+	claimed, ok := claim("a", now)
+	require.True(s.t, ok)
+	assert.Equal(s.t, high.ID, claimed.ID)
+	assert.Equal(s.t, queue.StatusRunning, claimed.Status)
+	assert.Equal(s.t, 1, claimed.Attempts)
+	assert.Equal(s.t, "a", claimed.LockedBy)
+	require.NotNil(s.t, claimed.LockedUntil)
+	assert.True(s.t, now.Add(time.Minute).Equal(*claimed.LockedUntil))
+
+	second, ok := claim("b", now)
+	require.True(s.t, ok)
+	assert.Equal(s.t, low.ID, second.ID)
+
+	_, ok = claim("c", now)
+	assert.False(s.t, ok)
+
+	// the lock of a is lost once its visibility timeout expires and b claims the job
+	lockA := queue.Lock{Worker: "a", Attempt: 1}
+	require.NoError(s.t, s.Jobs.ExtendJob(ctx, high.ID, lockA, now.Add(2*time.Minute)))
+	require.NoError(s.t, s.Jobs.ExtendJob(ctx, low.ID, queue.Lock{Worker: "b", Attempt: 1}, later))
+
+	_, ok = claim("b", now.Add(90*time.Second))
+	assert.False(s.t, ok, "the lock was extended")
+
+	reclaimed, ok := claim("b", now.Add(3*time.Minute))
+	require.True(s.t, ok)
+	assert.Equal(s.t, high.ID, reclaimed.ID)
+	assert.Equal(s.t, 2, reclaimed.Attempts)
+
+	assert.ErrorIs(s.t, s.Jobs.ExtendJob(ctx, high.ID, lockA, later), queue.ErrLockLost)
+
+	_, err := s.Jobs.CompleteJob(ctx, high.ID, lockA, queue.Completion{Status: queue.StatusSucceeded, Now: now})
+	assert.ErrorIs(s.t, err, queue.ErrLockLost)
+
+	// a failed attempt is retried at run_at
+	lockB := queue.Lock{Worker: "b", Attempt: 2}
+	retryAt := now.Add(5 * time.Minute)
+
+	failed, err := s.Jobs.CompleteJob(ctx, high.ID, lockB, queue.Completion{
+		Status: queue.StatusQueued,
+		Error:  "timeout",
+		RunAt:  retryAt,
+		Now:    now,
+	})
+	require.NoError(s.t, err)
+	assert.Equal(s.t, queue.StatusQueued, failed.Status)
+	assert.Equal(s.t, "timeout", failed.LastError)
+	assert.Empty(s.t, failed.LockedBy)
+	assert.Nil(s.t, failed.LockedUntil)
+	assert.Nil(s.t, failed.FinishedAt)
+	assert.True(s.t, retryAt.Equal(failed.RunAt))
+
+	done, err := s.Jobs.CompleteJob(ctx, second.ID, queue.Lock{Worker: "b", Attempt: 1}, queue.Completion{
+		Status: queue.StatusSucceeded,
+		Result: []byte(`{"rows":2}`),
+		Now:    now,
+	})
+	require.NoError(s.t, err)
+	assert.Equal(s.t, queue.StatusSucceeded, done.Status)
+	assert.JSONEq(s.t, `{"rows":2}`, string(done.Result))
+	assert.NotNil(s.t, done.FinishedAt)
+
+	found, err := s.Jobs.GetJob(ctx, done.ID)
+	require.NoError(s.t, err)
+	assert.Equal(s.t, done.ID, found.ID)
+	assert.JSONEq(s.t, `{"rows":2}`, string(found.Result))
+
+	// only dead jobs are retried
+	_, err = s.Jobs.RetryJob(ctx, delayed.ID, now)
+	assert.ErrorIs(s.t, err, queue.ErrNotDead)
+
+	retried, ok := claim("a", retryAt)
+	require.True(s.t, ok)
+	assert.Equal(s.t, high.ID, retried.ID)
+
+	dead, err := s.Jobs.CompleteJob(ctx, high.ID, queue.Lock{Worker: "a", Attempt: 3}, queue.Completion{Status: queue.StatusDead, Error: "gone", Now: retryAt})
+	require.NoError(s.t, err)
+	assert.Equal(s.t, queue.StatusDead, dead.Status)
+	assert.Equal(s.t, 3, dead.Attempts)
+
+	_, ok = claim("a", later.Add(time.Hour))
+	require.True(s.t, ok, "the delayed job")
+
+	_, ok = claim("a", later.Add(time.Hour))
+	assert.False(s.t, ok, "dead jobs are not claimed")
+
+	requeued, err := s.Jobs.RetryJob(ctx, high.ID, now)
+	require.NoError(s.t, err)
+	assert.Equal(s.t, queue.StatusQueued, requeued.Status)
+	assert.Zero(s.t, requeued.Attempts)
+	assert.Nil(s.t, requeued.FinishedAt)
+
+	// jobs are scoped to their tenant
+	other := dbx.NewContextFrom(s.tenant(), s.DB)
+
+	_, err = s.Jobs.GetJob(other, high.ID)
+	assert.ErrorIs(s.t, err, common.ErrNotFound)
+
+	_, err = s.Jobs.RetryJob(other, high.ID, now)
+	assert.ErrorIs(s.t, err, common.ErrNotFound)
+}
+
+func testDisputes(s *suite) {
+	ctx := s.tenant()
+	acc := s.mustCreateAccount(ctx, "12345678900")
+	db := dbx.NewContextFrom(ctx, s.DB)
+
+	purchase, err := s.createTransaction(ctx, transactions.TransactionCreation{
+		AccountID:     acc.ID,
+		OperationType: transactions.OperationTypePurchase,
+		Amount:        -12.5,
+	})
+	require.NoError(s.t, err)
+
+	credit, err := s.createTransaction(ctx, transactions.TransactionCreation{
+		AccountID:     acc.ID,
+		OperationType: transactions.OperationTypePayment,
+		Amount:        12.5,
+	})
+	require.NoError(s.t, err)
+
+	found, err := s.Disputes.GetTransaction(db, acc.ID, purchase.ID)
+	require.NoError(s.t, err)
+	assert.Equal(s.t, transactions.OperationTypePurchase, found.OperationType)
+	assert.Equal(s.t, -12.5, found.Amount)
+
+	// transactions are looked up in the account and the tenant
+	_, err = s.Disputes.GetTransaction(db, acc.ID+1, purchase.ID)
+	assert.ErrorIs(s.t, err, common.ErrNotFound)
+
+	_, err = s.Disputes.GetTransaction(dbx.NewContextFrom(s.tenant(), s.DB), acc.ID, purchase.ID)
+	assert.ErrorIs(s.t, err, common.ErrNotFound)
+
+	create := func(ctx context.Context) (disputes.Dispute, error) {
+		return dbx.TransactionWithResult[disputes.Dispute](ctx, s.DB, func(tx dbx.Context) (disputes.Dispute, error) {
+			return s.Disputes.CreateDispute(tx, disputes.DisputeCreation{
+				AccountID:           acc.ID,
+				TransactionID:       purchase.ID,
+				CreditTransactionID: credit.ID,
+				Reason:              "not received",
+				Amount:              12.5,
+			})
+		})
+	}
+
+	issued := func(ctx context.Context, transactionID int64) bool {
+		res, err := s.Disputes.IsDisputeTransaction(dbx.NewContextFrom(ctx, s.DB), transactionID)
+		require.NoError(s.t, err)
+
+		return res
+	}
+
+	assert.False(s.t, issued(ctx, credit.ID))
+
+	created, err := create(ctx)
+	require.NoError(s.t, err)
+	assert.Equal(s.t, disputes.StatusOpened, created.Status)
+	assert.Equal(s.t, credit.ID, created.CreditTransactionID)
+	assert.Zero(s.t, created.ReversalTransactionID)
+	assert.Nil(s.t, created.ResolvedAt)
+
+	// the credit belongs to the dispute, the disputed purchase does not
+	assert.True(s.t, issued(ctx, credit.ID))
+	assert.False(s.t, issued(ctx, purchase.ID))
+	assert.False(s.t, issued(s.tenant(), credit.ID))
+
+	_, err = create(ctx)
+	assert.ErrorIs(s.t, err, common.ErrDuplicate, "a purchase is disputed once")
+
+	event := func(status disputes.Status, note string) {
+		_, err := dbx.TransactionWithResult[disputes.Event](ctx, s.DB, func(tx dbx.Context) (disputes.Event, error) {
+			return s.Disputes.AddEvent(tx, disputes.EventCreation{
+				DisputeID: created.ID,
+				Status:    status,
+				Principal: "key:1",
+				Note:      note,
+			})
+		})
+		require.NoError(s.t, err)
+	}
+
+	resolve := func(from disputes.Status, resolution disputes.Resolution) (disputes.Dispute, error) {
+		return dbx.TransactionWithResult[disputes.Dispute](ctx, s.DB, func(tx dbx.Context) (disputes.Dispute, error) {
+			return s.Disputes.ResolveDispute(tx, created.ID, from, resolution)
+		})
+	}
+
+	event(disputes.StatusOpened, "not received")
+
+	review, err := resolve(disputes.StatusOpened, disputes.Resolution{Status: disputes.StatusUnderReview})
+	require.NoError(s.t, err)
+	assert.Equal(s.t, disputes.StatusUnderReview, review.Status)
+	assert.Nil(s.t, review.ResolvedAt)
+
+	event(disputes.StatusUnderReview, "")
+
+	// the update is guarded by the current status
+	_, err = resolve(disputes.StatusOpened, disputes.Resolution{Status: disputes.StatusUnderReview})
+	assert.ErrorIs(s.t, err, disputes.ErrInvalidTransition)
+
+	reversal, err := s.createTransaction(ctx, transactions.TransactionCreation{
+		AccountID:     acc.ID,
+		OperationType: transactions.OperationTypeDisputeReversal,
+		Amount:        -12.5,
+	})
+	require.NoError(s.t, err)
+
+	lost, err := resolve(disputes.StatusUnderReview, disputes.Resolution{Status: disputes.StatusLost, ReversalTransactionID: reversal.ID})
+	require.NoError(s.t, err)
+	assert.Equal(s.t, disputes.StatusLost, lost.Status)
+	assert.Equal(s.t, reversal.ID, lost.ReversalTransactionID)
+	assert.NotNil(s.t, lost.ResolvedAt)
+	assert.True(s.t, issued(ctx, reversal.ID))
+
+	event(disputes.StatusLost, "merchant proved delivery")
+
+	got, err := s.Disputes.GetDispute(db, created.ID)
+	require.NoError(s.t, err)
+	assert.Equal(s.t, disputes.StatusLost, got.Status)
+	require.Len(s.t, got.Events, 3)
+	assert.Equal(s.t, disputes.StatusOpened, got.Events[0].Status)
+	assert.Equal(s.t, "key:1", got.Events[0].Principal)
+	assert.Empty(s.t, got.Events[1].Note)
+	assert.Equal(s.t, "merchant proved delivery", got.Events[2].Note)
+
+	_, err = s.Disputes.GetDispute(dbx.NewContextFrom(s.tenant(), s.DB), created.ID)
+	assert.ErrorIs(s.t, err, common.ErrNotFound)
+
+	list := func(filter disputes.Filter) []disputes.Dispute {
+		res, err := s.Disputes.ListDisputes(db, filter)
+		require.NoError(s.t, err)
+
+		return res
+	}
+
+	listed := list(disputes.Filter{AccountID: acc.ID})
+	require.Len(s.t, listed, 1)
+	assert.Len(s.t, listed[0].Events, 3)
+
+	assert.Len(s.t, list(disputes.Filter{AccountID: acc.ID, Status: disputes.StatusLost}), 1)
+	assert.Empty(s.t, list(disputes.Filter{AccountID: acc.ID, Status: disputes.StatusOpened}))
+	assert.Empty(s.t, list(disputes.Filter{AccountID: acc.ID + 1}))
+}
+
+func testConcurrentClaims(s *suite) {
+	ctx := dbx.NewContextFrom(s.tenant(), s.DB)
+	typ := jobType()
+	now := time.Now().UTC()
+
+	for range concurrency {
+		_, err := s.Jobs.CreateJob(ctx, queue.JobCreation{Type: typ, Payload: []byte(`{}`), MaxAttempts: 1, RunAt: &now})
+		require.NoError(s.t, err)
+	}
+
+	var mu sync.Mutex
+	claimed := make(map[int64]int)
+
+	// twice as many workers as jobs, each job is claimed by one of them
+	parallel(concurrency*2, func(i int) {
+		job, ok, err := s.Jobs.ClaimJob(ctx, queue.Claim{
+			Types:       []string{typ},
+			Worker:      fmt.Sprintf("worker-%d", i),
+			Now:         now,
+			LockedUntil: now.Add(time.Minute),
+		})
+
+		if !assert.NoError(s.t, err) || !ok {
+			return
+		}
+
+		mu.Lock()
+		claimed[job.ID]++
+		mu.Unlock()
+	})
+
+	assert.Len(s.t, claimed, concurrency)
+
+	for id, n := range claimed {
+		assert.Equal(s.t, 1, n, "job %d claimed more than once", id)
+	}
+}
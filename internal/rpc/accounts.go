@@ -0,0 +1,51 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/ziflex/rm-rf-production/internal/rpc/rmrfv1"
+	"github.com/ziflex/rm-rf-production/pkg/accounts"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// documentNumberLength mirrors the AccountCreateRequest schema of the REST API.
+const documentNumberLength = 11
+
+type accountsServer struct {
+	rmrfv1.UnimplementedAccountsServiceServer
+	accounts accounts.Service
+}
+
+func (s *accountsServer) CreateAccount(ctx context.Context, req *rmrfv1.CreateAccountRequest) (*rmrfv1.CreateAccountResponse, error) {
+	if len(req.GetDocumentNumber()) != documentNumberLength {
+		return nil, status.Errorf(codes.InvalidArgument, "document_number must be %d characters long", documentNumberLength)
+	}
+
+	acc, err := s.accounts.CreateAccount(ctx, accounts.AccountCreation{
+		DocumentNumber: req.GetDocumentNumber(),
+	})
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &rmrfv1.CreateAccountResponse{Account: toAccount(acc)}, nil
+}
+
+func (s *accountsServer) GetAccount(ctx context.Context, req *rmrfv1.GetAccountRequest) (*rmrfv1.GetAccountResponse, error) {
+	acc, err := s.accounts.GetAccountByID(ctx, req.GetAccountId())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &rmrfv1.GetAccountResponse{Account: toAccount(acc)}, nil
+}
+
+func toAccount(acc accounts.Account) *rmrfv1.Account {
+	return &rmrfv1.Account{
+		AccountId:      acc.ID,
+		DocumentNumber: acc.DocumentNumber,
+	}
+}
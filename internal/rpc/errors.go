@@ -0,0 +1,75 @@
+package rpc
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ziflex/rm-rf-production/pkg/auth"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+	"github.com/ziflex/rm-rf-production/pkg/ratelimit"
+	"github.com/ziflex/rm-rf-production/pkg/transactions"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toStatus maps service errors to gRPC statuses the same way the REST API maps them to HTTP statuses.
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, common.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, common.ErrDuplicate):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, transactions.ErrInvalidOperationType), errors.Is(err, transactions.ErrInvalidAmount),
+		errors.Is(err, transactions.ErrInvalidMerchant):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, transactions.ErrAccountBlocked):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, auth.ErrUnauthorized), errors.Is(err, common.ErrMissingTenant):
+		return status.Error(codes.Unauthenticated, "missing or invalid credentials")
+	case errors.Is(err, auth.ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, ratelimit.ErrRateLimited):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	default:
+		return status.Error(codes.Internal, "internal server error")
+	}
+}
+
+// httpStatus returns the HTTP status the REST API responds with for the same error,
+// audit entries of both APIs are comparable this way.
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists:
+		return http.StatusConflict
+	case codes.FailedPrecondition:
+		return http.StatusUnprocessableEntity
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Canceled:
+		return 499
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,256 @@
+package rpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/ziflex/rm-rf-production/pkg/audit"
+	"github.com/ziflex/rm-rf-production/pkg/auth"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+	"github.com/ziflex/rm-rf-production/pkg/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// MetadataAPIKey carries the API key, gRPC metadata keys are lower case.
+	MetadataAPIKey = "x-api-key"
+	// MetadataRequestID is read from the call and echoed back in the response header, as X-Request-ID is over HTTP.
+	MetadataRequestID = "x-request-id"
+	// MetadataRetryAfter is the number of seconds to wait before calling again, sent with ResourceExhausted.
+	MetadataRetryAfter = "retry-after"
+
+	metadataAuthorization = "authorization"
+	bearerPrefix          = "bearer "
+)
+
+type requestIDKey struct{}
+
+// logCalls assigns a request ID to every call, puts a logger with it into the context and logs the outcome.
+func logCalls(logger zerolog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		requestID := firstValue(ctx, MetadataRequestID)
+
+		if requestID == "" {
+			requestID = newRequestID()
+		}
+
+		_ = grpc.SetHeader(ctx, metadata.Pairs(MetadataRequestID, requestID))
+
+		log := logger.With().Str("request_id", requestID).Str("method", info.FullMethod).Logger()
+		ctx = context.WithValue(log.WithContext(ctx), requestIDKey{}, requestID)
+		start := time.Now()
+
+		res, err := handler(ctx, req)
+
+		code := status.Code(err)
+		evt := log.Info()
+
+		if code == codes.Internal || code == codes.Unknown {
+			evt = log.Error().Err(err)
+		}
+
+		evt.Str("code", code.String()).Dur("latency", time.Since(start)).Msg("call")
+
+		return res, err
+	}
+}
+
+// recoverPanics turns a panic in a handler into an Internal status, so that it does not crash the process.
+func recoverPanics() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (res any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				zerolog.Ctx(ctx).Error().Interface("panic", r).Bytes("stack", debug.Stack()).Msg("recovered from panic")
+				err = status.Error(codes.Internal, "internal server error")
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// authenticate resolves the caller from the API key or the bearer token metadata, checks the scope required
+// by the method and stores the principal and its tenant in the context.
+func authenticate(keys auth.Service, tokens auth.TokenVerifier) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		m, ok := methods[info.FullMethod]
+
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		secret := firstValue(ctx, MetadataAPIKey)
+		authorization := firstValue(ctx, metadataAuthorization)
+
+		var principal auth.Principal
+		var err error
+
+		switch {
+		case secret != "":
+			principal, err = keys.Authenticate(ctx, secret)
+		case len(authorization) > len(bearerPrefix) && strings.EqualFold(authorization[:len(bearerPrefix)], bearerPrefix):
+			if tokens == nil {
+				return nil, toStatus(fmt.Errorf("%w: bearer tokens are not accepted", auth.ErrUnauthorized))
+			}
+
+			principal, err = tokens.Verify(ctx, authorization[len(bearerPrefix):])
+		default:
+			return nil, status.Error(codes.Unauthenticated, "missing credentials")
+		}
+
+		if err != nil {
+			return nil, toStatus(err)
+		}
+
+		if principal.TenantID == "" {
+			return nil, toStatus(fmt.Errorf("%w: principal is not bound to a tenant", auth.ErrUnauthorized))
+		}
+
+		if !principal.HasScope(m.scope) {
+			return nil, status.Errorf(codes.PermissionDenied, "missing scope: %s", m.scope)
+		}
+
+		// every repository call made while serving the call is scoped to the principal's tenant
+		ctx = common.WithTenant(auth.WithPrincipal(ctx, principal), principal.TenantID)
+
+		return handler(ctx, req)
+	}
+}
+
+// rateLimit enforces per client budgets, keyed like the HTTP server: by the authenticated principal,
+// else by the peer address. The operation IDs are the REST ones, so a shared limiter counts both protocols together.
+func rateLimit(policy ratelimit.Policy) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		m, ok := methods[info.FullMethod]
+
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		rule := policy.Rule(m.operationID)
+
+		if rule.IsZero() {
+			return handler(ctx, req)
+		}
+
+		res, err := policy.Limiter.Allow(ctx, m.operationID+":"+clientKey(ctx), rule)
+
+		if err != nil {
+			// an unavailable limiter must not take the API down
+			zerolog.Ctx(ctx).Error().Err(err).Str("operation_id", m.operationID).Msg("failed to check rate limit")
+
+			return handler(ctx, req)
+		}
+
+		if !res.Allowed {
+			_ = grpc.SetHeader(ctx, metadata.Pairs(MetadataRetryAfter, strconv.Itoa(int(math.Ceil(res.RetryAfter.Seconds())))))
+
+			return nil, toStatus(ratelimit.ErrRateLimited)
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func clientKey(ctx context.Context) string {
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		return ratelimit.PrincipalKey(principal.TenantID, principal.ID)
+	}
+
+	p, ok := peer.FromContext(ctx)
+
+	if !ok || p.Addr == nil {
+		return "ip:unknown"
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+
+	if err != nil {
+		host = p.Addr.String()
+	}
+
+	return "ip:" + host
+}
+
+// recordAudit writes an audit entry for every state-changing call made by an authenticated principal.
+func recordAudit(log audit.Service) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		m, ok := methods[info.FullMethod]
+
+		if !ok || !m.mutating {
+			return handler(ctx, req)
+		}
+
+		principal, ok := auth.PrincipalFromContext(ctx)
+
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		var payload []byte
+
+		if msg, ok := req.(proto.Message); ok {
+			// field names match the JSON bodies of the REST API, so the same fields are redacted
+			payload, _ = protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+		}
+
+		res, err := handler(ctx, req)
+
+		outcome := audit.OutcomeSuccess
+
+		if err != nil {
+			outcome = audit.OutcomeFailure
+		}
+
+		requestID, _ := ctx.Value(requestIDKey{}).(string)
+
+		// the entry must be stored even if the client has already gone away
+		_, auditErr := log.Record(context.WithoutCancel(ctx), audit.EntryCreation{
+			TenantID:    principal.TenantID,
+			Principal:   principal.ID,
+			RequestID:   requestID,
+			OperationID: m.operationID,
+			Payload:     audit.Redact(payload, audit.SensitiveFields),
+			Outcome:     outcome,
+			Status:      httpStatus(status.Code(err)),
+		})
+
+		if auditErr != nil {
+			zerolog.Ctx(ctx).Error().Err(auditErr).Str("operation_id", m.operationID).Msg("failed to write audit entry")
+		}
+
+		return res, err
+	}
+}
+
+func firstValue(ctx context.Context, key string) string {
+	values := metadata.ValueFromIncomingContext(ctx, key)
+
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
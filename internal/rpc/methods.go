@@ -0,0 +1,33 @@
+package rpc
+
+import (
+	"github.com/ziflex/rm-rf-production/internal/rpc/rmrfv1"
+	"github.com/ziflex/rm-rf-production/pkg/auth"
+)
+
+// method describes an API method. Operation IDs match the REST operations,
+// so the audit log and the logs do not depend on the protocol.
+type method struct {
+	operationID string
+	scope       auth.Scope
+	mutating    bool
+}
+
+// methods lists every API method by its full name. Methods that are not listed, e.g. health and reflection,
+// do not require credentials.
+var methods = map[string]method{
+	rmrfv1.AccountsService_CreateAccount_FullMethodName: {
+		operationID: "createAccount",
+		scope:       auth.ScopeAccountsWrite,
+		mutating:    true,
+	},
+	rmrfv1.AccountsService_GetAccount_FullMethodName: {
+		operationID: "getAccount",
+		scope:       auth.ScopeAccountsRead,
+	},
+	rmrfv1.TransactionsService_CreateTransaction_FullMethodName: {
+		operationID: "createTransaction",
+		scope:       auth.ScopeTransactionsWrite,
+		mutating:    true,
+	},
+}
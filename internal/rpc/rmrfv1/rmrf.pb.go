@@ -0,0 +1,716 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: rmrf/v1/rmrf.proto
+
+package rmrfv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type OperationType int32
+
+const (
+	OperationType_OPERATION_TYPE_UNSPECIFIED          OperationType = 0
+	OperationType_OPERATION_TYPE_PURCHASE             OperationType = 1
+	OperationType_OPERATION_TYPE_INSTALLMENT_PURCHASE OperationType = 2
+	OperationType_OPERATION_TYPE_WITHDRAWAL           OperationType = 3
+	OperationType_OPERATION_TYPE_PAYMENT              OperationType = 4
+)
+
+// Enum value maps for OperationType.
+var (
+	OperationType_name = map[int32]string{
+		0: "OPERATION_TYPE_UNSPECIFIED",
+		1: "OPERATION_TYPE_PURCHASE",
+		2: "OPERATION_TYPE_INSTALLMENT_PURCHASE",
+		3: "OPERATION_TYPE_WITHDRAWAL",
+		4: "OPERATION_TYPE_PAYMENT",
+	}
+	OperationType_value = map[string]int32{
+		"OPERATION_TYPE_UNSPECIFIED":          0,
+		"OPERATION_TYPE_PURCHASE":             1,
+		"OPERATION_TYPE_INSTALLMENT_PURCHASE": 2,
+		"OPERATION_TYPE_WITHDRAWAL":           3,
+		"OPERATION_TYPE_PAYMENT":              4,
+	}
+)
+
+func (x OperationType) Enum() *OperationType {
+	p := new(OperationType)
+	*p = x
+	return p
+}
+
+func (x OperationType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (OperationType) Descriptor() protoreflect.EnumDescriptor {
+	return file_rmrf_v1_rmrf_proto_enumTypes[0].Descriptor()
+}
+
+func (OperationType) Type() protoreflect.EnumType {
+	return &file_rmrf_v1_rmrf_proto_enumTypes[0]
+}
+
+func (x OperationType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use OperationType.Descriptor instead.
+func (OperationType) EnumDescriptor() ([]byte, []int) {
+	return file_rmrf_v1_rmrf_proto_rawDescGZIP(), []int{0}
+}
+
+type Account struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	AccountId      int64                  `protobuf:"varint,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	DocumentNumber string                 `protobuf:"bytes,2,opt,name=document_number,json=documentNumber,proto3" json:"document_number,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Account) Reset() {
+	*x = Account{}
+	mi := &file_rmrf_v1_rmrf_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Account) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Account) ProtoMessage() {}
+
+func (x *Account) ProtoReflect() protoreflect.Message {
+	mi := &file_rmrf_v1_rmrf_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Account.ProtoReflect.Descriptor instead.
+func (*Account) Descriptor() ([]byte, []int) {
+	return file_rmrf_v1_rmrf_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Account) GetAccountId() int64 {
+	if x != nil {
+		return x.AccountId
+	}
+	return 0
+}
+
+func (x *Account) GetDocumentNumber() string {
+	if x != nil {
+		return x.DocumentNumber
+	}
+	return ""
+}
+
+type Transaction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TransactionId int64                  `protobuf:"varint,1,opt,name=transaction_id,json=transactionId,proto3" json:"transaction_id,omitempty"`
+	AccountId     int64                  `protobuf:"varint,2,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	OperationType OperationType          `protobuf:"varint,3,opt,name=operation_type,json=operationType,proto3,enum=rmrf.v1.OperationType" json:"operation_type,omitempty"`
+	Amount        float64                `protobuf:"fixed64,4,opt,name=amount,proto3" json:"amount,omitempty"`
+	EventDate     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=event_date,json=eventDate,proto3" json:"event_date,omitempty"`
+	// Set for the transactions recorded with a merchant.
+	Merchant      *Merchant `protobuf:"bytes,6,opt,name=merchant,proto3" json:"merchant,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Transaction) Reset() {
+	*x = Transaction{}
+	mi := &file_rmrf_v1_rmrf_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transaction) ProtoMessage() {}
+
+func (x *Transaction) ProtoReflect() protoreflect.Message {
+	mi := &file_rmrf_v1_rmrf_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transaction.ProtoReflect.Descriptor instead.
+func (*Transaction) Descriptor() ([]byte, []int) {
+	return file_rmrf_v1_rmrf_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Transaction) GetTransactionId() int64 {
+	if x != nil {
+		return x.TransactionId
+	}
+	return 0
+}
+
+func (x *Transaction) GetAccountId() int64 {
+	if x != nil {
+		return x.AccountId
+	}
+	return 0
+}
+
+func (x *Transaction) GetOperationType() OperationType {
+	if x != nil {
+		return x.OperationType
+	}
+	return OperationType_OPERATION_TYPE_UNSPECIFIED
+}
+
+func (x *Transaction) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *Transaction) GetEventDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EventDate
+	}
+	return nil
+}
+
+func (x *Transaction) GetMerchant() *Merchant {
+	if x != nil {
+		return x.Merchant
+	}
+	return nil
+}
+
+// Merchant of a purchase, installment purchase or withdrawal.
+// Merchants are shared by the transactions of the tenant, the details sent with a transaction
+// update the merchant and details left empty keep their known value.
+type Merchant struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Merchant identifier at the card processor, required, at most 64 characters.
+	Id   string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	City string `protobuf:"bytes,3,opt,name=city,proto3" json:"city,omitempty"`
+	// ISO 3166-1 alpha-2 country code.
+	Country string `protobuf:"bytes,4,opt,name=country,proto3" json:"country,omitempty"`
+	// ISO 18245 merchant category code, 4 digits.
+	Mcc           string `protobuf:"bytes,5,opt,name=mcc,proto3" json:"mcc,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Merchant) Reset() {
+	*x = Merchant{}
+	mi := &file_rmrf_v1_rmrf_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Merchant) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Merchant) ProtoMessage() {}
+
+func (x *Merchant) ProtoReflect() protoreflect.Message {
+	mi := &file_rmrf_v1_rmrf_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Merchant.ProtoReflect.Descriptor instead.
+func (*Merchant) Descriptor() ([]byte, []int) {
+	return file_rmrf_v1_rmrf_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Merchant) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Merchant) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Merchant) GetCity() string {
+	if x != nil {
+		return x.City
+	}
+	return ""
+}
+
+func (x *Merchant) GetCountry() string {
+	if x != nil {
+		return x.Country
+	}
+	return ""
+}
+
+func (x *Merchant) GetMcc() string {
+	if x != nil {
+		return x.Mcc
+	}
+	return ""
+}
+
+type CreateAccountRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 11 characters, unique within the tenant.
+	DocumentNumber string `protobuf:"bytes,1,opt,name=document_number,json=documentNumber,proto3" json:"document_number,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CreateAccountRequest) Reset() {
+	*x = CreateAccountRequest{}
+	mi := &file_rmrf_v1_rmrf_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateAccountRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateAccountRequest) ProtoMessage() {}
+
+func (x *CreateAccountRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rmrf_v1_rmrf_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateAccountRequest.ProtoReflect.Descriptor instead.
+func (*CreateAccountRequest) Descriptor() ([]byte, []int) {
+	return file_rmrf_v1_rmrf_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CreateAccountRequest) GetDocumentNumber() string {
+	if x != nil {
+		return x.DocumentNumber
+	}
+	return ""
+}
+
+type CreateAccountResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Account       *Account               `protobuf:"bytes,1,opt,name=account,proto3" json:"account,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateAccountResponse) Reset() {
+	*x = CreateAccountResponse{}
+	mi := &file_rmrf_v1_rmrf_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateAccountResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateAccountResponse) ProtoMessage() {}
+
+func (x *CreateAccountResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rmrf_v1_rmrf_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateAccountResponse.ProtoReflect.Descriptor instead.
+func (*CreateAccountResponse) Descriptor() ([]byte, []int) {
+	return file_rmrf_v1_rmrf_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CreateAccountResponse) GetAccount() *Account {
+	if x != nil {
+		return x.Account
+	}
+	return nil
+}
+
+type GetAccountRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccountId     int64                  `protobuf:"varint,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAccountRequest) Reset() {
+	*x = GetAccountRequest{}
+	mi := &file_rmrf_v1_rmrf_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAccountRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAccountRequest) ProtoMessage() {}
+
+func (x *GetAccountRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rmrf_v1_rmrf_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAccountRequest.ProtoReflect.Descriptor instead.
+func (*GetAccountRequest) Descriptor() ([]byte, []int) {
+	return file_rmrf_v1_rmrf_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetAccountRequest) GetAccountId() int64 {
+	if x != nil {
+		return x.AccountId
+	}
+	return 0
+}
+
+type GetAccountResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Account       *Account               `protobuf:"bytes,1,opt,name=account,proto3" json:"account,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAccountResponse) Reset() {
+	*x = GetAccountResponse{}
+	mi := &file_rmrf_v1_rmrf_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAccountResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAccountResponse) ProtoMessage() {}
+
+func (x *GetAccountResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rmrf_v1_rmrf_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAccountResponse.ProtoReflect.Descriptor instead.
+func (*GetAccountResponse) Descriptor() ([]byte, []int) {
+	return file_rmrf_v1_rmrf_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetAccountResponse) GetAccount() *Account {
+	if x != nil {
+		return x.Account
+	}
+	return nil
+}
+
+type CreateTransactionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccountId     int64                  `protobuf:"varint,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	OperationType OperationType          `protobuf:"varint,2,opt,name=operation_type,json=operationType,proto3,enum=rmrf.v1.OperationType" json:"operation_type,omitempty"`
+	// Positive, the sign is derived from the operation type.
+	Amount float64 `protobuf:"fixed64,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	// Optional.
+	Merchant      *Merchant `protobuf:"bytes,4,opt,name=merchant,proto3" json:"merchant,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateTransactionRequest) Reset() {
+	*x = CreateTransactionRequest{}
+	mi := &file_rmrf_v1_rmrf_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateTransactionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateTransactionRequest) ProtoMessage() {}
+
+func (x *CreateTransactionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rmrf_v1_rmrf_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateTransactionRequest.ProtoReflect.Descriptor instead.
+func (*CreateTransactionRequest) Descriptor() ([]byte, []int) {
+	return file_rmrf_v1_rmrf_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CreateTransactionRequest) GetAccountId() int64 {
+	if x != nil {
+		return x.AccountId
+	}
+	return 0
+}
+
+func (x *CreateTransactionRequest) GetOperationType() OperationType {
+	if x != nil {
+		return x.OperationType
+	}
+	return OperationType_OPERATION_TYPE_UNSPECIFIED
+}
+
+func (x *CreateTransactionRequest) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *CreateTransactionRequest) GetMerchant() *Merchant {
+	if x != nil {
+		return x.Merchant
+	}
+	return nil
+}
+
+type CreateTransactionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Transaction   *Transaction           `protobuf:"bytes,1,opt,name=transaction,proto3" json:"transaction,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateTransactionResponse) Reset() {
+	*x = CreateTransactionResponse{}
+	mi := &file_rmrf_v1_rmrf_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateTransactionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateTransactionResponse) ProtoMessage() {}
+
+func (x *CreateTransactionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rmrf_v1_rmrf_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateTransactionResponse.ProtoReflect.Descriptor instead.
+func (*CreateTransactionResponse) Descriptor() ([]byte, []int) {
+	return file_rmrf_v1_rmrf_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *CreateTransactionResponse) GetTransaction() *Transaction {
+	if x != nil {
+		return x.Transaction
+	}
+	return nil
+}
+
+var File_rmrf_v1_rmrf_proto protoreflect.FileDescriptor
+
+const file_rmrf_v1_rmrf_proto_rawDesc = "" +
+	"\n" +
+	"\x12rmrf/v1/rmrf.proto\x12\armrf.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"Q\n" +
+	"\aAccount\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\x03R\taccountId\x12'\n" +
+	"\x0fdocument_number\x18\x02 \x01(\tR\x0edocumentNumber\"\x94\x02\n" +
+	"\vTransaction\x12%\n" +
+	"\x0etransaction_id\x18\x01 \x01(\x03R\rtransactionId\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x02 \x01(\x03R\taccountId\x12=\n" +
+	"\x0eoperation_type\x18\x03 \x01(\x0e2\x16.rmrf.v1.OperationTypeR\roperationType\x12\x16\n" +
+	"\x06amount\x18\x04 \x01(\x01R\x06amount\x129\n" +
+	"\n" +
+	"event_date\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\teventDate\x12-\n" +
+	"\bmerchant\x18\x06 \x01(\v2\x11.rmrf.v1.MerchantR\bmerchant\"n\n" +
+	"\bMerchant\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04city\x18\x03 \x01(\tR\x04city\x12\x18\n" +
+	"\acountry\x18\x04 \x01(\tR\acountry\x12\x10\n" +
+	"\x03mcc\x18\x05 \x01(\tR\x03mcc\"?\n" +
+	"\x14CreateAccountRequest\x12'\n" +
+	"\x0fdocument_number\x18\x01 \x01(\tR\x0edocumentNumber\"C\n" +
+	"\x15CreateAccountResponse\x12*\n" +
+	"\aaccount\x18\x01 \x01(\v2\x10.rmrf.v1.AccountR\aaccount\"2\n" +
+	"\x11GetAccountRequest\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\x03R\taccountId\"@\n" +
+	"\x12GetAccountResponse\x12*\n" +
+	"\aaccount\x18\x01 \x01(\v2\x10.rmrf.v1.AccountR\aaccount\"\xbf\x01\n" +
+	"\x18CreateTransactionRequest\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\x03R\taccountId\x12=\n" +
+	"\x0eoperation_type\x18\x02 \x01(\x0e2\x16.rmrf.v1.OperationTypeR\roperationType\x12\x16\n" +
+	"\x06amount\x18\x03 \x01(\x01R\x06amount\x12-\n" +
+	"\bmerchant\x18\x04 \x01(\v2\x11.rmrf.v1.MerchantR\bmerchant\"S\n" +
+	"\x19CreateTransactionResponse\x126\n" +
+	"\vtransaction\x18\x01 \x01(\v2\x14.rmrf.v1.TransactionR\vtransaction*\xb0\x01\n" +
+	"\rOperationType\x12\x1e\n" +
+	"\x1aOPERATION_TYPE_UNSPECIFIED\x10\x00\x12\x1b\n" +
+	"\x17OPERATION_TYPE_PURCHASE\x10\x01\x12'\n" +
+	"#OPERATION_TYPE_INSTALLMENT_PURCHASE\x10\x02\x12\x1d\n" +
+	"\x19OPERATION_TYPE_WITHDRAWAL\x10\x03\x12\x1a\n" +
+	"\x16OPERATION_TYPE_PAYMENT\x10\x042\xa8\x01\n" +
+	"\x0fAccountsService\x12N\n" +
+	"\rCreateAccount\x12\x1d.rmrf.v1.CreateAccountRequest\x1a\x1e.rmrf.v1.CreateAccountResponse\x12E\n" +
+	"\n" +
+	"GetAccount\x12\x1a.rmrf.v1.GetAccountRequest\x1a\x1b.rmrf.v1.GetAccountResponse2q\n" +
+	"\x13TransactionsService\x12Z\n" +
+	"\x11CreateTransaction\x12!.rmrf.v1.CreateTransactionRequest\x1a\".rmrf.v1.CreateTransactionResponseB?Z=github.com/ziflex/rm-rf-production/internal/rpc/rmrfv1;rmrfv1b\x06proto3"
+
+var (
+	file_rmrf_v1_rmrf_proto_rawDescOnce sync.Once
+	file_rmrf_v1_rmrf_proto_rawDescData []byte
+)
+
+func file_rmrf_v1_rmrf_proto_rawDescGZIP() []byte {
+	file_rmrf_v1_rmrf_proto_rawDescOnce.Do(func() {
+		file_rmrf_v1_rmrf_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_rmrf_v1_rmrf_proto_rawDesc), len(file_rmrf_v1_rmrf_proto_rawDesc)))
+	})
+	return file_rmrf_v1_rmrf_proto_rawDescData
+}
+
+var file_rmrf_v1_rmrf_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_rmrf_v1_rmrf_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_rmrf_v1_rmrf_proto_goTypes = []any{
+	(OperationType)(0),                // 0: rmrf.v1.OperationType
+	(*Account)(nil),                   // 1: rmrf.v1.Account
+	(*Transaction)(nil),               // 2: rmrf.v1.Transaction
+	(*Merchant)(nil),                  // 3: rmrf.v1.Merchant
+	(*CreateAccountRequest)(nil),      // 4: rmrf.v1.CreateAccountRequest
+	(*CreateAccountResponse)(nil),     // 5: rmrf.v1.CreateAccountResponse
+	(*GetAccountRequest)(nil),         // 6: rmrf.v1.GetAccountRequest
+	(*GetAccountResponse)(nil),        // 7: rmrf.v1.GetAccountResponse
+	(*CreateTransactionRequest)(nil),  // 8: rmrf.v1.CreateTransactionRequest
+	(*CreateTransactionResponse)(nil), // 9: rmrf.v1.CreateTransactionResponse
+	(*timestamppb.Timestamp)(nil),     // 10: google.protobuf.Timestamp
+}
+var file_rmrf_v1_rmrf_proto_depIdxs = []int32{
+	0,  // 0: rmrf.v1.Transaction.operation_type:type_name -> rmrf.v1.OperationType
+	10, // 1: rmrf.v1.Transaction.event_date:type_name -> google.protobuf.Timestamp
+	3,  // 2: rmrf.v1.Transaction.merchant:type_name -> rmrf.v1.Merchant
+	1,  // 3: rmrf.v1.CreateAccountResponse.account:type_name -> rmrf.v1.Account
+	1,  // 4: rmrf.v1.GetAccountResponse.account:type_name -> rmrf.v1.Account
+	0,  // 5: rmrf.v1.CreateTransactionRequest.operation_type:type_name -> rmrf.v1.OperationType
+	3,  // 6: rmrf.v1.CreateTransactionRequest.merchant:type_name -> rmrf.v1.Merchant
+	2,  // 7: rmrf.v1.CreateTransactionResponse.transaction:type_name -> rmrf.v1.Transaction
+	4,  // 8: rmrf.v1.AccountsService.CreateAccount:input_type -> rmrf.v1.CreateAccountRequest
+	6,  // 9: rmrf.v1.AccountsService.GetAccount:input_type -> rmrf.v1.GetAccountRequest
+	8,  // 10: rmrf.v1.TransactionsService.CreateTransaction:input_type -> rmrf.v1.CreateTransactionRequest
+	5,  // 11: rmrf.v1.AccountsService.CreateAccount:output_type -> rmrf.v1.CreateAccountResponse
+	7,  // 12: rmrf.v1.AccountsService.GetAccount:output_type -> rmrf.v1.GetAccountResponse
+	9,  // 13: rmrf.v1.TransactionsService.CreateTransaction:output_type -> rmrf.v1.CreateTransactionResponse
+	11, // [11:14] is the sub-list for method output_type
+	8,  // [8:11] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_rmrf_v1_rmrf_proto_init() }
+func file_rmrf_v1_rmrf_proto_init() {
+	if File_rmrf_v1_rmrf_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_rmrf_v1_rmrf_proto_rawDesc), len(file_rmrf_v1_rmrf_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   2,
+		},
+		GoTypes:           file_rmrf_v1_rmrf_proto_goTypes,
+		DependencyIndexes: file_rmrf_v1_rmrf_proto_depIdxs,
+		EnumInfos:         file_rmrf_v1_rmrf_proto_enumTypes,
+		MessageInfos:      file_rmrf_v1_rmrf_proto_msgTypes,
+	}.Build()
+	File_rmrf_v1_rmrf_proto = out.File
+	file_rmrf_v1_rmrf_proto_goTypes = nil
+	file_rmrf_v1_rmrf_proto_depIdxs = nil
+}
@@ -0,0 +1,122 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/rs/zerolog"
+	"github.com/ziflex/rm-rf-production/internal/rpc/rmrfv1"
+	"github.com/ziflex/rm-rf-production/pkg/accounts"
+	"github.com/ziflex/rm-rf-production/pkg/audit"
+	"github.com/ziflex/rm-rf-production/pkg/auth"
+	"github.com/ziflex/rm-rf-production/pkg/ratelimit"
+	"github.com/ziflex/rm-rf-production/pkg/transactions"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+type (
+	// Server serves the gRPC API on top of the same services as the REST API.
+	Server struct {
+		grpc   *grpc.Server
+		health *health.Server
+	}
+
+	Options struct {
+		Logger zerolog.Logger
+		Auth   auth.Service
+		// Tokens enables bearer token authentication when set.
+		Tokens auth.TokenVerifier
+		// RateLimit enables per client rate limiting when set. Sharing the policy of the HTTP server
+		// gives a client the same budgets over both protocols.
+		RateLimit *ratelimit.Policy
+		// Audit records state-changing calls when set.
+		Audit audit.Service
+	}
+)
+
+func NewServer(accounts accounts.Service, transactions transactions.Service, opts Options) (*Server, error) {
+	if opts.Auth == nil {
+		return nil, fmt.Errorf("auth service is required")
+	}
+
+	interceptors := []grpc.UnaryServerInterceptor{
+		logCalls(opts.Logger),
+		authenticate(opts.Auth, opts.Tokens),
+	}
+
+	if opts.RateLimit != nil {
+		if opts.RateLimit.Limiter == nil {
+			return nil, fmt.Errorf("rate limiter is required when rate limiting is enabled")
+		}
+
+		interceptors = append(interceptors, rateLimit(*opts.RateLimit))
+	}
+
+	if opts.Audit != nil {
+		interceptors = append(interceptors, recordAudit(opts.Audit))
+	}
+
+	// innermost, so that a panic is audited as a failed call
+	interceptors = append(interceptors, recoverPanics())
+
+	svr := &Server{
+		grpc:   grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...)),
+		health: health.NewServer(),
+	}
+
+	rmrfv1.RegisterAccountsServiceServer(svr.grpc, &accountsServer{accounts: accounts})
+	rmrfv1.RegisterTransactionsServiceServer(svr.grpc, &transactionsServer{transactions: transactions})
+	healthpb.RegisterHealthServer(svr.grpc, svr.health)
+	reflection.Register(svr.grpc)
+
+	for name := range svr.grpc.GetServiceInfo() {
+		svr.health.SetServingStatus(name, healthpb.HealthCheckResponse_SERVING)
+	}
+
+	return svr, nil
+}
+
+func (svr *Server) Run(port int) error {
+	lis, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", port))
+
+	if err != nil {
+		return err
+	}
+
+	return svr.Serve(lis)
+}
+
+func (svr *Server) Serve(lis net.Listener) error {
+	return svr.grpc.Serve(lis)
+}
+
+// Drain reports every service as not serving, while the server keeps serving the calls that still arrive.
+func (svr *Server) Drain() {
+	svr.health.Shutdown()
+}
+
+// Shutdown stops accepting new connections and waits for in-flight calls to complete
+// until the context is done, then closes the remaining connections.
+func (svr *Server) Shutdown(ctx context.Context) error {
+	svr.Drain()
+
+	done := make(chan struct{})
+
+	go func() {
+		svr.grpc.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		svr.grpc.Stop()
+
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,273 @@
+package rpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziflex/rm-rf-production/internal/memory"
+	"github.com/ziflex/rm-rf-production/internal/rpc"
+	"github.com/ziflex/rm-rf-production/internal/rpc/rmrfv1"
+	"github.com/ziflex/rm-rf-production/pkg/accounts"
+	"github.com/ziflex/rm-rf-production/pkg/audit"
+	"github.com/ziflex/rm-rf-production/pkg/auth"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+	"github.com/ziflex/rm-rf-production/pkg/ratelimit"
+	"github.com/ziflex/rm-rf-production/pkg/transactions"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+type testEnv struct {
+	server       *rpc.Server
+	conn         *grpc.ClientConn
+	accounts     rmrfv1.AccountsServiceClient
+	transactions rmrfv1.TransactionsServiceClient
+	audit        audit.Service
+	keys         auth.Service
+}
+
+func newTestEnv(t *testing.T, setters ...func(opts *rpc.Options)) *testEnv {
+	store := memory.NewStore()
+	db := store.DB()
+	keys := auth.NewService(db, memory.NewAPIKeysRepository(store))
+	auditLog := audit.NewService(db, memory.NewAuditRepository(store))
+
+	opts := rpc.Options{Logger: zerolog.Nop(), Auth: keys, Audit: auditLog}
+
+	for _, setter := range setters {
+		setter(&opts)
+	}
+
+	svr, err := rpc.NewServer(
+		accounts.NewService(db, memory.NewAccountsRepository(store)),
+		transactions.NewService(db, memory.NewTransactionsRepository(store), transactions.Options{}),
+		opts,
+	)
+	require.NoError(t, err)
+
+	lis := bufconn.Listen(1 << 20)
+
+	go svr.Serve(lis)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		conn.Close()
+		svr.Shutdown(context.Background())
+	})
+
+	return &testEnv{
+		server:       svr,
+		conn:         conn,
+		accounts:     rmrfv1.NewAccountsServiceClient(conn),
+		transactions: rmrfv1.NewTransactionsServiceClient(conn),
+		audit:        auditLog,
+		keys:         keys,
+	}
+}
+
+// withKey returns a context carrying a new API key with the given scopes.
+func (env *testEnv) withKey(t *testing.T, scopes ...auth.Scope) context.Context {
+	key, err := env.keys.CreateAPIKey(context.Background(), auth.APIKeyCreation{
+		TenantID: memory.DefaultTenant,
+		Name:     "test",
+		Scopes:   scopes,
+	})
+	require.NoError(t, err)
+
+	return metadata.AppendToOutgoingContext(context.Background(), rpc.MetadataAPIKey, key.Secret)
+}
+
+func TestServer_Accounts(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := env.withKey(t, auth.Scopes()...)
+
+	created, err := env.accounts.CreateAccount(ctx, &rmrfv1.CreateAccountRequest{DocumentNumber: "12345678900"})
+	require.NoError(t, err)
+	assert.Equal(t, "12345678900", created.GetAccount().GetDocumentNumber())
+
+	found, err := env.accounts.GetAccount(ctx, &rmrfv1.GetAccountRequest{AccountId: created.GetAccount().GetAccountId()})
+	require.NoError(t, err)
+	assert.Equal(t, created.GetAccount().GetAccountId(), found.GetAccount().GetAccountId())
+
+	_, err = env.accounts.CreateAccount(ctx, &rmrfv1.CreateAccountRequest{DocumentNumber: "12345678900"})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	_, err = env.accounts.CreateAccount(ctx, &rmrfv1.CreateAccountRequest{DocumentNumber: "123"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = env.accounts.GetAccount(ctx, &rmrfv1.GetAccountRequest{AccountId: 999})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestServer_Transactions(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := env.withKey(t, auth.Scopes()...)
+
+	acc, err := env.accounts.CreateAccount(ctx, &rmrfv1.CreateAccountRequest{DocumentNumber: "12345678900"})
+	require.NoError(t, err)
+
+	id := acc.GetAccount().GetAccountId()
+
+	res, err := env.transactions.CreateTransaction(ctx, &rmrfv1.CreateTransactionRequest{
+		AccountId:     id,
+		OperationType: rmrfv1.OperationType_OPERATION_TYPE_PURCHASE,
+		Amount:        123.45,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, -123.45, res.GetTransaction().GetAmount())
+	assert.Equal(t, rmrfv1.OperationType_OPERATION_TYPE_PURCHASE, res.GetTransaction().GetOperationType())
+	assert.False(t, res.GetTransaction().GetEventDate().AsTime().IsZero())
+	assert.Nil(t, res.GetTransaction().GetMerchant())
+
+	merchant := &rmrfv1.Merchant{Id: "MER-0042", Name: "Corner Cafe", City: "Lisbon", Country: "PT", Mcc: "5814"}
+	res, err = env.transactions.CreateTransaction(ctx, &rmrfv1.CreateTransactionRequest{
+		AccountId:     id,
+		OperationType: rmrfv1.OperationType_OPERATION_TYPE_PURCHASE,
+		Amount:        10,
+		Merchant:      merchant,
+	})
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(merchant, res.GetTransaction().GetMerchant()))
+
+	testCases := []struct {
+		Name    string
+		Request *rmrfv1.CreateTransactionRequest
+		Code    codes.Code
+	}{
+		{"missing account", &rmrfv1.CreateTransactionRequest{AccountId: id + 1, OperationType: rmrfv1.OperationType_OPERATION_TYPE_PAYMENT, Amount: 1}, codes.NotFound},
+		{"unspecified operation", &rmrfv1.CreateTransactionRequest{AccountId: id, Amount: 1}, codes.InvalidArgument},
+		{"negative amount", &rmrfv1.CreateTransactionRequest{AccountId: id, OperationType: rmrfv1.OperationType_OPERATION_TYPE_PAYMENT, Amount: -1}, codes.InvalidArgument},
+		{"invalid account", &rmrfv1.CreateTransactionRequest{OperationType: rmrfv1.OperationType_OPERATION_TYPE_PAYMENT, Amount: 1}, codes.InvalidArgument},
+		{"merchant without id", &rmrfv1.CreateTransactionRequest{AccountId: id, OperationType: rmrfv1.OperationType_OPERATION_TYPE_PURCHASE, Amount: 1, Merchant: &rmrfv1.Merchant{Name: "Corner Cafe"}}, codes.InvalidArgument},
+		{"invalid mcc", &rmrfv1.CreateTransactionRequest{AccountId: id, OperationType: rmrfv1.OperationType_OPERATION_TYPE_PURCHASE, Amount: 1, Merchant: &rmrfv1.Merchant{Id: "MER-0042", Mcc: "58"}}, codes.InvalidArgument},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			_, err := env.transactions.CreateTransaction(ctx, tc.Request)
+			assert.Equal(t, tc.Code, status.Code(err))
+		})
+	}
+}
+
+func TestServer_Auth(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, err := env.accounts.GetAccount(context.Background(), &rmrfv1.GetAccountRequest{AccountId: 1})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	invalid := metadata.AppendToOutgoingContext(context.Background(), rpc.MetadataAPIKey, "rrp_invalid")
+	_, err = env.accounts.GetAccount(invalid, &rmrfv1.GetAccountRequest{AccountId: 1})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	readOnly := env.withKey(t, auth.ScopeAccountsRead)
+	_, err = env.accounts.CreateAccount(readOnly, &rmrfv1.CreateAccountRequest{DocumentNumber: "12345678900"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	env := newTestEnv(t, func(opts *rpc.Options) {
+		opts.RateLimit = &ratelimit.Policy{
+			Limiter: ratelimit.NewMemoryLimiter(ratelimit.MemoryOptions{}),
+			Operations: map[string]ratelimit.Rule{
+				"createAccount": {Limit: 1, Period: time.Minute},
+			},
+		}
+	})
+	ctx := env.withKey(t, auth.Scopes()...)
+
+	_, err := env.accounts.CreateAccount(ctx, &rmrfv1.CreateAccountRequest{DocumentNumber: "12345678900"})
+	require.NoError(t, err)
+
+	var header metadata.MD
+	_, err = env.accounts.CreateAccount(ctx, &rmrfv1.CreateAccountRequest{DocumentNumber: "12345678901"}, grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.NotEmpty(t, header.Get(rpc.MetadataRetryAfter))
+
+	// operations without a rule are not limited
+	_, err = env.accounts.GetAccount(ctx, &rmrfv1.GetAccountRequest{AccountId: 1})
+	assert.NoError(t, err)
+
+	// every principal has a budget of its own
+	_, err = env.accounts.CreateAccount(env.withKey(t, auth.Scopes()...), &rmrfv1.CreateAccountRequest{DocumentNumber: "12345678901"})
+	assert.NoError(t, err)
+}
+
+func TestServer_Audit(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := env.withKey(t, auth.Scopes()...)
+
+	_, err := env.accounts.CreateAccount(ctx, &rmrfv1.CreateAccountRequest{DocumentNumber: "12345678900"})
+	require.NoError(t, err)
+
+	_, err = env.accounts.CreateAccount(ctx, &rmrfv1.CreateAccountRequest{DocumentNumber: "12345678900"})
+	require.Error(t, err)
+
+	// reads are not audited
+	_, err = env.accounts.GetAccount(ctx, &rmrfv1.GetAccountRequest{AccountId: 1})
+	require.NoError(t, err)
+
+	entries, err := env.audit.ListEntries(common.WithTenant(context.Background(), memory.DefaultTenant), audit.Filter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, "createAccount", entries[0].OperationID)
+	assert.Equal(t, audit.OutcomeFailure, entries[0].Outcome)
+	assert.Equal(t, 409, entries[0].Status)
+	assert.Equal(t, audit.OutcomeSuccess, entries[1].Outcome)
+	assert.Equal(t, 200, entries[1].Status)
+	assert.NotEmpty(t, entries[1].RequestID)
+	assert.NotContains(t, string(entries[1].Payload), "12345678900")
+}
+
+func TestServer_HealthAndReflection(t *testing.T) {
+	env := newTestEnv(t)
+	client := healthpb.NewHealthClient(env.conn)
+
+	res, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "rmrf.v1.AccountsService"})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, res.GetStatus())
+
+	stream, err := reflectionpb.NewServerReflectionClient(env.conn).ServerReflectionInfo(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}))
+
+	list, err := stream.Recv()
+	require.NoError(t, err)
+
+	var names []string
+
+	for _, svc := range list.GetListServicesResponse().GetService() {
+		names = append(names, svc.GetName())
+	}
+
+	assert.Contains(t, names, "rmrf.v1.AccountsService")
+	assert.Contains(t, names, "rmrf.v1.TransactionsService")
+
+	env.server.Drain()
+
+	res, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, res.GetStatus())
+}
@@ -0,0 +1,75 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/ziflex/rm-rf-production/internal/rpc/rmrfv1"
+	"github.com/ziflex/rm-rf-production/pkg/transactions"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type transactionsServer struct {
+	rmrfv1.UnimplementedTransactionsServiceServer
+	transactions transactions.Service
+}
+
+func (s *transactionsServer) CreateTransaction(ctx context.Context, req *rmrfv1.CreateTransactionRequest) (*rmrfv1.CreateTransactionResponse, error) {
+	if req.GetAccountId() < 1 {
+		return nil, status.Error(codes.InvalidArgument, "account_id must be positive")
+	}
+
+	// enum values match the operation type ids of the REST API
+	tx, err := s.transactions.CreateTransaction(ctx, transactions.TransactionCreation{
+		AccountID:     req.GetAccountId(),
+		OperationType: transactions.NewOperationType(int(req.GetOperationType())),
+		Amount:        req.GetAmount(),
+		Merchant:      fromMerchant(req.GetMerchant()),
+	})
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &rmrfv1.CreateTransactionResponse{Transaction: toTransaction(tx)}, nil
+}
+
+func toTransaction(tx transactions.Transaction) *rmrfv1.Transaction {
+	return &rmrfv1.Transaction{
+		TransactionId: tx.ID,
+		AccountId:     tx.AccountID,
+		OperationType: rmrfv1.OperationType(tx.OperationType),
+		Amount:        tx.Amount,
+		EventDate:     timestamppb.New(tx.EventDate),
+		Merchant:      toMerchant(tx.Merchant),
+	}
+}
+
+func toMerchant(m *transactions.Merchant) *rmrfv1.Merchant {
+	if m == nil {
+		return nil
+	}
+
+	return &rmrfv1.Merchant{
+		Id:      m.ID,
+		Name:    m.Name,
+		City:    m.City,
+		Country: m.Country,
+		Mcc:     m.MCC,
+	}
+}
+
+func fromMerchant(m *rmrfv1.Merchant) *transactions.Merchant {
+	if m == nil {
+		return nil
+	}
+
+	return &transactions.Merchant{
+		ID:      m.GetId(),
+		Name:    m.GetName(),
+		City:    m.GetCity(),
+		Country: m.GetCountry(),
+		MCC:     m.GetMcc(),
+	}
+}
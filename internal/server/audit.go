@@ -0,0 +1,87 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/ziflex/rm-rf-production/pkg/audit"
+	"github.com/ziflex/rm-rf-production/pkg/auth"
+)
+
+// recordAudit writes an audit entry for every state-changing request made by an authenticated principal.
+// Anonymous requests are rejected before they can change anything, so they are not recorded.
+func recordAudit(log audit.Service, ops operations) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			opID := ops.ID(c)
+
+			if opID == "" || !isMutating(req.Method) {
+				return next(c)
+			}
+
+			principal, ok := auth.PrincipalFromContext(req.Context())
+
+			if !ok {
+				return next(c)
+			}
+
+			var payload []byte
+
+			if req.Body != nil {
+				body, err := io.ReadAll(req.Body)
+
+				if err != nil {
+					return err
+				}
+
+				req.Body = io.NopCloser(bytes.NewReader(body))
+				payload = body
+			}
+
+			// the error is handled here to know the final status code
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+
+			status := c.Response().Status
+			outcome := audit.OutcomeSuccess
+
+			if status >= http.StatusBadRequest {
+				outcome = audit.OutcomeFailure
+			}
+
+			// the entry must be stored even if the client has already gone away
+			ctx := context.WithoutCancel(req.Context())
+
+			_, err := log.Record(ctx, audit.EntryCreation{
+				TenantID:    principal.TenantID,
+				Principal:   principal.ID,
+				RequestID:   c.Response().Header().Get(echo.HeaderXCorrelationID),
+				OperationID: opID,
+				Payload:     audit.Redact(payload, audit.SensitiveFields),
+				Outcome:     outcome,
+				Status:      status,
+			})
+
+			if err != nil {
+				zerolog.Ctx(ctx).Error().Err(err).Str("operation_id", opID).Msg("failed to write audit entry")
+			}
+
+			return nil
+		}
+	}
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/labstack/echo/v4"
+	oapiecho "github.com/oapi-codegen/echo-middleware"
+	"github.com/ziflex/rm-rf-production/pkg/auth"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+)
+
+const HeaderAPIKey = "X-API-Key"
+
+const bearerPrefix = "Bearer "
+
+// authenticate resolves the caller from the API key header or the bearer token
+// and stores the principal and its tenant in the request context. Requests without credentials are passed through,
+// the OpenAPI validator decides whether the operation requires them.
+func authenticate(keys auth.Service, tokens auth.TokenVerifier) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := c.Request().Context()
+			secret := c.Request().Header.Get(HeaderAPIKey)
+			authorization := c.Request().Header.Get(echo.HeaderAuthorization)
+
+			var principal auth.Principal
+			var err error
+
+			switch {
+			case secret != "":
+				principal, err = keys.Authenticate(ctx, secret)
+			case len(authorization) > len(bearerPrefix) && strings.EqualFold(authorization[:len(bearerPrefix)], bearerPrefix):
+				if tokens == nil {
+					return fmt.Errorf("%w: bearer tokens are not accepted", auth.ErrUnauthorized)
+				}
+
+				principal, err = tokens.Verify(ctx, authorization[len(bearerPrefix):])
+			default:
+				return next(c)
+			}
+
+			if err != nil {
+				return err
+			}
+
+			if principal.TenantID == "" {
+				return fmt.Errorf("%w: principal is not bound to a tenant", auth.ErrUnauthorized)
+			}
+
+			// every repository call made while serving the request is scoped to the principal's tenant
+			ctx = common.WithTenant(auth.WithPrincipal(ctx, principal), principal.TenantID)
+			c.SetRequest(c.Request().WithContext(ctx))
+
+			return next(c)
+		}
+	}
+}
+
+// authorizer returns the authentication function checking that the authenticated principal has all the scopes
+// declared by the security requirement of the matched operation.
+// Operator scopes are only honoured for the principals of the operator tenant, none when it is empty.
+func authorizer(operatorTenant string) openapi3filter.AuthenticationFunc {
+	return func(ctx context.Context, input *openapi3filter.AuthenticationInput) error {
+		c := oapiecho.GetEchoContext(ctx)
+
+		if c == nil {
+			return fmt.Errorf("echo context is missing")
+		}
+
+		principal, ok := auth.PrincipalFromContext(c.Request().Context())
+
+		if !ok {
+			return &echo.HTTPError{
+				Code:     http.StatusUnauthorized,
+				Message:  "missing credentials",
+				Internal: auth.ErrUnauthorized,
+			}
+		}
+
+		for _, scope := range input.Scopes {
+			if !principal.HasScope(auth.Scope(scope)) {
+				return &echo.HTTPError{
+					Code:     http.StatusForbidden,
+					Message:  fmt.Sprintf("missing scope: %s", scope),
+					Internal: fmt.Errorf("%w: missing scope %s", auth.ErrForbidden, scope),
+				}
+			}
+
+			if auth.Scope(scope).IsOperator() && (operatorTenant == "" || principal.TenantID != operatorTenant) {
+				return &echo.HTTPError{
+					Code:     http.StatusForbidden,
+					Message:  fmt.Sprintf("scope %s is reserved to the operator", scope),
+					Internal: fmt.Errorf("%w: tenant %s is not the operator", auth.ErrForbidden, principal.TenantID),
+				}
+			}
+		}
+
+		return nil
+	}
+}
@@ -2,43 +2,254 @@ package server
 
 import (
 	"errors"
+	"fmt"
+	"net/http"
+	"strings"
 
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
 	"github.com/labstack/echo/v4"
+	"github.com/ziflex/rm-rf-production/pkg/accounts"
+	"github.com/ziflex/rm-rf-production/pkg/audit"
+	"github.com/ziflex/rm-rf-production/pkg/auth"
 	"github.com/ziflex/rm-rf-production/pkg/common"
+	"github.com/ziflex/rm-rf-production/pkg/disputes"
+	"github.com/ziflex/rm-rf-production/pkg/ratelimit"
+	"github.com/ziflex/rm-rf-production/pkg/reconciliation"
 	"github.com/ziflex/rm-rf-production/pkg/transactions"
 )
 
-type ApiError struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
-}
+const (
+	MIMEApplicationProblemJSON = "application/problem+json"
+
+	problemTypePrefix = "urn:rm-rf-production:problem:"
+)
 
-func NewApiError(code, message string) *ApiError {
-	return &ApiError{
-		Code:    code,
-		Message: message,
+type (
+	// Problem is an RFC 7807 problem details object.
+	// Code is an extension member that identifies the problem, Type is derived from it.
+	Problem struct {
+		Type     string       `json:"type"`
+		Title    string       `json:"title"`
+		Status   int          `json:"status"`
+		Detail   string       `json:"detail,omitempty"`
+		Instance string       `json:"instance,omitempty"`
+		Code     string       `json:"code"`
+		Errors   []FieldError `json:"errors,omitempty"`
 	}
-}
 
-func NewApiErrorFrom(code string, cause error) *ApiError {
-	return &ApiError{
-		Code:    code,
-		Message: cause.Error(),
+	// FieldError describes a field of the request that failed the validation.
+	FieldError struct {
+		Field   string `json:"field"`
+		Rule    string `json:"rule"`
+		Message string `json:"message"`
+	}
+)
+
+func NewProblem(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   problemTypePrefix + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
 	}
 }
 
+func NewProblemFrom(status int, code string, cause error) *Problem {
+	return NewProblem(status, code, cause.Error())
+}
+
 func errorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	var problem *Problem
+
 	if errors.Is(err, common.ErrNotFound) {
-		c.JSON(404, NewApiErrorFrom("notFound", err))
+		problem = NewProblemFrom(404, "notFound", err)
 	} else if errors.Is(err, common.ErrDuplicate) {
-		c.JSON(409, NewApiErrorFrom("duplicate", err))
+		problem = NewProblemFrom(409, "duplicate", err)
+	} else if errors.Is(err, accounts.ErrVersionMismatch) {
+		problem = NewProblemFrom(412, "versionMismatch", err)
+	} else if errors.Is(err, accounts.ErrVersionRequired) {
+		problem = NewProblemFrom(428, "preconditionRequired", err)
 	} else if errors.Is(err, transactions.ErrInvalidOperationType) {
-		c.JSON(400, NewApiErrorFrom("invalidOperationType", err))
+		problem = NewProblemFrom(400, "invalidOperationType", err)
 	} else if errors.Is(err, transactions.ErrInvalidAmount) {
-		c.JSON(400, NewApiErrorFrom("invalidAmount", err))
+		problem = NewProblemFrom(400, "invalidAmount", err)
+	} else if errors.Is(err, transactions.ErrInvalidMerchant) {
+		problem = NewProblemFrom(400, "invalidMerchant", err)
+	} else if errors.Is(err, transactions.ErrAccountBlocked) {
+		problem = NewProblemFrom(422, "accountBlocked", err)
+	} else if errors.Is(err, disputes.ErrNotDisputable) {
+		problem = NewProblemFrom(422, "notDisputable", err)
+	} else if errors.Is(err, disputes.ErrInvalidTransition) {
+		problem = NewProblemFrom(409, "invalidTransition", err)
+	} else if errors.Is(err, disputes.ErrInvalidReason) {
+		problem = NewProblemFrom(400, "invalidReason", err)
+	} else if errors.Is(err, disputes.ErrInvalidStatus) {
+		problem = NewProblemFrom(400, "invalidStatus", err)
+	} else if errors.Is(err, audit.ErrInvalidFilter) || errors.Is(err, audit.ErrInvalidOutcome) ||
+		errors.Is(err, reconciliation.ErrInvalidCategory) || errors.Is(err, transactions.ErrInvalidFilter) {
+		problem = NewProblemFrom(400, "invalidFilter", err)
+	} else if errors.Is(err, auth.ErrUnauthorized) {
+		problem = NewProblem(401, "unauthorized", "missing or invalid credentials")
+	} else if errors.Is(err, auth.ErrForbidden) {
+		problem = NewProblemFrom(403, "forbidden", unwrapHTTPError(err))
+	} else if errors.Is(err, ratelimit.ErrRateLimited) {
+		problem = NewProblemFrom(429, "rateLimited", err)
 	} else if he, ok := err.(*echo.HTTPError); ok {
-		c.JSON(he.Code, NewApiError("badRequest", he.Message.(string)))
+		problem = NewProblem(he.Code, statusCode(he.Code), httpErrorMessage(he))
+		problem.Errors = fieldErrors(he.Internal, "", nil)
 	} else {
-		c.JSON(500, NewApiError("internalError", "internal server error"))
+		problem = NewProblem(500, "internalError", "internal server error")
+	}
+
+	problem.Instance = c.Response().Header().Get(echo.HeaderXCorrelationID)
+
+	if problem.Instance == "" {
+		problem.Instance = c.Request().Header.Get(echo.HeaderXCorrelationID)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, MIMEApplicationProblemJSON)
+
+	if err := c.JSON(problem.Status, problem); err != nil {
+		c.Logger().Error(err)
+	}
+}
+
+// validationError turns the errors of the request validation into a response error.
+// Failed security requirements take precedence, the request is not validated further for the client.
+func validationError(me openapi3.MultiError) *echo.HTTPError {
+	for _, err := range me {
+		var se *openapi3filter.SecurityRequirementsError
+
+		if !errors.As(err, &se) {
+			continue
+		}
+
+		for _, err := range se.Errors {
+			if he, ok := err.(*echo.HTTPError); ok {
+				return he
+			}
+		}
+
+		return &echo.HTTPError{
+			Code:     http.StatusForbidden,
+			Message:  se.Error(),
+			Internal: se,
+		}
+	}
+
+	return &echo.HTTPError{
+		Code:     http.StatusBadRequest,
+		Message:  "request validation failed",
+		Internal: me,
 	}
 }
+
+// fieldErrors flattens the validation errors of kin-openapi to one entry per invalid field.
+// Body fields are named by their dot separated path, parameters by their name.
+func fieldErrors(err error, field string, out []FieldError) []FieldError {
+	switch e := err.(type) {
+	case nil:
+		return out
+	case openapi3.MultiError:
+		for _, err := range e {
+			out = fieldErrors(err, field, out)
+		}
+
+		return out
+	case *openapi3filter.RequestError:
+		switch {
+		case e.Parameter != nil:
+			field = e.Parameter.Name
+		case e.RequestBody != nil:
+			field = "body"
+		}
+
+		if e.Err == nil {
+			return append(out, FieldError{Field: field, Rule: "invalid", Message: e.Reason})
+		}
+
+		return fieldErrors(e.Err, field, out)
+	case *openapi3.SchemaError:
+		if path := e.JSONPointer(); len(path) > 0 {
+			if field == "body" {
+				field = strings.Join(path, ".")
+			} else {
+				field += "." + strings.Join(path, ".")
+			}
+		}
+
+		message := e.Reason
+
+		if message == "" {
+			message = e.Error()
+		}
+
+		return append(out, FieldError{Field: field, Rule: e.SchemaField, Message: message})
+	case *openapi3filter.ParseError:
+		return append(out, FieldError{Field: field, Rule: "format", Message: e.Error()})
+	default:
+		if errors.Is(err, openapi3filter.ErrInvalidRequired) {
+			return append(out, FieldError{Field: field, Rule: "required", Message: "value is required"})
+		}
+
+		if field == "" {
+			return out
+		}
+
+		return append(out, FieldError{Field: field, Rule: "invalid", Message: err.Error()})
+	}
+}
+
+// statusCode returns the problem code of the errors echo and its middlewares respond with.
+func statusCode(status int) string {
+	switch status {
+	case http.StatusUnauthorized:
+		return "unauthorized"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusNotFound:
+		return "notFound"
+	case http.StatusMethodNotAllowed:
+		return "methodNotAllowed"
+	case http.StatusRequestEntityTooLarge:
+		return "payloadTooLarge"
+	case http.StatusUnsupportedMediaType:
+		return "unsupportedMediaType"
+	case http.StatusTooManyRequests:
+		return "rateLimited"
+	}
+
+	if status >= http.StatusInternalServerError {
+		return "internalError"
+	}
+
+	return "badRequest"
+}
+
+// httpErrorMessage returns the message of the error, echo allows it to be of any type.
+func httpErrorMessage(he *echo.HTTPError) string {
+	switch m := he.Message.(type) {
+	case string:
+		return m
+	case error:
+		return m.Error()
+	case nil:
+		return http.StatusText(he.Code)
+	default:
+		return fmt.Sprint(m)
+	}
+}
+
+func unwrapHTTPError(err error) error {
+	if he, ok := err.(*echo.HTTPError); ok && he.Internal != nil {
+		return he.Internal
+	}
+
+	return err
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ziflex/rm-rf-production/internal/health"
+)
+
+// live reports that the process is up and serving requests. It never checks dependencies,
+// so a database outage does not make the orchestrator restart healthy instances.
+func live(c echo.Context) error {
+	return c.JSON(http.StatusOK, health.Report{Status: health.StatusOK})
+}
+
+// ready reports whether the instance can serve traffic along with the result of every dependency check.
+// A draining instance is never ready.
+func (svr *Server) ready(checker *health.Checker) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if svr.draining.Load() {
+			return c.JSON(http.StatusServiceUnavailable, health.Report{
+				Status: health.StatusFailing,
+				Checks: map[string]health.CheckResult{
+					"shutdown": {Status: health.StatusFailing, Error: "server is shutting down"},
+				},
+			})
+		}
+
+		if checker == nil {
+			return c.JSON(http.StatusOK, health.Report{Status: health.StatusOK})
+		}
+
+		report := checker.Run(c.Request().Context())
+		status := http.StatusOK
+
+		if report.Status != health.StatusOK {
+			status = http.StatusServiceUnavailable
+		}
+
+		return c.JSON(status, report)
+	}
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/ziflex/rm-rf-production/internal/metrics"
+)
+
+const unmatchedOperation = "unmatched"
+
+// measure records RED metrics of every request labeled by the OpenAPI operation ID.
+func measure(m *metrics.Metrics, ops operations, skipper middleware.Skipper) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if skipper(c) {
+				return next(c)
+			}
+
+			start := time.Now()
+
+			// the error is handled here to know the final status code
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+
+			opID := ops.ID(c)
+
+			if opID == "" {
+				opID = unmatchedOperation
+			}
+
+			method := c.Request().Method
+			status := c.Response().Status
+			code := strconv.Itoa(status)
+
+			m.HTTPRequests.WithLabelValues(opID, method, code).Inc()
+			m.HTTPDuration.WithLabelValues(opID, method).Observe(time.Since(start).Seconds())
+
+			if status >= 400 {
+				m.HTTPErrors.WithLabelValues(opID, method, code).Inc()
+			}
+
+			return nil
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/labstack/echo/v4"
+)
+
+// operations maps Echo routes ("METHOD /path/:param") to OpenAPI operation IDs.
+type operations map[string]string
+
+// add maps the routes of the spec, the versions of an operation share its ID.
+func (ops operations) add(spec *openapi3.T) {
+	for path, item := range spec.Paths.Map() {
+		for method, op := range item.Operations() {
+			ops[method+" "+toEchoPath(path)] = op.OperationID
+		}
+	}
+}
+
+// ID returns the operation ID of the matched route or an empty string for routes outside the spec.
+func (ops operations) ID(c echo.Context) string {
+	return ops[c.Request().Method+" "+c.Path()]
+}
+
+// toEchoPath converts OpenAPI path templates ("/accounts/{accountId}") to Echo routes ("/accounts/:accountId").
+func toEchoPath(path string) string {
+	return strings.NewReplacer("{", ":", "}", "").Replace(path)
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/ziflex/rm-rf-production/pkg/auth"
+	"github.com/ziflex/rm-rf-production/pkg/ratelimit"
+)
+
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+	HeaderRetryAfter         = "Retry-After"
+)
+
+// rateLimit enforces per client budgets. Clients are identified by the authenticated principal
+// and fall back to the client IP. Each operation has its own budget.
+func rateLimit(policy ratelimit.Policy, ops operations) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			opID := ops.ID(c)
+
+			if opID == "" {
+				return next(c)
+			}
+
+			rule := policy.Rule(opID)
+
+			if rule.IsZero() {
+				return next(c)
+			}
+
+			ctx := c.Request().Context()
+			res, err := policy.Limiter.Allow(ctx, opID+":"+clientKey(c), rule)
+
+			if err != nil {
+				// an unavailable limiter must not take the API down
+				zerolog.Ctx(ctx).Error().Err(err).Str("operation_id", opID).Msg("failed to check rate limit")
+
+				return next(c)
+			}
+
+			header := c.Response().Header()
+			header.Set(HeaderRateLimitLimit, strconv.Itoa(res.Limit))
+			header.Set(HeaderRateLimitRemaining, strconv.Itoa(res.Remaining))
+			header.Set(HeaderRateLimitReset, toSeconds(res.Reset))
+
+			if !res.Allowed {
+				header.Set(HeaderRetryAfter, toSeconds(res.RetryAfter))
+
+				return ratelimit.ErrRateLimited
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func clientKey(c echo.Context) string {
+	if principal, ok := auth.PrincipalFromContext(c.Request().Context()); ok {
+		return ratelimit.PrincipalKey(principal.TenantID, principal.ID)
+	}
+
+	return "ip:" + c.RealIP()
+}
+
+func toSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
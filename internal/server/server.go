@@ -4,36 +4,126 @@ import (
 	"context"
 	"fmt"
 	"io/fs"
+	"net"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	oapiecho "github.com/oapi-codegen/echo-middleware"
 	"github.com/rs/zerolog"
 	"github.com/ziflex/lecho/v3"
 	"github.com/ziflex/rm-rf-production/internal/api"
+	"github.com/ziflex/rm-rf-production/internal/apiv2"
+	"github.com/ziflex/rm-rf-production/internal/health"
+	"github.com/ziflex/rm-rf-production/internal/metrics"
+	"github.com/ziflex/rm-rf-production/pkg/audit"
+	"github.com/ziflex/rm-rf-production/pkg/auth"
+	"github.com/ziflex/rm-rf-production/pkg/ratelimit"
+)
+
+const (
+	HeaderDeprecation = "Deprecation"
+	HeaderSunset      = "Sunset"
+	HeaderLink        = "Link"
 )
 
 type (
 	Server struct {
-		engine *echo.Echo
+		engine   *echo.Echo
+		draining atomic.Bool
 	}
 
 	Options struct {
 		Logger zerolog.Logger
 		Spec   []byte
 		UI     fs.FS
+		Auth   auth.Service
+		// Tokens enables bearer token authentication when set.
+		Tokens auth.TokenVerifier
+		// RateLimit enables per client rate limiting when set.
+		RateLimit *ratelimit.Policy
+		// TrustedProxies are the networks of the proxies whose X-Forwarded-For header is trusted.
+		// The client IP is the peer address when none is set, the forwarding headers are ignored.
+		TrustedProxies []*net.IPNet
+		// OperatorTenant is the tenant whose principals may use the operator scopes, which expose the data of all tenants.
+		// Operator scopes are refused to everyone when empty.
+		OperatorTenant string
+		// Audit records state-changing requests when set.
+		Audit audit.Service
+		// Metrics exposes Prometheus metrics at /metrics when set.
+		Metrics *metrics.Metrics
+		// Readiness runs the dependency checks behind /readyz. The server is always ready when nil.
+		Readiness *health.Checker
+		// V2 mounts the second version of the API under /v2 when set.
+		V2 *V2Options
+		// Deprecation announces the end of the first version when set.
+		Deprecation *Deprecation
+	}
+
+	V2Options struct {
+		// Handler serves the operations that changed in the second version, the others are served by the handler of the first one.
+		Handler apiv2.StrictServerInterface
+		Spec    []byte
+	}
+
+	// Deprecation is sent in the headers of the responses of a deprecated version.
+	Deprecation struct {
+		// At is when the version was deprecated, sent in the Deprecation header (RFC 9745) when set.
+		At time.Time
+		// Sunset is when the version stops working, sent in the Sunset header (RFC 8594) when set.
+		Sunset time.Time
+		// Link points to the migration guide, sent in a Link header with rel="deprecation" when set.
+		Link string
 	}
 )
 
 func NewServer(handler api.StrictServerInterface, opts Options) (*Server, error) {
-	loader := openapi3.NewLoader()
-	spec, err := loader.LoadFromData(opts.Spec)
+	if opts.Auth == nil {
+		return nil, fmt.Errorf("auth service is required")
+	}
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to load OpenAPI spec: %w", err)
+	if opts.RateLimit != nil && opts.RateLimit.Limiter == nil {
+		return nil, fmt.Errorf("rate limiter is required when rate limiting is enabled")
+	}
+
+	v1 := func(g api.EchoRouter) {
+		api.RegisterHandlers(g, api.NewStrictHandler(handler, nil))
+	}
+
+	// the first version is served under /v1 and, for the clients that predate versioning, at the root
+	mounts := []mount{
+		{prefix: "/v1", spec: opts.Spec, register: v1, deprecation: opts.Deprecation},
+		{prefix: "", spec: opts.Spec, register: v1, deprecation: opts.Deprecation},
+	}
+
+	if opts.V2 != nil {
+		mounts = append(mounts, mount{
+			prefix: "/v2",
+			spec:   opts.V2.Spec,
+			register: func(g api.EchoRouter) {
+				api.RegisterHandlers(g, apiv2.NewServer(api.NewStrictHandler(handler, nil), apiv2.NewStrictHandler(opts.V2.Handler, nil)))
+			},
+		})
+	}
+
+	ops := make(operations)
+
+	for i := range mounts {
+		spec, err := loadSpec(mounts[i].spec, mounts[i].prefix)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to load OpenAPI spec of %q: %w", mounts[i].prefix, err)
+		}
+
+		mounts[i].doc = spec
+		ops.add(spec)
 	}
 
 	echoLogger := lecho.From(opts.Logger)
@@ -42,54 +132,238 @@ func NewServer(handler api.StrictServerInterface, opts Options) (*Server, error)
 	svr.engine.Logger = echoLogger
 	svr.engine.HideBanner = true
 	svr.engine.HTTPErrorHandler = errorHandler
+	svr.engine.IPExtractor = ipExtractor(opts.TrustedProxies)
 
 	svr.engine.Use(middleware.BodyLimit("1M"))
 	svr.engine.Use(middleware.RequestIDWithConfig(middleware.RequestIDConfig{
 		TargetHeader: echo.HeaderXCorrelationID,
 	}))
+	// a panic in any of the middlewares below ends with a 500 carrying the request ID
+	svr.engine.Use(middleware.Recover())
+
+	if opts.Metrics != nil {
+		svr.engine.Use(measure(opts.Metrics, ops, isServiceRoute))
+	}
+
+	svr.engine.Use(traceRequests(ops, isServiceRoute))
+
 	svr.engine.Use(lecho.Middleware(lecho.Config{
 		Logger:          echoLogger,
 		RequestIDKey:    "request_id",
 		RequestIDHeader: echo.HeaderXCorrelationID,
 		HandleError:     false,
-		Skipper: func(c echo.Context) bool {
-			return c.Path() == "/health" || c.Path() == "/openapi.yaml" || strings.HasPrefix(c.Path(), "/docs")
-		},
+		Skipper:         isServiceRoute,
+		Enricher:        withTraceIDs,
 	}))
-	spec.Servers = nil
-	svr.engine.Use(oapiecho.OapiRequestValidatorWithOptions(spec, &oapiecho.Options{
-		Skipper: func(c echo.Context) bool {
-			path := c.Request().URL.Path
+	svr.engine.Use(authenticate(opts.Auth, opts.Tokens))
+
+	if opts.RateLimit != nil {
+		svr.engine.Use(rateLimit(*opts.RateLimit, ops))
+	}
+
+	if opts.Audit != nil {
+		svr.engine.Use(recordAudit(opts.Audit, ops))
+	}
 
-			return path == "/health" || path == "/openapi.yaml" || strings.HasPrefix(path, "/docs")
-		},
-	}))
-	svr.engine.Use(middleware.Recover())
 	svr.engine.Use(middleware.GzipWithConfig(middleware.GzipConfig{
 		Level: 5,
 	}))
 
-	svr.engine.GET("/health", func(c echo.Context) error {
-		return c.NoContent(http.StatusOK)
-	})
+	// /health is kept as an alias of /livez for existing probes
+	svr.engine.GET("/health", live)
+	svr.engine.GET("/livez", live)
+	svr.engine.GET("/readyz", svr.ready(opts.Readiness))
 
-	svr.engine.GET("/openapi.yaml", func(c echo.Context) error {
-		return c.Blob(http.StatusOK, "application/x-yaml", opts.Spec)
-	})
+	svr.engine.GET("/openapi.yaml", serveSpec(opts.Spec))
+	svr.engine.GET("/v1/openapi.yaml", serveSpec(opts.Spec))
+
+	if opts.V2 != nil {
+		svr.engine.GET("/v2/openapi.yaml", serveSpec(opts.V2.Spec))
+	}
+
+	if opts.Metrics != nil {
+		svr.engine.GET("/metrics", echo.WrapHandler(opts.Metrics.Handler()))
+	}
 
 	if opts.UI != nil {
 		svr.engine.StaticFS("/docs", opts.UI)
 	}
 
-	api.RegisterHandlers(svr.engine, api.NewStrictHandler(handler, nil))
+	for _, m := range mounts {
+		var mws []echo.MiddlewareFunc
+
+		if m.deprecation != nil {
+			mws = append(mws, deprecate(*m.deprecation))
+		}
+
+		// each version validates the requests against its own spec
+		mws = append(mws, oapiecho.OapiRequestValidatorWithOptions(m.doc, &oapiecho.Options{
+			Options: openapi3filter.Options{
+				AuthenticationFunc: authorizer(opts.OperatorTenant),
+				// all invalid fields are reported at once
+				MultiError: true,
+			},
+			MultiErrorHandler: validationError,
+			Skipper:           isServiceRoute,
+		}))
+
+		m.register(routes{engine: svr.engine, prefix: m.prefix, middlewares: mws})
+	}
 
 	return svr, nil
 }
 
+// mount is a version of the API served under a path prefix.
+type mount struct {
+	prefix      string
+	spec        []byte
+	doc         *openapi3.T
+	register    func(g api.EchoRouter)
+	deprecation *Deprecation
+}
+
+// routes registers the routes of a mount with its middlewares.
+// Unlike an echo group, it adds no catch-all routes under the prefix, so the requests that match
+// no operation, including the ones to the root alias, get the 404 and 405 responses of the router untouched.
+type routes struct {
+	engine      *echo.Echo
+	prefix      string
+	middlewares []echo.MiddlewareFunc
+}
+
+func (r routes) add(method, path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route {
+	return r.engine.Add(method, r.prefix+path, h, append(slices.Clone(r.middlewares), m...)...)
+}
+
+func (r routes) CONNECT(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route {
+	return r.add(http.MethodConnect, path, h, m...)
+}
+
+func (r routes) DELETE(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route {
+	return r.add(http.MethodDelete, path, h, m...)
+}
+
+func (r routes) GET(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route {
+	return r.add(http.MethodGet, path, h, m...)
+}
+
+func (r routes) HEAD(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route {
+	return r.add(http.MethodHead, path, h, m...)
+}
+
+func (r routes) OPTIONS(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route {
+	return r.add(http.MethodOptions, path, h, m...)
+}
+
+func (r routes) PATCH(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route {
+	return r.add(http.MethodPatch, path, h, m...)
+}
+
+func (r routes) POST(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route {
+	return r.add(http.MethodPost, path, h, m...)
+}
+
+func (r routes) PUT(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route {
+	return r.add(http.MethodPut, path, h, m...)
+}
+
+func (r routes) TRACE(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route {
+	return r.add(http.MethodTrace, path, h, m...)
+}
+
+// loadSpec loads the spec with the paths moved under the prefix,
+// so that the validator matches the requests of the mount only.
+func loadSpec(data []byte, prefix string) (*openapi3.T, error) {
+	spec, err := openapi3.NewLoader().LoadFromData(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the servers are documentation, the validator would match the host against them
+	spec.Servers = nil
+
+	paths := openapi3.NewPaths()
+
+	for path, item := range spec.Paths.Map() {
+		paths.Set(prefix+path, item)
+	}
+
+	spec.Paths = paths
+
+	return spec, nil
+}
+
+// ipExtractor reads the client IP from the X-Forwarded-For header sent by the trusted proxies only.
+// The defaults of echo trust the private networks, any client there could pick its IP.
+func ipExtractor(trusted []*net.IPNet) echo.IPExtractor {
+	if len(trusted) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	opts := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+
+	for _, network := range trusted {
+		opts = append(opts, echo.TrustIPRange(network))
+	}
+
+	return echo.ExtractIPFromXFFHeader(opts...)
+}
+
+func serveSpec(spec []byte) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return c.Blob(http.StatusOK, "application/x-yaml", spec)
+	}
+}
+
+// deprecate adds the deprecation headers to the responses.
+func deprecate(d Deprecation) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			header := c.Response().Header()
+
+			if !d.At.IsZero() {
+				header.Set(HeaderDeprecation, "@"+strconv.FormatInt(d.At.Unix(), 10))
+			}
+
+			if !d.Sunset.IsZero() {
+				header.Set(HeaderSunset, d.Sunset.UTC().Format(http.TimeFormat))
+			}
+
+			if d.Link != "" {
+				header.Add(HeaderLink, fmt.Sprintf(`<%s>; rel="deprecation"`, d.Link))
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// isServiceRoute reports whether the request targets one of the service routes (probes, spec, docs, metrics)
+// that are not part of the API and are excluded from logging, validation and metrics.
+func isServiceRoute(c echo.Context) bool {
+	switch path := c.Request().URL.Path; path {
+	case "/health", "/livez", "/readyz", "/metrics", "/openapi.yaml", "/v1/openapi.yaml", "/v2/openapi.yaml":
+		return true
+	default:
+		return strings.HasPrefix(path, "/docs")
+	}
+}
+
 func (svr *Server) Run(port int) error {
 	return svr.engine.Start(fmt.Sprintf("0.0.0.0:%d", port))
 }
 
-func (svr *Server) Shutdown() error {
-	return svr.engine.Shutdown(context.TODO())
+// Drain flips readiness to failing, so that load balancers stop routing new requests to the instance,
+// while the server keeps serving the requests that still arrive.
+func (svr *Server) Drain() {
+	svr.draining.Store(true)
+}
+
+// Shutdown stops accepting new connections and waits for in-flight requests to complete
+// until the context is done.
+func (svr *Server) Shutdown(ctx context.Context) error {
+	svr.Drain()
+
+	return svr.engine.Shutdown(ctx)
 }
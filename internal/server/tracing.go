@@ -0,0 +1,73 @@
+package server
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/rs/zerolog"
+	"github.com/ziflex/rm-rf-production/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceRequests continues the trace of the W3C traceparent header, or starts a new one,
+// and wraps the request in a server span named after the OpenAPI operation ID.
+func traceRequests(ops operations, skipper middleware.Skipper) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if skipper(c) {
+				return next(c)
+			}
+
+			req := c.Request()
+			ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+
+			name := ops.ID(c)
+
+			if name == "" {
+				name = unmatchedOperation
+			}
+
+			ctx, span := tracing.Tracer().Start(ctx, name,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(req.Method),
+					semconv.HTTPRoute(c.Path()),
+					semconv.URLPath(req.URL.Path),
+				),
+			)
+			defer span.End()
+
+			c.SetRequest(req.WithContext(ctx))
+
+			// the error is handled here to know the final status code
+			if err := next(c); err != nil {
+				span.RecordError(err)
+				c.Error(err)
+			}
+
+			status := c.Response().Status
+			span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+
+			if status >= 500 {
+				span.SetStatus(codes.Error, "")
+			}
+
+			return nil
+		}
+	}
+}
+
+// withTraceIDs adds the trace and span IDs of the request to the logger
+// that is passed down to the services through the request context.
+func withTraceIDs(c echo.Context, logger zerolog.Context) zerolog.Context {
+	sc := trace.SpanContextFromContext(c.Request().Context())
+
+	if !sc.IsValid() {
+		return logger
+	}
+
+	return logger.Str("trace_id", sc.TraceID().String()).Str("span_id", sc.SpanID().String())
+}
@@ -0,0 +1,83 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/ziflex/rm-rf-production/pkg/accounts"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type accountsService struct {
+	next accounts.Service
+}
+
+// NewAccountsService decorates the service with a span per method call.
+func NewAccountsService(next accounts.Service) accounts.Service {
+	return &accountsService{next}
+}
+
+func (s *accountsService) CreateAccount(ctx context.Context, creation accounts.AccountCreation) (accounts.Account, error) {
+	ctx, span := start(ctx, "accounts.CreateAccount")
+	defer span.End()
+
+	acc, err := s.next.CreateAccount(ctx, creation)
+
+	if err == nil {
+		span.SetAttributes(attribute.Int64("account.id", acc.ID))
+	}
+
+	finish(span, err)
+
+	return acc, err
+}
+
+func (s *accountsService) GetAccountByID(ctx context.Context, id int64) (accounts.Account, error) {
+	ctx, span := start(ctx, "accounts.GetAccountByID", trace.WithAttributes(attribute.Int64("account.id", id)))
+	defer span.End()
+
+	acc, err := s.next.GetAccountByID(ctx, id)
+	finish(span, err)
+
+	return acc, err
+}
+
+func (s *accountsService) BlockAccount(ctx context.Context, id int64) error {
+	ctx, span := start(ctx, "accounts.BlockAccount", trace.WithAttributes(attribute.Int64("account.id", id)))
+	defer span.End()
+
+	err := s.next.BlockAccount(ctx, id)
+	finish(span, err)
+
+	return err
+}
+
+func (s *accountsService) UnblockAccount(ctx context.Context, id int64) error {
+	ctx, span := start(ctx, "accounts.UnblockAccount", trace.WithAttributes(attribute.Int64("account.id", id)))
+	defer span.End()
+
+	err := s.next.UnblockAccount(ctx, id)
+	finish(span, err)
+
+	return err
+}
+
+func (s *accountsService) UpdateAccount(
+	ctx context.Context,
+	id int64,
+	update accounts.AccountUpdate,
+	versions []int64,
+) (accounts.Account, error) {
+	ctx, span := start(ctx, "accounts.UpdateAccount", trace.WithAttributes(attribute.Int64("account.id", id)))
+	defer span.End()
+
+	acc, err := s.next.UpdateAccount(ctx, id, update, versions)
+
+	if err == nil {
+		span.SetAttributes(attribute.Int64("account.version", acc.Version))
+	}
+
+	finish(span, err)
+
+	return acc, err
+}
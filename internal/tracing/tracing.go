@@ -0,0 +1,137 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+)
+
+const (
+	ExporterDisabled = "disabled"
+	ExporterOTLP     = "otlp"
+	ExporterFile     = "file"
+)
+
+type (
+	Options struct {
+		// ServiceName is reported as the service.name resource attribute.
+		ServiceName string
+		// Exporter is one of "otlp", "file" or "disabled".
+		Exporter string
+		// Endpoint is the host:port of the OTLP/HTTP collector.
+		// Standard OTEL_EXPORTER_OTLP_* variables are used when empty.
+		Endpoint string
+		// Insecure disables TLS for the OTLP exporter.
+		Insecure bool
+		// File receives spans as JSON documents when the file exporter is used.
+		File string
+		// SampleRatio is the ratio of root traces to sample. Remote parent decisions are always respected.
+		SampleRatio float64
+	}
+
+	// Provider owns the tracer provider installed globally by New.
+	Provider struct {
+		tp   *sdktrace.TracerProvider
+		file *os.File
+	}
+)
+
+// New installs the W3C trace context propagator and, unless tracing is disabled, a global tracer provider
+// that exports spans with the configured exporter.
+// The propagator is installed even when tracing is disabled, so incoming trace IDs still reach the logs.
+func New(ctx context.Context, opts Options) (*Provider, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	p := &Provider{}
+
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	switch opts.Exporter {
+	case "", ExporterDisabled:
+		return p, nil
+	case ExporterOTLP:
+		var clientOpts []otlptracehttp.Option
+
+		if opts.Endpoint != "" {
+			clientOpts = append(clientOpts, otlptracehttp.WithEndpoint(opts.Endpoint))
+		}
+
+		if opts.Insecure {
+			clientOpts = append(clientOpts, otlptracehttp.WithInsecure())
+		}
+
+		exporter, err = otlptracehttp.New(ctx, clientOpts...)
+	case ExporterFile:
+		if opts.File == "" {
+			return nil, fmt.Errorf("trace file is required by the file exporter")
+		}
+
+		p.file, err = os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to open trace file: %w", err)
+		}
+
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(p.file))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter: %s", opts.Exporter)
+	}
+
+	if err != nil {
+		p.closeFile()
+
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(opts.ServiceName),
+	))
+
+	if err != nil {
+		p.closeFile()
+
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	p.tp = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(p.tp)
+
+	return p, nil
+}
+
+// Shutdown flushes the pending spans and releases the exporter.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p.tp == nil {
+		return nil
+	}
+
+	err := p.tp.Shutdown(ctx)
+	p.closeFile()
+
+	return err
+}
+
+func (p *Provider) closeFile() {
+	if p.file != nil {
+		p.file.Close()
+		p.file = nil
+	}
+}
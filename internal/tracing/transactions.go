@@ -0,0 +1,55 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/ziflex/rm-rf-production/pkg/transactions"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type transactionsService struct {
+	next transactions.Service
+}
+
+// NewTransactionsService decorates the service with a span per method call.
+func NewTransactionsService(next transactions.Service) transactions.Service {
+	return &transactionsService{next}
+}
+
+func (s *transactionsService) CreateTransaction(ctx context.Context, creation transactions.TransactionCreation) (transactions.Transaction, error) {
+	ctx, span := start(ctx, "transactions.CreateTransaction", trace.WithAttributes(
+		attribute.Int64("account.id", creation.AccountID),
+		attribute.String("transaction.operation_type", creation.OperationType.String()),
+	))
+	defer span.End()
+
+	tx, err := s.next.CreateTransaction(ctx, creation)
+
+	if err == nil {
+		span.SetAttributes(attribute.Int64("transaction.id", tx.ID))
+	}
+
+	finish(span, err)
+
+	return tx, err
+}
+
+func (s *transactionsService) ExportTransactions(ctx context.Context, filter transactions.ExportFilter, fn func(transactions.Transaction) error) error {
+	ctx, span := start(ctx, "transactions.ExportTransactions", trace.WithAttributes(
+		attribute.Int64("account.id", filter.AccountID),
+	))
+	defer span.End()
+
+	count := 0
+	err := s.next.ExportTransactions(ctx, filter, func(tr transactions.Transaction) error {
+		count++
+
+		return fn(tr)
+	})
+
+	span.SetAttributes(attribute.Int("transaction.count", count))
+	finish(span, err)
+
+	return err
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/internal/database"
+	"github.com/ziflex/rm-rf-production/pkg/scheduler"
+)
+
+// newJobs returns the maintenance jobs of the Postgres database.
+// Jobs with a schedule are run by the scheduler of the server, all of them can be run with admin run-job.
+func newJobs(cfg Config, db *sql.DB) []scheduler.Job {
+	return []scheduler.Job{
+		{
+			Name:        "purge-rate-limits",
+			Description: "delete expired rate limit counters",
+			Schedule:    cfg.SchedulePurgeRateLimits,
+			Func: func(ctx context.Context) (string, error) {
+				deleted, err := database.NewRateLimitsRepository().DeleteExpired(dbx.NewContextFrom(ctx, db), time.Now())
+
+				if err != nil {
+					return "", err
+				}
+
+				return fmt.Sprintf("%d expired counters deleted", deleted), nil
+			},
+		},
+	}
+}
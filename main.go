@@ -1,30 +1,109 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"io/fs"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 	"github.com/rs/zerolog"
-	"github.com/ziflex/rm-rf-production/internal/api"
 	"github.com/ziflex/rm-rf-production/internal/database"
-	"github.com/ziflex/rm-rf-production/internal/server"
-	"github.com/ziflex/rm-rf-production/pkg/accounts"
-	"github.com/ziflex/rm-rf-production/pkg/transactions"
-	"github.com/ziflex/rm-rf-production/spec"
 )
 
 type Config struct {
 	Port     int           `env:"PORT" envDefault:"8080"`
+	GrpcPort int           `env:"GRPC_PORT" envDefault:"9090"`
 	LogLevel zerolog.Level `env:"LOG_LEVEL" envDefault:"trace"`
+	DbDriver string        `env:"DB_DRIVER" envDefault:"postgres"`
 	DbHost   string        `env:"DB_HOST" envDefault:"localhost"`
 	DbPort   int           `env:"DB_PORT" envDefault:"5432"`
 	DbName   string        `env:"DB_NAME" envDefault:"mydb"`
 	DbUser   string        `env:"DB_USER" envDefault:"user"`
-	DbPass   string        `env:"DB_PASS" envDefault:"password"`
+	DbPass   string        `env:"DB_PASS" envDefault:"password" redact:"true"`
+
+	DbURL              string        `env:"DB_URL" redact:"true"`
+	DbSSLMode          string        `env:"DB_SSL_MODE" envDefault:"disable"`
+	DbSSLRootCert      string        `env:"DB_SSL_ROOT_CERT"`
+	DbSSLCert          string        `env:"DB_SSL_CERT"`
+	DbSSLKey           string        `env:"DB_SSL_KEY"`
+	DbConnectTimeout   time.Duration `env:"DB_CONNECT_TIMEOUT" envDefault:"5s"`
+	DbStatementTimeout time.Duration `env:"DB_STATEMENT_TIMEOUT" envDefault:"30s"`
+	DbApplicationName  string        `env:"DB_APPLICATION_NAME" envDefault:"rm-rf-production"`
+	DbMaxOpenConns     int           `env:"DB_MAX_OPEN_CONNS" envDefault:"25"`
+	DbMaxIdleConns     int           `env:"DB_MAX_IDLE_CONNS" envDefault:"25"`
+	DbConnMaxLifetime  time.Duration `env:"DB_CONN_MAX_LIFETIME" envDefault:"30m"`
+	DbConnMaxIdleTime  time.Duration `env:"DB_CONN_MAX_IDLE_TIME" envDefault:"5m"`
+	DbWaitTimeout      time.Duration `env:"DB_WAIT_TIMEOUT" envDefault:"0s"`
+	MigrateOnStart     bool          `env:"MIGRATE_ON_START"`
+
+	DbReplicaURLs          []string      `env:"DB_REPLICA_URLS" redact:"true"`
+	DbReplicaCheckInterval time.Duration `env:"DB_REPLICA_CHECK_INTERVAL" envDefault:"5s"`
+	DbReplicaMaxLag        time.Duration `env:"DB_REPLICA_MAX_LAG" envDefault:"10s"`
+	DbReadYourWrites       time.Duration `env:"DB_READ_YOUR_WRITES" envDefault:"5s"`
+
+	ReadyzTimeout time.Duration `env:"READYZ_TIMEOUT" envDefault:"2s"`
+
+	ExportTimeout time.Duration `env:"EXPORT_TX_TIMEOUT" envDefault:"10s"`
+
+	ReconciliationFormat        string            `env:"RECONCILIATION_FORMAT" envDefault:"csv"`
+	ReconciliationColumns       map[string]string `env:"RECONCILIATION_COLUMNS" envDefault:"reference:0,amount:1,date:2"`
+	ReconciliationDelimiter     string            `env:"RECONCILIATION_DELIMITER" envDefault:","`
+	ReconciliationSkipHeader    bool              `env:"RECONCILIATION_SKIP_HEADER" envDefault:"true"`
+	ReconciliationDateLayout    string            `env:"RECONCILIATION_DATE_LAYOUT" envDefault:"2006-01-02"`
+	ReconciliationMinorUnits    bool              `env:"RECONCILIATION_MINOR_UNITS"`
+	ReconciliationNegate        bool              `env:"RECONCILIATION_NEGATE"`
+	ReconciliationDateTolerance time.Duration     `env:"RECONCILIATION_DATE_TOLERANCE" envDefault:"24h"`
+
+	SchedulerEnabled        bool   `env:"SCHEDULER_ENABLED" envDefault:"true"`
+	SchedulerInstance       string `env:"SCHEDULER_INSTANCE"`
+	SchedulePurgeRateLimits string `env:"SCHEDULE_PURGE_RATE_LIMITS" envDefault:"0 * * * *"`
+
+	QueueWorkers           int           `env:"QUEUE_WORKERS" envDefault:"4"`
+	QueuePollInterval      time.Duration `env:"QUEUE_POLL_INTERVAL" envDefault:"1s"`
+	QueueVisibilityTimeout time.Duration `env:"QUEUE_VISIBILITY_TIMEOUT" envDefault:"5m"`
+	QueueMaxAttempts       int           `env:"QUEUE_MAX_ATTEMPTS" envDefault:"5"`
+	QueueRetryBase         time.Duration `env:"QUEUE_RETRY_BASE" envDefault:"10s"`
+	QueueRetryMax          time.Duration `env:"QUEUE_RETRY_MAX" envDefault:"1h"`
+
+	ApiV1DeprecatedAt    time.Time `env:"API_V1_DEPRECATED_AT"`
+	ApiV1SunsetAt        time.Time `env:"API_V1_SUNSET_AT"`
+	ApiV1DeprecationLink string    `env:"API_V1_DEPRECATION_LINK"`
+
+	ShutdownDelay   time.Duration `env:"SHUTDOWN_DELAY" envDefault:"5s"`
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"30s"`
+
+	JwksURL     string        `env:"JWT_JWKS_URL"`
+	JwksFile    string        `env:"JWT_JWKS_FILE"`
+	JwksRefresh time.Duration `env:"JWT_JWKS_REFRESH" envDefault:"15m"`
+	JwtIssuer   string        `env:"JWT_ISSUER"`
+	JwtAudience string        `env:"JWT_AUDIENCE"`
+
+	RateLimitBackend    string            `env:"RATE_LIMIT_BACKEND" envDefault:"memory"`
+	RateLimitDefault    string            `env:"RATE_LIMIT_DEFAULT" envDefault:"100/1m"`
+	RateLimitOperations map[string]string `env:"RATE_LIMIT_OPERATIONS" envDefault:"createAccount:20/1m,createTransaction:30/1m,getAccount:300/1m"`
+	// TrustedProxies are CIDRs of the proxies allowed to set X-Forwarded-For, the peer address is used otherwise.
+	TrustedProxies []string `env:"TRUSTED_PROXIES"`
+	OperatorTenant string   `env:"OPERATOR_TENANT"`
+
+	TraceExporter    string  `env:"TRACE_EXPORTER" envDefault:"disabled"`
+	TraceEndpoint    string  `env:"TRACE_OTLP_ENDPOINT"`
+	TraceInsecure    bool    `env:"TRACE_OTLP_INSECURE"`
+	TraceFile        string  `env:"TRACE_FILE" envDefault:"traces.json"`
+	TraceSampleRatio float64 `env:"TRACE_SAMPLE_RATIO" envDefault:"1"`
 }
 
+const usage = `usage: rm-rf-production [command] [args]
+
+commands:
+  serve           run the HTTP and gRPC servers (default)
+  migrate         manage the database schema
+  seed            generate accounts and transactions for local development
+  admin           manage tenants, api keys and accounts, run one-off jobs, import settlement files
+  config print    print the effective configuration with secrets redacted
+`
+
 func main() {
 	var cfg Config
 
@@ -33,44 +112,79 @@ func main() {
 		os.Exit(1)
 	}
 
-	uiSub, err := fs.Sub(spec.UI, "ui")
+	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
 
-	if err != nil {
-		fmt.Printf("failed to load embedded ui: %+v\n", err)
+	cmd, args := "serve", os.Args[1:]
+
+	// flags without a command belong to serve
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
+	}
+
+	if err := run(cfg, logger, cmd, args); err != nil {
+		fmt.Printf("%s failed: %+v\n", cmd, err)
 		os.Exit(1)
 	}
+}
 
-	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
+func run(cfg Config, logger zerolog.Logger, cmd string, args []string) error {
+	ctx := logger.WithContext(context.Background())
 
-	db, err := database.New(database.Options{
-		Name: cfg.DbName,
-		Host: cfg.DbHost,
-		Port: cfg.DbPort,
-		User: cfg.DbUser,
-		Pass: cfg.DbPass,
-	})
+	switch cmd {
+	case "serve", "migrate", "seed", "admin":
+	case "config":
+		return runConfig(os.Stdout, cfg, args)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
 
-	if err != nil {
-		fmt.Printf("failed to connect to db: %+v\n", err)
-		os.Exit(1)
+		return nil
+	default:
+		fmt.Print(usage)
+
+		return fmt.Errorf("unknown command: %s", cmd)
+	}
+
+	if cfg.DbDriver == driverMemory && cmd != "serve" {
+		return fmt.Errorf("%s is not supported by the %s database driver", cmd, driverMemory)
 	}
 
-	svr, err := server.NewServer(api.NewHandler(
-		accounts.NewService(db, database.NewAccountsRepository()),
-		transactions.NewService(db, database.NewTransactions()),
-	), server.Options{
-		Logger: logger,
-		Spec:   spec.File,
-		UI:     uiSub,
-	})
+	a, err := newApp(ctx, cfg, logger)
 
 	if err != nil {
-		fmt.Printf("failed to create server: %+v\n", err)
-		os.Exit(1)
+		return err
 	}
 
-	if err = svr.Run(cfg.Port); err != nil {
-		fmt.Printf("server error: %+v\n", err)
-		os.Exit(1)
+	// serve closes the database itself as the last step of the graceful shutdown
+	if cmd == "serve" {
+		return serve(a, args)
+	}
+
+	defer a.Close()
+
+	switch cmd {
+	case "migrate":
+		m, err := database.NewMigrator(ctx, a.db, logger)
+
+		if err != nil {
+			return err
+		}
+
+		defer m.Close()
+
+		return (&migrator{out: os.Stdout, migrator: m}).run(args)
+	case "seed":
+		return (&seeder{out: os.Stdout, accounts: a.accounts, transactions: a.transactions}).run(ctx, args)
+	default:
+		return (&admin{
+			out:      os.Stdout,
+			keys:     a.keys,
+			tenants:  a.tenants,
+			accounts: a.accounts,
+			audit:    a.audit,
+			jobs:     a.scheduler,
+			queue:    a.queue,
+
+			reconciliations: a.reconciliations,
+		}).run(ctx, args)
 	}
 }
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/ziflex/rm-rf-production/internal/database"
+)
+
+const migrateUsage = `usage: rm-rf-production migrate <command>
+
+commands:
+  up        apply all pending migrations
+  down N    roll back the last N migrations
+  status    print the applied and the latest embedded versions
+  force V   set the version to V and clear the dirty flag without running migrations
+`
+
+type migrator struct {
+	out      io.Writer
+	migrator *database.Migrator
+}
+
+func (m *migrator) run(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(m.out, migrateUsage)
+
+		return fmt.Errorf("missing migrate command")
+	}
+
+	switch args[0] {
+	case "up":
+		if err := m.migrator.Up(); err != nil {
+			return err
+		}
+
+		return m.status()
+	case "down":
+		n, err := m.intArg(args, "N")
+
+		if err != nil {
+			return err
+		}
+
+		if err := m.migrator.Down(n); err != nil {
+			return err
+		}
+
+		return m.status()
+	case "status":
+		return m.status()
+	case "force":
+		v, err := m.intArg(args, "V")
+
+		if err != nil {
+			return err
+		}
+
+		if err := m.migrator.Force(v); err != nil {
+			return err
+		}
+
+		return m.status()
+	default:
+		fmt.Fprint(m.out, migrateUsage)
+
+		return fmt.Errorf("unknown migrate command: %s", args[0])
+	}
+}
+
+func (m *migrator) status() error {
+	status, err := m.migrator.Status()
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(m.out, "version: %d\n", status.Version)
+	fmt.Fprintf(m.out, "dirty: %t\n", status.Dirty)
+	fmt.Fprintf(m.out, "latest: %d\n", status.Latest)
+	fmt.Fprintf(m.out, "pending: %d\n", len(status.Pending))
+
+	for _, v := range status.Pending {
+		fmt.Fprintf(m.out, "  %d\n", v)
+	}
+
+	return nil
+}
+
+func (m *migrator) intArg(args []string, name string) (int, error) {
+	if len(args) != 2 {
+		fmt.Fprint(m.out, migrateUsage)
+
+		return 0, fmt.Errorf("%s requires exactly one argument %s", args[0], name)
+	}
+
+	n, err := strconv.Atoi(args[1])
+
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %s", name, args[1])
+	}
+
+	return n, nil
+}
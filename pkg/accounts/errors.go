@@ -0,0 +1,8 @@
+package accounts
+
+import "errors"
+
+var (
+	ErrVersionMismatch = errors.New("version mismatch")
+	ErrVersionRequired = errors.New("version required")
+)
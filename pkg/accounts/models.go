@@ -5,8 +5,16 @@ type (
 		DocumentNumber string `json:"document_number" db:"document_number"`
 	}
 
+	// AccountUpdate holds the changed fields, nil fields are left as they are.
+	AccountUpdate struct {
+		Blocked *bool `json:"blocked,omitempty"`
+	}
+
 	Account struct {
 		ID             int64  `json:"id" db:"id"`
 		DocumentNumber string `json:"document_number" db:"document_number"`
+		Blocked        bool   `json:"blocked" db:"blocked"`
+		// Version is incremented by every change of the account.
+		Version int64 `json:"version" db:"version"`
 	}
 )
@@ -7,4 +7,7 @@ import (
 type Repository interface {
 	CreateAccount(ctx dbx.Context, acc AccountCreation) (Account, error)
 	GetAccountByID(ctx dbx.Context, id int64) (Account, error)
+	SetAccountBlocked(ctx dbx.Context, id int64, blocked bool) error
+	// UpdateAccount applies the update if the account has one of the versions, nil versions match any.
+	UpdateAccount(ctx dbx.Context, id int64, update AccountUpdate, versions []int64) (Account, error)
 }
@@ -5,12 +5,19 @@ import (
 
 	"github.com/rs/zerolog"
 	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/common"
 )
 
 type (
 	Service interface {
 		CreateAccount(ctx context.Context, creation AccountCreation) (Account, error)
 		GetAccountByID(ctx context.Context, id int64) (Account, error)
+		// BlockAccount prevents new transactions on the account.
+		BlockAccount(ctx context.Context, id int64) error
+		UnblockAccount(ctx context.Context, id int64) error
+		// UpdateAccount applies the update if the account has one of the versions, nil versions match any.
+		// It fails with ErrVersionMismatch if the account has changed.
+		UpdateAccount(ctx context.Context, id int64, update AccountUpdate, versions []int64) (Account, error)
 	}
 
 	serviceImpl struct {
@@ -46,7 +53,7 @@ func (s *serviceImpl) GetAccountByID(ctx context.Context, id int64) (Account, er
 	log := zerolog.Ctx(ctx)
 	log.Info().Int64("id", id).Msg("getting account")
 
-	acc, err := s.repository.GetAccountByID(dbx.NewContextFrom(ctx, s.db), id)
+	acc, err := s.repository.GetAccountByID(dbx.NewContextFrom(ctx, common.ForRead(ctx, s.db)), id)
 
 	if err != nil {
 		log.Error().Err(err).Int64("id", id).Msg("failed to get account")
@@ -58,3 +65,47 @@ func (s *serviceImpl) GetAccountByID(ctx context.Context, id int64) (Account, er
 
 	return acc, nil
 }
+
+func (s *serviceImpl) BlockAccount(ctx context.Context, id int64) error {
+	return s.setBlocked(ctx, id, true)
+}
+
+func (s *serviceImpl) UnblockAccount(ctx context.Context, id int64) error {
+	return s.setBlocked(ctx, id, false)
+}
+
+func (s *serviceImpl) setBlocked(ctx context.Context, id int64, blocked bool) error {
+	log := zerolog.Ctx(ctx)
+	log.Info().Int64("id", id).Bool("blocked", blocked).Msg("changing account block")
+
+	err := s.repository.SetAccountBlocked(dbx.NewContextFrom(ctx, s.db), id, blocked)
+
+	if err != nil {
+		log.Error().Err(err).Int64("id", id).Msg("failed to change account block")
+
+		return err
+	}
+
+	log.Info().Int64("id", id).Bool("blocked", blocked).Msg("account block changed")
+
+	return nil
+}
+
+func (s *serviceImpl) UpdateAccount(ctx context.Context, id int64, update AccountUpdate, versions []int64) (Account, error) {
+	log := zerolog.Ctx(ctx)
+	log.Info().Int64("id", id).Ints64("versions", versions).Msg("updating account")
+
+	return dbx.TransactionWithResult[Account](ctx, s.db, func(tx dbx.Context) (Account, error) {
+		acc, err := s.repository.UpdateAccount(tx, id, update, versions)
+
+		if err != nil {
+			log.Error().Err(err).Int64("id", id).Msg("failed to update account")
+
+			return Account{}, err
+		}
+
+		log.Info().Int64("id", id).Int64("version", acc.Version).Msg("account updated")
+
+		return acc, nil
+	})
+}
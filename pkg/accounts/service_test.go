@@ -14,6 +14,14 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+const testTenant = "acme"
+
+var accountColumns = []string{"id", "document_number", "blocked", "version"}
+
+func tenantCtx() context.Context {
+	return common.WithTenant(context.Background(), testTenant)
+}
+
 func TestService_CreateAccount_Success(t *testing.T) {
 	mockDB, mock, err := sqlmock.New()
 	assert.NoError(t, err)
@@ -24,18 +32,19 @@ func TestService_CreateAccount_Success(t *testing.T) {
 	expected := accounts.Account{
 		ID:             1,
 		DocumentNumber: "abc",
+		Version:        1,
 	}
 
 	mock.ExpectBegin().WillReturnError(nil)
-	mock.ExpectQuery(`INSERT INTO accounts \(document_number\) VALUES \(\$1\) RETURNING id`).
-		WithArgs(expected.DocumentNumber).
+	mock.ExpectQuery(`INSERT INTO accounts \(tenant_id, document_number\) VALUES \(\$1, \$2\) RETURNING id, version`).
+		WithArgs(testTenant, expected.DocumentNumber).
 		WillReturnRows(
-			sqlmock.NewRows([]string{"id"}).
-				AddRow(1),
+			sqlmock.NewRows([]string{"id", "version"}).
+				AddRow(1, 1),
 		)
 	mock.ExpectCommit()
 
-	actual, err := svc.CreateAccount(context.Background(), accounts.AccountCreation{DocumentNumber: expected.DocumentNumber})
+	actual, err := svc.CreateAccount(tenantCtx(), accounts.AccountCreation{DocumentNumber: expected.DocumentNumber})
 
 	assert.NoError(t, err)
 	assert.Equal(t, expected, actual)
@@ -51,8 +60,8 @@ func TestService_CreateAccount_Error_Duplicate(t *testing.T) {
 	svc := accounts.NewService(db, database.NewAccountsRepository())
 
 	mock.ExpectBegin().WillReturnError(nil)
-	mock.ExpectQuery(`INSERT INTO accounts \(document_number\) VALUES \(\$1\) RETURNING id`).
-		WithArgs("abc").
+	mock.ExpectQuery(`INSERT INTO accounts \(tenant_id, document_number\) VALUES \(\$1, \$2\) RETURNING id`).
+		WithArgs(testTenant, "abc").
 		WillReturnError(
 			&pq.Error{
 				Code: "23505",
@@ -60,7 +69,7 @@ func TestService_CreateAccount_Error_Duplicate(t *testing.T) {
 		)
 	mock.ExpectRollback()
 
-	_, err = svc.CreateAccount(context.Background(), accounts.AccountCreation{DocumentNumber: "abc"})
+	_, err = svc.CreateAccount(tenantCtx(), accounts.AccountCreation{DocumentNumber: "abc"})
 
 	assert.ErrorIs(t, err, common.ErrDuplicate)
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -74,14 +83,14 @@ func TestService_CreateAccount_Error_Propagated(t *testing.T) {
 	svc := accounts.NewService(db, database.NewAccountsRepository())
 
 	mock.ExpectBegin().WillReturnError(nil)
-	mock.ExpectQuery(`INSERT INTO accounts \(document_number\) VALUES \(\$1\) RETURNING id`).WillReturnError(
+	mock.ExpectQuery(`INSERT INTO accounts \(tenant_id, document_number\) VALUES \(\$1, \$2\) RETURNING id`).WillReturnError(
 		&pq.Error{
 			Code: "08006",
 		},
 	)
 	mock.ExpectRollback()
 
-	_, err = svc.CreateAccount(context.Background(), accounts.AccountCreation{DocumentNumber: "abc"})
+	_, err = svc.CreateAccount(tenantCtx(), accounts.AccountCreation{DocumentNumber: "abc"})
 
 	assert.Error(t, err)
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -94,25 +103,57 @@ func TestService_GetAccountByID_Success(t *testing.T) {
 	db := dbx.New(mockDB)
 	svc := accounts.NewService(db, database.NewAccountsRepository())
 
-	mock.ExpectQuery(`SELECT \* FROM accounts WHERE id=\$1`).
-		WithArgs(7).
+	mock.ExpectQuery(`SELECT id, document_number, blocked_at IS NOT NULL, version FROM accounts WHERE tenant_id=\$1 AND id=\$2`).
+		WithArgs(testTenant, 7).
 		WillReturnRows(
-			sqlmock.NewRows([]string{"id", "document_number"}).
-				AddRow(7, "abc"),
+			sqlmock.NewRows(accountColumns).
+				AddRow(7, "abc", true, 2),
 		)
 
 	expected := accounts.Account{
 		ID:             7,
 		DocumentNumber: "abc",
+		Blocked:        true,
+		Version:        2,
 	}
 
-	actual, err := svc.GetAccountByID(context.Background(), 7)
+	actual, err := svc.GetAccountByID(tenantCtx(), 7)
 
 	assert.NoError(t, err)
 	assert.Equal(t, expected, actual)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+// replicaRouter serves all reads from the replica.
+type replicaRouter struct {
+	dbx.Database
+	replica dbx.Database
+}
+
+func (r *replicaRouter) ForRead(_ context.Context) dbx.Database {
+	return r.replica
+}
+
+func TestService_GetAccountByID_ReadsFromReplica(t *testing.T) {
+	primaryDB, primary, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer primaryDB.Close()
+	replicaDB, replica, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer replicaDB.Close()
+	svc := accounts.NewService(&replicaRouter{dbx.New(primaryDB), dbx.New(replicaDB)}, database.NewAccountsRepository())
+
+	replica.ExpectQuery(`SELECT id, document_number, blocked_at IS NOT NULL, version FROM accounts`).
+		WithArgs(testTenant, 7).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(7, "abc", false, 1))
+
+	_, err = svc.GetAccountByID(tenantCtx(), 7)
+
+	assert.NoError(t, err)
+	assert.NoError(t, replica.ExpectationsWereMet())
+	assert.NoError(t, primary.ExpectationsWereMet())
+}
+
 func TestService_GetAccountByID_Error_NotFound(t *testing.T) {
 	mockDB, mock, err := sqlmock.New()
 	assert.NoError(t, err)
@@ -120,11 +161,11 @@ func TestService_GetAccountByID_Error_NotFound(t *testing.T) {
 	db := dbx.New(mockDB)
 	svc := accounts.NewService(db, database.NewAccountsRepository())
 
-	mock.ExpectQuery(`SELECT \* FROM accounts WHERE id=\$1`).WillReturnRows(
-		sqlmock.NewRows([]string{"id", "document_number"}),
+	mock.ExpectQuery(`SELECT id, document_number, blocked_at IS NOT NULL, version FROM accounts WHERE tenant_id=\$1 AND id=\$2`).WillReturnRows(
+		sqlmock.NewRows(accountColumns),
 	)
 
-	_, err = svc.GetAccountByID(context.Background(), 7)
+	_, err = svc.GetAccountByID(tenantCtx(), 7)
 
 	assert.ErrorIs(t, err, common.ErrNotFound)
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -137,16 +178,132 @@ func TestService_GetAccountByID_Error_Propagated(t *testing.T) {
 	db := dbx.New(mockDB)
 	svc := accounts.NewService(db, database.NewAccountsRepository())
 
-	mock.ExpectQuery(`SELECT \* FROM accounts WHERE id=\$1`).
-		WithArgs(7).
+	mock.ExpectQuery(`SELECT id, document_number, blocked_at IS NOT NULL, version FROM accounts WHERE tenant_id=\$1 AND id=\$2`).
+		WithArgs(testTenant, 7).
 		WillReturnError(
 			&pq.Error{
 				Code: "08006",
 			},
 		)
 
-	_, err = svc.GetAccountByID(context.Background(), 7)
+	_, err = svc.GetAccountByID(tenantCtx(), 7)
 
 	assert.Error(t, err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestService_GetAccountByID_Error_MissingTenant(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := accounts.NewService(db, database.NewAccountsRepository())
+
+	_, err = svc.GetAccountByID(context.Background(), 7)
+
+	assert.ErrorIs(t, err, common.ErrMissingTenant)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_BlockAccount_Success(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := accounts.NewService(db, database.NewAccountsRepository())
+
+	mock.ExpectExec(`UPDATE accounts SET\s+blocked_at = .+,\s+version = version \+ 1\s+WHERE tenant_id=\$1 AND id=\$2`).
+		WithArgs(testTenant, 7, true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE accounts SET\s+blocked_at = .+,\s+version = version \+ 1\s+WHERE tenant_id=\$1 AND id=\$2`).
+		WithArgs(testTenant, 7, false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, svc.BlockAccount(tenantCtx(), 7))
+	assert.NoError(t, svc.UnblockAccount(tenantCtx(), 7))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_BlockAccount_Error_NotFound(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := accounts.NewService(db, database.NewAccountsRepository())
+
+	mock.ExpectExec(`UPDATE accounts SET blocked_at`).
+		WithArgs(testTenant, 7, true).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = svc.BlockAccount(tenantCtx(), 7)
+
+	assert.ErrorIs(t, err, common.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_UpdateAccount_Success(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := accounts.NewService(db, database.NewAccountsRepository())
+
+	blocked := true
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE accounts SET .+ WHERE tenant_id=\$1 AND id=\$2 AND \(\$4::bigint\[\] IS NULL OR version = ANY\(\$4\)\)`).
+		WithArgs(testTenant, 7, &blocked, pq.Array([]int64{3})).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(7, "abc", true, 4))
+	mock.ExpectCommit()
+
+	actual, err := svc.UpdateAccount(tenantCtx(), 7, accounts.AccountUpdate{Blocked: &blocked}, []int64{3})
+
+	assert.NoError(t, err)
+	assert.Equal(t, accounts.Account{ID: 7, DocumentNumber: "abc", Blocked: true, Version: 4}, actual)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_UpdateAccount_Error_VersionMismatch(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := accounts.NewService(db, database.NewAccountsRepository())
+
+	blocked := true
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE accounts SET`).
+		WithArgs(testTenant, 7, &blocked, pq.Array([]int64{3})).
+		WillReturnRows(sqlmock.NewRows(accountColumns))
+	mock.ExpectQuery(`SELECT id, document_number, blocked_at IS NOT NULL, version FROM accounts`).
+		WithArgs(testTenant, 7).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(7, "abc", false, 4))
+	mock.ExpectRollback()
+
+	_, err = svc.UpdateAccount(tenantCtx(), 7, accounts.AccountUpdate{Blocked: &blocked}, []int64{3})
+
+	assert.ErrorIs(t, err, accounts.ErrVersionMismatch)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_UpdateAccount_Error_NotFound(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := accounts.NewService(db, database.NewAccountsRepository())
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE accounts SET`).
+		WillReturnRows(sqlmock.NewRows(accountColumns))
+	mock.ExpectQuery(`SELECT id, document_number, blocked_at IS NOT NULL, version FROM accounts`).
+		WithArgs(testTenant, 7).
+		WillReturnRows(sqlmock.NewRows(accountColumns))
+	mock.ExpectRollback()
+
+	_, err = svc.UpdateAccount(tenantCtx(), 7, accounts.AccountUpdate{}, nil)
+
+	assert.ErrorIs(t, err, common.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
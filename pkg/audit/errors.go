@@ -0,0 +1,8 @@
+package audit
+
+import "errors"
+
+var (
+	ErrInvalidOutcome = errors.New("invalid outcome")
+	ErrInvalidFilter  = errors.New("invalid filter")
+)
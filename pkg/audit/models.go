@@ -0,0 +1,62 @@
+package audit
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type (
+	Outcome string
+
+	EntryCreation struct {
+		// TenantID is empty for system wide actions, e.g. tenant creation.
+		TenantID    string          `json:"tenant_id" db:"tenant_id"`
+		Principal   string          `json:"principal" db:"principal"`
+		RequestID   string          `json:"request_id" db:"request_id"`
+		OperationID string          `json:"operation_id" db:"operation_id"`
+		Payload     json.RawMessage `json:"payload" db:"payload"`
+		Outcome     Outcome         `json:"outcome" db:"outcome"`
+		Status      int             `json:"status" db:"status"`
+	}
+
+	Entry struct {
+		ID          int64           `json:"id" db:"id"`
+		TenantID    string          `json:"tenant_id" db:"tenant_id"`
+		Principal   string          `json:"principal" db:"principal"`
+		RequestID   string          `json:"request_id" db:"request_id"`
+		OperationID string          `json:"operation_id" db:"operation_id"`
+		Payload     json.RawMessage `json:"payload" db:"payload"`
+		Outcome     Outcome         `json:"outcome" db:"outcome"`
+		Status      int             `json:"status" db:"status"`
+		CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+	}
+
+	Filter struct {
+		Principal   string
+		OperationID string
+		Outcome     Outcome
+		From        *time.Time
+		To          *time.Time
+		// BeforeID is used for pagination, entries are returned newest first.
+		BeforeID int64
+		Limit    int
+	}
+)
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+)
+
+const (
+	DefaultLimit = 100
+	MaxLimit     = 500
+)
+
+func (o Outcome) IsValid() bool {
+	return o == OutcomeSuccess || o == OutcomeFailure
+}
+
+func (o Outcome) String() string {
+	return string(o)
+}
@@ -0,0 +1,73 @@
+package audit
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// SensitiveFields lists payload fields that never reach the audit log in plain text.
+var SensitiveFields = []string{
+	// settlement files are stored with their import job, the audit log keeps the file name only
+	"content",
+	"document_number",
+	"password",
+	"secret",
+	"token",
+}
+
+// Redact replaces values of sensitive fields at any depth of a JSON payload.
+// Payloads that are not valid JSON are dropped entirely.
+func Redact(payload []byte, fields []string) json.RawMessage {
+	if len(payload) == 0 {
+		return nil
+	}
+
+	var doc any
+
+	if err := json.Unmarshal(payload, &doc); err != nil {
+		return nil
+	}
+
+	out, err := json.Marshal(redact(doc, fields))
+
+	if err != nil {
+		return nil
+	}
+
+	return out
+}
+
+func redact(value any, fields []string) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, nested := range v {
+			if isSensitive(key, fields) {
+				v[key] = redacted
+			} else {
+				v[key] = redact(nested, fields)
+			}
+		}
+
+		return v
+	case []any:
+		for i, nested := range v {
+			v[i] = redact(nested, fields)
+		}
+
+		return v
+	default:
+		return v
+	}
+}
+
+func isSensitive(key string, fields []string) bool {
+	for _, f := range fields {
+		if strings.EqualFold(key, f) {
+			return true
+		}
+	}
+
+	return false
+}
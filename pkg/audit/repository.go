@@ -0,0 +1,11 @@
+package audit
+
+import (
+	"github.com/ziflex/dbx"
+)
+
+type Repository interface {
+	CreateEntry(ctx dbx.Context, entry EntryCreation) (Entry, error)
+	// ListEntries returns entries of the tenant from the context matching the filter, newest first.
+	ListEntries(ctx dbx.Context, filter Filter) ([]Entry, error)
+}
@@ -0,0 +1,73 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog"
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+)
+
+type (
+	Service interface {
+		Record(ctx context.Context, entry EntryCreation) (Entry, error)
+		ListEntries(ctx context.Context, filter Filter) ([]Entry, error)
+	}
+
+	serviceImpl struct {
+		db         dbx.Database
+		repository Repository
+	}
+)
+
+func NewService(db dbx.Database, repository Repository) Service {
+	return &serviceImpl{db, repository}
+}
+
+func (s *serviceImpl) Record(ctx context.Context, entry EntryCreation) (Entry, error) {
+	log := zerolog.Ctx(ctx)
+
+	if !entry.Outcome.IsValid() {
+		return Entry{}, fmt.Errorf("%w: %s", ErrInvalidOutcome, entry.Outcome)
+	}
+
+	res, err := s.repository.CreateEntry(dbx.NewContextFrom(ctx, s.db), entry)
+
+	if err != nil {
+		log.Error().Err(err).Str("operation_id", entry.OperationID).Msg("failed to record audit entry")
+
+		return Entry{}, err
+	}
+
+	return res, nil
+}
+
+func (s *serviceImpl) ListEntries(ctx context.Context, filter Filter) ([]Entry, error) {
+	log := zerolog.Ctx(ctx)
+	log.Info().Msg("listing audit entries")
+
+	if filter.Outcome != "" && !filter.Outcome.IsValid() {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidOutcome, filter.Outcome)
+	}
+
+	if filter.From != nil && filter.To != nil && filter.From.After(*filter.To) {
+		return nil, fmt.Errorf("%w: from is after to", ErrInvalidFilter)
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultLimit
+	}
+
+	filter.Limit = min(filter.Limit, MaxLimit)
+
+	entries, err := s.repository.ListEntries(dbx.NewContextFrom(ctx, common.ForRead(ctx, s.db)), filter)
+
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list audit entries")
+
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,150 @@
+package audit_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/internal/database"
+	"github.com/ziflex/rm-rf-production/pkg/audit"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+)
+
+var columns = []string{"id", "tenant_id", "principal", "request_id", "operation_id", "payload", "outcome", "status", "created_at"}
+
+func TestService_Record_Success(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := audit.NewService(db, database.NewAuditRepository())
+
+	ts := time.Now()
+	payload := json.RawMessage(`{"document_number":"[REDACTED]"}`)
+
+	mock.ExpectQuery(`INSERT INTO audit_log \(tenant_id, principal, request_id, operation_id, payload, outcome, status\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7\) RETURNING id, tenant_id, principal, request_id, operation_id, payload, outcome, status, created_at`).
+		WithArgs("acme", "apikey:1", "req-1", "createAccount", string(payload), "success", 201).
+		WillReturnRows(
+			sqlmock.NewRows(columns).
+				AddRow(1, "acme", "apikey:1", "req-1", "createAccount", []byte(payload), "success", 201, ts),
+		)
+
+	actual, err := svc.Record(context.Background(), audit.EntryCreation{
+		TenantID:    "acme",
+		Principal:   "apikey:1",
+		RequestID:   "req-1",
+		OperationID: "createAccount",
+		Payload:     payload,
+		Outcome:     audit.OutcomeSuccess,
+		Status:      201,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, audit.Entry{
+		ID:          1,
+		TenantID:    "acme",
+		Principal:   "apikey:1",
+		RequestID:   "req-1",
+		OperationID: "createAccount",
+		Payload:     payload,
+		Outcome:     audit.OutcomeSuccess,
+		Status:      201,
+		CreatedAt:   ts,
+	}, actual)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_Record_Error_InvalidOutcome(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := audit.NewService(db, database.NewAuditRepository())
+
+	_, err = svc.Record(context.Background(), audit.EntryCreation{Outcome: "maybe"})
+
+	assert.ErrorIs(t, err, audit.ErrInvalidOutcome)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_ListEntries_Success(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := audit.NewService(db, database.NewAuditRepository())
+
+	from := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	ts := time.Now()
+
+	mock.ExpectQuery(`SELECT id, tenant_id, principal, request_id, operation_id, payload, outcome, status, created_at FROM audit_log WHERE tenant_id=\$1 AND principal=\$2 AND outcome=\$3 AND created_at>=\$4 ORDER BY id DESC LIMIT \$5`).
+		WithArgs("acme", "apikey:1", "failure", from, audit.DefaultLimit).
+		WillReturnRows(
+			sqlmock.NewRows(columns).
+				AddRow(5, "acme", "apikey:1", "req-5", "createTransaction", nil, "failure", 404, ts),
+		)
+
+	actual, err := svc.ListEntries(common.WithTenant(context.Background(), "acme"), audit.Filter{
+		Principal: "apikey:1",
+		Outcome:   audit.OutcomeFailure,
+		From:      &from,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []audit.Entry{
+		{
+			ID:          5,
+			TenantID:    "acme",
+			Principal:   "apikey:1",
+			RequestID:   "req-5",
+			OperationID: "createTransaction",
+			Outcome:     audit.OutcomeFailure,
+			Status:      404,
+			CreatedAt:   ts,
+		},
+	}, actual)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_ListEntries_Error_MissingTenant(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := audit.NewService(db, database.NewAuditRepository())
+
+	_, err = svc.ListEntries(context.Background(), audit.Filter{})
+
+	assert.ErrorIs(t, err, common.ErrMissingTenant)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_ListEntries_Error_InvalidFilter(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := audit.NewService(db, database.NewAuditRepository())
+
+	from := time.Now()
+	to := from.Add(-time.Hour)
+
+	_, err = svc.ListEntries(common.WithTenant(context.Background(), "acme"), audit.Filter{From: &from, To: &to})
+
+	assert.ErrorIs(t, err, audit.ErrInvalidFilter)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRedact(t *testing.T) {
+	payload := []byte(`{"document_number":"12345678900","nested":{"Secret":"s3cr3t","amount":10},"items":[{"token":"t"}]}`)
+
+	actual := audit.Redact(payload, audit.SensitiveFields)
+
+	assert.JSONEq(t, `{"document_number":"[REDACTED]","nested":{"Secret":"[REDACTED]","amount":10},"items":[{"token":"[REDACTED]"}]}`, string(actual))
+	assert.Nil(t, audit.Redact([]byte("not json"), audit.SensitiveFields))
+	assert.Nil(t, audit.Redact(nil, audit.SensitiveFields))
+}
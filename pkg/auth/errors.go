@@ -0,0 +1,11 @@
+package auth
+
+import "errors"
+
+var (
+	ErrUnauthorized  = errors.New("unauthorized")
+	ErrForbidden     = errors.New("forbidden")
+	ErrInvalidScope  = errors.New("invalid scope")
+	ErrInvalidName   = errors.New("invalid name")
+	ErrInvalidTenant = errors.New("invalid tenant")
+)
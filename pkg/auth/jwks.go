@@ -0,0 +1,303 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	defaultJWKSRefreshInterval    = 15 * time.Minute
+	defaultJWKSMinRefreshInterval = 30 * time.Second
+)
+
+type (
+	// KeyProvider resolves a public key used to verify token signatures by its key ID.
+	KeyProvider interface {
+		Key(ctx context.Context, kid string) (crypto.PublicKey, error)
+	}
+
+	JWKSOptions struct {
+		// URL of the JWKS document. Mutually exclusive with File.
+		URL string
+		// File is a path to a JWKS document on disk. Mutually exclusive with URL.
+		File string
+		// RefreshInterval defines how long a loaded key set is considered fresh.
+		RefreshInterval time.Duration
+		// MinRefreshInterval is the least time between two reloads, successful or not.
+		// It throttles the reloads triggered by unknown key IDs and by an unavailable source.
+		MinRefreshInterval time.Duration
+		HTTPClient         *http.Client
+	}
+
+	// JWKS is a cached JSON Web Key Set that is reloaded periodically
+	// and whenever a token signed with an unknown key shows up, so that key rotation
+	// on the issuer side is picked up without restarts.
+	JWKS struct {
+		opts      JWKSOptions
+		refreshMu sync.Mutex
+		mu        sync.RWMutex
+		keys      map[string]crypto.PublicKey
+		loadedAt  time.Time
+		// attemptedAt is the start of the last reload, failed ones included.
+		attemptedAt time.Time
+	}
+
+	jsonWebKeySet struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+
+	jsonWebKey struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		Alg string `json:"alg"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+		Crv string `json:"crv"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	}
+)
+
+func NewJWKS(ctx context.Context, opts JWKSOptions) (*JWKS, error) {
+	if (opts.URL == "") == (opts.File == "") {
+		return nil, fmt.Errorf("exactly one of jwks url or file must be set")
+	}
+
+	if opts.RefreshInterval <= 0 {
+		opts.RefreshInterval = defaultJWKSRefreshInterval
+	}
+
+	if opts.MinRefreshInterval <= 0 {
+		opts.MinRefreshInterval = defaultJWKSMinRefreshInterval
+	}
+
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	jwks := &JWKS{opts: opts}
+
+	if err := jwks.refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	return jwks, nil
+}
+
+func (j *JWKS) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	j.mu.RLock()
+	key, found := j.keys[kid]
+	stale := time.Since(j.loadedAt) > j.opts.RefreshInterval
+	throttled := time.Since(j.attemptedAt) < j.opts.MinRefreshInterval
+	j.mu.RUnlock()
+
+	// unknown key IDs do not reach the source more often than the minimum interval,
+	// whether the last reload failed or not
+	if (stale || !found) && !throttled {
+		if err := j.refresh(ctx); err != nil {
+			// keep serving the cached keys if the source is temporarily unavailable
+			if !found {
+				return nil, fmt.Errorf("%w: unknown key id %q: %v", ErrUnauthorized, kid, err)
+			}
+
+			return key, nil
+		}
+
+		j.mu.RLock()
+		key, found = j.keys[kid]
+		j.mu.RUnlock()
+	}
+
+	if !found {
+		return nil, fmt.Errorf("%w: unknown key id %q", ErrUnauthorized, kid)
+	}
+
+	return key, nil
+}
+
+func (j *JWKS) refresh(ctx context.Context) error {
+	j.refreshMu.Lock()
+	defer j.refreshMu.Unlock()
+
+	j.mu.Lock()
+	attemptedAt := j.attemptedAt
+
+	// another goroutine has just tried to reload the set while we were waiting
+	if !attemptedAt.IsZero() && time.Since(attemptedAt) < j.opts.MinRefreshInterval {
+		j.mu.Unlock()
+
+		return nil
+	}
+
+	j.attemptedAt = time.Now()
+	j.mu.Unlock()
+
+	data, err := j.fetch(ctx)
+
+	if err != nil {
+		return fmt.Errorf("failed to load jwks: %w", err)
+	}
+
+	keys, err := ParseJWKS(data)
+
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	j.keys = keys
+	j.loadedAt = time.Now()
+	j.mu.Unlock()
+
+	return nil
+}
+
+func (j *JWKS) fetch(ctx context.Context) ([]byte, error) {
+	if j.opts.File != "" {
+		return os.ReadFile(j.opts.File)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.opts.URL, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := j.opts.HTTPClient.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", res.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(res.Body, 1<<20))
+}
+
+// ParseJWKS parses a JSON Web Key Set document. Only RSA and EC signing keys are supported,
+// everything else is skipped.
+func ParseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set jsonWebKeySet
+
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid jwks: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		var key crypto.PublicKey
+		var err error
+
+		switch jwk.Kty {
+		case "RSA":
+			key, err = jwk.rsaPublicKey()
+		case "EC":
+			key, err = jwk.ecdsaPublicKey()
+		default:
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwk %q: %w", jwk.Kid, err)
+		}
+
+		keys[jwk.Kid] = key
+	}
+
+	return keys, nil
+}
+
+func (k jsonWebKey) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+
+	exp := new(big.Int).SetBytes(e)
+
+	if !exp.IsInt64() || exp.Int64() < 3 || exp.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("invalid exponent")
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(exp.Int64()),
+	}, nil
+}
+
+func (k jsonWebKey) ecdsaPublicKey() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	var ecdhCurve ecdh.Curve
+
+	switch k.Crv {
+	case "P-256":
+		curve, ecdhCurve = elliptic.P256(), ecdh.P256()
+	case "P-384":
+		curve, ecdhCurve = elliptic.P384(), ecdh.P384()
+	case "P-521":
+		curve, ecdhCurve = elliptic.P521(), ecdh.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve: %s", k.Crv)
+	}
+
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid x coordinate: %w", err)
+	}
+
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid y coordinate: %w", err)
+	}
+
+	size := (curve.Params().BitSize + 7) / 8
+
+	if len(x) != size || len(y) != size {
+		return nil, fmt.Errorf("invalid coordinate length")
+	}
+
+	// ecdh validates that the point is on the curve
+	point := append(append([]byte{4}, x...), y...)
+
+	if _, err := ecdhCurve.NewPublicKey(point); err != nil {
+		return nil, err
+	}
+
+	return &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}, nil
+}
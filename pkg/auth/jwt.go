@@ -0,0 +1,296 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+)
+
+type (
+	// TokenVerifier validates bearer tokens and maps them to principals.
+	TokenVerifier interface {
+		Verify(ctx context.Context, token string) (Principal, error)
+	}
+
+	JWTOptions struct {
+		Keys     KeyProvider
+		Issuer   string
+		Audience string
+		// Leeway is the allowed clock skew when validating exp and nbf.
+		Leeway time.Duration
+		Now    func() time.Time
+	}
+
+	jwtVerifier struct {
+		opts JWTOptions
+	}
+
+	jwtHeader struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+		Typ string `json:"typ"`
+	}
+
+	jwtClaims struct {
+		Issuer    string       `json:"iss"`
+		Subject   string       `json:"sub"`
+		Audience  stringOrList `json:"aud"`
+		ExpiresAt *json.Number `json:"exp"`
+		NotBefore *json.Number `json:"nbf"`
+		Scope     string       `json:"scope"`
+		Scp       stringOrList `json:"scp"`
+		Name      string       `json:"name"`
+		TenantID  string       `json:"tenant_id"`
+	}
+
+	stringOrList []string
+)
+
+func NewJWTVerifier(opts JWTOptions) (TokenVerifier, error) {
+	if opts.Keys == nil {
+		return nil, fmt.Errorf("key provider is required")
+	}
+
+	if opts.Issuer == "" {
+		return nil, fmt.Errorf("issuer is required")
+	}
+
+	if opts.Audience == "" {
+		return nil, fmt.Errorf("audience is required")
+	}
+
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	return &jwtVerifier{opts}, nil
+}
+
+func (v *jwtVerifier) Verify(ctx context.Context, token string) (Principal, error) {
+	parts := strings.Split(token, ".")
+
+	if len(parts) != 3 {
+		return Principal{}, invalidToken("malformed token")
+	}
+
+	var header jwtHeader
+
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Principal{}, invalidToken("malformed header")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+
+	if err != nil {
+		return Principal{}, invalidToken("malformed signature")
+	}
+
+	key, err := v.opts.Keys.Key(ctx, header.Kid)
+
+	if err != nil {
+		return Principal{}, err
+	}
+
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return Principal{}, err
+	}
+
+	var claims jwtClaims
+
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Principal{}, invalidToken("malformed claims")
+	}
+
+	if err := v.validateClaims(claims); err != nil {
+		return Principal{}, err
+	}
+
+	name := claims.Name
+
+	if name == "" {
+		name = claims.Subject
+	}
+
+	return Principal{
+		ID:       "jwt:" + claims.Subject,
+		Name:     name,
+		TenantID: claims.TenantID,
+		Scopes:   claims.scopes(),
+	}, nil
+}
+
+func (v *jwtVerifier) validateClaims(claims jwtClaims) error {
+	now := v.opts.Now()
+
+	if claims.Issuer != v.opts.Issuer {
+		return invalidToken("unexpected issuer")
+	}
+
+	if !slices.Contains(claims.Audience, v.opts.Audience) {
+		return invalidToken("unexpected audience")
+	}
+
+	if claims.Subject == "" {
+		return invalidToken("missing subject")
+	}
+
+	if claims.TenantID == "" {
+		return invalidToken("missing tenant")
+	}
+
+	if claims.ExpiresAt == nil {
+		return invalidToken("missing expiration")
+	}
+
+	exp, err := numericDate(*claims.ExpiresAt)
+
+	if err != nil {
+		return invalidToken("invalid expiration")
+	}
+
+	if !now.Before(exp.Add(v.opts.Leeway)) {
+		return invalidToken("token expired")
+	}
+
+	if claims.NotBefore != nil {
+		nbf, err := numericDate(*claims.NotBefore)
+
+		if err != nil {
+			return invalidToken("invalid not before")
+		}
+
+		if now.Add(v.opts.Leeway).Before(nbf) {
+			return invalidToken("token not yet valid")
+		}
+	}
+
+	return nil
+}
+
+// scopes maps the standard "scope" claim (space separated string) and
+// the "scp" claim (list) used by some issuers to the scopes our operations declare.
+// Unknown scopes are dropped.
+func (c jwtClaims) scopes() []Scope {
+	raw := strings.Fields(c.Scope)
+
+	for _, s := range c.Scp {
+		raw = append(raw, strings.Fields(s)...)
+	}
+
+	out := make([]Scope, 0, len(raw))
+
+	for _, s := range raw {
+		scope := Scope(s)
+
+		if scope.IsValid() && !slices.Contains(out, scope) {
+			out = append(out, scope)
+		}
+	}
+
+	return out
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signed))
+
+	switch alg {
+	case AlgRS256:
+		pub, ok := key.(*rsa.PublicKey)
+
+		if !ok {
+			return invalidToken("key type does not match algorithm")
+		}
+
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature); err != nil {
+			return invalidToken("invalid signature")
+		}
+
+		return nil
+	case AlgES256:
+		pub, ok := key.(*ecdsa.PublicKey)
+
+		if !ok || pub.Curve.Params().Name != "P-256" {
+			return invalidToken("key type does not match algorithm")
+		}
+
+		if len(signature) != 64 {
+			return invalidToken("invalid signature")
+		}
+
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return invalidToken("invalid signature")
+		}
+
+		return nil
+	default:
+		return invalidToken(fmt.Sprintf("unsupported algorithm %q", alg))
+	}
+}
+
+func decodeSegment(segment string, out any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+
+	return dec.Decode(out)
+}
+
+func numericDate(n json.Number) (time.Time, error) {
+	f, err := n.Float64()
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(int64(f), 0), nil
+}
+
+func invalidToken(reason string) error {
+	return fmt.Errorf("%w: %s", ErrUnauthorized, reason)
+}
+
+func (s *stringOrList) UnmarshalJSON(data []byte) error {
+	var single string
+
+	if err := json.Unmarshal(data, &single); err == nil {
+		if single == "" {
+			*s = nil
+		} else {
+			*s = stringOrList{single}
+		}
+
+		return nil
+	}
+
+	var list []string
+
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+
+	*s = list
+
+	return nil
+}
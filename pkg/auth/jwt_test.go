@@ -0,0 +1,328 @@
+package auth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ziflex/rm-rf-production/pkg/auth"
+)
+
+const (
+	testIssuer   = "https://gateway.internal"
+	testAudience = "rm-rf-production"
+)
+
+type testKey struct {
+	kid string
+	alg string
+	key crypto.Signer
+}
+
+func newRSAKey(t *testing.T, kid string) testKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	return testKey{kid, auth.AlgRS256, key}
+}
+
+func newECKey(t *testing.T, kid string) testKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	return testKey{kid, auth.AlgES256, key}
+}
+
+func (k testKey) jwk() map[string]string {
+	switch pub := k.key.Public().(type) {
+	case *rsa.PublicKey:
+		return map[string]string{
+			"kty": "RSA",
+			"kid": k.kid,
+			"use": "sig",
+			"alg": k.alg,
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}
+	case *ecdsa.PublicKey:
+		return map[string]string{
+			"kty": "EC",
+			"kid": k.kid,
+			"use": "sig",
+			"alg": k.alg,
+			"crv": "P-256",
+			"x":   base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, 32))),
+			"y":   base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, 32))),
+		}
+	default:
+		panic("unsupported key")
+	}
+}
+
+func (k testKey) sign(t *testing.T, claims map[string]any) string {
+	header, err := json.Marshal(map[string]string{"alg": k.alg, "kid": k.kid, "typ": "JWT"})
+	assert.NoError(t, err)
+
+	payload, err := json.Marshal(claims)
+	assert.NoError(t, err)
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var sig []byte
+
+	switch key := k.key.(type) {
+	case *rsa.PrivateKey:
+		sig, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		assert.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		assert.NoError(t, err)
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func jwksDocument(t *testing.T, keys ...testKey) []byte {
+	set := make([]map[string]string, 0, len(keys))
+
+	for _, k := range keys {
+		set = append(set, k.jwk())
+	}
+
+	data, err := json.Marshal(map[string]any{"keys": set})
+	assert.NoError(t, err)
+
+	return data
+}
+
+func writeJWKS(t *testing.T, keys ...testKey) string {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	assert.NoError(t, os.WriteFile(path, jwksDocument(t, keys...), 0o600))
+
+	return path
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"iss":       testIssuer,
+		"aud":       testAudience,
+		"sub":       "svc-billing",
+		"tenant_id": "acme",
+		"exp":       time.Now().Add(time.Hour).Unix(),
+		"scope":     "accounts:read transactions:write unknown:scope",
+	}
+}
+
+func newVerifier(t *testing.T, keys auth.KeyProvider) auth.TokenVerifier {
+	verifier, err := auth.NewJWTVerifier(auth.JWTOptions{
+		Keys:     keys,
+		Issuer:   testIssuer,
+		Audience: testAudience,
+	})
+	assert.NoError(t, err)
+
+	return verifier
+}
+
+func TestJWTVerifier_Verify_Success(t *testing.T) {
+	keys := []testKey{newRSAKey(t, "rsa-1"), newECKey(t, "ec-1")}
+	jwks, err := auth.NewJWKS(context.Background(), auth.JWKSOptions{File: writeJWKS(t, keys...)})
+	assert.NoError(t, err)
+	verifier := newVerifier(t, jwks)
+
+	for _, key := range keys {
+		t.Run(key.alg, func(t *testing.T) {
+			principal, err := verifier.Verify(context.Background(), key.sign(t, validClaims()))
+
+			assert.NoError(t, err)
+			assert.Equal(t, auth.Principal{
+				ID:       "jwt:svc-billing",
+				Name:     "svc-billing",
+				TenantID: "acme",
+				Scopes:   []auth.Scope{auth.ScopeAccountsRead, auth.ScopeTransactionsWrite},
+			}, principal)
+		})
+	}
+}
+
+func TestJWTVerifier_Verify_Error_Claims(t *testing.T) {
+	key := newRSAKey(t, "rsa-1")
+	jwks, err := auth.NewJWKS(context.Background(), auth.JWKSOptions{File: writeJWKS(t, key)})
+	assert.NoError(t, err)
+	verifier := newVerifier(t, jwks)
+
+	type testCase struct {
+		Name   string
+		Modify func(claims map[string]any)
+	}
+
+	tsdata := []testCase{
+		{"Expired", func(c map[string]any) { c["exp"] = time.Now().Add(-time.Minute).Unix() }},
+		{"MissingExpiration", func(c map[string]any) { delete(c, "exp") }},
+		{"NotYetValid", func(c map[string]any) { c["nbf"] = time.Now().Add(time.Hour).Unix() }},
+		{"WrongIssuer", func(c map[string]any) { c["iss"] = "https://evil.example" }},
+		{"WrongAudience", func(c map[string]any) { c["aud"] = []string{"other-service"} }},
+		{"MissingTenant", func(c map[string]any) { delete(c, "tenant_id") }},
+	}
+
+	for _, tc := range tsdata {
+		t.Run(tc.Name, func(t *testing.T) {
+			claims := validClaims()
+			tc.Modify(claims)
+
+			_, err := verifier.Verify(context.Background(), key.sign(t, claims))
+
+			assert.ErrorIs(t, err, auth.ErrUnauthorized)
+		})
+	}
+}
+
+func TestJWTVerifier_Verify_Error_Signature(t *testing.T) {
+	key := newRSAKey(t, "rsa-1")
+	forged := newRSAKey(t, "rsa-1")
+	jwks, err := auth.NewJWKS(context.Background(), auth.JWKSOptions{File: writeJWKS(t, key)})
+	assert.NoError(t, err)
+	verifier := newVerifier(t, jwks)
+
+	_, err = verifier.Verify(context.Background(), forged.sign(t, validClaims()))
+	assert.ErrorIs(t, err, auth.ErrUnauthorized)
+
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","kid":"rsa-1"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"x"}`)) + "."
+	_, err = verifier.Verify(context.Background(), none)
+	assert.ErrorIs(t, err, auth.ErrUnauthorized)
+}
+
+func TestJWKS_Key_Rotation(t *testing.T) {
+	oldKey := newECKey(t, "ec-1")
+	newKey := newECKey(t, "ec-2")
+
+	var mu sync.Mutex
+	current := jwksDocument(t, oldKey)
+	fetches := 0
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		fetches++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(current)
+	}))
+	defer srv.Close()
+
+	jwks, err := auth.NewJWKS(context.Background(), auth.JWKSOptions{
+		URL:                srv.URL,
+		MinRefreshInterval: time.Millisecond,
+	})
+	assert.NoError(t, err)
+	verifier := newVerifier(t, jwks)
+
+	_, err = verifier.Verify(context.Background(), oldKey.sign(t, validClaims()))
+	assert.NoError(t, err)
+
+	mu.Lock()
+	current = jwksDocument(t, newKey)
+	mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	_, err = verifier.Verify(context.Background(), newKey.sign(t, validClaims()))
+	assert.NoError(t, err)
+
+	mu.Lock()
+	assert.Equal(t, 2, fetches)
+	mu.Unlock()
+}
+
+func TestJWKS_Key_FailedRefreshBackoff(t *testing.T) {
+	key := newECKey(t, "ec-1")
+
+	var fetches, failing atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fetches.Add(1)
+
+		if failing.Load() == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(jwksDocument(t, key))
+	}))
+	defer srv.Close()
+
+	jwks, err := auth.NewJWKS(context.Background(), auth.JWKSOptions{
+		URL:                srv.URL,
+		RefreshInterval:    time.Millisecond,
+		MinRefreshInterval: 50 * time.Millisecond,
+	})
+	assert.NoError(t, err)
+
+	failing.Store(1)
+	time.Sleep(60 * time.Millisecond)
+
+	// the stale set is reloaded once, the failure is not retried on every request
+	for i := 0; i < 10; i++ {
+		_, err := jwks.Key(context.Background(), "ec-1")
+		assert.NoError(t, err, "the cached key is served while the source is down")
+
+		_, err = jwks.Key(context.Background(), fmt.Sprintf("random-%d", i))
+		assert.ErrorIs(t, err, auth.ErrUnauthorized)
+	}
+
+	assert.Equal(t, int32(2), fetches.Load())
+}
+
+func TestJWKS_Key_UnknownKeyFailedRefresh(t *testing.T) {
+	key := newECKey(t, "ec-1")
+
+	var failing atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if failing.Load() == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(jwksDocument(t, key))
+	}))
+	defer srv.Close()
+
+	jwks, err := auth.NewJWKS(context.Background(), auth.JWKSOptions{
+		URL:                srv.URL,
+		MinRefreshInterval: time.Millisecond,
+	})
+	assert.NoError(t, err)
+
+	failing.Store(1)
+	time.Sleep(5 * time.Millisecond)
+
+	// a token signed with an unknown key is rejected as unauthorized even if the set cannot be reloaded
+	_, err = jwks.Key(context.Background(), "ec-2")
+	assert.ErrorIs(t, err, auth.ErrUnauthorized)
+	assert.ErrorContains(t, err, "503")
+}
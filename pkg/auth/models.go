@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"slices"
+	"time"
+)
+
+type (
+	Scope string
+
+	Principal struct {
+		ID       string  `json:"id"`
+		Name     string  `json:"name"`
+		TenantID string  `json:"tenant_id"`
+		Scopes   []Scope `json:"scopes"`
+	}
+
+	APIKeyCreation struct {
+		TenantID string  `json:"tenant_id" db:"tenant_id"`
+		Name     string  `json:"name" db:"name"`
+		Scopes   []Scope `json:"scopes" db:"scopes"`
+	}
+
+	APIKey struct {
+		ID        int64      `json:"id" db:"id"`
+		TenantID  string     `json:"tenant_id" db:"tenant_id"`
+		Name      string     `json:"name" db:"name"`
+		Scopes    []Scope    `json:"scopes" db:"scopes"`
+		CreatedAt time.Time  `json:"created_at" db:"created_at"`
+		RevokedAt *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	}
+
+	// IssuedAPIKey is returned only once, at creation time.
+	// The plain-text secret is never persisted.
+	IssuedAPIKey struct {
+		APIKey
+		Secret string `json:"secret"`
+	}
+)
+
+const (
+	ScopeAccountsRead         Scope = "accounts:read"
+	ScopeAccountsWrite        Scope = "accounts:write"
+	ScopeTransactionsRead     Scope = "transactions:read"
+	ScopeTransactionsWrite    Scope = "transactions:write"
+	ScopeAuditRead            Scope = "audit:read"
+	ScopeReconciliationsRead  Scope = "reconciliations:read"
+	ScopeReconciliationsWrite Scope = "reconciliations:write"
+	ScopeJobsRead             Scope = "jobs:read"
+	ScopeDisputesRead         Scope = "disputes:read"
+	ScopeDisputesWrite        Scope = "disputes:write"
+	ScopeSchedulerRead        Scope = "scheduler:read"
+)
+
+var scopes = []Scope{
+	ScopeAccountsRead,
+	ScopeAccountsWrite,
+	ScopeTransactionsRead,
+	ScopeTransactionsWrite,
+	ScopeAuditRead,
+	ScopeReconciliationsRead,
+	ScopeReconciliationsWrite,
+	ScopeJobsRead,
+	ScopeDisputesRead,
+	ScopeDisputesWrite,
+	ScopeSchedulerRead,
+}
+
+func Scopes() []Scope {
+	return slices.Clone(scopes)
+}
+
+func (s Scope) IsValid() bool {
+	return slices.Contains(scopes, s)
+}
+
+// IsOperator reports whether the scope exposes the data of all tenants.
+// It is granted only to the principals of the operator tenant.
+func (s Scope) IsOperator() bool {
+	return s == ScopeSchedulerRead
+}
+
+func (s Scope) String() string {
+	return string(s)
+}
+
+func (p Principal) HasScope(scope Scope) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+func (k APIKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
@@ -0,0 +1,11 @@
+package auth
+
+import (
+	"github.com/ziflex/dbx"
+)
+
+type Repository interface {
+	CreateAPIKey(ctx dbx.Context, key APIKeyCreation, hash string) (APIKey, error)
+	GetAPIKeyByHash(ctx dbx.Context, hash string) (APIKey, error)
+	RevokeAPIKey(ctx dbx.Context, id int64) error
+}
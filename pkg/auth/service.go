@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+)
+
+const (
+	apiKeyPrefix = "rrp_"
+	apiKeyBytes  = 32
+)
+
+type (
+	Service interface {
+		CreateAPIKey(ctx context.Context, creation APIKeyCreation) (IssuedAPIKey, error)
+		RevokeAPIKey(ctx context.Context, id int64) error
+		Authenticate(ctx context.Context, secret string) (Principal, error)
+	}
+
+	serviceImpl struct {
+		db         dbx.Database
+		repository Repository
+	}
+)
+
+func NewService(db dbx.Database, repository Repository) Service {
+	return &serviceImpl{db, repository}
+}
+
+func (s *serviceImpl) CreateAPIKey(ctx context.Context, creation APIKeyCreation) (IssuedAPIKey, error) {
+	log := zerolog.Ctx(ctx)
+	log.Info().Str("name", creation.Name).Msg("creating api key")
+
+	if strings.TrimSpace(creation.Name) == "" {
+		return IssuedAPIKey{}, ErrInvalidName
+	}
+
+	if strings.TrimSpace(creation.TenantID) == "" {
+		return IssuedAPIKey{}, ErrInvalidTenant
+	}
+
+	if len(creation.Scopes) == 0 {
+		return IssuedAPIKey{}, fmt.Errorf("%w: at least one scope is required", ErrInvalidScope)
+	}
+
+	for _, scope := range creation.Scopes {
+		if !scope.IsValid() {
+			return IssuedAPIKey{}, fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+		}
+	}
+
+	secret, err := generateSecret()
+
+	if err != nil {
+		log.Error().Err(err).Msg("failed to generate api key secret")
+
+		return IssuedAPIKey{}, err
+	}
+
+	return dbx.TransactionWithResult[IssuedAPIKey](ctx, s.db, func(tx dbx.Context) (IssuedAPIKey, error) {
+		key, err := s.repository.CreateAPIKey(tx, creation, hashSecret(secret))
+
+		if err != nil {
+			log.Error().Err(err).Msg("failed to create api key")
+
+			return IssuedAPIKey{}, err
+		}
+
+		log.Info().Int64("id", key.ID).Msg("api key created")
+
+		return IssuedAPIKey{
+			APIKey: key,
+			Secret: secret,
+		}, nil
+	})
+}
+
+func (s *serviceImpl) RevokeAPIKey(ctx context.Context, id int64) error {
+	log := zerolog.Ctx(ctx)
+	log.Info().Int64("id", id).Msg("revoking api key")
+
+	return dbx.Transaction(ctx, s.db, func(tx dbx.Context) error {
+		if err := s.repository.RevokeAPIKey(tx, id); err != nil {
+			log.Error().Err(err).Int64("id", id).Msg("failed to revoke api key")
+
+			return err
+		}
+
+		log.Info().Int64("id", id).Msg("api key revoked")
+
+		return nil
+	})
+}
+
+func (s *serviceImpl) Authenticate(ctx context.Context, secret string) (Principal, error) {
+	log := zerolog.Ctx(ctx)
+
+	if !strings.HasPrefix(secret, apiKeyPrefix) {
+		return Principal{}, ErrUnauthorized
+	}
+
+	key, err := s.repository.GetAPIKeyByHash(dbx.NewContextFrom(ctx, s.db), hashSecret(secret))
+
+	if err != nil {
+		if errors.Is(err, common.ErrNotFound) {
+			return Principal{}, ErrUnauthorized
+		}
+
+		log.Error().Err(err).Msg("failed to get api key")
+
+		return Principal{}, err
+	}
+
+	if key.IsRevoked() {
+		log.Warn().Int64("id", key.ID).Msg("revoked api key used")
+
+		return Principal{}, ErrUnauthorized
+	}
+
+	return Principal{
+		ID:       "apikey:" + strconv.FormatInt(key.ID, 10),
+		Name:     key.Name,
+		TenantID: key.TenantID,
+		Scopes:   key.Scopes,
+	}, nil
+}
+
+func generateSecret() (string, error) {
+	buf := make([]byte, apiKeyBytes)
+
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return apiKeyPrefix + hex.EncodeToString(buf), nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+
+	return hex.EncodeToString(sum[:])
+}
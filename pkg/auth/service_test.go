@@ -0,0 +1,186 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/internal/database"
+	"github.com/ziflex/rm-rf-production/pkg/auth"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+)
+
+func TestService_CreateAPIKey_Success(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := auth.NewService(db, database.NewAPIKeysRepository())
+
+	ts := time.Now()
+
+	mock.ExpectBegin().WillReturnError(nil)
+	mock.ExpectQuery(`INSERT INTO api_keys \(tenant_id, name, key_hash, scopes\) VALUES \(\$1, \$2, \$3, \$4\) RETURNING id, tenant_id, name, scopes, created_at, revoked_at`).
+		WithArgs("acme", "ci", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(
+			sqlmock.NewRows([]string{"id", "tenant_id", "name", "scopes", "created_at", "revoked_at"}).
+				AddRow(1, "acme", "ci", "{accounts:read}", ts, nil),
+		)
+	mock.ExpectCommit()
+
+	actual, err := svc.CreateAPIKey(context.Background(), auth.APIKeyCreation{
+		TenantID: "acme",
+		Name:     "ci",
+		Scopes:   []auth.Scope{auth.ScopeAccountsRead},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), actual.ID)
+	assert.Equal(t, []auth.Scope{auth.ScopeAccountsRead}, actual.Scopes)
+	assert.NotEmpty(t, actual.Secret)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_CreateAPIKey_Error_InvalidScope(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := auth.NewService(db, database.NewAPIKeysRepository())
+
+	_, err = svc.CreateAPIKey(context.Background(), auth.APIKeyCreation{
+		TenantID: "acme",
+		Name:     "ci",
+		Scopes:   []auth.Scope{"accounts:delete"},
+	})
+
+	assert.ErrorIs(t, err, auth.ErrInvalidScope)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_CreateAPIKey_Error_MissingTenant(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := auth.NewService(db, database.NewAPIKeysRepository())
+
+	_, err = svc.CreateAPIKey(context.Background(), auth.APIKeyCreation{
+		Name:   "ci",
+		Scopes: []auth.Scope{auth.ScopeAccountsRead},
+	})
+
+	assert.ErrorIs(t, err, auth.ErrInvalidTenant)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_Authenticate_Success(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := auth.NewService(db, database.NewAPIKeysRepository())
+
+	mock.ExpectQuery(`SELECT id, tenant_id, name, scopes, created_at, revoked_at FROM api_keys WHERE key_hash=\$1`).
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnRows(
+			sqlmock.NewRows([]string{"id", "tenant_id", "name", "scopes", "created_at", "revoked_at"}).
+				AddRow(3, "acme", "ci", "{accounts:read,transactions:write}", time.Now(), nil),
+		)
+
+	actual, err := svc.Authenticate(context.Background(), "rrp_secret")
+
+	assert.NoError(t, err)
+	assert.Equal(t, auth.Principal{
+		ID:       "apikey:3",
+		Name:     "ci",
+		TenantID: "acme",
+		Scopes:   []auth.Scope{auth.ScopeAccountsRead, auth.ScopeTransactionsWrite},
+	}, actual)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_Authenticate_Error_Revoked(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := auth.NewService(db, database.NewAPIKeysRepository())
+
+	mock.ExpectQuery(`SELECT id, tenant_id, name, scopes, created_at, revoked_at FROM api_keys WHERE key_hash=\$1`).
+		WillReturnRows(
+			sqlmock.NewRows([]string{"id", "tenant_id", "name", "scopes", "created_at", "revoked_at"}).
+				AddRow(3, "acme", "ci", "{accounts:read}", time.Now(), time.Now()),
+		)
+
+	_, err = svc.Authenticate(context.Background(), "rrp_secret")
+
+	assert.ErrorIs(t, err, auth.ErrUnauthorized)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_Authenticate_Error_Unknown(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := auth.NewService(db, database.NewAPIKeysRepository())
+
+	mock.ExpectQuery(`SELECT id, tenant_id, name, scopes, created_at, revoked_at FROM api_keys WHERE key_hash=\$1`).
+		WillReturnRows(
+			sqlmock.NewRows([]string{"id", "tenant_id", "name", "scopes", "created_at", "revoked_at"}),
+		)
+
+	_, err = svc.Authenticate(context.Background(), "rrp_secret")
+
+	assert.ErrorIs(t, err, auth.ErrUnauthorized)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_RevokeAPIKey_Error_NotFound(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := auth.NewService(db, database.NewAPIKeysRepository())
+
+	mock.ExpectBegin().WillReturnError(nil)
+	mock.ExpectExec(`UPDATE api_keys SET revoked_at=CURRENT_TIMESTAMP WHERE id=\$1 AND revoked_at IS NULL`).
+		WithArgs(9).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = svc.RevokeAPIKey(context.Background(), 9)
+
+	assert.ErrorIs(t, err, common.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_CreateAPIKey_Error_Propagated(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := auth.NewService(db, database.NewAPIKeysRepository())
+
+	mock.ExpectBegin().WillReturnError(nil)
+	mock.ExpectQuery(`INSERT INTO api_keys`).WillReturnError(
+		&pq.Error{
+			Code: "08006",
+		},
+	)
+	mock.ExpectRollback()
+
+	_, err = svc.CreateAPIKey(context.Background(), auth.APIKeyCreation{
+		TenantID: "acme",
+		Name:     "ci",
+		Scopes:   []auth.Scope{auth.ScopeAccountsRead},
+	})
+
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,22 @@
+package common
+
+import (
+	"context"
+
+	"github.com/ziflex/dbx"
+)
+
+// ReadRouter is implemented by databases that serve read-only queries from replicas.
+type ReadRouter interface {
+	ForRead(ctx context.Context) dbx.Database
+}
+
+// ForRead returns the database for read-only queries. Replicas may lag behind the primary,
+// so it must not be used for reads that decide what to write.
+func ForRead(ctx context.Context, db dbx.Database) dbx.Database {
+	if router, ok := db.(ReadRouter); ok {
+		return router.ForRead(ctx)
+	}
+
+	return db
+}
@@ -0,0 +1,25 @@
+package common
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrMissingTenant = errors.New("missing tenant")
+
+type tenantKey struct{}
+
+// WithTenant scopes all repository calls made with the returned context to the given tenant.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+func TenantFromContext(ctx context.Context) (string, error) {
+	tenantID, ok := ctx.Value(tenantKey{}).(string)
+
+	if !ok || tenantID == "" {
+		return "", ErrMissingTenant
+	}
+
+	return tenantID, nil
+}
@@ -0,0 +1,12 @@
+package disputes
+
+import "errors"
+
+var (
+	// ErrNotDisputable is returned for transactions other than card purchases,
+	// and for the credits and reversals of disputes.
+	ErrNotDisputable     = errors.New("transaction is not disputable")
+	ErrInvalidStatus     = errors.New("invalid dispute status")
+	ErrInvalidTransition = errors.New("invalid dispute transition")
+	ErrInvalidReason     = errors.New("invalid dispute reason")
+)
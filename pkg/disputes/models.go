@@ -0,0 +1,121 @@
+package disputes
+
+import (
+	"slices"
+	"time"
+
+	"github.com/ziflex/rm-rf-production/pkg/transactions"
+)
+
+type (
+	Status string
+
+	// Opening disputes a purchase of an account.
+	Opening struct {
+		AccountID     int64
+		TransactionID int64
+		Reason        string
+	}
+
+	// Change moves a dispute to another status, the note is kept with the event.
+	Change struct {
+		Status Status
+		Note   string
+	}
+
+	DisputeCreation struct {
+		AccountID           int64
+		TransactionID       int64
+		CreditTransactionID int64
+		Reason              string
+		Amount              float64
+	}
+
+	// Resolution records the new status of a dispute, the reversal is set for lost disputes.
+	Resolution struct {
+		Status                Status
+		ReversalTransactionID int64
+		Now                   time.Time
+	}
+
+	EventCreation struct {
+		DisputeID int64
+		Status    Status
+		Principal string
+		Note      string
+	}
+
+	Dispute struct {
+		ID            int64
+		AccountID     int64
+		TransactionID int64
+		// CreditTransactionID is the payment crediting the disputed amount while the dispute is open.
+		CreditTransactionID int64
+		// ReversalTransactionID debits the credit again, it is set for lost disputes only.
+		ReversalTransactionID int64
+		Status                Status
+		Reason                string
+		// Amount is the disputed amount, positive.
+		Amount     float64
+		CreatedAt  time.Time
+		UpdatedAt  time.Time
+		ResolvedAt *time.Time
+		// Events are the state changes of the dispute, oldest first.
+		Events []Event
+	}
+
+	Event struct {
+		ID        int64
+		Status    Status
+		Principal string
+		Note      string
+		CreatedAt time.Time
+	}
+
+	// Filter selects the disputes of an account, optionally in a status.
+	Filter struct {
+		AccountID int64
+		Status    Status
+	}
+)
+
+const (
+	StatusOpened      Status = "opened"
+	StatusUnderReview Status = "under_review"
+	StatusWon         Status = "won"
+	StatusLost        Status = "lost"
+)
+
+// transitions lists the statuses a dispute moves to from each status.
+var transitions = map[Status][]Status{
+	StatusOpened:      {StatusUnderReview},
+	StatusUnderReview: {StatusWon, StatusLost},
+}
+
+func (s Status) String() string {
+	return string(s)
+}
+
+func (s Status) IsValid() bool {
+	switch s {
+	case StatusOpened, StatusUnderReview, StatusWon, StatusLost:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsResolved reports whether the dispute reached its final status.
+func (s Status) IsResolved() bool {
+	return s == StatusWon || s == StatusLost
+}
+
+// CanMoveTo reports whether a dispute in the status moves to the next one.
+func (s Status) CanMoveTo(next Status) bool {
+	return slices.Contains(transitions[s], next)
+}
+
+// IsDisputable reports whether customers dispute transactions of the operation type.
+func IsDisputable(op transactions.OperationType) bool {
+	return op == transactions.OperationTypePurchase || op == transactions.OperationTypeInstallmentPurchase
+}
@@ -0,0 +1,25 @@
+package disputes
+
+import (
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/transactions"
+)
+
+type Repository interface {
+	// GetTransaction returns a transaction of an account in the tenant from the context.
+	GetTransaction(ctx dbx.Context, accountID, transactionID int64) (transactions.Transaction, error)
+	// IsDisputeTransaction reports whether the transaction is the credit or the reversal of a dispute.
+	IsDisputeTransaction(ctx dbx.Context, transactionID int64) (bool, error)
+	// CreateDispute stores a dispute in the tenant from the context, it fails with common.ErrDuplicate
+	// when the transaction is already disputed.
+	CreateDispute(ctx dbx.Context, creation DisputeCreation) (Dispute, error)
+	// GetDispute returns a dispute of the tenant from the context with its events.
+	GetDispute(ctx dbx.Context, id int64) (Dispute, error)
+	// ResolveDispute moves a dispute in the status from to the status of the resolution.
+	// It fails with ErrInvalidTransition when the dispute is not in that status anymore.
+	// The dispute is returned without its events.
+	ResolveDispute(ctx dbx.Context, id int64, from Status, resolution Resolution) (Dispute, error)
+	AddEvent(ctx dbx.Context, creation EventCreation) (Event, error)
+	// ListDisputes returns the disputes matching the filter with their events, newest first.
+	ListDisputes(ctx dbx.Context, filter Filter) ([]Dispute, error)
+}
@@ -0,0 +1,220 @@
+package disputes
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/auth"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+	"github.com/ziflex/rm-rf-production/pkg/transactions"
+)
+
+// SystemPrincipal is recorded with the events of changes made without an authenticated principal.
+const SystemPrincipal = "system"
+
+type (
+	Service interface {
+		// OpenDispute disputes a purchase and credits its amount to the account with a payment.
+		OpenDispute(ctx context.Context, opening Opening) (Dispute, error)
+		// UpdateStatus moves a dispute to the next status, the credit is reversed when the dispute is lost.
+		UpdateStatus(ctx context.Context, id int64, change Change) (Dispute, error)
+		// ListDisputes returns the disputes of an account with their events, newest first.
+		// The account is not checked, a missing account has no disputes.
+		ListDisputes(ctx context.Context, filter Filter) ([]Dispute, error)
+	}
+
+	serviceImpl struct {
+		db           dbx.Database
+		repository   Repository
+		transactions transactions.Repository
+	}
+)
+
+// NewService returns the dispute service, the provisional credits and their reversals are created
+// with the transactions repository in the same database transaction as the dispute changes.
+func NewService(db dbx.Database, repository Repository, transactions transactions.Repository) Service {
+	return &serviceImpl{db, repository, transactions}
+}
+
+func (s *serviceImpl) OpenDispute(ctx context.Context, opening Opening) (Dispute, error) {
+	log := zerolog.Ctx(ctx).With().
+		Int64("account_id", opening.AccountID).
+		Int64("transaction_id", opening.TransactionID).
+		Logger()
+	log.Info().Msg("opening dispute")
+
+	if strings.TrimSpace(opening.Reason) == "" {
+		return Dispute{}, ErrInvalidReason
+	}
+
+	res, err := dbx.TransactionWithResult[Dispute](ctx, s.db, func(tx dbx.Context) (Dispute, error) {
+		disputed, err := s.repository.GetTransaction(tx, opening.AccountID, opening.TransactionID)
+
+		if err != nil {
+			return Dispute{}, err
+		}
+
+		if !IsDisputable(disputed.OperationType) {
+			return Dispute{}, fmt.Errorf("%w: %s %d", ErrNotDisputable, disputed.OperationType, disputed.ID)
+		}
+
+		// reversals written before they had their own operation type pass for purchases
+		issued, err := s.repository.IsDisputeTransaction(tx, disputed.ID)
+
+		if err != nil {
+			return Dispute{}, err
+		}
+
+		if issued {
+			return Dispute{}, fmt.Errorf("%w: transaction %d belongs to a dispute", ErrNotDisputable, disputed.ID)
+		}
+
+		amount := math.Abs(disputed.Amount)
+
+		// the credit and the reversal are issued on blocked accounts too, disputes are resolved either way
+		credit, err := s.transactions.CreateTransaction(tx, transactions.TransactionCreation{
+			AccountID:     opening.AccountID,
+			OperationType: transactions.OperationTypePayment,
+			Amount:        amount,
+			AllowBlocked:  true,
+		})
+
+		if err != nil {
+			return Dispute{}, err
+		}
+
+		dispute, err := s.repository.CreateDispute(tx, DisputeCreation{
+			AccountID:           opening.AccountID,
+			TransactionID:       opening.TransactionID,
+			CreditTransactionID: credit.ID,
+			Reason:              opening.Reason,
+			Amount:              amount,
+		})
+
+		if err != nil {
+			return Dispute{}, err
+		}
+
+		return s.addEvent(tx, dispute, opening.Reason)
+	})
+
+	if err != nil {
+		log.Error().Err(err).Msg("failed to open dispute")
+
+		return Dispute{}, err
+	}
+
+	log.Info().
+		Int64("dispute_id", res.ID).
+		Int64("credit_transaction_id", res.CreditTransactionID).
+		Msg("dispute opened")
+
+	return res, nil
+}
+
+func (s *serviceImpl) UpdateStatus(ctx context.Context, id int64, change Change) (Dispute, error) {
+	log := zerolog.Ctx(ctx).With().Int64("dispute_id", id).Str("status", change.Status.String()).Logger()
+	log.Info().Msg("updating dispute status")
+
+	if !change.Status.IsValid() {
+		return Dispute{}, fmt.Errorf("%w: %s", ErrInvalidStatus, change.Status)
+	}
+
+	res, err := dbx.TransactionWithResult[Dispute](ctx, s.db, func(tx dbx.Context) (Dispute, error) {
+		current, err := s.repository.GetDispute(tx, id)
+
+		if err != nil {
+			return Dispute{}, err
+		}
+
+		if !current.Status.CanMoveTo(change.Status) {
+			return Dispute{}, fmt.Errorf("%w: %s to %s", ErrInvalidTransition, current.Status, change.Status)
+		}
+
+		resolution := Resolution{Status: change.Status}
+
+		if change.Status == StatusLost {
+			// the reversal debits the account as the disputed purchase did
+			reversal, err := s.transactions.CreateTransaction(tx, transactions.TransactionCreation{
+				AccountID:     current.AccountID,
+				OperationType: transactions.OperationTypeDisputeReversal,
+				Amount:        -current.Amount,
+				AllowBlocked:  true,
+			})
+
+			if err != nil {
+				return Dispute{}, err
+			}
+
+			resolution.ReversalTransactionID = reversal.ID
+		}
+
+		// the status is checked again by the update, a concurrent change of the dispute fails the transition
+		updated, err := s.repository.ResolveDispute(tx, id, current.Status, resolution)
+
+		if err != nil {
+			return Dispute{}, err
+		}
+
+		updated.Events = current.Events
+
+		return s.addEvent(tx, updated, change.Note)
+	})
+
+	if err != nil {
+		log.Error().Err(err).Msg("failed to update dispute status")
+
+		return Dispute{}, err
+	}
+
+	log.Info().Int64("reversal_transaction_id", res.ReversalTransactionID).Msg("dispute status updated")
+
+	return res, nil
+}
+
+func (s *serviceImpl) ListDisputes(ctx context.Context, filter Filter) ([]Dispute, error) {
+	log := zerolog.Ctx(ctx)
+	log.Info().Int64("account_id", filter.AccountID).Msg("listing disputes")
+
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidStatus, filter.Status)
+	}
+
+	res, err := s.repository.ListDisputes(dbx.NewContextFrom(ctx, common.ForRead(ctx, s.db)), filter)
+
+	if err != nil {
+		log.Error().Err(err).Int64("account_id", filter.AccountID).Msg("failed to list disputes")
+
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// addEvent records the current status of the dispute, along with the principal that changed it.
+func (s *serviceImpl) addEvent(tx dbx.Context, dispute Dispute, note string) (Dispute, error) {
+	principal := SystemPrincipal
+
+	if p, ok := auth.PrincipalFromContext(tx); ok && p.ID != "" {
+		principal = p.ID
+	}
+
+	event, err := s.repository.AddEvent(tx, EventCreation{
+		DisputeID: dispute.ID,
+		Status:    dispute.Status,
+		Principal: principal,
+		Note:      note,
+	})
+
+	if err != nil {
+		return Dispute{}, err
+	}
+
+	dispute.Events = append(dispute.Events, event)
+
+	return dispute, nil
+}
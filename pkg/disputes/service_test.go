@@ -0,0 +1,262 @@
+package disputes_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/internal/database"
+	"github.com/ziflex/rm-rf-production/pkg/auth"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+	"github.com/ziflex/rm-rf-production/pkg/disputes"
+)
+
+const testTenant = "acme"
+
+var (
+	transactionColumns = []string{
+		"id", "account_id", "operation_type", "amount", "event_date", "external_ref",
+		"merchant_id", "merchant_name", "merchant_city", "merchant_country", "mcc",
+	}
+	disputeColumns = []string{
+		"id", "account_id", "transaction_id", "credit_transaction_id", "reversal_transaction_id",
+		"status", "reason", "amount", "created_at", "updated_at", "resolved_at",
+	}
+	eventColumns = []string{"dispute_id", "id", "status", "principal", "note", "created_at"}
+)
+
+func tenantCtx() context.Context {
+	ctx := common.WithTenant(context.Background(), testTenant)
+
+	return auth.WithPrincipal(ctx, auth.Principal{ID: "key:1", TenantID: testTenant})
+}
+
+func newService(t *testing.T) (disputes.Service, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { mockDB.Close() })
+
+	return disputes.NewService(dbx.New(mockDB), database.NewDisputesRepository(), database.NewTransactions()), mock
+}
+
+func TestService_OpenDispute_Success(t *testing.T) {
+	svc, mock := newService(t)
+	ts := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, account_id, operation_type, amount, event_date, COALESCE\(external_ref, ''\) FROM transactions WHERE tenant_id=\$1 AND account_id=\$2 AND id=\$3`).
+		WithArgs(testTenant, int64(5), int64(7)).
+		WillReturnRows(sqlmock.NewRows(transactionColumns[:6]).AddRow(7, 5, "installment_purchase", -30.0, ts, ""))
+	mock.ExpectQuery(`SELECT EXISTS \(.+ FROM disputes WHERE tenant_id=\$1 AND \(credit_transaction_id=\$2 OR reversal_transaction_id=\$2\)`).
+		WithArgs(testTenant, int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	// the credit is a payment of the disputed amount, it is not held back on blocked accounts
+	mock.ExpectQuery(`INSERT INTO transactions .+ SELECT \$1, \$2, \$3, \$4, NULLIF\(\$5, ''\), NULLIF\(\$6, ''\)\s+RETURNING`).
+		WithArgs(testTenant, int64(5), "payment", 30.0, "", "").
+		WillReturnRows(sqlmock.NewRows(transactionColumns).AddRow(8, 5, "payment", 30.0, ts, "", "", "", "", "", ""))
+	mock.ExpectQuery(`INSERT INTO disputes \(tenant_id, account_id, transaction_id, credit_transaction_id, reason, amount\)`).
+		WithArgs(testTenant, int64(5), int64(7), int64(8), "not received", 30.0).
+		WillReturnRows(sqlmock.NewRows(disputeColumns).AddRow(1, 5, 7, 8, 0, "opened", "not received", 30.0, ts, ts, nil))
+	mock.ExpectQuery(`INSERT INTO dispute_events \(dispute_id, status, principal, note\)`).
+		WithArgs(int64(1), "opened", "key:1", "not received").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, ts))
+	mock.ExpectCommit()
+
+	res, err := svc.OpenDispute(tenantCtx(), disputes.Opening{AccountID: 5, TransactionID: 7, Reason: "not received"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, disputes.StatusOpened, res.Status)
+	assert.Equal(t, 30.0, res.Amount)
+	assert.Equal(t, int64(8), res.CreditTransactionID)
+	assert.Len(t, res.Events, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_OpenDispute_Error_InvalidReason(t *testing.T) {
+	svc, mock := newService(t)
+
+	_, err := svc.OpenDispute(tenantCtx(), disputes.Opening{AccountID: 5, TransactionID: 7, Reason: " "})
+
+	assert.ErrorIs(t, err, disputes.ErrInvalidReason)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_OpenDispute_Error_NotDisputable(t *testing.T) {
+	type testCase struct {
+		Name          string
+		OperationType string
+		Issued        bool
+	}
+
+	tsdata := []testCase{
+		// the credits of disputes are payments
+		{"Payment", "payment", false},
+		{"Withdrawal", "withdrawal", false},
+		{"Reversal", "dispute_reversal", false},
+		// a reversal written as a purchase, before reversals had their own operation type
+		{"LinkedPurchase", "purchase", true},
+	}
+
+	for _, tc := range tsdata {
+		t.Run(tc.Name, func(t *testing.T) {
+			svc, mock := newService(t)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(`FROM transactions WHERE tenant_id=\$1 AND account_id=\$2 AND id=\$3`).
+				WithArgs(testTenant, int64(5), int64(7)).
+				WillReturnRows(sqlmock.NewRows(transactionColumns[:6]).AddRow(7, 5, tc.OperationType, -30.0, time.Now(), ""))
+
+			if tc.OperationType == "purchase" {
+				mock.ExpectQuery(`SELECT EXISTS`).
+					WithArgs(testTenant, int64(7)).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(tc.Issued))
+			}
+
+			mock.ExpectRollback()
+
+			_, err := svc.OpenDispute(tenantCtx(), disputes.Opening{AccountID: 5, TransactionID: 7, Reason: "fraud"})
+
+			assert.ErrorIs(t, err, disputes.ErrNotDisputable)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestService_OpenDispute_Error_Duplicate(t *testing.T) {
+	svc, mock := newService(t)
+	ts := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM transactions WHERE tenant_id=\$1 AND account_id=\$2 AND id=\$3`).
+		WillReturnRows(sqlmock.NewRows(transactionColumns[:6]).AddRow(7, 5, "purchase", -10.0, ts, ""))
+	mock.ExpectQuery(`SELECT EXISTS`).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WillReturnRows(sqlmock.NewRows(transactionColumns).AddRow(8, 5, "payment", 10.0, ts, "", "", "", "", "", ""))
+	mock.ExpectQuery(`INSERT INTO disputes`).
+		WillReturnError(&pq.Error{Code: "23505"})
+	// the credit is rolled back along with the dispute
+	mock.ExpectRollback()
+
+	_, err := svc.OpenDispute(tenantCtx(), disputes.Opening{AccountID: 5, TransactionID: 7, Reason: "fraud"})
+
+	assert.ErrorIs(t, err, common.ErrDuplicate)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_UpdateStatus_Won(t *testing.T) {
+	svc, mock := newService(t)
+	ts := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM disputes WHERE tenant_id=\$1 AND id=\$2`).
+		WithArgs(testTenant, int64(1)).
+		WillReturnRows(sqlmock.NewRows(disputeColumns).AddRow(1, 5, 7, 8, 0, "under_review", "fraud", 10.0, ts, ts, nil))
+	mock.ExpectQuery(`FROM dispute_events WHERE dispute_id=ANY\(\$1\)`).
+		WillReturnRows(sqlmock.NewRows(eventColumns).
+			AddRow(1, 1, "opened", "key:1", "fraud", ts).
+			AddRow(1, 2, "under_review", "key:1", "", ts))
+	mock.ExpectQuery(`UPDATE disputes SET status=\$4, reversal_transaction_id=NULLIF\(\$5, 0\)`).
+		WithArgs(testTenant, int64(1), "under_review", "won", int64(0), true).
+		WillReturnRows(sqlmock.NewRows(disputeColumns).AddRow(1, 5, 7, 8, 0, "won", "fraud", 10.0, ts, ts, ts))
+	mock.ExpectQuery(`INSERT INTO dispute_events`).
+		WithArgs(int64(1), "won", "key:1", "by support").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(3, ts))
+	mock.ExpectCommit()
+
+	res, err := svc.UpdateStatus(tenantCtx(), 1, disputes.Change{Status: disputes.StatusWon, Note: "by support"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, disputes.StatusWon, res.Status)
+	assert.NotNil(t, res.ResolvedAt)
+	assert.Zero(t, res.ReversalTransactionID)
+	assert.Len(t, res.Events, 3)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_UpdateStatus_Lost_BlockedAccount(t *testing.T) {
+	svc, mock := newService(t)
+	ts := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM disputes WHERE tenant_id=\$1 AND id=\$2`).
+		WithArgs(testTenant, int64(1)).
+		WillReturnRows(sqlmock.NewRows(disputeColumns).AddRow(1, 5, 7, 8, 0, "under_review", "fraud", 25.0, ts, ts, nil))
+	mock.ExpectQuery(`FROM dispute_events`).
+		WillReturnRows(sqlmock.NewRows(eventColumns))
+	// the reversal has its own operation type and skips the blocked account check,
+	// a blocked account does not leave the dispute under review
+	mock.ExpectQuery(`INSERT INTO transactions .+ SELECT \$1, \$2, \$3, \$4, NULLIF\(\$5, ''\), NULLIF\(\$6, ''\)\s+RETURNING`).
+		WithArgs(testTenant, int64(5), "dispute_reversal", -25.0, "", "").
+		WillReturnRows(sqlmock.NewRows(transactionColumns).AddRow(9, 5, "dispute_reversal", -25.0, ts, "", "", "", "", "", ""))
+	mock.ExpectQuery(`UPDATE disputes`).
+		WithArgs(testTenant, int64(1), "under_review", "lost", int64(9), true).
+		WillReturnRows(sqlmock.NewRows(disputeColumns).AddRow(1, 5, 7, 8, 9, "lost", "fraud", 25.0, ts, ts, ts))
+	mock.ExpectQuery(`INSERT INTO dispute_events`).
+		WithArgs(int64(1), "lost", "key:1", nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(3, ts))
+	mock.ExpectCommit()
+
+	res, err := svc.UpdateStatus(tenantCtx(), 1, disputes.Change{Status: disputes.StatusLost})
+
+	assert.NoError(t, err)
+	assert.Equal(t, disputes.StatusLost, res.Status)
+	assert.Equal(t, int64(9), res.ReversalTransactionID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_UpdateStatus_Error(t *testing.T) {
+	svc, mock := newService(t)
+	ts := time.Now()
+
+	_, err := svc.UpdateStatus(tenantCtx(), 1, disputes.Change{Status: "closed"})
+	assert.ErrorIs(t, err, disputes.ErrInvalidStatus)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM disputes WHERE tenant_id=\$1 AND id=\$2`).
+		WithArgs(testTenant, int64(2)).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	_, err = svc.UpdateStatus(tenantCtx(), 2, disputes.Change{Status: disputes.StatusUnderReview})
+	assert.ErrorIs(t, err, common.ErrNotFound)
+
+	// disputes are reviewed before they are resolved
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM disputes WHERE tenant_id=\$1 AND id=\$2`).
+		WithArgs(testTenant, int64(1)).
+		WillReturnRows(sqlmock.NewRows(disputeColumns).AddRow(1, 5, 7, 8, 0, "opened", "fraud", 10.0, ts, ts, nil))
+	mock.ExpectQuery(`FROM dispute_events`).
+		WillReturnRows(sqlmock.NewRows(eventColumns))
+	mock.ExpectRollback()
+
+	_, err = svc.UpdateStatus(tenantCtx(), 1, disputes.Change{Status: disputes.StatusWon})
+	assert.ErrorIs(t, err, disputes.ErrInvalidTransition)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_ListDisputes(t *testing.T) {
+	svc, mock := newService(t)
+	ts := time.Now()
+
+	mock.ExpectQuery(`FROM disputes WHERE tenant_id=\$1 AND account_id=\$2 AND status=\$3\s+ORDER BY id DESC`).
+		WithArgs(testTenant, int64(5), "lost").
+		WillReturnRows(sqlmock.NewRows(disputeColumns).AddRow(1, 5, 7, 8, 9, "lost", "fraud", 25.0, ts, ts, ts))
+	mock.ExpectQuery(`FROM dispute_events WHERE dispute_id=ANY\(\$1\)`).
+		WillReturnRows(sqlmock.NewRows(eventColumns).AddRow(1, 1, "opened", "key:1", "fraud", ts))
+
+	res, err := svc.ListDisputes(tenantCtx(), disputes.Filter{AccountID: 5, Status: disputes.StatusLost})
+
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Len(t, res[0].Events, 1)
+
+	_, err = svc.ListDisputes(tenantCtx(), disputes.Filter{AccountID: 5, Status: "closed"})
+	assert.ErrorIs(t, err, disputes.ErrInvalidStatus)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
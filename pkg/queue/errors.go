@@ -0,0 +1,36 @@
+package queue
+
+import "errors"
+
+var (
+	ErrUnknownType = errors.New("unknown job type")
+	ErrInvalidJob  = errors.New("invalid job")
+	// ErrLockLost is returned when the visibility timeout of a job expired and another worker may have claimed it.
+	ErrLockLost = errors.New("job lock lost")
+	// ErrNotDead is returned when a job that is not dead-lettered is retried.
+	ErrNotDead = errors.New("job is not dead")
+)
+
+// permanentError fails a job without retrying it.
+type permanentError struct {
+	err error
+}
+
+// Permanent wraps an error that retrying cannot fix, e.g. an invalid payload. The job is dead-lettered right away.
+func Permanent(err error) error {
+	return &permanentError{err}
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+func isPermanent(err error) bool {
+	var p *permanentError
+
+	return errors.As(err, &p)
+}
@@ -0,0 +1,86 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+type (
+	Status string
+
+	// Handler processes a job of a type. The tenant of the job is in the context.
+	// The result is stored with the job and returned by its status resource.
+	Handler func(ctx context.Context, job Job) (json.RawMessage, error)
+
+	JobCreation struct {
+		Type    string
+		Payload json.RawMessage
+		// Priority orders the due jobs, higher first.
+		Priority int
+		// MaxAttempts is the number of attempts before the job is dead-lettered, the queue default is used when zero.
+		MaxAttempts int
+		// RunAt delays the first attempt when set.
+		RunAt *time.Time
+	}
+
+	Job struct {
+		ID          int64
+		TenantID    string
+		Type        string
+		Payload     json.RawMessage
+		Status      Status
+		Priority    int
+		Attempts    int
+		MaxAttempts int
+		// RunAt is when the job is due, the time of the next attempt for a job waiting for a retry.
+		RunAt       time.Time
+		LockedBy    string
+		LockedUntil *time.Time
+		// LastError is the error of the last failed attempt, it is kept when a retry succeeds.
+		LastError  string
+		Result     json.RawMessage
+		CreatedAt  time.Time
+		UpdatedAt  time.Time
+		FinishedAt *time.Time
+	}
+
+	// Claim takes the next due job of the types for a worker until the visibility timeout expires.
+	Claim struct {
+		Types       []string
+		Worker      string
+		Now         time.Time
+		LockedUntil time.Time
+	}
+
+	// Lock identifies the attempt of a worker, a job is updated by the worker holding it only.
+	Lock struct {
+		Worker  string
+		Attempt int
+	}
+
+	Completion struct {
+		// Status is StatusQueued for a job that is retried at RunAt.
+		Status Status
+		Result json.RawMessage
+		Error  string
+		RunAt  time.Time
+		Now    time.Time
+	}
+)
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	// StatusDead marks dead-lettered jobs, which failed all their attempts or with a permanent error.
+	StatusDead Status = "dead"
+)
+
+func (s Status) String() string {
+	return string(s)
+}
+
+func (j Job) lock() Lock {
+	return Lock{Worker: j.LockedBy, Attempt: j.Attempts}
+}
@@ -0,0 +1,23 @@
+package queue
+
+import (
+	"time"
+
+	"github.com/ziflex/dbx"
+)
+
+type Repository interface {
+	// CreateJob queues a job of the tenant from the context.
+	CreateJob(ctx dbx.Context, creation JobCreation) (Job, error)
+	// GetJob returns a job of the tenant from the context.
+	GetJob(ctx dbx.Context, id int64) (Job, error)
+	// ClaimJob locks the due job of the highest priority, of any tenant, skipping the jobs locked by other transactions.
+	// Running jobs whose lock expired are due again. It returns false when no job is due.
+	ClaimJob(ctx dbx.Context, claim Claim) (Job, bool, error)
+	// ExtendJob moves the visibility timeout of a running job, it fails with ErrLockLost when the lock is not held anymore.
+	ExtendJob(ctx dbx.Context, id int64, lock Lock, until time.Time) error
+	// CompleteJob ends an attempt, it fails with ErrLockLost when the lock is not held anymore.
+	CompleteJob(ctx dbx.Context, id int64, lock Lock, completion Completion) (Job, error)
+	// RetryJob queues a dead job of the tenant from the context again, with its attempts reset.
+	RetryJob(ctx dbx.Context, id int64, now time.Time) (Job, error)
+}
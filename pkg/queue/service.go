@@ -0,0 +1,445 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+)
+
+const (
+	DefaultWorkers           = 4
+	DefaultPollInterval      = time.Second
+	DefaultVisibilityTimeout = 5 * time.Minute
+	DefaultMaxAttempts       = 5
+	DefaultRetryBase         = 10 * time.Second
+	DefaultRetryMax          = time.Hour
+	// MaxTypeLength is the longest job type the jobs table holds.
+	MaxTypeLength = 64
+)
+
+type (
+	Service interface {
+		// Register sets the handler of a job type. Handlers are registered before the workers are started.
+		Register(jobType string, handler Handler) error
+		// Enqueue queues a job for the tenant from the context.
+		Enqueue(ctx context.Context, creation JobCreation) (Job, error)
+		GetJob(ctx context.Context, id int64) (Job, error)
+		// Retry queues a dead job again.
+		Retry(ctx context.Context, id int64) (Job, error)
+		// Start runs the workers in the background until ctx is done or Stop is called.
+		Start(ctx context.Context)
+		// Stop stops claiming jobs and waits for the running ones until ctx is done, then cancels them.
+		Stop(ctx context.Context) error
+	}
+
+	Options struct {
+		// Workers is the number of jobs processed at once by the instance, DefaultWorkers is used when zero.
+		Workers int
+		// PollInterval is how long an idle worker waits before looking for due jobs again.
+		PollInterval time.Duration
+		// VisibilityTimeout is how long a claimed job stays invisible to the other workers.
+		// The worker extends it while the job runs, so it only expires when the worker dies.
+		VisibilityTimeout time.Duration
+		// MaxAttempts is the default number of attempts of a job before it is dead-lettered.
+		MaxAttempts int
+		// RetryBase is the delay before the first retry, it doubles with every attempt up to RetryMax.
+		RetryBase time.Duration
+		RetryMax  time.Duration
+		// Instance identifies the process in the locks of the jobs, the host name is used when empty.
+		Instance string
+	}
+
+	serviceImpl struct {
+		db         dbx.Database
+		repository Repository
+		opts       Options
+
+		mu       sync.Mutex
+		handlers map[string]Handler
+		types    []string
+		started  bool
+		stop     context.CancelFunc
+		cancel   context.CancelFunc
+		wake     chan struct{}
+		workers  sync.WaitGroup
+	}
+)
+
+func NewService(db dbx.Database, repository Repository, opts Options) Service {
+	if opts.Workers <= 0 {
+		opts.Workers = DefaultWorkers
+	}
+
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+
+	if opts.VisibilityTimeout <= 0 {
+		opts.VisibilityTimeout = DefaultVisibilityTimeout
+	}
+
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+
+	if opts.RetryBase <= 0 {
+		opts.RetryBase = DefaultRetryBase
+	}
+
+	if opts.RetryMax <= 0 {
+		opts.RetryMax = DefaultRetryMax
+	}
+
+	if opts.Instance == "" {
+		opts.Instance = defaultInstance()
+	}
+
+	return &serviceImpl{
+		db:         db,
+		repository: repository,
+		opts:       opts,
+		handlers:   make(map[string]Handler),
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+func (s *serviceImpl) Register(jobType string, handler Handler) error {
+	if jobType == "" || len(jobType) > MaxTypeLength {
+		return fmt.Errorf("%w: type must be 1 to %d characters long", ErrInvalidJob, MaxTypeLength)
+	}
+
+	if handler == nil {
+		return fmt.Errorf("%w: %s: missing handler", ErrInvalidJob, jobType)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return fmt.Errorf("%w: %s: the workers are already started", ErrInvalidJob, jobType)
+	}
+
+	if _, exists := s.handlers[jobType]; exists {
+		return fmt.Errorf("%w: %s: already registered", ErrInvalidJob, jobType)
+	}
+
+	s.handlers[jobType] = handler
+	s.types = append(s.types, jobType)
+
+	return nil
+}
+
+func (s *serviceImpl) Enqueue(ctx context.Context, creation JobCreation) (Job, error) {
+	log := zerolog.Ctx(ctx).With().Str("job_type", creation.Type).Logger()
+	log.Info().Msg("enqueuing job")
+
+	s.mu.Lock()
+	_, known := s.handlers[creation.Type]
+	s.mu.Unlock()
+
+	if !known {
+		return Job{}, fmt.Errorf("%w: %s", ErrUnknownType, creation.Type)
+	}
+
+	if creation.MaxAttempts < 0 {
+		return Job{}, fmt.Errorf("%w: max attempts must not be negative", ErrInvalidJob)
+	}
+
+	if creation.MaxAttempts == 0 {
+		creation.MaxAttempts = s.opts.MaxAttempts
+	}
+
+	if len(creation.Payload) == 0 {
+		creation.Payload = json.RawMessage("{}")
+	}
+
+	if !json.Valid(creation.Payload) {
+		return Job{}, fmt.Errorf("%w: payload is not valid json", ErrInvalidJob)
+	}
+
+	if creation.RunAt == nil {
+		now := time.Now().UTC()
+		creation.RunAt = &now
+	}
+
+	job, err := s.repository.CreateJob(dbx.NewContextFrom(ctx, s.db), creation)
+
+	if err != nil {
+		log.Error().Err(err).Msg("failed to enqueue job")
+
+		return Job{}, err
+	}
+
+	log.Info().Int64("job_id", job.ID).Msg("job enqueued")
+
+	// a local worker picks the job up without waiting for its next poll
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	return job, nil
+}
+
+func (s *serviceImpl) GetJob(ctx context.Context, id int64) (Job, error) {
+	log := zerolog.Ctx(ctx)
+	log.Info().Int64("job_id", id).Msg("getting job")
+
+	job, err := s.repository.GetJob(dbx.NewContextFrom(ctx, common.ForRead(ctx, s.db)), id)
+
+	if err != nil {
+		log.Error().Err(err).Int64("job_id", id).Msg("failed to get job")
+
+		return Job{}, err
+	}
+
+	return job, nil
+}
+
+func (s *serviceImpl) Retry(ctx context.Context, id int64) (Job, error) {
+	log := zerolog.Ctx(ctx)
+	log.Info().Int64("job_id", id).Msg("retrying dead job")
+
+	job, err := s.repository.RetryJob(dbx.NewContextFrom(ctx, s.db), id, time.Now().UTC())
+
+	if err != nil {
+		log.Error().Err(err).Int64("job_id", id).Msg("failed to retry job")
+
+		return Job{}, err
+	}
+
+	return job, nil
+}
+
+func (s *serviceImpl) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return
+	}
+
+	s.started = true
+
+	// the running jobs outlive the workers loops, Stop gives them time to finish
+	loopCtx, stop := context.WithCancel(ctx)
+	jobsCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+
+	s.stop = stop
+	s.cancel = cancel
+
+	if len(s.types) == 0 {
+		return
+	}
+
+	for i := range s.opts.Workers {
+		worker := fmt.Sprintf("%s/%d", s.opts.Instance, i)
+		s.workers.Add(1)
+
+		go func() {
+			defer s.workers.Done()
+
+			s.work(loopCtx, jobsCtx, worker)
+		}()
+	}
+
+	zerolog.Ctx(ctx).Info().Int("workers", s.opts.Workers).Strs("types", s.types).Msg("queue workers started")
+}
+
+func (s *serviceImpl) Stop(ctx context.Context) error {
+	s.mu.Lock()
+
+	if !s.started {
+		s.mu.Unlock()
+
+		return nil
+	}
+
+	stop, cancel := s.stop, s.cancel
+	s.mu.Unlock()
+
+	stop()
+
+	finished := make(chan struct{})
+
+	go func() {
+		s.workers.Wait()
+		close(finished)
+	}()
+
+	defer cancel()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		// the cancelled jobs are retried, the ones that cannot record it are claimed again after the visibility timeout
+		return fmt.Errorf("running jobs did not finish: %w", ctx.Err())
+	}
+}
+
+// work claims and processes jobs until ctx is done. An idle worker polls every PollInterval.
+func (s *serviceImpl) work(ctx, jobsCtx context.Context, worker string) {
+	log := zerolog.Ctx(ctx).With().Str("worker", worker).Logger()
+
+	for ctx.Err() == nil {
+		now := time.Now().UTC()
+
+		job, claimed, err := s.repository.ClaimJob(dbx.NewContextFrom(ctx, s.db), Claim{
+			Types:       s.types,
+			Worker:      worker,
+			Now:         now,
+			LockedUntil: now.Add(s.opts.VisibilityTimeout),
+		})
+
+		if err != nil && ctx.Err() == nil {
+			log.Error().Err(err).Msg("failed to claim job")
+		}
+
+		if claimed {
+			s.process(log.WithContext(jobsCtx), job)
+
+			continue
+		}
+
+		timer := time.NewTimer(s.opts.PollInterval)
+
+		select {
+		case <-ctx.Done():
+		case <-s.wake:
+		case <-timer.C:
+		}
+
+		timer.Stop()
+	}
+}
+
+// process runs an attempt of a claimed job and records its outcome.
+func (s *serviceImpl) process(ctx context.Context, job Job) {
+	log := zerolog.Ctx(ctx).With().
+		Int64("job_id", job.ID).
+		Str("job_type", job.Type).
+		Str("tenant", job.TenantID).
+		Int("attempt", job.Attempts).
+		Logger()
+
+	var result json.RawMessage
+	var err error
+
+	if job.Attempts > job.MaxAttempts {
+		// the workers of the previous attempts died, running it again could kill this one too
+		err = Permanent(fmt.Errorf("visibility timeout expired on the last of %d attempts", job.MaxAttempts))
+	} else {
+		log.Info().Msg("running job")
+		result, err = s.run(log.WithContext(ctx), job)
+	}
+
+	now := time.Now().UTC()
+	completion := Completion{Status: StatusSucceeded, Result: result, Now: now}
+
+	if err != nil {
+		completion.Error = err.Error()
+
+		if isPermanent(err) || job.Attempts >= job.MaxAttempts {
+			completion.Status = StatusDead
+		} else {
+			completion.Status = StatusQueued
+			completion.RunAt = now.Add(s.backoff(job.Attempts))
+		}
+	}
+
+	// the outcome is recorded even when the job was cancelled
+	_, cerr := s.repository.CompleteJob(dbx.NewContextFrom(context.WithoutCancel(ctx), s.db), job.ID, job.lock(), completion)
+
+	switch {
+	case cerr != nil:
+		log.Error().Err(cerr).Msg("failed to record job outcome")
+	case completion.Status == StatusSucceeded:
+		log.Info().Msg("job succeeded")
+	case completion.Status == StatusQueued:
+		log.Warn().Err(err).Time("run_at", completion.RunAt).Msg("job failed, retrying")
+	default:
+		log.Error().Err(err).Msg("job failed, dead-lettered")
+	}
+}
+
+// run calls the handler with the tenant of the job, extending the visibility timeout until it returns.
+// The handler is cancelled when the lock is lost, since another worker may be running the job already.
+func (s *serviceImpl) run(ctx context.Context, job Job) (result json.RawMessage, err error) {
+	s.mu.Lock()
+	handler := s.handlers[job.Type]
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(common.WithTenant(ctx, job.TenantID))
+	defer cancel()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go s.heartbeat(ctx, cancel, done, job)
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	return handler(ctx, job)
+}
+
+func (s *serviceImpl) heartbeat(ctx context.Context, cancel context.CancelFunc, done <-chan struct{}, job Job) {
+	ticker := time.NewTicker(s.opts.VisibilityTimeout / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		until := time.Now().UTC().Add(s.opts.VisibilityTimeout)
+		err := s.repository.ExtendJob(dbx.NewContextFrom(ctx, s.db), job.ID, job.lock(), until)
+
+		if errors.Is(err, ErrLockLost) {
+			zerolog.Ctx(ctx).Error().Int64("job_id", job.ID).Msg("job lock lost, cancelling job")
+			cancel()
+
+			return
+		}
+
+		// the lock holds until the visibility timeout, the next beat may succeed
+		if err != nil && ctx.Err() == nil {
+			zerolog.Ctx(ctx).Error().Err(err).Int64("job_id", job.ID).Msg("failed to extend job lock")
+		}
+	}
+}
+
+// backoff returns the delay before the retry following the attempt.
+func (s *serviceImpl) backoff(attempt int) time.Duration {
+	delay := s.opts.RetryBase
+
+	for i := 1; i < attempt && delay < s.opts.RetryMax; i++ {
+		delay *= 2
+	}
+
+	return min(delay, s.opts.RetryMax)
+}
+
+func defaultInstance() string {
+	host, err := os.Hostname()
+
+	if err != nil {
+		host = "unknown"
+	}
+
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
@@ -0,0 +1,328 @@
+package queue_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/internal/memory"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+	"github.com/ziflex/rm-rf-production/pkg/queue"
+)
+
+type fixture struct {
+	svc  queue.Service
+	repo queue.Repository
+	db   dbx.Database
+	ctx  context.Context
+}
+
+func newFixture(t *testing.T, opts queue.Options) fixture {
+	store := memory.NewStore()
+	f := fixture{
+		repo: memory.NewJobsRepository(store),
+		db:   store.DB(),
+		ctx:  common.WithTenant(context.Background(), memory.DefaultTenant),
+	}
+
+	opts.Instance = "test"
+	opts.PollInterval = 10 * time.Millisecond
+	opts.RetryBase = time.Millisecond
+	opts.RetryMax = 4 * time.Millisecond
+
+	if opts.Workers == 0 {
+		opts.Workers = 1
+	}
+
+	f.svc = queue.NewService(f.db, f.repo, opts)
+
+	return f
+}
+
+func (f fixture) start(t *testing.T) {
+	f.svc.Start(context.Background())
+
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		assert.NoError(t, f.svc.Stop(ctx))
+	})
+}
+
+// wait polls the job until it reaches the status.
+func (f fixture) wait(t *testing.T, id int64, status queue.Status) queue.Job {
+	var job queue.Job
+
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = f.svc.GetJob(f.ctx, id)
+
+		return err == nil && job.Status == status
+	}, 2*time.Second, 5*time.Millisecond, "job %d is %s", id, job.Status)
+
+	return job
+}
+
+func TestService_Enqueue_Success(t *testing.T) {
+	f := newFixture(t, queue.Options{})
+
+	var tenant string
+
+	require.NoError(t, f.svc.Register("export", func(ctx context.Context, job queue.Job) (json.RawMessage, error) {
+		tenant, _ = common.TenantFromContext(ctx)
+
+		return json.RawMessage(`{"rows":3}`), nil
+	}))
+
+	job, err := f.svc.Enqueue(f.ctx, queue.JobCreation{Type: "export", Payload: json.RawMessage(`{"account_id":1}`)})
+
+	require.NoError(t, err)
+	assert.Equal(t, queue.StatusQueued, job.Status)
+	assert.Equal(t, memory.DefaultTenant, job.TenantID)
+	assert.Equal(t, queue.DefaultMaxAttempts, job.MaxAttempts)
+
+	f.start(t)
+
+	done := f.wait(t, job.ID, queue.StatusSucceeded)
+
+	assert.Equal(t, memory.DefaultTenant, tenant)
+	assert.Equal(t, 1, done.Attempts)
+	assert.JSONEq(t, `{"rows":3}`, string(done.Result))
+	assert.Empty(t, done.LockedBy)
+	assert.NotNil(t, done.FinishedAt)
+}
+
+func TestService_Enqueue_Error(t *testing.T) {
+	f := newFixture(t, queue.Options{})
+
+	require.NoError(t, f.svc.Register("export", func(context.Context, queue.Job) (json.RawMessage, error) {
+		return nil, nil
+	}))
+
+	_, err := f.svc.Enqueue(f.ctx, queue.JobCreation{Type: "unknown"})
+	assert.ErrorIs(t, err, queue.ErrUnknownType)
+
+	_, err = f.svc.Enqueue(f.ctx, queue.JobCreation{Type: "export", Payload: json.RawMessage(`{`)})
+	assert.ErrorIs(t, err, queue.ErrInvalidJob)
+
+	_, err = f.svc.Enqueue(f.ctx, queue.JobCreation{Type: "export", MaxAttempts: -1})
+	assert.ErrorIs(t, err, queue.ErrInvalidJob)
+}
+
+func TestService_Retries(t *testing.T) {
+	f := newFixture(t, queue.Options{})
+
+	var calls atomic.Int32
+
+	require.NoError(t, f.svc.Register("webhook", func(_ context.Context, job queue.Job) (json.RawMessage, error) {
+		if calls.Add(1) < 3 {
+			return nil, errors.New("connection refused")
+		}
+
+		return nil, nil
+	}))
+
+	job, err := f.svc.Enqueue(f.ctx, queue.JobCreation{Type: "webhook", MaxAttempts: 3})
+	require.NoError(t, err)
+
+	f.start(t)
+
+	done := f.wait(t, job.ID, queue.StatusSucceeded)
+
+	assert.Equal(t, 3, done.Attempts)
+	assert.Equal(t, "connection refused", done.LastError, "the error of the last failed attempt is kept")
+}
+
+func TestService_DeadLetter(t *testing.T) {
+	f := newFixture(t, queue.Options{})
+
+	var calls atomic.Int32
+
+	require.NoError(t, f.svc.Register("webhook", func(context.Context, queue.Job) (json.RawMessage, error) {
+		calls.Add(1)
+
+		return nil, errors.New("connection refused")
+	}))
+
+	require.NoError(t, f.svc.Register("import", func(context.Context, queue.Job) (json.RawMessage, error) {
+		panic("nil map")
+	}))
+
+	require.NoError(t, f.svc.Register("export", func(context.Context, queue.Job) (json.RawMessage, error) {
+		return nil, queue.Permanent(errors.New("unknown account"))
+	}))
+
+	webhook, err := f.svc.Enqueue(f.ctx, queue.JobCreation{Type: "webhook", MaxAttempts: 2})
+	require.NoError(t, err)
+
+	imp, err := f.svc.Enqueue(f.ctx, queue.JobCreation{Type: "import", MaxAttempts: 1})
+	require.NoError(t, err)
+
+	export, err := f.svc.Enqueue(f.ctx, queue.JobCreation{Type: "export"})
+	require.NoError(t, err)
+
+	f.start(t)
+
+	dead := f.wait(t, webhook.ID, queue.StatusDead)
+	assert.Equal(t, 2, dead.Attempts)
+	assert.EqualValues(t, 2, calls.Load())
+
+	dead = f.wait(t, imp.ID, queue.StatusDead)
+	assert.Equal(t, "job panicked: nil map", dead.LastError)
+
+	dead = f.wait(t, export.ID, queue.StatusDead)
+	assert.Equal(t, 1, dead.Attempts, "permanent errors are not retried")
+	assert.Equal(t, "unknown account", dead.LastError)
+
+	retried, err := f.svc.Retry(f.ctx, webhook.ID)
+	require.NoError(t, err)
+	assert.Equal(t, queue.StatusQueued, retried.Status)
+	assert.Zero(t, retried.Attempts)
+
+	f.wait(t, webhook.ID, queue.StatusDead)
+	assert.EqualValues(t, 4, calls.Load())
+}
+
+func TestService_Retry_Error(t *testing.T) {
+	f := newFixture(t, queue.Options{})
+
+	require.NoError(t, f.svc.Register("export", func(context.Context, queue.Job) (json.RawMessage, error) {
+		return nil, nil
+	}))
+
+	job, err := f.svc.Enqueue(f.ctx, queue.JobCreation{Type: "export"})
+	require.NoError(t, err)
+
+	_, err = f.svc.Retry(f.ctx, job.ID)
+	assert.ErrorIs(t, err, queue.ErrNotDead)
+
+	_, err = f.svc.Retry(f.ctx, job.ID+1)
+	assert.ErrorIs(t, err, common.ErrNotFound)
+
+	// jobs are scoped to their tenant
+	_, err = f.svc.GetJob(common.WithTenant(context.Background(), "other"), job.ID)
+	assert.ErrorIs(t, err, common.ErrNotFound)
+}
+
+func TestService_Priority(t *testing.T) {
+	f := newFixture(t, queue.Options{})
+
+	order := make(chan string, 3)
+
+	require.NoError(t, f.svc.Register("export", func(_ context.Context, job queue.Job) (json.RawMessage, error) {
+		var payload struct{ Name string }
+		_ = json.Unmarshal(job.Payload, &payload)
+		order <- payload.Name
+
+		return nil, nil
+	}))
+
+	for _, creation := range []queue.JobCreation{
+		{Type: "export", Payload: json.RawMessage(`{"name":"low"}`), Priority: -1},
+		{Type: "export", Payload: json.RawMessage(`{"name":"normal"}`)},
+		{Type: "export", Payload: json.RawMessage(`{"name":"high"}`), Priority: 10},
+	} {
+		_, err := f.svc.Enqueue(f.ctx, creation)
+		require.NoError(t, err)
+	}
+
+	f.start(t)
+
+	assert.Equal(t, "high", <-order)
+	assert.Equal(t, "normal", <-order)
+	assert.Equal(t, "low", <-order)
+}
+
+func TestService_VisibilityTimeout(t *testing.T) {
+	f := newFixture(t, queue.Options{VisibilityTimeout: 30 * time.Millisecond})
+
+	require.NoError(t, f.svc.Register("import", func(ctx context.Context, _ queue.Job) (json.RawMessage, error) {
+		// outlives the visibility timeout, the worker keeps extending it
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+			return nil, nil
+		}
+	}))
+
+	// a worker died while holding the last attempt of a job
+	past := time.Now().Add(-time.Minute)
+	db := dbx.NewContextFrom(f.ctx, f.db)
+
+	crashed, err := f.repo.CreateJob(db, queue.JobCreation{Type: "import", MaxAttempts: 1, RunAt: &past})
+	require.NoError(t, err)
+
+	_, claimed, err := f.repo.ClaimJob(db, queue.Claim{Types: []string{"import"}, Worker: "dead", Now: past, LockedUntil: past})
+	require.NoError(t, err)
+	require.True(t, claimed)
+
+	job, err := f.svc.Enqueue(f.ctx, queue.JobCreation{Type: "import"})
+	require.NoError(t, err)
+
+	f.start(t)
+
+	dead := f.wait(t, crashed.ID, queue.StatusDead)
+	assert.Equal(t, 2, dead.Attempts)
+	assert.Contains(t, dead.LastError, "visibility timeout expired")
+
+	done := f.wait(t, job.ID, queue.StatusSucceeded)
+	assert.Equal(t, 1, done.Attempts, "the heartbeat keeps the job locked")
+}
+
+func TestService_Register_Error(t *testing.T) {
+	f := newFixture(t, queue.Options{})
+	noop := func(context.Context, queue.Job) (json.RawMessage, error) { return nil, nil }
+
+	require.NoError(t, f.svc.Register("export", noop))
+
+	assert.ErrorIs(t, f.svc.Register("", noop), queue.ErrInvalidJob)
+	assert.ErrorIs(t, f.svc.Register("import", nil), queue.ErrInvalidJob)
+	assert.ErrorIs(t, f.svc.Register("export", noop), queue.ErrInvalidJob, "duplicate")
+
+	f.start(t)
+
+	assert.ErrorIs(t, f.svc.Register("late", noop), queue.ErrInvalidJob)
+}
+
+func TestService_Stop(t *testing.T) {
+	f := newFixture(t, queue.Options{})
+
+	started := make(chan struct{})
+
+	require.NoError(t, f.svc.Register("import", func(ctx context.Context, _ queue.Job) (json.RawMessage, error) {
+		close(started)
+		<-ctx.Done()
+
+		return nil, ctx.Err()
+	}))
+
+	job, err := f.svc.Enqueue(f.ctx, queue.JobCreation{Type: "import"})
+	require.NoError(t, err)
+
+	f.svc.Start(context.Background())
+	f.svc.Start(context.Background())
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, f.svc.Stop(ctx), context.DeadlineExceeded)
+
+	// the cancelled job is queued for a retry
+	require.Eventually(t, func() bool {
+		job, err = f.svc.GetJob(f.ctx, job.ID)
+
+		return err == nil && job.Status == queue.StatusQueued
+	}, time.Second, 5*time.Millisecond)
+
+	assert.Equal(t, context.Canceled.Error(), job.LastError)
+	assert.NoError(t, f.svc.Stop(context.Background()))
+}
@@ -0,0 +1,8 @@
+package ratelimit
+
+import "errors"
+
+var (
+	ErrRateLimited = errors.New("rate limit exceeded")
+	ErrInvalidRule = errors.New("invalid rate limit rule")
+)
@@ -0,0 +1,8 @@
+package ratelimit
+
+import "context"
+
+// Limiter decides whether a request identified by key fits into the budget defined by rule.
+type Limiter interface {
+	Allow(ctx context.Context, key string, rule Rule) (Result, error)
+}
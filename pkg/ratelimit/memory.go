@@ -0,0 +1,110 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+const defaultIdleTTL = 10 * time.Minute
+
+type (
+	MemoryOptions struct {
+		// IdleTTL defines how long an unused bucket is kept in memory.
+		IdleTTL time.Duration
+		Now     func() time.Time
+	}
+
+	// memoryLimiter is a token bucket limiter local to the process.
+	// Each key gets a bucket of rule.Limit tokens that is refilled continuously over rule.Period.
+	memoryLimiter struct {
+		opts      MemoryOptions
+		mu        sync.Mutex
+		buckets   map[string]*bucket
+		lastSweep time.Time
+	}
+
+	bucket struct {
+		tokens   float64
+		updated  time.Time
+		lastSeen time.Time
+	}
+)
+
+func NewMemoryLimiter(opts MemoryOptions) Limiter {
+	if opts.IdleTTL <= 0 {
+		opts.IdleTTL = defaultIdleTTL
+	}
+
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	return &memoryLimiter{
+		opts:      opts,
+		buckets:   make(map[string]*bucket),
+		lastSweep: opts.Now(),
+	}
+}
+
+func (l *memoryLimiter) Allow(_ context.Context, key string, rule Rule) (Result, error) {
+	if rule.IsZero() {
+		return Result{}, ErrInvalidRule
+	}
+
+	now := l.opts.Now()
+	rate := float64(rule.Limit) / rule.Period.Seconds()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	b, found := l.buckets[key]
+
+	if !found {
+		b = &bucket{tokens: float64(rule.Limit), updated: now}
+		l.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.updated).Seconds()
+	b.tokens = math.Min(float64(rule.Limit), b.tokens+elapsed*rate)
+	b.updated = now
+	b.lastSeen = now
+
+	res := Result{
+		Limit: rule.Limit,
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - b.tokens) / rate)
+	}
+
+	res.Remaining = int(math.Floor(b.tokens))
+	res.Reset = seconds((float64(rule.Limit) - b.tokens) / rate)
+
+	return res, nil
+}
+
+// sweep drops idle buckets, it runs at most once per IdleTTL.
+func (l *memoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.opts.IdleTTL {
+		return
+	}
+
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) >= l.opts.IdleTTL {
+			delete(l.buckets, key)
+		}
+	}
+
+	l.lastSweep = now
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
@@ -0,0 +1,91 @@
+package ratelimit_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ziflex/rm-rf-production/pkg/ratelimit"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestMemoryLimiter_Allow(t *testing.T) {
+	clk := &clock{now: time.Date(2025, 8, 30, 12, 0, 0, 0, time.UTC)}
+	limiter := ratelimit.NewMemoryLimiter(ratelimit.MemoryOptions{Now: clk.Now})
+	rule := ratelimit.Rule{Limit: 2, Period: 10 * time.Second}
+	ctx := context.Background()
+
+	res, err := limiter.Allow(ctx, "client", rule)
+	assert.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 1, res.Remaining)
+
+	res, err = limiter.Allow(ctx, "client", rule)
+	assert.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+	assert.Equal(t, 10*time.Second, res.Reset)
+
+	res, err = limiter.Allow(ctx, "client", rule)
+	assert.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 5*time.Second, res.RetryAfter)
+
+	// other clients have their own budget
+	res, err = limiter.Allow(ctx, "other", rule)
+	assert.NoError(t, err)
+	assert.True(t, res.Allowed)
+
+	// one token is refilled every 5 seconds
+	clk.Advance(5 * time.Second)
+
+	res, err = limiter.Allow(ctx, "client", rule)
+	assert.NoError(t, err)
+	assert.True(t, res.Allowed)
+
+	res, err = limiter.Allow(ctx, "client", rule)
+	assert.NoError(t, err)
+	assert.False(t, res.Allowed)
+}
+
+func TestMemoryLimiter_Allow_Error_InvalidRule(t *testing.T) {
+	limiter := ratelimit.NewMemoryLimiter(ratelimit.MemoryOptions{})
+
+	_, err := limiter.Allow(context.Background(), "client", ratelimit.Rule{})
+
+	assert.ErrorIs(t, err, ratelimit.ErrInvalidRule)
+}
+
+func TestPrincipalKey(t *testing.T) {
+	key := ratelimit.PrincipalKey("acme", strings.Repeat("s", 1000))
+
+	assert.Len(t, key, len("acme:")+64)
+	assert.True(t, strings.HasPrefix(key, "acme:"))
+	assert.Equal(t, key, ratelimit.PrincipalKey("acme", strings.Repeat("s", 1000)))
+	assert.NotEqual(t, key, ratelimit.PrincipalKey("acme", strings.Repeat("s", 999)))
+	assert.NotEqual(t, key, ratelimit.PrincipalKey("other", strings.Repeat("s", 1000)))
+}
+
+func TestParseRule(t *testing.T) {
+	rule, err := ratelimit.ParseRule("100/1m")
+	assert.NoError(t, err)
+	assert.Equal(t, ratelimit.Rule{Limit: 100, Period: time.Minute}, rule)
+
+	for _, s := range []string{"", "100", "0/1m", "abc/1m", "10/abc", "10/-1s"} {
+		_, err := ratelimit.ParseRule(s)
+		assert.ErrorIs(t, err, ratelimit.ErrInvalidRule, s)
+	}
+}
@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// Rule allows Limit requests per Period.
+	Rule struct {
+		Limit  int
+		Period time.Duration
+	}
+
+	// Policy assigns a budget to every operation of an API.
+	Policy struct {
+		Limiter Limiter
+		// Default is applied to operations without a dedicated rule.
+		Default Rule
+		// Operations holds per operation ID rules, e.g. a stricter budget for createTransaction.
+		Operations map[string]Rule
+	}
+
+	Result struct {
+		Allowed   bool
+		Limit     int
+		Remaining int
+		// Reset is the time until the budget is fully restored.
+		Reset time.Duration
+		// RetryAfter is the time until the next request is allowed, set only when the request is rejected.
+		RetryAfter time.Duration
+	}
+)
+
+// ParseRule parses rules in the "<limit>/<period>" format, e.g. "100/1m".
+func ParseRule(s string) (Rule, error) {
+	limit, period, found := strings.Cut(strings.TrimSpace(s), "/")
+
+	if !found {
+		return Rule{}, fmt.Errorf("%w: %q", ErrInvalidRule, s)
+	}
+
+	n, err := strconv.Atoi(limit)
+
+	if err != nil || n <= 0 {
+		return Rule{}, fmt.Errorf("%w: invalid limit %q", ErrInvalidRule, limit)
+	}
+
+	d, err := time.ParseDuration(period)
+
+	if err != nil || d <= 0 {
+		return Rule{}, fmt.Errorf("%w: invalid period %q", ErrInvalidRule, period)
+	}
+
+	return Rule{Limit: n, Period: d}, nil
+}
+
+// PrincipalKey identifies the budget of an authenticated principal of a tenant.
+// The principal ID is hashed: token subjects have no length limit, while the keys of the shared limiter do.
+func PrincipalKey(tenantID, principalID string) string {
+	sum := sha256.Sum256([]byte(principalID))
+
+	return tenantID + ":" + hex.EncodeToString(sum[:])
+}
+
+// Rule returns the budget of the operation, the default one when it has no dedicated rule.
+func (p Policy) Rule(opID string) Rule {
+	if rule, found := p.Operations[opID]; found {
+		return rule
+	}
+
+	return p.Default
+}
+
+func (r Rule) IsZero() bool {
+	return r.Limit == 0 || r.Period == 0
+}
+
+func (r Rule) String() string {
+	return fmt.Sprintf("%d/%s", r.Limit, r.Period)
+}
@@ -0,0 +1,13 @@
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/ziflex/dbx"
+)
+
+type Repository interface {
+	// Increment increments the hit counter of the key within the window and returns the new value.
+	Increment(ctx dbx.Context, key string, window time.Time, expiresAt time.Time) (int, error)
+	DeleteExpired(ctx dbx.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/ziflex/dbx"
+)
+
+const defaultCleanupInterval = time.Minute
+
+type (
+	SharedOptions struct {
+		// CleanupInterval defines how often expired counters are removed.
+		CleanupInterval time.Duration
+		Now             func() time.Time
+	}
+
+	// sharedLimiter is a fixed window counter stored in the database,
+	// so that all instances of the service share the same budget.
+	sharedLimiter struct {
+		db          dbx.Database
+		repository  Repository
+		opts        SharedOptions
+		mu          sync.Mutex
+		lastCleanup time.Time
+	}
+)
+
+func NewSharedLimiter(db dbx.Database, repository Repository, opts SharedOptions) Limiter {
+	if opts.CleanupInterval <= 0 {
+		opts.CleanupInterval = defaultCleanupInterval
+	}
+
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	return &sharedLimiter{
+		db:          db,
+		repository:  repository,
+		opts:        opts,
+		lastCleanup: opts.Now(),
+	}
+}
+
+func (l *sharedLimiter) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	if rule.IsZero() {
+		return Result{}, ErrInvalidRule
+	}
+
+	now := l.opts.Now()
+	window := now.Truncate(rule.Period)
+	windowEnd := window.Add(rule.Period)
+	dbCtx := dbx.NewContextFrom(ctx, l.db)
+
+	hits, err := l.repository.Increment(dbCtx, key, window, windowEnd)
+
+	if err != nil {
+		return Result{}, err
+	}
+
+	l.cleanup(dbCtx, now)
+
+	res := Result{
+		Allowed:   hits <= rule.Limit,
+		Limit:     rule.Limit,
+		Remaining: max(rule.Limit-hits, 0),
+		Reset:     windowEnd.Sub(now),
+	}
+
+	if !res.Allowed {
+		res.RetryAfter = res.Reset
+	}
+
+	return res, nil
+}
+
+func (l *sharedLimiter) cleanup(ctx dbx.Context, now time.Time) {
+	l.mu.Lock()
+
+	if now.Sub(l.lastCleanup) < l.opts.CleanupInterval {
+		l.mu.Unlock()
+
+		return
+	}
+
+	l.lastCleanup = now
+	l.mu.Unlock()
+
+	if _, err := l.repository.DeleteExpired(ctx, now); err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Msg("failed to delete expired rate limit counters")
+	}
+}
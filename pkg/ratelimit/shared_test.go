@@ -0,0 +1,101 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/internal/database"
+	"github.com/ziflex/rm-rf-production/pkg/ratelimit"
+)
+
+func TestSharedLimiter_Allow(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	clk := &clock{now: time.Date(2025, 8, 30, 12, 0, 15, 0, time.UTC)}
+	limiter := ratelimit.NewSharedLimiter(db, database.NewRateLimitsRepository(), ratelimit.SharedOptions{Now: clk.Now})
+	rule := ratelimit.Rule{Limit: 2, Period: time.Minute}
+	window := time.Date(2025, 8, 30, 12, 0, 0, 0, time.UTC)
+
+	type testCase struct {
+		Hits      int
+		Allowed   bool
+		Remaining int
+	}
+
+	tsdata := []testCase{
+		{1, true, 1},
+		{2, true, 0},
+		{3, false, 0},
+	}
+
+	for _, tc := range tsdata {
+		mock.ExpectQuery(`INSERT INTO rate_limits \(key, window_start, hits, expires_at\) VALUES \(\$1, \$2, 1, \$3\) ON CONFLICT \(key, window_start\) DO UPDATE SET hits = rate_limits.hits \+ 1 RETURNING hits`).
+			WithArgs("client", window, window.Add(time.Minute)).
+			WillReturnRows(sqlmock.NewRows([]string{"hits"}).AddRow(tc.Hits))
+
+		res, err := limiter.Allow(context.Background(), "client", rule)
+
+		assert.NoError(t, err)
+		assert.Equal(t, tc.Allowed, res.Allowed)
+		assert.Equal(t, tc.Remaining, res.Remaining)
+		assert.Equal(t, 45*time.Second, res.Reset)
+
+		if !tc.Allowed {
+			assert.Equal(t, 45*time.Second, res.RetryAfter)
+		}
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSharedLimiter_Allow_Cleanup(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	clk := &clock{now: time.Date(2025, 8, 30, 12, 0, 0, 0, time.UTC)}
+	limiter := ratelimit.NewSharedLimiter(db, database.NewRateLimitsRepository(), ratelimit.SharedOptions{
+		Now:             clk.Now,
+		CleanupInterval: time.Minute,
+	})
+
+	clk.Advance(2 * time.Minute)
+
+	mock.ExpectQuery(`INSERT INTO rate_limits`).
+		WillReturnRows(sqlmock.NewRows([]string{"hits"}).AddRow(1))
+	mock.ExpectExec(`DELETE FROM rate_limits WHERE expires_at < \$1`).
+		WithArgs(clk.now).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	res, err := limiter.Allow(context.Background(), "client", ratelimit.Rule{Limit: 1, Period: time.Minute})
+
+	assert.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSharedLimiter_Allow_Error_Propagated(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	limiter := ratelimit.NewSharedLimiter(db, database.NewRateLimitsRepository(), ratelimit.SharedOptions{})
+
+	mock.ExpectQuery(`INSERT INTO rate_limits`).WillReturnError(
+		&pq.Error{
+			Code: "08006",
+		},
+	)
+
+	_, err = limiter.Allow(context.Background(), "client", ratelimit.Rule{Limit: 1, Period: time.Minute})
+
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,9 @@
+package reconciliation
+
+import "errors"
+
+var (
+	ErrInvalidFile     = errors.New("invalid settlement file")
+	ErrInvalidLayout   = errors.New("invalid settlement layout")
+	ErrInvalidCategory = errors.New("invalid category")
+)
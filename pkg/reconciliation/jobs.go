@@ -0,0 +1,52 @@
+package reconciliation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ziflex/rm-rf-production/pkg/queue"
+)
+
+// JobType is the queue job type of the settlement file imports.
+const JobType = "reconcile-settlement"
+
+type (
+	// ImportPayload is the payload of an import job, the file is stored with the job until it is reconciled.
+	ImportPayload struct {
+		FileName string `json:"file_name"`
+		Content  string `json:"content"`
+	}
+
+	// ImportResult is the result of a succeeded import job.
+	ImportResult struct {
+		ReconciliationID int64   `json:"reconciliation_id"`
+		Summary          Summary `json:"summary"`
+	}
+)
+
+// NewImportHandler returns the queue handler reconciling the settlement files of the import jobs.
+// Files the layout cannot parse fail the job without a retry.
+func NewImportHandler(svc Service) queue.Handler {
+	return func(ctx context.Context, job queue.Job) (json.RawMessage, error) {
+		var payload ImportPayload
+
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return nil, queue.Permanent(fmt.Errorf("%w: %w", queue.ErrInvalidJob, err))
+		}
+
+		rec, err := svc.Reconcile(ctx, SettlementFile{Name: payload.FileName, Body: strings.NewReader(payload.Content)})
+
+		if errors.Is(err, ErrInvalidFile) {
+			return nil, queue.Permanent(err)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return json.Marshal(ImportResult{ReconciliationID: rec.ID, Summary: rec.Summary})
+	}
+}
@@ -0,0 +1,81 @@
+package reconciliation_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziflex/rm-rf-production/internal/memory"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+	"github.com/ziflex/rm-rf-production/pkg/queue"
+	"github.com/ziflex/rm-rf-production/pkg/reconciliation"
+)
+
+func TestImportHandler(t *testing.T) {
+	store := memory.NewStore()
+	db := store.DB()
+	ctx := common.WithTenant(context.Background(), memory.DefaultTenant)
+
+	svc := reconciliation.NewService(db, memory.NewReconciliationsRepository(store), reconciliation.Options{
+		Layout: csvLayout(t),
+	})
+	jobs := queue.NewService(db, memory.NewJobsRepository(store), queue.Options{
+		Workers:      1,
+		PollInterval: 10 * time.Millisecond,
+		RetryBase:    time.Millisecond,
+	})
+
+	require.NoError(t, jobs.Register(reconciliation.JobType, reconciliation.NewImportHandler(svc)))
+
+	enqueue := func(content string) queue.Job {
+		payload, err := json.Marshal(reconciliation.ImportPayload{FileName: "settlement.csv", Content: content})
+		require.NoError(t, err)
+
+		job, err := jobs.Enqueue(ctx, queue.JobCreation{Type: reconciliation.JobType, Payload: payload})
+		require.NoError(t, err)
+
+		return job
+	}
+
+	valid := enqueue("reference,amount,date\nPRC-1,-10,2025-08-30\n")
+	invalid := enqueue("reference,amount,date\nPRC-1,-10\n")
+
+	jobs.Start(context.Background())
+
+	defer func() {
+		assert.NoError(t, jobs.Stop(context.Background()))
+	}()
+
+	wait := func(id int64) queue.Job {
+		var job queue.Job
+
+		require.Eventually(t, func() bool {
+			var err error
+			job, err = jobs.GetJob(ctx, id)
+
+			return err == nil && (job.Status == queue.StatusSucceeded || job.Status == queue.StatusDead)
+		}, 2*time.Second, 5*time.Millisecond)
+
+		return job
+	}
+
+	done := wait(valid.ID)
+	require.Equal(t, queue.StatusSucceeded, done.Status)
+
+	var result reconciliation.ImportResult
+	require.NoError(t, json.Unmarshal(done.Result, &result))
+	assert.Equal(t, reconciliation.Summary{MissingInLedger: 1}, result.Summary)
+
+	rec, err := svc.GetReconciliation(ctx, result.ReconciliationID, reconciliation.Filter{})
+	require.NoError(t, err)
+	assert.Equal(t, "settlement.csv", rec.FileName)
+
+	// a file that cannot be parsed is not retried
+	dead := wait(invalid.ID)
+	assert.Equal(t, queue.StatusDead, dead.Status)
+	assert.Equal(t, 1, dead.Attempts)
+	assert.Contains(t, dead.LastError, reconciliation.ErrInvalidFile.Error())
+}
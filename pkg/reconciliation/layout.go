@@ -0,0 +1,284 @@
+package reconciliation
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	Kind string
+
+	Field string
+
+	// Column locates a field in a line. CSV columns are the index of the field,
+	// fixed-width columns are the [Start, End) byte range of the field.
+	Column struct {
+		Start int
+		End   int
+	}
+
+	// Layout describes the format of the settlement files of the processor.
+	Layout struct {
+		Kind    Kind
+		Columns map[Field]Column
+		// Delimiter separates the fields of CSV files, a comma when zero.
+		Delimiter rune
+		// SkipHeader skips the first line of the file.
+		SkipHeader bool
+		// DateLayout is the Go time layout of the date field.
+		DateLayout string
+		// MinorUnits is set when amounts are integers in cents, as is common in fixed-width files.
+		MinorUnits bool
+		// Negate flips the sign of the amounts, for processors that send debits as positive amounts.
+		Negate bool
+	}
+)
+
+const (
+	KindCSV   Kind = "csv"
+	KindFixed Kind = "fixed"
+)
+
+const (
+	FieldReference Field = "reference"
+	FieldAmount    Field = "amount"
+	FieldDate      Field = "date"
+)
+
+// MaxReferenceLength is the longest external reference of a transaction.
+const MaxReferenceLength = 64
+
+// ParseColumns parses the columns of a layout, "N" for CSV and "N-M" for fixed-width files.
+func ParseColumns(kind Kind, columns map[string]string) (map[Field]Column, error) {
+	res := make(map[Field]Column, len(columns))
+
+	for name, spec := range columns {
+		var col Column
+		var err error
+
+		if kind == KindFixed {
+			start, end, found := strings.Cut(spec, "-")
+
+			if !found {
+				return nil, fmt.Errorf("%w: column %s must be a range: %s", ErrInvalidLayout, name, spec)
+			}
+
+			if col.Start, err = strconv.Atoi(start); err == nil {
+				col.End, err = strconv.Atoi(end)
+			}
+		} else {
+			col.Start, err = strconv.Atoi(spec)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: column %s: %s", ErrInvalidLayout, name, spec)
+		}
+
+		res[Field(name)] = col
+	}
+
+	return res, nil
+}
+
+func (l Layout) Validate() error {
+	if l.Kind != KindCSV && l.Kind != KindFixed {
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidLayout, l.Kind)
+	}
+
+	if l.DateLayout == "" {
+		return fmt.Errorf("%w: date layout is required", ErrInvalidLayout)
+	}
+
+	for _, field := range []Field{FieldReference, FieldAmount, FieldDate} {
+		col, found := l.Columns[field]
+
+		if !found {
+			return fmt.Errorf("%w: column %s is required", ErrInvalidLayout, field)
+		}
+
+		if col.Start < 0 || (l.Kind == KindFixed && col.End <= col.Start) {
+			return fmt.Errorf("%w: column %s is out of range", ErrInvalidLayout, field)
+		}
+	}
+
+	return nil
+}
+
+// Parse reads the lines of a settlement file. Blank lines are skipped.
+func (l Layout) Parse(r io.Reader) ([]Line, error) {
+	if err := l.Validate(); err != nil {
+		return nil, err
+	}
+
+	var lines []Line
+
+	err := l.records(r, func(number int, field func(Field) (string, error)) error {
+		line, err := l.parseLine(number, field)
+
+		if err != nil {
+			return fmt.Errorf("%w: line %d: %w", ErrInvalidFile, number, err)
+		}
+
+		lines = append(lines, line)
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("%w: no lines", ErrInvalidFile)
+	}
+
+	return lines, nil
+}
+
+// records calls fn with the number of every line of the file and an accessor of its fields.
+func (l Layout) records(r io.Reader, fn func(number int, field func(Field) (string, error)) error) error {
+	if l.Kind == KindCSV {
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+
+		if l.Delimiter != 0 {
+			reader.Comma = l.Delimiter
+		}
+
+		for first := true; ; first = false {
+			record, err := reader.Read()
+
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			if err != nil {
+				return fmt.Errorf("%w: %w", ErrInvalidFile, err)
+			}
+
+			if first && l.SkipHeader {
+				continue
+			}
+
+			number, _ := reader.FieldPos(0)
+
+			err = fn(number, func(f Field) (string, error) {
+				col := l.Columns[f]
+
+				if col.Start >= len(record) {
+					return "", fmt.Errorf("missing column %s", f)
+				}
+
+				return strings.TrimSpace(record[col.Start]), nil
+			})
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+
+	for number := 1; scanner.Scan(); number++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+
+		if (number == 1 && l.SkipHeader) || strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		err := fn(number, func(f Field) (string, error) {
+			col := l.Columns[f]
+
+			if col.End > len(text) {
+				return "", fmt.Errorf("line is too short for column %s", f)
+			}
+
+			return strings.TrimSpace(text[col.Start:col.End]), nil
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidFile, err)
+	}
+
+	return nil
+}
+
+func (l Layout) parseLine(number int, field func(Field) (string, error)) (Line, error) {
+	line := Line{Number: number}
+
+	ref, err := field(FieldReference)
+
+	if err != nil {
+		return Line{}, err
+	}
+
+	// a longer reference cannot belong to a transaction
+	if len(ref) > MaxReferenceLength {
+		return Line{}, fmt.Errorf("reference is too long: %s", ref)
+	}
+
+	line.Reference = ref
+
+	amount, err := field(FieldAmount)
+
+	if err != nil {
+		return Line{}, err
+	}
+
+	if line.Amount, err = l.parseAmount(amount); err != nil {
+		return Line{}, err
+	}
+
+	date, err := field(FieldDate)
+
+	if err != nil {
+		return Line{}, err
+	}
+
+	if line.Date, err = time.ParseInLocation(l.DateLayout, date, time.UTC); err != nil {
+		return Line{}, fmt.Errorf("invalid date: %s", date)
+	}
+
+	return line, nil
+}
+
+func (l Layout) parseAmount(s string) (float64, error) {
+	var amount float64
+
+	if l.MinorUnits {
+		cents, err := strconv.ParseInt(s, 10, 64)
+
+		if err != nil {
+			return 0, fmt.Errorf("invalid amount: %s", s)
+		}
+
+		amount = float64(cents) / 100
+	} else {
+		v, err := strconv.ParseFloat(s, 64)
+
+		if err != nil {
+			return 0, fmt.Errorf("invalid amount: %s", s)
+		}
+
+		amount = v
+	}
+
+	if l.Negate {
+		amount = -amount
+	}
+
+	return amount, nil
+}
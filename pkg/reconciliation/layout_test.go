@@ -0,0 +1,99 @@
+package reconciliation_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ziflex/rm-rf-production/pkg/reconciliation"
+)
+
+func csvLayout(t *testing.T) reconciliation.Layout {
+	columns, err := reconciliation.ParseColumns(reconciliation.KindCSV, map[string]string{
+		"reference": "0",
+		"amount":    "1",
+		"date":      "2",
+	})
+	assert.NoError(t, err)
+
+	return reconciliation.Layout{
+		Kind:       reconciliation.KindCSV,
+		Columns:    columns,
+		SkipHeader: true,
+		DateLayout: "2006-01-02",
+	}
+}
+
+func TestLayout_Parse_CSV(t *testing.T) {
+	lines, err := csvLayout(t).Parse(strings.NewReader("reference,amount,date\nPRC-1,-10.50,2025-08-30\n\n,20,2025-08-31\n"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []reconciliation.Line{
+		{Number: 2, Reference: "PRC-1", Amount: -10.5, Date: time.Date(2025, time.August, 30, 0, 0, 0, 0, time.UTC)},
+		{Number: 4, Reference: "", Amount: 20, Date: time.Date(2025, time.August, 31, 0, 0, 0, 0, time.UTC)},
+	}, lines)
+}
+
+func TestLayout_Parse_Fixed(t *testing.T) {
+	columns, err := reconciliation.ParseColumns(reconciliation.KindFixed, map[string]string{
+		"reference": "0-8",
+		"amount":    "8-18",
+		"date":      "18-26",
+	})
+	assert.NoError(t, err)
+
+	layout := reconciliation.Layout{
+		Kind:       reconciliation.KindFixed,
+		Columns:    columns,
+		DateLayout: "20060102",
+		MinorUnits: true,
+		Negate:     true,
+	}
+
+	lines, err := layout.Parse(strings.NewReader("PRC-1   000000105020250830\r\nPRC-2   000000200020250831\r\n"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []reconciliation.Line{
+		{Number: 1, Reference: "PRC-1", Amount: -10.5, Date: time.Date(2025, time.August, 30, 0, 0, 0, 0, time.UTC)},
+		{Number: 2, Reference: "PRC-2", Amount: -20, Date: time.Date(2025, time.August, 31, 0, 0, 0, 0, time.UTC)},
+	}, lines)
+}
+
+func TestLayout_Parse_Error(t *testing.T) {
+	type testCase struct {
+		name  string
+		input string
+		err   string
+	}
+
+	tsdata := []testCase{
+		{"Empty", "reference,amount,date\n", "no lines"},
+		{"Amount", "header\nPRC-1,ten,2025-08-30\n", "line 2: invalid amount: ten"},
+		{"Date", "header\nPRC-1,10,30/08/2025\n", "line 2: invalid date: 30/08/2025"},
+		{"Columns", "header\nPRC-1,10\n", "line 2: missing column date"},
+		{"Reference", "header\n" + strings.Repeat("R", 65) + ",10,2025-08-30\n", "line 2: reference is too long"},
+	}
+
+	for _, tc := range tsdata {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := csvLayout(t).Parse(strings.NewReader(tc.input))
+
+			assert.ErrorIs(t, err, reconciliation.ErrInvalidFile)
+			assert.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestLayout_Validate(t *testing.T) {
+	layout := csvLayout(t)
+	assert.NoError(t, layout.Validate())
+
+	delete(layout.Columns, reconciliation.FieldAmount)
+	assert.ErrorIs(t, layout.Validate(), reconciliation.ErrInvalidLayout)
+
+	_, err := reconciliation.ParseColumns(reconciliation.KindFixed, map[string]string{"reference": "8"})
+	assert.ErrorIs(t, err, reconciliation.ErrInvalidLayout)
+
+	assert.ErrorIs(t, reconciliation.Layout{Kind: "xml"}.Validate(), reconciliation.ErrInvalidLayout)
+}
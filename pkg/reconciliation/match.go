@@ -0,0 +1,126 @@
+package reconciliation
+
+import (
+	"math"
+	"time"
+
+	"github.com/ziflex/rm-rf-production/pkg/transactions"
+)
+
+// Period returns the [start, end) range of event dates the transactions of the lines are looked up in.
+// It covers the days of the lines, widened by the tolerance on both sides
+// for the transactions the processor settles on another day than they were created.
+// The widening only serves matching, the transactions outside the days of the lines are not missing in the file.
+func Period(lines []Line, tolerance time.Duration) (time.Time, time.Time) {
+	start := lines[0].Date
+	end := lines[0].Date
+
+	for _, line := range lines[1:] {
+		if line.Date.Before(start) {
+			start = line.Date
+		}
+
+		if line.Date.After(end) {
+			end = line.Date
+		}
+	}
+
+	start = start.UTC().Truncate(24 * time.Hour).Add(-tolerance)
+	end = end.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour).Add(tolerance)
+
+	return start, end
+}
+
+// Match matches the lines of a file to the transactions of its period.
+//
+// A line matches the transaction with its reference, it is an amount mismatch if their amounts differ.
+// A line without a reference matches a transaction without one that has the same amount and day.
+// Lines left are missing in the ledger. Transactions left are missing in the file if they were created
+// on one of the days of the lines, those of the other days are expected in the files of their days.
+// A transaction is matched once, a reference repeated in the file is missing in the ledger.
+// Transactions the processor does not settle, e.g. payments and dispute credits, are ignored.
+func Match(lines []Line, ledger []transactions.Transaction) ([]Item, Summary) {
+	byRef := make(map[string]int, len(ledger))
+	// transactions without a reference in ledger order, by amount and day
+	unreferenced := make(map[dayAmount][]int)
+	days := make(map[int64]bool)
+
+	for _, line := range lines {
+		days[day(line.Date)] = true
+	}
+
+	for i, tr := range ledger {
+		if !tr.OperationType.IsSettled() {
+			continue
+		}
+
+		if tr.ExternalRef != "" {
+			byRef[tr.ExternalRef] = i
+		} else {
+			key := dayAmount{day(tr.EventDate), cents(tr.Amount)}
+			unreferenced[key] = append(unreferenced[key], i)
+		}
+	}
+
+	matched := make([]bool, len(ledger))
+	items := make([]Item, 0, len(lines))
+	var summary Summary
+
+	add := func(c Category, line *Line, i int) {
+		item := Item{Category: c, Line: line}
+
+		if i >= 0 {
+			matched[i] = true
+			item.Transaction = &ledger[i]
+		}
+
+		items = append(items, item)
+		summary.Add(c)
+	}
+
+	for n := range lines {
+		line := &lines[n]
+		i := -1
+
+		if line.Reference != "" {
+			if j, found := byRef[line.Reference]; found && !matched[j] {
+				i = j
+			}
+		} else if key := (dayAmount{day(line.Date), cents(line.Amount)}); len(unreferenced[key]) > 0 {
+			i = unreferenced[key][0]
+			unreferenced[key] = unreferenced[key][1:]
+		}
+
+		switch {
+		case i < 0:
+			add(CategoryMissingInLedger, line, i)
+		case cents(line.Amount) != cents(ledger[i].Amount):
+			add(CategoryAmountMismatch, line, i)
+		default:
+			add(CategoryMatched, line, i)
+		}
+	}
+
+	for i, tr := range ledger {
+		if !matched[i] && tr.OperationType.IsSettled() && days[day(tr.EventDate)] {
+			add(CategoryMissingInFile, nil, i)
+		}
+	}
+
+	return items, summary
+}
+
+type dayAmount struct {
+	day   int64
+	cents int64
+}
+
+// day returns the UTC day of the time, as the number of days since the epoch.
+func day(t time.Time) int64 {
+	return t.UTC().Truncate(24*time.Hour).Unix() / 86400
+}
+
+// cents compares amounts as they are stored, with two decimals.
+func cents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
@@ -0,0 +1,115 @@
+package reconciliation_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ziflex/rm-rf-production/pkg/reconciliation"
+	"github.com/ziflex/rm-rf-production/pkg/transactions"
+)
+
+func TestMatch(t *testing.T) {
+	day := time.Date(2025, time.August, 30, 0, 0, 0, 0, time.UTC)
+	at := day.Add(12 * time.Hour)
+
+	purchase := transactions.OperationTypePurchase
+	ledger := []transactions.Transaction{
+		{ID: 1, OperationType: purchase, Amount: -10, EventDate: at, ExternalRef: "PRC-1"},
+		{ID: 2, OperationType: purchase, Amount: -20, EventDate: at, ExternalRef: "PRC-2"},
+		{ID: 3, OperationType: transactions.OperationTypeWithdrawal, Amount: -30, EventDate: at, ExternalRef: "PRC-3"},
+		{ID: 4, OperationType: purchase, Amount: -5, EventDate: at},
+		{ID: 5, OperationType: purchase, Amount: -5, EventDate: at.Add(24 * time.Hour)},
+	}
+
+	lines := []reconciliation.Line{
+		{Number: 1, Reference: "PRC-1", Amount: -10, Date: day},
+		{Number: 2, Reference: "PRC-2", Amount: -25, Date: day},
+		{Number: 3, Reference: "PRC-9", Amount: -1, Date: day},
+		{Number: 4, Reference: "PRC-1", Amount: -10, Date: day},
+		{Number: 5, Amount: -5, Date: day},
+		{Number: 6, Amount: -5, Date: day},
+	}
+
+	items, summary := reconciliation.Match(lines, ledger)
+
+	type result struct {
+		category    reconciliation.Category
+		line        int
+		transaction int64
+	}
+
+	var results []result
+
+	for _, item := range items {
+		r := result{category: item.Category}
+
+		if item.Line != nil {
+			r.line = item.Line.Number
+		}
+
+		if item.Transaction != nil {
+			r.transaction = item.Transaction.ID
+		}
+
+		results = append(results, r)
+	}
+
+	assert.Equal(t, []result{
+		{reconciliation.CategoryMatched, 1, 1},
+		{reconciliation.CategoryAmountMismatch, 2, 2},
+		{reconciliation.CategoryMissingInLedger, 3, 0},
+		// a transaction is matched once
+		{reconciliation.CategoryMissingInLedger, 4, 0},
+		// lines without a reference match by amount and day
+		{reconciliation.CategoryMatched, 5, 4},
+		{reconciliation.CategoryMissingInLedger, 6, 0},
+		{reconciliation.CategoryMissingInFile, 0, 3},
+		// the transaction of the next day is expected in the file of its day
+	}, results)
+
+	assert.Equal(t, reconciliation.Summary{Matched: 2, MissingInLedger: 3, MissingInFile: 1, AmountMismatch: 1}, summary)
+}
+
+func TestMatch_Tolerance(t *testing.T) {
+	day := time.Date(2025, time.August, 30, 0, 0, 0, 0, time.UTC)
+	at := day.Add(12 * time.Hour)
+	purchase := transactions.OperationTypePurchase
+
+	ledger := []transactions.Transaction{
+		{ID: 1, OperationType: purchase, Amount: -10, EventDate: at.Add(-24 * time.Hour), ExternalRef: "PRC-1"},
+		{ID: 2, OperationType: purchase, Amount: -20, EventDate: at.Add(-24 * time.Hour), ExternalRef: "PRC-2"},
+		{ID: 3, OperationType: purchase, Amount: -30, EventDate: at, ExternalRef: "PRC-3"},
+		{ID: 4, OperationType: transactions.OperationTypeWithdrawal, Amount: -40, EventDate: at.Add(24 * time.Hour), ExternalRef: "PRC-4"},
+		{ID: 5, OperationType: transactions.OperationTypePayment, Amount: 50, EventDate: at, ExternalRef: "PAY-5"},
+		{ID: 6, OperationType: transactions.OperationTypePayment, Amount: 5, EventDate: at},
+	}
+
+	lines := []reconciliation.Line{
+		// settled a day after it was created
+		{Number: 1, Reference: "PRC-1", Amount: -10, Date: day},
+		{Number: 2, Amount: 5, Date: day},
+	}
+
+	items, summary := reconciliation.Match(lines, ledger)
+
+	// the transactions of the days around the file are only matched, payments are ignored
+	assert.Equal(t, reconciliation.Summary{Matched: 1, MissingInLedger: 1, MissingInFile: 1}, summary)
+	assert.Len(t, items, 3)
+	assert.Equal(t, int64(1), items[0].Transaction.ID)
+	assert.Equal(t, reconciliation.CategoryMissingInLedger, items[1].Category)
+	assert.Equal(t, reconciliation.CategoryMissingInFile, items[2].Category)
+	assert.Equal(t, int64(3), items[2].Transaction.ID)
+}
+
+func TestPeriod(t *testing.T) {
+	lines := []reconciliation.Line{
+		{Date: time.Date(2025, time.August, 31, 0, 0, 0, 0, time.UTC)},
+		{Date: time.Date(2025, time.August, 30, 0, 0, 0, 0, time.UTC)},
+	}
+
+	start, end := reconciliation.Period(lines, 24*time.Hour)
+
+	assert.Equal(t, time.Date(2025, time.August, 29, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2025, time.September, 2, 0, 0, 0, 0, time.UTC), end)
+}
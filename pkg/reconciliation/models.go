@@ -0,0 +1,100 @@
+package reconciliation
+
+import (
+	"io"
+	"time"
+
+	"github.com/ziflex/rm-rf-production/pkg/transactions"
+)
+
+type (
+	Category string
+
+	// SettlementFile is a file sent by the card processor.
+	SettlementFile struct {
+		Name string
+		Body io.Reader
+	}
+
+	// Line is a settled transaction of a settlement file.
+	Line struct {
+		// Number is the line of the file, starting at 1.
+		Number    int       `json:"number"`
+		Reference string    `json:"reference"`
+		Amount    float64   `json:"amount"`
+		Date      time.Time `json:"date"`
+	}
+
+	// Item is an entry of the report. Line is nil for transactions missing in the file,
+	// Transaction is nil for lines missing in the ledger.
+	Item struct {
+		Category    Category                  `json:"category"`
+		Line        *Line                     `json:"line,omitempty"`
+		Transaction *transactions.Transaction `json:"transaction,omitempty"`
+	}
+
+	Summary struct {
+		Matched         int `json:"matched"`
+		MissingInLedger int `json:"missing_in_ledger"`
+		MissingInFile   int `json:"missing_in_file"`
+		AmountMismatch  int `json:"amount_mismatch"`
+	}
+
+	ReconciliationCreation struct {
+		FileName string
+		// PeriodStart and PeriodEnd bound the event dates of the transactions matched against the file.
+		PeriodStart time.Time
+		PeriodEnd   time.Time
+		Summary     Summary
+		Items       []Item
+	}
+
+	Reconciliation struct {
+		ID          int64     `json:"id" db:"id"`
+		FileName    string    `json:"file_name" db:"file_name"`
+		PeriodStart time.Time `json:"period_start" db:"period_start"`
+		PeriodEnd   time.Time `json:"period_end" db:"period_end"`
+		Summary     Summary   `json:"summary"`
+		Items       []Item    `json:"items"`
+		CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	}
+
+	Filter struct {
+		// Category selects the items of a category, all items are returned when empty.
+		Category Category
+	}
+)
+
+const (
+	CategoryMatched         Category = "matched"
+	CategoryMissingInLedger Category = "missing_in_ledger"
+	CategoryMissingInFile   Category = "missing_in_file"
+	CategoryAmountMismatch  Category = "amount_mismatch"
+)
+
+func (c Category) IsValid() bool {
+	switch c {
+	case CategoryMatched, CategoryMissingInLedger, CategoryMissingInFile, CategoryAmountMismatch:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c Category) String() string {
+	return string(c)
+}
+
+// Add counts an item of the category.
+func (s *Summary) Add(c Category) {
+	switch c {
+	case CategoryMatched:
+		s.Matched++
+	case CategoryMissingInLedger:
+		s.MissingInLedger++
+	case CategoryMissingInFile:
+		s.MissingInFile++
+	case CategoryAmountMismatch:
+		s.AmountMismatch++
+	}
+}
@@ -0,0 +1,18 @@
+package reconciliation
+
+import (
+	"time"
+
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/transactions"
+)
+
+type Repository interface {
+	// CreateReconciliation stores a reconciliation with its items in the tenant from the context.
+	CreateReconciliation(ctx dbx.Context, creation ReconciliationCreation) (Reconciliation, error)
+	// GetReconciliation returns a reconciliation with the items matching the filter, in the order they were stored.
+	GetReconciliation(ctx dbx.Context, id int64, filter Filter) (Reconciliation, error)
+	// ListLedger passes the settled transactions (see transactions.OperationType.IsSettled) of the tenant
+	// created in [from, to) to fn, in the order of their ids.
+	ListLedger(ctx dbx.Context, from, to time.Time, fn func(transactions.Transaction) error) error
+}
@@ -0,0 +1,120 @@
+package reconciliation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+	"github.com/ziflex/rm-rf-production/pkg/transactions"
+)
+
+const DefaultDateTolerance = 24 * time.Hour
+
+type (
+	Service interface {
+		// Reconcile matches a settlement file to the ledger of the tenant from the context and stores the report.
+		Reconcile(ctx context.Context, file SettlementFile) (Reconciliation, error)
+		GetReconciliation(ctx context.Context, id int64, filter Filter) (Reconciliation, error)
+	}
+
+	Options struct {
+		Layout Layout
+		// DateTolerance widens the period of the file, for transactions settled on another day than they were created.
+		// DefaultDateTolerance is used when zero.
+		DateTolerance time.Duration
+	}
+
+	serviceImpl struct {
+		db         dbx.Database
+		repository Repository
+		opts       Options
+	}
+)
+
+func NewService(db dbx.Database, repository Repository, opts Options) Service {
+	if opts.DateTolerance <= 0 {
+		opts.DateTolerance = DefaultDateTolerance
+	}
+
+	return &serviceImpl{db, repository, opts}
+}
+
+func (s *serviceImpl) Reconcile(ctx context.Context, file SettlementFile) (Reconciliation, error) {
+	log := zerolog.Ctx(ctx).With().Str("file", file.Name).Logger()
+	log.Info().Msg("reconciling settlement file")
+
+	lines, err := s.opts.Layout.Parse(file.Body)
+
+	if err != nil {
+		log.Error().Err(err).Msg("failed to parse settlement file")
+
+		return Reconciliation{}, err
+	}
+
+	start, end := Period(lines, s.opts.DateTolerance)
+
+	var ledger []transactions.Transaction
+
+	// the ledger is read from the primary, a lagging replica would report fresh transactions as missing
+	err = s.repository.ListLedger(dbx.NewContextFrom(ctx, s.db), start, end, func(tr transactions.Transaction) error {
+		ledger = append(ledger, tr)
+
+		return nil
+	})
+
+	if err != nil {
+		log.Error().Err(err).Msg("failed to read ledger")
+
+		return Reconciliation{}, err
+	}
+
+	items, summary := Match(lines, ledger)
+
+	res, err := dbx.TransactionWithResult[Reconciliation](ctx, s.db, func(tx dbx.Context) (Reconciliation, error) {
+		return s.repository.CreateReconciliation(tx, ReconciliationCreation{
+			FileName:    file.Name,
+			PeriodStart: start,
+			PeriodEnd:   end,
+			Summary:     summary,
+			Items:       items,
+		})
+	})
+
+	if err != nil {
+		log.Error().Err(err).Msg("failed to store reconciliation")
+
+		return Reconciliation{}, err
+	}
+
+	log.Info().
+		Int64("reconciliation_id", res.ID).
+		Int("matched", summary.Matched).
+		Int("missing_in_ledger", summary.MissingInLedger).
+		Int("missing_in_file", summary.MissingInFile).
+		Int("amount_mismatch", summary.AmountMismatch).
+		Msg("settlement file reconciled")
+
+	return res, nil
+}
+
+func (s *serviceImpl) GetReconciliation(ctx context.Context, id int64, filter Filter) (Reconciliation, error) {
+	log := zerolog.Ctx(ctx)
+	log.Info().Int64("reconciliation_id", id).Msg("getting reconciliation")
+
+	if filter.Category != "" && !filter.Category.IsValid() {
+		return Reconciliation{}, fmt.Errorf("%w: %s", ErrInvalidCategory, filter.Category)
+	}
+
+	res, err := s.repository.GetReconciliation(dbx.NewContextFrom(ctx, common.ForRead(ctx, s.db)), id, filter)
+
+	if err != nil {
+		log.Error().Err(err).Int64("reconciliation_id", id).Msg("failed to get reconciliation")
+
+		return Reconciliation{}, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,114 @@
+package reconciliation_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/internal/database"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+	"github.com/ziflex/rm-rf-production/pkg/reconciliation"
+)
+
+const testTenant = "acme"
+
+func tenantCtx() context.Context {
+	return common.WithTenant(context.Background(), testTenant)
+}
+
+func TestService_Reconcile_Success(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+
+	svc := reconciliation.NewService(dbx.New(mockDB), database.NewReconciliationsRepository(), reconciliation.Options{
+		Layout: csvLayout(t),
+	})
+
+	start := time.Date(2025, time.August, 29, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, time.September, 1, 0, 0, 0, 0, time.UTC)
+	at := time.Date(2025, time.August, 30, 12, 0, 0, 0, time.UTC)
+	createdAt := time.Date(2025, time.August, 31, 3, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT id, account_id, operation_type, amount, event_date, COALESCE\(external_ref, ''\) FROM transactions WHERE tenant_id=\$1 AND event_date>=\$2 AND event_date<\$3 AND operation_type IN \('purchase', 'installment_purchase', 'withdrawal'\) ORDER BY id`).
+		WithArgs(testTenant, start, end).
+		WillReturnRows(sqlmock.
+			NewRows([]string{"id", "account_id", "operation_type", "amount", "event_date", "external_ref"}).
+			AddRow(1, 5, "purchase", -10.0, at, "PRC-1").
+			AddRow(2, 5, "withdrawal", -20.0, at, "PRC-2"),
+		)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO reconciliations \(tenant_id, file_name, period_start, period_end, matched, missing_in_ledger, missing_in_file, amount_mismatch\)`).
+		WithArgs(testTenant, "settlement.csv", start, end, 1, 1, 1, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(3, createdAt))
+	mock.ExpectExec(`INSERT INTO reconciliation_items .+ FROM unnest\(`).
+		WithArgs(int64(3), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	res, err := svc.Reconcile(tenantCtx(), reconciliation.SettlementFile{
+		Name: "settlement.csv",
+		Body: strings.NewReader("reference,amount,date\nPRC-1,-10,2025-08-30\nPRC-9,-1,2025-08-30\n"),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), res.ID)
+	assert.Equal(t, createdAt, res.CreatedAt)
+	assert.Equal(t, reconciliation.Summary{Matched: 1, MissingInLedger: 1, MissingInFile: 1}, res.Summary)
+	assert.Len(t, res.Items, 3)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_Reconcile_Error_InvalidFile(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+
+	svc := reconciliation.NewService(dbx.New(mockDB), database.NewReconciliationsRepository(), reconciliation.Options{
+		Layout: csvLayout(t),
+	})
+
+	_, err = svc.Reconcile(tenantCtx(), reconciliation.SettlementFile{
+		Name: "settlement.csv",
+		Body: strings.NewReader("reference,amount,date\nPRC-1,-10\n"),
+	})
+
+	assert.ErrorIs(t, err, reconciliation.ErrInvalidFile)
+	// nothing is stored for a file that cannot be parsed
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_GetReconciliation_Error_NotFound(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+
+	svc := reconciliation.NewService(dbx.New(mockDB), database.NewReconciliationsRepository(), reconciliation.Options{})
+
+	mock.ExpectQuery(`SELECT id, file_name, period_start, period_end, .+ FROM reconciliations WHERE tenant_id=\$1 AND id=\$2`).
+		WithArgs(testTenant, int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err = svc.GetReconciliation(tenantCtx(), 1, reconciliation.Filter{})
+
+	assert.ErrorIs(t, err, common.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_GetReconciliation_Error_InvalidCategory(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+
+	svc := reconciliation.NewService(dbx.New(mockDB), database.NewReconciliationsRepository(), reconciliation.Options{})
+
+	_, err = svc.GetReconciliation(tenantCtx(), 1, reconciliation.Filter{Category: "unknown"})
+
+	assert.ErrorIs(t, err, reconciliation.ErrInvalidCategory)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,217 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	// the day matches either field when both are restricted, as in Vixie cron
+	domAny, dowAny bool
+}
+
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	fieldMinute = cronField{name: "minute", min: 0, max: 59}
+	fieldHour   = cronField{name: "hour", min: 0, max: 23}
+	fieldDom    = cronField{name: "day of month", min: 1, max: 31}
+	fieldMonth  = cronField{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 7 is accepted for Sunday as well
+	fieldDow = cronField{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseSchedule parses a cron expression with five fields (minute, hour, day of month, month and day of week)
+// or one of the @yearly, @monthly, @weekly, @daily and @hourly descriptors.
+// Fields accept *, values, ranges, steps and lists, months and days of week accept three letter names.
+func ParseSchedule(expr string) (Schedule, error) {
+	spec := strings.TrimSpace(expr)
+
+	if d, ok := descriptors[strings.ToLower(spec)]; ok {
+		spec = d
+	}
+
+	parts := strings.Fields(spec)
+
+	if len(parts) != 5 {
+		return Schedule{}, fmt.Errorf("%w: %q: expected 5 fields, got %d", ErrInvalidSchedule, expr, len(parts))
+	}
+
+	var s Schedule
+	var err error
+
+	fields := []struct {
+		field cronField
+		bits  *uint64
+	}{
+		{fieldMinute, &s.minute},
+		{fieldHour, &s.hour},
+		{fieldDom, &s.dom},
+		{fieldMonth, &s.month},
+		{fieldDow, &s.dow},
+	}
+
+	for i, f := range fields {
+		if *f.bits, err = f.field.parse(parts[i]); err != nil {
+			return Schedule{}, fmt.Errorf("%w: %q: %w", ErrInvalidSchedule, expr, err)
+		}
+	}
+
+	s.domAny = parts[2] == "*" || strings.HasPrefix(parts[2], "*/")
+	s.dowAny = parts[4] == "*" || strings.HasPrefix(parts[4], "*/")
+
+	// Sunday is bit 0
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+
+	if s.Next(time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)).IsZero() {
+		return Schedule{}, fmt.Errorf("%w: %q: never runs", ErrInvalidSchedule, expr)
+	}
+
+	return s, nil
+}
+
+// Next returns the first time after t the schedule fires at, in UTC.
+// It returns the zero time when the schedule does not fire within five years.
+func (s Schedule) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if !has(s.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+
+			continue
+		}
+
+		if !s.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+
+			continue
+		}
+
+		if !has(s.hour, t.Hour()) {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+
+			continue
+		}
+
+		if !has(s.minute, t.Minute()) {
+			t = t.Add(time.Minute)
+
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (s Schedule) matchDay(t time.Time) bool {
+	dom := has(s.dom, t.Day())
+	dow := has(s.dow, int(t.Weekday()))
+
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+func has(bits uint64, v int) bool {
+	return bits&(1<<uint(v)) != 0
+}
+
+// parse parses a comma separated list of *, N, N-M, */S, N/S and N-M/S items into a bit set.
+func (f cronField) parse(s string) (uint64, error) {
+	var bits uint64
+
+	for _, item := range strings.Split(s, ",") {
+		rng, step, hasStep := strings.Cut(item, "/")
+		lo, hi := f.min, f.max
+
+		if rng != "*" {
+			from, to, isRange := strings.Cut(rng, "-")
+			var err error
+
+			if lo, err = f.value(from); err != nil {
+				return 0, err
+			}
+
+			hi = lo
+
+			if isRange {
+				if hi, err = f.value(to); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				// N/S runs from N to the end of the range
+				hi = f.max
+			}
+
+			if lo > hi {
+				return 0, fmt.Errorf("%s: invalid range %s", f.name, rng)
+			}
+		}
+
+		n := 1
+
+		if hasStep {
+			var err error
+
+			if n, err = strconv.Atoi(step); err != nil || n <= 0 {
+				return 0, fmt.Errorf("%s: invalid step %s", f.name, step)
+			}
+		}
+
+		for v := lo; v <= hi; v += n {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(s)
+
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s: invalid value %s", f.name, s)
+	}
+
+	return v, nil
+}
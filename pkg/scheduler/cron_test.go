@@ -0,0 +1,68 @@
+package scheduler_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ziflex/rm-rf-production/pkg/scheduler"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	// a Saturday
+	from := time.Date(2025, time.August, 30, 10, 17, 42, 0, time.UTC)
+
+	type testCase struct {
+		expr     string
+		expected time.Time
+	}
+
+	tsdata := []testCase{
+		{"* * * * *", time.Date(2025, time.August, 30, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, time.August, 30, 10, 30, 0, 0, time.UTC)},
+		{"17 * * * *", time.Date(2025, time.August, 30, 11, 17, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2025, time.August, 30, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2025, time.August, 31, 0, 0, 0, 0, time.UTC)},
+		{"30 2 * * mon-fri", time.Date(2025, time.September, 1, 2, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2025, time.August, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 6,18 * * *", time.Date(2025, time.August, 30, 18, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2025, time.September, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 5/10 * *", time.Date(2025, time.September, 5, 0, 0, 0, 0, time.UTC)},
+		// both days restricted, either matches
+		{"0 0 15 * sun", time.Date(2025, time.August, 31, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range tsdata {
+		t.Run(tc.expr, func(t *testing.T) {
+			s, err := scheduler.ParseSchedule(tc.expr)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, s.Next(from))
+		})
+	}
+}
+
+func TestParseSchedule_Error(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@every 5m",
+		// never runs
+		"0 0 31 feb *",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := scheduler.ParseSchedule(expr)
+
+			assert.ErrorIs(t, err, scheduler.ErrInvalidSchedule)
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package scheduler
+
+import "errors"
+
+var (
+	ErrInvalidSchedule = errors.New("invalid schedule")
+	ErrInvalidJob      = errors.New("invalid job")
+	// ErrLocked is returned when the job is running on another instance, or in another goroutine of this one.
+	ErrLocked = errors.New("job is locked")
+	// ErrAlreadyRan is returned when another instance has already run the job at the scheduled time.
+	ErrAlreadyRan = errors.New("job already ran")
+)
@@ -0,0 +1,42 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+)
+
+type (
+	// Locker guarantees that a job runs on a single instance at a time.
+	Locker interface {
+		// TryLock acquires the lock of the job without waiting. The lock is held until unlock is called.
+		TryLock(ctx context.Context, job string) (unlock func(), acquired bool, err error)
+	}
+
+	// LocalLocker locks the jobs within the process, for deployments running a single instance.
+	LocalLocker struct {
+		mu     sync.Mutex
+		locked map[string]bool
+	}
+)
+
+func NewLocalLocker() *LocalLocker {
+	return &LocalLocker{locked: make(map[string]bool)}
+}
+
+func (l *LocalLocker) TryLock(_ context.Context, job string) (func(), bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.locked[job] {
+		return nil, false, nil
+	}
+
+	l.locked[job] = true
+
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		delete(l.locked, job)
+	}, true, nil
+}
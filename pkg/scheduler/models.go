@@ -0,0 +1,72 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	RunStatus string
+
+	// Job is a named periodic task.
+	Job struct {
+		Name        string
+		Description string
+		// Schedule is a cron expression evaluated in UTC. A job without a schedule runs on demand only.
+		Schedule string
+		// Func returns a short human readable result, stored in the run history.
+		Func func(ctx context.Context) (string, error)
+	}
+
+	RunCreation struct {
+		Job      string
+		Instance string
+		// ScheduledAt is the time the run was due at, a job runs once per scheduled time across all instances.
+		ScheduledAt time.Time
+	}
+
+	RunCompletion struct {
+		Status RunStatus
+		Result string
+		Error  string
+	}
+
+	Run struct {
+		ID          int64
+		Job         string
+		Instance    string
+		Status      RunStatus
+		ScheduledAt time.Time
+		StartedAt   time.Time
+		FinishedAt  *time.Time
+		Result      string
+		Error       string
+	}
+
+	RunFilter struct {
+		Job    string
+		Status RunStatus
+		Limit  int
+	}
+
+	JobStatus struct {
+		Name        string
+		Description string
+		Schedule    string
+		// NextRunAt is nil for jobs running on demand only.
+		NextRunAt *time.Time
+		// Runs are the latest runs, newest first.
+		Runs        []Run
+		LastFailure *Run
+	}
+)
+
+const (
+	RunStatusRunning   RunStatus = "running"
+	RunStatusSucceeded RunStatus = "succeeded"
+	RunStatusFailed    RunStatus = "failed"
+)
+
+func (s RunStatus) String() string {
+	return string(s)
+}
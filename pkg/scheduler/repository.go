@@ -0,0 +1,16 @@
+package scheduler
+
+import (
+	"github.com/ziflex/dbx"
+)
+
+type Repository interface {
+	// CreateRun records the start of a run. It fails with common.ErrDuplicate when the job already ran at the scheduled time.
+	CreateRun(ctx dbx.Context, creation RunCreation) (Run, error)
+	FinishRun(ctx dbx.Context, id int64, completion RunCompletion) (Run, error)
+	// InterruptRuns marks the runs of the job that are still running as failed, it returns how many there were.
+	// It is called with the lock of the job held, so these runs belong to instances that stopped before finishing them.
+	InterruptRuns(ctx dbx.Context, job string, reason string) (int64, error)
+	// ListRuns returns the runs matching the filter, newest first.
+	ListRuns(ctx dbx.Context, filter RunFilter) ([]Run, error)
+}
@@ -0,0 +1,437 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+)
+
+const (
+	DefaultHistory = 10
+	// MaxJobNameLength is the longest job name the run history holds.
+	MaxJobNameLength = 64
+)
+
+type (
+	Service interface {
+		// Register adds a job. Jobs are registered before the scheduler is started.
+		Register(job Job) error
+		// Jobs returns the registered jobs sorted by name.
+		Jobs() []Job
+		// Start runs the scheduled jobs in the background until ctx is done or Stop is called.
+		Start(ctx context.Context)
+		// Stop stops scheduling new runs and waits for the running ones until ctx is done, then cancels them.
+		Stop(ctx context.Context) error
+		// Run runs a job now, under the same lock and with the same history as the scheduled runs.
+		Run(ctx context.Context, name string) (Run, error)
+		Status(ctx context.Context) ([]JobStatus, error)
+	}
+
+	Options struct {
+		// Instance identifies the process in the run history, the host name is used when empty.
+		Instance string
+		// History is the number of latest runs reported per job, DefaultHistory is used when zero.
+		History int
+	}
+
+	entry struct {
+		Job
+		// schedule is nil for jobs running on demand only
+		schedule *Schedule
+	}
+
+	serviceImpl struct {
+		db         dbx.Database
+		locker     Locker
+		repository Repository
+		opts       Options
+
+		mu      sync.Mutex
+		jobs    map[string]entry
+		started bool
+		stop    context.CancelFunc
+		cancel  context.CancelFunc
+		done    chan struct{}
+		running sync.WaitGroup
+	}
+)
+
+func NewService(db dbx.Database, locker Locker, repository Repository, opts Options) Service {
+	if opts.Instance == "" {
+		opts.Instance = defaultInstance()
+	}
+
+	if opts.History <= 0 {
+		opts.History = DefaultHistory
+	}
+
+	return &serviceImpl{
+		db:         db,
+		locker:     locker,
+		repository: repository,
+		opts:       opts,
+		jobs:       make(map[string]entry),
+	}
+}
+
+func (s *serviceImpl) Register(job Job) error {
+	if job.Name == "" || len(job.Name) > MaxJobNameLength {
+		return fmt.Errorf("%w: name must be 1 to %d characters long", ErrInvalidJob, MaxJobNameLength)
+	}
+
+	if job.Func == nil {
+		return fmt.Errorf("%w: %s: missing func", ErrInvalidJob, job.Name)
+	}
+
+	e := entry{Job: job}
+
+	if job.Schedule != "" {
+		schedule, err := ParseSchedule(job.Schedule)
+
+		if err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrInvalidJob, job.Name, err)
+		}
+
+		e.schedule = &schedule
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return fmt.Errorf("%w: %s: the scheduler is already started", ErrInvalidJob, job.Name)
+	}
+
+	if _, exists := s.jobs[job.Name]; exists {
+		return fmt.Errorf("%w: %s: already registered", ErrInvalidJob, job.Name)
+	}
+
+	s.jobs[job.Name] = e
+
+	return nil
+}
+
+func (s *serviceImpl) Jobs() []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := make([]Job, 0, len(s.jobs))
+
+	for _, e := range s.jobs {
+		res = append(res, e.Job)
+	}
+
+	slices.SortFunc(res, func(a, b Job) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return res
+}
+
+func (s *serviceImpl) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return
+	}
+
+	s.started = true
+
+	// the running jobs outlive the loop, Stop gives them time to finish
+	loopCtx, stop := context.WithCancel(ctx)
+	jobsCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+
+	s.stop = stop
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	go s.loop(loopCtx, jobsCtx)
+
+	zerolog.Ctx(ctx).Info().Int("jobs", len(s.jobs)).Str("instance", s.opts.Instance).Msg("scheduler started")
+}
+
+func (s *serviceImpl) Stop(ctx context.Context) error {
+	s.mu.Lock()
+
+	if !s.started {
+		s.mu.Unlock()
+
+		return nil
+	}
+
+	stop, cancel, done := s.stop, s.cancel, s.done
+	s.mu.Unlock()
+
+	stop()
+	<-done
+
+	finished := make(chan struct{})
+
+	go func() {
+		s.running.Wait()
+		close(finished)
+	}()
+
+	defer cancel()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		// runs that cannot record their end are marked as failed by the next run of the job
+		return fmt.Errorf("running jobs did not finish: %w", ctx.Err())
+	}
+}
+
+func (s *serviceImpl) Run(ctx context.Context, name string) (Run, error) {
+	s.mu.Lock()
+	e, exists := s.jobs[name]
+	s.mu.Unlock()
+
+	if !exists {
+		return Run{}, fmt.Errorf("job %w: %s", common.ErrNotFound, name)
+	}
+
+	return s.run(ctx, e.Job, time.Now().UTC().Truncate(time.Microsecond))
+}
+
+func (s *serviceImpl) Status(ctx context.Context) ([]JobStatus, error) {
+	log := zerolog.Ctx(ctx)
+	log.Info().Msg("getting scheduler status")
+
+	s.mu.Lock()
+	entries := make([]entry, 0, len(s.jobs))
+
+	for _, e := range s.jobs {
+		entries = append(entries, e)
+	}
+
+	s.mu.Unlock()
+
+	slices.SortFunc(entries, func(a, b entry) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	db := dbx.NewContextFrom(ctx, common.ForRead(ctx, s.db))
+	now := time.Now()
+	res := make([]JobStatus, 0, len(entries))
+
+	for _, e := range entries {
+		status := JobStatus{
+			Name:        e.Name,
+			Description: e.Description,
+			Schedule:    e.Schedule,
+		}
+
+		if e.schedule != nil {
+			next := e.schedule.Next(now)
+			status.NextRunAt = &next
+		}
+
+		runs, err := s.repository.ListRuns(db, RunFilter{Job: e.Name, Limit: s.opts.History})
+
+		if err != nil {
+			log.Error().Err(err).Str("job", e.Name).Msg("failed to list runs")
+
+			return nil, err
+		}
+
+		failures, err := s.repository.ListRuns(db, RunFilter{Job: e.Name, Status: RunStatusFailed, Limit: 1})
+
+		if err != nil {
+			log.Error().Err(err).Str("job", e.Name).Msg("failed to list failed runs")
+
+			return nil, err
+		}
+
+		status.Runs = runs
+
+		if len(failures) > 0 {
+			status.LastFailure = &failures[0]
+		}
+
+		res = append(res, status)
+	}
+
+	return res, nil
+}
+
+// loop starts the runs of the scheduled jobs when they are due.
+// Runs missed while no instance was up are not caught up, the job runs at its next scheduled time.
+func (s *serviceImpl) loop(ctx, jobsCtx context.Context) {
+	defer close(s.done)
+
+	next := make(map[string]time.Time)
+	now := time.Now()
+
+	for name, e := range s.jobs {
+		if e.schedule != nil {
+			next[name] = e.schedule.Next(now)
+		}
+	}
+
+	for {
+		var due time.Time
+
+		for _, at := range next {
+			if due.IsZero() || at.Before(due) {
+				due = at
+			}
+		}
+
+		if due.IsZero() {
+			<-ctx.Done()
+
+			return
+		}
+
+		timer := time.NewTimer(time.Until(due))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return
+		case <-timer.C:
+		}
+
+		now := time.Now()
+
+		for name, at := range next {
+			if at.After(now) {
+				continue
+			}
+
+			e := s.jobs[name]
+			next[name] = e.schedule.Next(now)
+
+			s.running.Add(1)
+
+			go func() {
+				defer s.running.Done()
+
+				s.runScheduled(jobsCtx, e.Job, at)
+			}()
+		}
+	}
+}
+
+func (s *serviceImpl) runScheduled(ctx context.Context, job Job, at time.Time) {
+	_, err := s.run(ctx, job, at)
+
+	// every instance tries every run, the ones losing the race skip it
+	if errors.Is(err, ErrLocked) || errors.Is(err, ErrAlreadyRan) {
+		zerolog.Ctx(ctx).Debug().Err(err).Str("job", job.Name).Time("scheduled_at", at).Msg("job skipped")
+	}
+}
+
+// run runs the job under its lock and records the run. The scheduled time identifies the run across instances.
+func (s *serviceImpl) run(ctx context.Context, job Job, scheduledAt time.Time) (Run, error) {
+	log := zerolog.Ctx(ctx).With().Str("job", job.Name).Logger()
+
+	unlock, acquired, err := s.locker.TryLock(ctx, job.Name)
+
+	if err != nil {
+		log.Error().Err(err).Msg("failed to lock job")
+
+		return Run{}, err
+	}
+
+	if !acquired {
+		return Run{}, fmt.Errorf("%w: %s", ErrLocked, job.Name)
+	}
+
+	defer unlock()
+
+	db := dbx.NewContextFrom(ctx, s.db)
+
+	interrupted, err := s.repository.InterruptRuns(db, job.Name, "interrupted, the instance stopped before the run finished")
+
+	if err != nil {
+		log.Error().Err(err).Msg("failed to mark interrupted runs")
+
+		return Run{}, err
+	}
+
+	if interrupted > 0 {
+		log.Warn().Int64("runs", interrupted).Msg("interrupted runs marked as failed")
+	}
+
+	run, err := s.repository.CreateRun(db, RunCreation{
+		Job:         job.Name,
+		Instance:    s.opts.Instance,
+		ScheduledAt: scheduledAt,
+	})
+
+	if errors.Is(err, common.ErrDuplicate) {
+		return Run{}, fmt.Errorf("%w: %s at %s", ErrAlreadyRan, job.Name, scheduledAt.Format(time.RFC3339))
+	}
+
+	if err != nil {
+		log.Error().Err(err).Msg("failed to record run")
+
+		return Run{}, err
+	}
+
+	log = log.With().Int64("run_id", run.ID).Logger()
+	log.Info().Time("scheduled_at", scheduledAt).Msg("running job")
+
+	result, jobErr := call(log.WithContext(ctx), job)
+
+	completion := RunCompletion{Status: RunStatusSucceeded, Result: result}
+
+	if jobErr != nil {
+		completion.Status = RunStatusFailed
+		completion.Error = jobErr.Error()
+	}
+
+	// the end is recorded even when the job was cancelled
+	finished, err := s.repository.FinishRun(dbx.NewContextFrom(context.WithoutCancel(ctx), s.db), run.ID, completion)
+
+	if err != nil {
+		log.Error().Err(err).Msg("failed to record run completion")
+
+		return run, errors.Join(jobErr, err)
+	}
+
+	if jobErr != nil {
+		log.Error().Err(jobErr).Msg("job failed")
+
+		return finished, jobErr
+	}
+
+	log.Info().Str("result", result).Dur("duration", finished.FinishedAt.Sub(finished.StartedAt)).Msg("job finished")
+
+	return finished, nil
+}
+
+// call runs the job, a panic fails the run instead of the process.
+func call(ctx context.Context, job Job) (result string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	return job.Func(ctx)
+}
+
+func defaultInstance() string {
+	host, err := os.Hostname()
+
+	if err != nil {
+		host = "unknown"
+	}
+
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
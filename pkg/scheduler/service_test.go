@@ -0,0 +1,184 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/internal/memory"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+	"github.com/ziflex/rm-rf-production/pkg/scheduler"
+)
+
+type fixture struct {
+	svc    scheduler.Service
+	locker *scheduler.LocalLocker
+	repo   scheduler.Repository
+	db     dbx.Database
+}
+
+func newFixture(t *testing.T, jobs ...scheduler.Job) fixture {
+	store := memory.NewStore()
+	f := fixture{
+		locker: scheduler.NewLocalLocker(),
+		repo:   memory.NewSchedulerRepository(store),
+		db:     store.DB(),
+	}
+
+	f.svc = scheduler.NewService(f.db, f.locker, f.repo, scheduler.Options{Instance: "test"})
+
+	for _, job := range jobs {
+		require.NoError(t, f.svc.Register(job))
+	}
+
+	return f
+}
+
+func job(name, schedule string, fn func(ctx context.Context) (string, error)) scheduler.Job {
+	return scheduler.Job{Name: name, Description: name + " job", Schedule: schedule, Func: fn}
+}
+
+func TestService_Run_Success(t *testing.T) {
+	f := newFixture(t, job("purge", "0 * * * *", func(context.Context) (string, error) {
+		return "3 rows deleted", nil
+	}))
+
+	run, err := f.svc.Run(context.Background(), "purge")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "purge", run.Job)
+	assert.Equal(t, "test", run.Instance)
+	assert.Equal(t, scheduler.RunStatusSucceeded, run.Status)
+	assert.Equal(t, "3 rows deleted", run.Result)
+	assert.NotNil(t, run.FinishedAt)
+
+	status, err := f.svc.Status(context.Background())
+
+	assert.NoError(t, err)
+	require.Len(t, status, 1)
+	assert.Equal(t, "purge", status[0].Name)
+	assert.Equal(t, "0 * * * *", status[0].Schedule)
+	require.NotNil(t, status[0].NextRunAt)
+	assert.Zero(t, status[0].NextRunAt.Minute())
+	assert.Equal(t, []scheduler.Run{run}, status[0].Runs)
+	assert.Nil(t, status[0].LastFailure)
+}
+
+func TestService_Run_Error_JobFailed(t *testing.T) {
+	failure := errors.New("boom")
+	calls := 0
+
+	f := newFixture(t, job("statements", "", func(context.Context) (string, error) {
+		calls++
+
+		if calls == 2 {
+			panic("out of range")
+		}
+
+		return "", failure
+	}))
+
+	_, err := f.svc.Run(context.Background(), "statements")
+	assert.ErrorIs(t, err, failure)
+
+	// a panic fails the run
+	_, err = f.svc.Run(context.Background(), "statements")
+	assert.ErrorContains(t, err, "job panicked: out of range")
+
+	status, err := f.svc.Status(context.Background())
+
+	assert.NoError(t, err)
+	require.Len(t, status, 1)
+	assert.Nil(t, status[0].NextRunAt, "on demand only")
+	require.Len(t, status[0].Runs, 2)
+	require.NotNil(t, status[0].LastFailure)
+	assert.Equal(t, status[0].Runs[0], *status[0].LastFailure)
+	assert.Equal(t, scheduler.RunStatusFailed, status[0].Runs[1].Status)
+	assert.Equal(t, "boom", status[0].Runs[1].Error)
+}
+
+func TestService_Run_Error_Locked(t *testing.T) {
+	f := newFixture(t, job("interest", "@daily", func(context.Context) (string, error) {
+		return "", nil
+	}))
+
+	// another instance holds the lock
+	unlock, acquired, err := f.locker.TryLock(context.Background(), "interest")
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	_, err = f.svc.Run(context.Background(), "interest")
+	assert.ErrorIs(t, err, scheduler.ErrLocked)
+
+	unlock()
+
+	_, err = f.svc.Run(context.Background(), "interest")
+	assert.NoError(t, err)
+}
+
+func TestService_Run_InterruptedRuns(t *testing.T) {
+	f := newFixture(t, job("holds", "", func(context.Context) (string, error) {
+		return "", nil
+	}))
+
+	// an instance died while running the job
+	_, err := f.repo.CreateRun(dbx.NewContextFrom(context.Background(), f.db), scheduler.RunCreation{
+		Job:         "holds",
+		Instance:    "dead",
+		ScheduledAt: time.Date(2025, time.August, 30, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+
+	_, err = f.svc.Run(context.Background(), "holds")
+	assert.NoError(t, err)
+
+	status, err := f.svc.Status(context.Background())
+
+	assert.NoError(t, err)
+	require.NotNil(t, status[0].LastFailure)
+	assert.Equal(t, "dead", status[0].LastFailure.Instance)
+	assert.Contains(t, status[0].LastFailure.Error, "interrupted")
+}
+
+func TestService_Run_Error_NotFound(t *testing.T) {
+	f := newFixture(t)
+
+	_, err := f.svc.Run(context.Background(), "unknown")
+
+	assert.ErrorIs(t, err, common.ErrNotFound)
+}
+
+func TestService_Register_Error(t *testing.T) {
+	noop := func(context.Context) (string, error) { return "", nil }
+	f := newFixture(t, job("purge", "", noop))
+
+	assert.ErrorIs(t, f.svc.Register(job("", "", noop)), scheduler.ErrInvalidJob)
+	assert.ErrorIs(t, f.svc.Register(job("nil", "", nil)), scheduler.ErrInvalidJob)
+	assert.ErrorIs(t, f.svc.Register(job("purge", "", noop)), scheduler.ErrInvalidJob, "duplicate")
+	assert.ErrorIs(t, f.svc.Register(job("cron", "* *", noop)), scheduler.ErrInvalidSchedule)
+
+	f.svc.Start(context.Background())
+	defer f.svc.Stop(context.Background())
+
+	assert.ErrorIs(t, f.svc.Register(job("late", "", noop)), scheduler.ErrInvalidJob)
+	assert.Len(t, f.svc.Jobs(), 1)
+}
+
+func TestService_Stop(t *testing.T) {
+	f := newFixture(t, job("purge", "* * * * *", func(context.Context) (string, error) {
+		return "", nil
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	f.svc.Start(ctx)
+	f.svc.Start(ctx)
+
+	assert.NoError(t, f.svc.Stop(ctx))
+	assert.NoError(t, f.svc.Stop(ctx))
+}
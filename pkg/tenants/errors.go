@@ -0,0 +1,8 @@
+package tenants
+
+import "errors"
+
+var (
+	ErrInvalidID   = errors.New("invalid tenant id")
+	ErrInvalidName = errors.New("invalid tenant name")
+)
@@ -0,0 +1,16 @@
+package tenants
+
+import "time"
+
+type (
+	TenantCreation struct {
+		ID   string `json:"id" db:"id"`
+		Name string `json:"name" db:"name"`
+	}
+
+	Tenant struct {
+		ID        string    `json:"id" db:"id"`
+		Name      string    `json:"name" db:"name"`
+		CreatedAt time.Time `json:"created_at" db:"created_at"`
+	}
+)
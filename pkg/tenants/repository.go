@@ -0,0 +1,11 @@
+package tenants
+
+import (
+	"github.com/ziflex/dbx"
+)
+
+type Repository interface {
+	CreateTenant(ctx dbx.Context, tenant TenantCreation) (Tenant, error)
+	// GetTenant fails with common.ErrNotFound when the tenant does not exist.
+	GetTenant(ctx dbx.Context, id string) (Tenant, error)
+}
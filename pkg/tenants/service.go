@@ -0,0 +1,60 @@
+package tenants
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/ziflex/dbx"
+)
+
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+type (
+	Service interface {
+		CreateTenant(ctx context.Context, creation TenantCreation) (Tenant, error)
+		// GetTenant fails with common.ErrNotFound when the tenant does not exist.
+		GetTenant(ctx context.Context, id string) (Tenant, error)
+	}
+
+	serviceImpl struct {
+		db         dbx.Database
+		repository Repository
+	}
+)
+
+func NewService(db dbx.Database, repository Repository) Service {
+	return &serviceImpl{db, repository}
+}
+
+func (s *serviceImpl) CreateTenant(ctx context.Context, creation TenantCreation) (Tenant, error) {
+	log := zerolog.Ctx(ctx)
+	log.Info().Str("id", creation.ID).Msg("creating tenant")
+
+	if !idPattern.MatchString(creation.ID) {
+		return Tenant{}, ErrInvalidID
+	}
+
+	if strings.TrimSpace(creation.Name) == "" {
+		return Tenant{}, ErrInvalidName
+	}
+
+	return dbx.TransactionWithResult[Tenant](ctx, s.db, func(tx dbx.Context) (Tenant, error) {
+		tenant, err := s.repository.CreateTenant(tx, creation)
+
+		if err != nil {
+			log.Error().Err(err).Msg("failed to create tenant")
+
+			return Tenant{}, err
+		}
+
+		log.Info().Str("id", tenant.ID).Msg("tenant created")
+
+		return tenant, nil
+	})
+}
+
+func (s *serviceImpl) GetTenant(ctx context.Context, id string) (Tenant, error) {
+	return s.repository.GetTenant(dbx.NewContextFrom(ctx, s.db), id)
+}
@@ -0,0 +1,93 @@
+package tenants_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/internal/database"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+	"github.com/ziflex/rm-rf-production/pkg/tenants"
+)
+
+func TestService_CreateTenant_Success(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := tenants.NewService(db, database.NewTenantsRepository())
+
+	ts := time.Now()
+
+	mock.ExpectBegin().WillReturnError(nil)
+	mock.ExpectQuery(`INSERT INTO tenants \(id, name\) VALUES \(\$1, \$2\) RETURNING id, name, created_at`).
+		WithArgs("acme", "Acme").
+		WillReturnRows(
+			sqlmock.NewRows([]string{"id", "name", "created_at"}).
+				AddRow("acme", "Acme", ts),
+		)
+	mock.ExpectCommit()
+
+	actual, err := svc.CreateTenant(context.Background(), tenants.TenantCreation{ID: "acme", Name: "Acme"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, tenants.Tenant{ID: "acme", Name: "Acme", CreatedAt: ts}, actual)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_CreateTenant_Error_InvalidID(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := tenants.NewService(db, database.NewTenantsRepository())
+
+	_, err = svc.CreateTenant(context.Background(), tenants.TenantCreation{ID: "Acme Inc", Name: "Acme"})
+
+	assert.ErrorIs(t, err, tenants.ErrInvalidID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_CreateTenant_Error_Duplicate(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := tenants.NewService(db, database.NewTenantsRepository())
+
+	mock.ExpectBegin().WillReturnError(nil)
+	mock.ExpectQuery(`INSERT INTO tenants`).
+		WithArgs("acme", "Acme").
+		WillReturnError(
+			&pq.Error{
+				Code: "23505",
+			},
+		)
+	mock.ExpectRollback()
+
+	_, err = svc.CreateTenant(context.Background(), tenants.TenantCreation{ID: "acme", Name: "Acme"})
+
+	assert.ErrorIs(t, err, common.ErrDuplicate)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_GetTenant_Error_NotFound(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := tenants.NewService(db, database.NewTenantsRepository())
+
+	mock.ExpectQuery(`SELECT id, name, created_at FROM tenants WHERE id=\$1`).
+		WithArgs("acme").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at"}))
+
+	_, err = svc.GetTenant(context.Background(), "acme")
+
+	assert.ErrorIs(t, err, common.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -5,4 +5,7 @@ import "errors"
 var (
 	ErrInvalidOperationType = errors.New("invalid operation type")
 	ErrInvalidAmount        = errors.New("invalid amount")
+	ErrAccountBlocked       = errors.New("account is blocked")
+	ErrInvalidMerchant      = errors.New("invalid merchant")
+	ErrInvalidFilter        = errors.New("invalid filter")
 )
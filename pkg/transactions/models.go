@@ -1,6 +1,8 @@
 package transactions
 
 import (
+	"fmt"
+	"regexp"
 	"strings"
 	"time"
 )
@@ -12,6 +14,25 @@ type (
 		AccountID     int64         `json:"account_id" db:"account_id"`
 		OperationType OperationType `json:"operation_type" db:"operation_type"`
 		Amount        float64       `json:"amount" db:"amount"`
+		// ExternalRef is the reference of the transaction at the card processor, optional.
+		ExternalRef string `json:"external_ref,omitempty" db:"external_ref"`
+		// Merchant is where the card was used, optional and set on purchases and withdrawals only.
+		Merchant *Merchant `json:"merchant,omitempty" db:"-"`
+		// AllowBlocked creates the transaction on a blocked account. It is set for the transactions
+		// issued by the system, such as dispute credits and reversals, never from client input.
+		AllowBlocked bool `json:"-" db:"-"`
+	}
+
+	// Merchant is stored once per tenant and ID, the other fields are optional.
+	// Details sent with a later transaction replace the stored ones, details left empty are kept.
+	Merchant struct {
+		// ID is the merchant ID assigned by the acquirer.
+		ID      string `json:"id" db:"id"`
+		Name    string `json:"name,omitempty" db:"name"`
+		City    string `json:"city,omitempty" db:"city"`
+		Country string `json:"country,omitempty" db:"country"`
+		// MCC is the ISO 18245 merchant category code, 4 digits.
+		MCC string `json:"mcc,omitempty" db:"mcc"`
 	}
 
 	Transaction struct {
@@ -20,7 +41,32 @@ type (
 		OperationType OperationType `json:"operation_type" db:"operation_type"`
 		Amount        float64       `json:"amount" db:"amount"`
 		EventDate     time.Time     `json:"event_date" db:"event_date"`
+		ExternalRef   string        `json:"external_ref,omitempty" db:"external_ref"`
+		Merchant      *Merchant     `json:"merchant,omitempty" db:"-"`
 	}
+
+	// ExportFilter selects the transactions of an account created in [From, To).
+	ExportFilter struct {
+		AccountID int64
+		From      *time.Time
+		To        *time.Time
+		// MCC selects the transactions of merchants in the category, optional.
+		MCC string
+		// AfterID resumes an export after the last transaction it returned.
+		AfterID int64
+	}
+)
+
+// Bounds of the merchant columns.
+const (
+	MaxMerchantIDLength   = 64
+	MaxMerchantNameLength = 255
+	MaxMerchantCityLength = 100
+)
+
+var (
+	mccPattern     = regexp.MustCompile(`^[0-9]{4}$`)
+	countryPattern = regexp.MustCompile(`^[A-Z]{2}$`)
 )
 
 const (
@@ -29,6 +75,9 @@ const (
 	OperationTypeInstallmentPurchase
 	OperationTypeWithdrawal
 	OperationTypePayment
+	// OperationTypeDisputeReversal debits the provisional credit of a lost dispute.
+	// It is issued by the system only, clients cannot create it.
+	OperationTypeDisputeReversal
 )
 
 func NewOperationType(op int) OperationType {
@@ -56,6 +105,8 @@ func NewOperationTypeFromString(s string) OperationType {
 		return OperationTypeWithdrawal
 	case "payment":
 		return OperationTypePayment
+	case "dispute_reversal":
+		return OperationTypeDisputeReversal
 	default:
 		return OperationTypeUnknown
 	}
@@ -71,7 +122,45 @@ func (o OperationType) String() string {
 		return "withdrawal"
 	case OperationTypePayment:
 		return "payment"
+	case OperationTypeDisputeReversal:
+		return "dispute_reversal"
 	default:
 		return ""
 	}
 }
+
+// HasMerchant reports whether transactions of the operation type are made at a merchant.
+func (o OperationType) HasMerchant() bool {
+	return o == OperationTypePurchase || o == OperationTypeInstallmentPurchase || o == OperationTypeWithdrawal
+}
+
+// IsSettled reports whether the card processor settles transactions of the operation type,
+// only those are listed in its settlement files.
+func (o OperationType) IsSettled() bool {
+	return o == OperationTypePurchase || o == OperationTypeInstallmentPurchase || o == OperationTypeWithdrawal
+}
+
+// Validate checks the merchant fields against the bounds of the merchants table.
+func (m Merchant) Validate() error {
+	switch {
+	case strings.TrimSpace(m.ID) == "":
+		return fmt.Errorf("%w: id is required", ErrInvalidMerchant)
+	case len(m.ID) > MaxMerchantIDLength:
+		return fmt.Errorf("%w: id is longer than %d", ErrInvalidMerchant, MaxMerchantIDLength)
+	case len(m.Name) > MaxMerchantNameLength:
+		return fmt.Errorf("%w: name is longer than %d", ErrInvalidMerchant, MaxMerchantNameLength)
+	case len(m.City) > MaxMerchantCityLength:
+		return fmt.Errorf("%w: city is longer than %d", ErrInvalidMerchant, MaxMerchantCityLength)
+	case m.Country != "" && !countryPattern.MatchString(m.Country):
+		return fmt.Errorf("%w: country must be an ISO 3166-1 alpha-2 code: %s", ErrInvalidMerchant, m.Country)
+	case m.MCC != "" && !IsValidMCC(m.MCC):
+		return fmt.Errorf("%w: mcc must be 4 digits: %s", ErrInvalidMerchant, m.MCC)
+	default:
+		return nil
+	}
+}
+
+// IsValidMCC reports whether s is a merchant category code.
+func IsValidMCC(s string) bool {
+	return mccPattern.MatchString(s)
+}
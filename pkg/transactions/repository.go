@@ -6,4 +6,7 @@ import (
 
 type Repository interface {
 	CreateTransaction(ctx dbx.Context, tr TransactionCreation) (Transaction, error)
+	// ExportTransactions passes the transactions to fn in the order of their ids, as they are read.
+	// It stops at the first error returned by fn.
+	ExportTransactions(ctx dbx.Context, filter ExportFilter, fn func(Transaction) error) error
 }
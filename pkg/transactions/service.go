@@ -2,29 +2,52 @@ package transactions
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/pkg/common"
 )
 
+const DefaultExportTimeout = 10 * time.Second
+
 type (
 	Service interface {
 		CreateTransaction(ctx context.Context, creation TransactionCreation) (Transaction, error)
+		// ExportTransactions passes the transactions of an account to fn in the order of their ids.
+		// The account is not checked, the export of a missing account is empty.
+		ExportTransactions(ctx context.Context, filter ExportFilter, fn func(Transaction) error) error
+	}
+
+	Options struct {
+		// ExportTimeout bounds every query of an export, so that a slow client does not keep a database
+		// transaction open. The export continues with a new query after the last transaction passed to fn.
+		// DefaultExportTimeout is used when zero.
+		ExportTimeout time.Duration
 	}
 
 	serviceImpl struct {
 		db         dbx.Database
 		repository Repository
+		opts       Options
 	}
 )
 
 func NewService(
 	db dbx.Database,
 	repository Repository,
+	opts Options,
 ) Service {
+	if opts.ExportTimeout <= 0 {
+		opts.ExportTimeout = DefaultExportTimeout
+	}
+
 	return &serviceImpl{
 		db:         db,
 		repository: repository,
+		opts:       opts,
 	}
 }
 
@@ -44,11 +67,18 @@ func (s *serviceImpl) CreateTransaction(ctx context.Context, creation Transactio
 		return Transaction{}, err
 	}
 
+	if err := s.validateMerchant(creation.OperationType, creation.Merchant); err != nil {
+		log.Error().Err(err).Msg("invalid merchant")
+		return Transaction{}, err
+	}
+
 	return dbx.TransactionWithResult[Transaction](ctx, s.db, func(tx dbx.Context) (Transaction, error) {
 		t, err := s.repository.CreateTransaction(tx, TransactionCreation{
 			AccountID:     creation.AccountID,
 			OperationType: creation.OperationType,
 			Amount:        amt,
+			ExternalRef:   creation.ExternalRef,
+			Merchant:      creation.Merchant,
 		})
 
 		if err != nil {
@@ -63,6 +93,58 @@ func (s *serviceImpl) CreateTransaction(ctx context.Context, creation Transactio
 	})
 }
 
+func (s *serviceImpl) ExportTransactions(ctx context.Context, filter ExportFilter, fn func(Transaction) error) error {
+	log := zerolog.Ctx(ctx)
+	log.Info().Int64("account_id", filter.AccountID).Msg("exporting transactions")
+
+	if filter.MCC != "" && !IsValidMCC(filter.MCC) {
+		return fmt.Errorf("%w: mcc must be 4 digits: %s", ErrInvalidFilter, filter.MCC)
+	}
+
+	total := 0
+
+	for {
+		count, err := s.exportBatch(ctx, &filter, fn)
+		total += count
+
+		// the query ran out of time, the next one continues after the last transaction passed to fn
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil && count > 0 {
+			log.Debug().Int64("after_id", filter.AfterID).Msg("continuing export")
+
+			continue
+		}
+
+		if err != nil {
+			log.Error().Err(err).Int("count", total).Msg("failed to export transactions")
+
+			return err
+		}
+
+		log.Info().Int("count", total).Msg("transactions exported")
+
+		return nil
+	}
+}
+
+func (s *serviceImpl) exportBatch(ctx context.Context, filter *ExportFilter, fn func(Transaction) error) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.opts.ExportTimeout)
+	defer cancel()
+
+	count := 0
+	err := s.repository.ExportTransactions(dbx.NewContextFrom(ctx, common.ForRead(ctx, s.db)), *filter, func(t Transaction) error {
+		if err := fn(t); err != nil {
+			return err
+		}
+
+		filter.AfterID = t.ID
+		count++
+
+		return nil
+	})
+
+	return count, err
+}
+
 func (s *serviceImpl) handleOperation(op OperationType, amount float64) (float64, error) {
 	switch op {
 	case OperationTypePurchase, OperationTypeInstallmentPurchase, OperationTypeWithdrawal:
@@ -73,3 +155,15 @@ func (s *serviceImpl) handleOperation(op OperationType, amount float64) (float64
 		return 0, ErrInvalidOperationType
 	}
 }
+
+func (s *serviceImpl) validateMerchant(op OperationType, merchant *Merchant) error {
+	if merchant == nil {
+		return nil
+	}
+
+	if !op.HasMerchant() {
+		return fmt.Errorf("%w: %s transactions have no merchant", ErrInvalidMerchant, op)
+	}
+
+	return merchant.Validate()
+}
@@ -2,6 +2,7 @@ package transactions_test
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -14,12 +15,23 @@ import (
 	"github.com/ziflex/rm-rf-production/pkg/transactions"
 )
 
+const testTenant = "acme"
+
+var transactionColumns = []string{
+	"id", "account_id", "operation_type", "amount", "event_date", "external_ref",
+	"merchant_id", "merchant_name", "merchant_city", "merchant_country", "mcc",
+}
+
+func tenantCtx() context.Context {
+	return common.WithTenant(context.Background(), testTenant)
+}
+
 func TestService_CreateTransaction_Success(t *testing.T) {
 	mockDB, mock, err := sqlmock.New()
 	assert.NoError(t, err)
 	defer mockDB.Close()
 	db := dbx.New(mockDB)
-	svc := transactions.NewService(db, database.NewTransactions())
+	svc := transactions.NewService(db, database.NewTransactions(), transactions.Options{})
 
 	type testCase struct {
 		Name          string
@@ -44,12 +56,12 @@ func TestService_CreateTransaction_Success(t *testing.T) {
 
 			mock.ExpectBegin().WillReturnError(nil)
 			mock.ExpectQuery(
-				`INSERT INTO transactions \(account_id, operation_type, amount\) VALUES \(\$1, \$2, \$3\) RETURNING id, account_id, operation_type, amount, event_date`,
+				`WITH t AS \( INSERT INTO transactions \(tenant_id, account_id, operation_type, amount, external_ref, merchant_id\) SELECT \$1, \$2, \$3, \$4, NULLIF\(\$5, ''\), NULLIF\(\$6, ''\) WHERE NOT EXISTS \(.+blocked_at IS NOT NULL\) RETURNING .+ \) SELECT .+ FROM t LEFT JOIN merchants m ON m.tenant_id=\$1 AND m.id=t.merchant_id`,
 			).
-				WithArgs(txAccountId, tc.OperationType.String(), tc.AmountOut).
+				WithArgs(testTenant, txAccountId, tc.OperationType.String(), tc.AmountOut, "", "").
 				WillReturnRows(sqlmock.
-					NewRows([]string{"id", "account_id", "operation_type", "amount", "event_date"}).
-					AddRow(txId, txAccountId, tc.OperationType.String(), tc.AmountOut, ts),
+					NewRows(transactionColumns).
+					AddRow(txId, txAccountId, tc.OperationType.String(), tc.AmountOut, ts, "", "", "", "", "", ""),
 				)
 			mock.ExpectCommit()
 
@@ -61,7 +73,7 @@ func TestService_CreateTransaction_Success(t *testing.T) {
 				EventDate:     ts,
 			}
 
-			actual, err := svc.CreateTransaction(context.Background(), transactions.TransactionCreation{
+			actual, err := svc.CreateTransaction(tenantCtx(), transactions.TransactionCreation{
 				AccountID:     txAccountId,
 				OperationType: tc.OperationType,
 				Amount:        tc.AmountIn,
@@ -79,9 +91,9 @@ func TestService_CreateTransaction_Error_InvalidAmount(t *testing.T) {
 	assert.NoError(t, err)
 	defer mockDB.Close()
 	db := dbx.New(mockDB)
-	svc := transactions.NewService(db, database.NewTransactions())
+	svc := transactions.NewService(db, database.NewTransactions(), transactions.Options{})
 
-	_, err = svc.CreateTransaction(context.Background(), transactions.TransactionCreation{
+	_, err = svc.CreateTransaction(tenantCtx(), transactions.TransactionCreation{
 		AccountID:     100,
 		OperationType: transactions.OperationTypePurchase,
 		Amount:        0,
@@ -96,14 +108,24 @@ func TestService_CreateTransaction_Error_InvalidOperation(t *testing.T) {
 	assert.NoError(t, err)
 	defer mockDB.Close()
 	db := dbx.New(mockDB)
-	svc := transactions.NewService(db, database.NewTransactions())
+	svc := transactions.NewService(db, database.NewTransactions(), transactions.Options{})
 
-	_, err = svc.CreateTransaction(context.Background(), transactions.TransactionCreation{
+	_, err = svc.CreateTransaction(tenantCtx(), transactions.TransactionCreation{
 		AccountID:     100,
 		OperationType: transactions.OperationType(999), // Invalid operation type
 		Amount:        10000,
 	})
 
+	assert.ErrorIs(t, err, transactions.ErrInvalidOperationType)
+
+	// reversals are issued by the dispute service only
+	_, err = svc.CreateTransaction(tenantCtx(), transactions.TransactionCreation{
+		AccountID:     100,
+		OperationType: transactions.OperationTypeDisputeReversal,
+		Amount:        10,
+		AllowBlocked:  true,
+	})
+
 	assert.ErrorIs(t, err, transactions.ErrInvalidOperationType)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -113,7 +135,7 @@ func TestService_CreateTransaction_Error_InvalidAccountID(t *testing.T) {
 	assert.NoError(t, err)
 	defer mockDB.Close()
 	db := dbx.New(mockDB)
-	svc := transactions.NewService(db, database.NewTransactions())
+	svc := transactions.NewService(db, database.NewTransactions(), transactions.Options{})
 
 	var accId int64 = 0 // Invalid account ID
 	var opType transactions.OperationType = transactions.OperationTypePurchase
@@ -121,7 +143,7 @@ func TestService_CreateTransaction_Error_InvalidAccountID(t *testing.T) {
 
 	mock.ExpectBegin().WillReturnError(nil)
 	mock.ExpectQuery(`.*`).
-		WithArgs(accId, opType.String(), -amt).
+		WithArgs(testTenant, accId, opType.String(), -amt, "", "").
 		WillReturnError(
 			&pq.Error{
 				Code: "23503",
@@ -129,7 +151,7 @@ func TestService_CreateTransaction_Error_InvalidAccountID(t *testing.T) {
 		)
 	mock.ExpectRollback()
 
-	_, err = svc.CreateTransaction(context.Background(), transactions.TransactionCreation{
+	_, err = svc.CreateTransaction(tenantCtx(), transactions.TransactionCreation{
 		AccountID:     accId,
 		OperationType: opType,
 		Amount:        amt,
@@ -143,7 +165,7 @@ func TestService_CreateTransaction_Error_Propagate(t *testing.T) {
 	assert.NoError(t, err)
 	defer mockDB.Close()
 	db := dbx.New(mockDB)
-	svc := transactions.NewService(db, database.NewTransactions())
+	svc := transactions.NewService(db, database.NewTransactions(), transactions.Options{})
 
 	var accId int64 = 0 // Invalid account ID
 	var opType transactions.OperationType = transactions.OperationTypePurchase
@@ -151,7 +173,7 @@ func TestService_CreateTransaction_Error_Propagate(t *testing.T) {
 
 	mock.ExpectBegin().WillReturnError(nil)
 	mock.ExpectQuery(`.*`).
-		WithArgs(accId, opType.String(), -amt).
+		WithArgs(testTenant, accId, opType.String(), -amt, "", "").
 		WillReturnError(
 			&pq.Error{
 				Code: "22004",
@@ -159,7 +181,7 @@ func TestService_CreateTransaction_Error_Propagate(t *testing.T) {
 		)
 	mock.ExpectRollback()
 
-	_, err = svc.CreateTransaction(context.Background(), transactions.TransactionCreation{
+	_, err = svc.CreateTransaction(tenantCtx(), transactions.TransactionCreation{
 		AccountID:     accId,
 		OperationType: opType,
 		Amount:        amt,
@@ -167,3 +189,275 @@ func TestService_CreateTransaction_Error_Propagate(t *testing.T) {
 
 	assert.Error(t, err)
 }
+
+func TestService_CreateTransaction_Error_DuplicateExternalRef(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := transactions.NewService(db, database.NewTransactions(), transactions.Options{})
+
+	mock.ExpectBegin().WillReturnError(nil)
+	mock.ExpectQuery(`.*`).
+		WithArgs(testTenant, int64(1), transactions.OperationTypePayment.String(), 10.0, "PRC-1", "").
+		WillReturnError(
+			&pq.Error{
+				Code: "23505",
+			},
+		)
+	mock.ExpectRollback()
+
+	_, err = svc.CreateTransaction(tenantCtx(), transactions.TransactionCreation{
+		AccountID:     1,
+		OperationType: transactions.OperationTypePayment,
+		Amount:        10,
+		ExternalRef:   "PRC-1",
+	})
+
+	assert.ErrorIs(t, err, common.ErrDuplicate)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_CreateTransaction_Error_MissingTenant(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := transactions.NewService(db, database.NewTransactions(), transactions.Options{})
+
+	mock.ExpectBegin().WillReturnError(nil)
+	mock.ExpectRollback()
+
+	_, err = svc.CreateTransaction(context.Background(), transactions.TransactionCreation{
+		AccountID:     1,
+		OperationType: transactions.OperationTypePayment,
+		Amount:        10,
+	})
+
+	assert.ErrorIs(t, err, common.ErrMissingTenant)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_CreateTransaction_Error_AccountBlocked(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := transactions.NewService(db, database.NewTransactions(), transactions.Options{})
+
+	var accId int64 = 5
+
+	// nothing is inserted for a blocked account
+	mock.ExpectBegin().WillReturnError(nil)
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WithArgs(testTenant, accId, transactions.OperationTypePurchase.String(), -10.0, "", "").
+		WillReturnRows(sqlmock.NewRows(transactionColumns))
+	mock.ExpectRollback()
+
+	_, err = svc.CreateTransaction(tenantCtx(), transactions.TransactionCreation{
+		AccountID:     accId,
+		OperationType: transactions.OperationTypePurchase,
+		Amount:        10,
+	})
+
+	assert.ErrorIs(t, err, transactions.ErrAccountBlocked)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_CreateTransaction_Merchant(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := transactions.NewService(db, database.NewTransactions(), transactions.Options{})
+
+	ts := time.Now()
+	merchant := transactions.Merchant{ID: "M-1", Name: "Corner Cafe", MCC: "5814"}
+
+	// the merchant is known, its details are updated if they changed
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO merchants \(tenant_id, id, name, city, country, mcc\) .+ ON CONFLICT \(tenant_id, id\) DO NOTHING`).
+		WithArgs(testTenant, "M-1", "Corner Cafe", "", "", "5814").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE merchants SET .+ WHERE tenant_id=\$1 AND id=\$2 AND`).
+		WithArgs(testTenant, "M-1", "Corner Cafe", "", "", "5814").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO transactions`).
+		WithArgs(testTenant, int64(5), transactions.OperationTypePurchase.String(), -4.5, "", "M-1").
+		WillReturnRows(sqlmock.NewRows(transactionColumns).
+			AddRow(1, 5, "purchase", -4.5, ts, "", "M-1", "Corner Cafe", "Lisbon", "PT", "5814"))
+	mock.ExpectCommit()
+
+	tr, err := svc.CreateTransaction(tenantCtx(), transactions.TransactionCreation{
+		AccountID:     5,
+		OperationType: transactions.OperationTypePurchase,
+		Amount:        4.5,
+		Merchant:      &merchant,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, &transactions.Merchant{ID: "M-1", Name: "Corner Cafe", City: "Lisbon", Country: "PT", MCC: "5814"}, tr.Merchant)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_CreateTransaction_Error_InvalidMerchant(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := transactions.NewService(db, database.NewTransactions(), transactions.Options{})
+
+	tsdata := []struct {
+		Name          string
+		OperationType transactions.OperationType
+		Merchant      transactions.Merchant
+	}{
+		{"Payment", transactions.OperationTypePayment, transactions.Merchant{ID: "M-1"}},
+		{"MissingID", transactions.OperationTypePurchase, transactions.Merchant{Name: "Corner Cafe"}},
+		{"MCC", transactions.OperationTypePurchase, transactions.Merchant{ID: "M-1", MCC: "58a4"}},
+		{"Country", transactions.OperationTypeWithdrawal, transactions.Merchant{ID: "M-1", Country: "prt"}},
+		{"Name", transactions.OperationTypeInstallmentPurchase, transactions.Merchant{ID: "M-1", Name: strings.Repeat("a", 256)}},
+	}
+
+	for _, tc := range tsdata {
+		t.Run(tc.Name, func(t *testing.T) {
+			_, err := svc.CreateTransaction(tenantCtx(), transactions.TransactionCreation{
+				AccountID:     5,
+				OperationType: tc.OperationType,
+				Amount:        10,
+				Merchant:      &tc.Merchant,
+			})
+
+			assert.ErrorIs(t, err, transactions.ErrInvalidMerchant)
+		})
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_ExportTransactions_MCC(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := transactions.NewService(db, database.NewTransactions(), transactions.Options{})
+
+	mock.ExpectQuery(`FROM transactions t LEFT JOIN merchants m .+ WHERE t.tenant_id=\$1 AND t.account_id=\$2 AND m.mcc=\$3 ORDER BY t.id`).
+		WithArgs(testTenant, int64(5), "5814").
+		WillReturnRows(sqlmock.NewRows(transactionColumns))
+
+	noop := func(transactions.Transaction) error { return nil }
+
+	assert.NoError(t, svc.ExportTransactions(tenantCtx(), transactions.ExportFilter{AccountID: 5, MCC: "5814"}, noop))
+	assert.ErrorIs(t, svc.ExportTransactions(tenantCtx(), transactions.ExportFilter{AccountID: 5, MCC: "58"}, noop), transactions.ErrInvalidFilter)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_ExportTransactions_Success(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	db := dbx.New(mockDB)
+	svc := transactions.NewService(db, database.NewTransactions(), transactions.Options{})
+
+	from := time.Date(2025, time.August, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, time.September, 1, 0, 0, 0, 0, time.UTC)
+	ts := time.Date(2025, time.August, 30, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(
+		`SELECT t.id, .+ FROM transactions t LEFT JOIN merchants m ON m.tenant_id=t.tenant_id AND m.id=t.merchant_id WHERE t.tenant_id=\$1 AND t.account_id=\$2 AND t.event_date>=\$3 AND t.event_date<\$4 ORDER BY t.id`,
+	).
+		WithArgs(testTenant, int64(5), from, to).
+		WillReturnRows(sqlmock.
+			NewRows(transactionColumns).
+			AddRow(1, 5, "purchase", -10.0, ts, "PRC-1", "M-1", "Corner Cafe", "Lisbon", "PT", "5814").
+			AddRow(2, 5, "payment", 20.0, ts, "", "", "", "", "", ""),
+		)
+
+	var exported []transactions.Transaction
+
+	err = svc.ExportTransactions(tenantCtx(), transactions.ExportFilter{AccountID: 5, From: &from, To: &to}, func(tr transactions.Transaction) error {
+		exported = append(exported, tr)
+
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []transactions.Transaction{
+		{ID: 1, AccountID: 5, OperationType: transactions.OperationTypePurchase, Amount: -10, EventDate: ts, ExternalRef: "PRC-1",
+			Merchant: &transactions.Merchant{ID: "M-1", Name: "Corner Cafe", City: "Lisbon", Country: "PT", MCC: "5814"}},
+		{ID: 2, AccountID: 5, OperationType: transactions.OperationTypePayment, Amount: 20, EventDate: ts},
+	}, exported)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// slowRepository passes a batch of transactions per export and then stalls until the context is done.
+type slowRepository struct {
+	transactions.Repository
+	ids     []int64
+	batch   int
+	resumed []int64
+}
+
+func (r *slowRepository) ExportTransactions(ctx dbx.Context, filter transactions.ExportFilter, fn func(transactions.Transaction) error) error {
+	r.resumed = append(r.resumed, filter.AfterID)
+	passed := 0
+
+	for _, id := range r.ids {
+		if id <= filter.AfterID {
+			continue
+		}
+
+		if passed == r.batch {
+			<-ctx.Done()
+
+			return ctx.Err()
+		}
+
+		if err := fn(transactions.Transaction{ID: id, AccountID: filter.AccountID}); err != nil {
+			return err
+		}
+
+		passed++
+	}
+
+	return nil
+}
+
+func TestService_ExportTransactions_ResumesAfterTimeout(t *testing.T) {
+	mockDB, _, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+
+	repo := &slowRepository{ids: []int64{1, 2, 3, 4, 5}, batch: 2}
+	svc := transactions.NewService(dbx.New(mockDB), repo, transactions.Options{ExportTimeout: 10 * time.Millisecond})
+
+	var exported []int64
+
+	err = svc.ExportTransactions(tenantCtx(), transactions.ExportFilter{AccountID: 5}, func(tr transactions.Transaction) error {
+		exported = append(exported, tr.ID)
+
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3, 4, 5}, exported)
+	assert.Equal(t, []int64{0, 2, 4}, repo.resumed)
+}
+
+func TestService_ExportTransactions_Error_Timeout(t *testing.T) {
+	mockDB, _, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+
+	// a query that passes nothing before its deadline is not retried
+	repo := &slowRepository{ids: []int64{1}, batch: 0}
+	svc := transactions.NewService(dbx.New(mockDB), repo, transactions.Options{ExportTimeout: 10 * time.Millisecond})
+
+	err = svc.ExportTransactions(tenantCtx(), transactions.ExportFilter{AccountID: 5}, func(tr transactions.Transaction) error {
+		return nil
+	})
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, []int64{0}, repo.resumed)
+}
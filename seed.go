@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/ziflex/rm-rf-production/pkg/accounts"
+	"github.com/ziflex/rm-rf-production/pkg/common"
+	"github.com/ziflex/rm-rf-production/pkg/transactions"
+)
+
+// maxDocumentAttempts bounds retries of randomly generated document numbers that already exist.
+const maxDocumentAttempts = 10
+
+type (
+	seeder struct {
+		out          io.Writer
+		accounts     accounts.Service
+		transactions transactions.Service
+		rnd          *rand.Rand
+	}
+
+	// seedOperation describes how often an operation type occurs and its typical amount range.
+	seedOperation struct {
+		operationType transactions.OperationType
+		weight        int
+		min           float64
+		max           float64
+	}
+)
+
+var seedOperations = []seedOperation{
+	{transactions.OperationTypePurchase, 50, 3, 300},
+	{transactions.OperationTypeInstallmentPurchase, 15, 100, 2500},
+	{transactions.OperationTypeWithdrawal, 10, 20, 500},
+	{transactions.OperationTypePayment, 25, 50, 1500},
+}
+
+func (s *seeder) run(ctx context.Context, args []string) error {
+	fset := flag.NewFlagSet("seed", flag.ContinueOnError)
+	tenant := fset.String("tenant", "default", "tenant the data is created for")
+	numAccounts := fset.Int("accounts", 10, "number of accounts to create")
+	perAccount := fset.Int("transactions", 20, "number of transactions per account")
+	seed := fset.Uint64("seed", uint64(time.Now().UnixNano()), "random seed, the same seed produces the same data")
+
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+
+	if *numAccounts < 0 || *perAccount < 0 {
+		return fmt.Errorf("number of accounts and transactions must not be negative")
+	}
+
+	s.rnd = rand.New(rand.NewPCG(*seed, *seed))
+	ctx = common.WithTenant(ctx, *tenant)
+
+	var created, txCount int
+
+	for i := 0; i < *numAccounts; i++ {
+		acc, err := s.createAccount(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		created++
+
+		for j := 0; j < *perAccount; j++ {
+			if _, err := s.transactions.CreateTransaction(ctx, s.newTransaction(acc.ID)); err != nil {
+				return err
+			}
+
+			txCount++
+		}
+	}
+
+	fmt.Fprintf(s.out, "tenant %s: created %d accounts and %d transactions (seed %d)\n", *tenant, created, txCount, *seed)
+
+	return nil
+}
+
+func (s *seeder) createAccount(ctx context.Context) (accounts.Account, error) {
+	for attempt := 0; ; attempt++ {
+		acc, err := s.accounts.CreateAccount(ctx, accounts.AccountCreation{
+			DocumentNumber: s.documentNumber(),
+		})
+
+		if errors.Is(err, common.ErrDuplicate) && attempt < maxDocumentAttempts {
+			continue
+		}
+
+		return acc, err
+	}
+}
+
+// documentNumber generates an 11 digit number, the length of a CPF.
+func (s *seeder) documentNumber() string {
+	return fmt.Sprintf("%011d", s.rnd.Int64N(100_000_000_000))
+}
+
+func (s *seeder) newTransaction(accountID int64) transactions.TransactionCreation {
+	op := s.operation()
+	amount := op.min + s.rnd.Float64()*(op.max-op.min)
+
+	if op.operationType == transactions.OperationTypeWithdrawal {
+		// cash is withdrawn in multiples of 10
+		amount = math.Round(amount/10) * 10
+	}
+
+	return transactions.TransactionCreation{
+		AccountID:     accountID,
+		OperationType: op.operationType,
+		Amount:        math.Round(amount*100) / 100,
+	}
+}
+
+func (s *seeder) operation() seedOperation {
+	total := 0
+
+	for _, op := range seedOperations {
+		total += op.weight
+	}
+
+	n := s.rnd.IntN(total)
+
+	for _, op := range seedOperations {
+		if n < op.weight {
+			return op
+		}
+
+		n -= op.weight
+	}
+
+	return seedOperations[0]
+}
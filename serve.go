@@ -0,0 +1,413 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/ziflex/dbx"
+	"github.com/ziflex/rm-rf-production/internal/api"
+	"github.com/ziflex/rm-rf-production/internal/apiv2"
+	"github.com/ziflex/rm-rf-production/internal/database"
+	"github.com/ziflex/rm-rf-production/internal/health"
+	"github.com/ziflex/rm-rf-production/internal/metrics"
+	"github.com/ziflex/rm-rf-production/internal/rpc"
+	"github.com/ziflex/rm-rf-production/internal/server"
+	"github.com/ziflex/rm-rf-production/internal/tracing"
+	"github.com/ziflex/rm-rf-production/pkg/accounts"
+	"github.com/ziflex/rm-rf-production/pkg/auth"
+	"github.com/ziflex/rm-rf-production/pkg/disputes"
+	"github.com/ziflex/rm-rf-production/pkg/ratelimit"
+	"github.com/ziflex/rm-rf-production/pkg/transactions"
+	"github.com/ziflex/rm-rf-production/spec"
+)
+
+// serve runs the HTTP and gRPC servers until a termination signal is received and then shuts them down gracefully.
+func serve(a *app, args []string) error {
+	cfg := a.cfg
+	logger := a.logger
+
+	fset := flag.NewFlagSet("serve", flag.ContinueOnError)
+	migrateOnStart := fset.Bool("migrate-on-start", cfg.MigrateOnStart, "apply pending migrations before serving requests")
+
+	if err := fset.Parse(args); err != nil {
+		a.Close()
+
+		return err
+	}
+
+	// the key set is refreshed in the background, both servers share it
+	tokens, err := newTokenVerifier(cfg)
+
+	if err != nil {
+		a.Close()
+
+		return err
+	}
+
+	// both servers share the limiter, a client has the same budget of an operation over both protocols
+	rateLimit, err := newRateLimitPolicy(cfg, a.db)
+
+	if err != nil {
+		a.Close()
+
+		return fmt.Errorf("failed to configure rate limiting: %w", err)
+	}
+
+	// both servers call the services through the same traces and business metrics
+	svcs := instrument(a)
+
+	svr, tracer, err := newServer(a, svcs, tokens, rateLimit, *migrateOnStart)
+
+	if err != nil {
+		a.Close()
+
+		return err
+	}
+
+	servers := []drainer{svr}
+	// both servers report to the same channel, the first failure stops the service
+	serverErr := make(chan error, 2)
+
+	if cfg.GrpcPort > 0 {
+		rpcSvr, err := newRPCServer(a, svcs, tokens, rateLimit)
+
+		if err != nil {
+			tracer.Shutdown(context.Background())
+			a.Close()
+
+			return err
+		}
+
+		servers = append(servers, rpcSvr)
+
+		go func() {
+			logger.Info().Int("port", cfg.GrpcPort).Msg("grpc server started")
+			serverErr <- rpcSvr.Run(cfg.GrpcPort)
+		}()
+	}
+
+	// workers stop in order, the running jobs get the remaining shutdown timeout before tracing and the database go away
+	var workers []worker
+
+	if cfg.SchedulerEnabled {
+		a.scheduler.Start(logger.WithContext(context.Background()))
+		workers = append(workers, worker{name: "scheduler", stop: a.scheduler.Stop})
+	}
+
+	// with no workers the instance only queues jobs, the other instances run them
+	if cfg.QueueWorkers > 0 {
+		a.queue.Start(logger.WithContext(context.Background()))
+		workers = append(workers, worker{name: "queue", stop: a.queue.Stop})
+	}
+
+	workers = append(workers, worker{name: "tracing", stop: tracer.Shutdown})
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	go func() {
+		serverErr <- svr.Run(cfg.Port)
+	}()
+
+	select {
+	case err = <-serverErr:
+		logger.Error().Err(err).Msg("server failed")
+	case <-ctx.Done():
+		logger.Info().Msg("received termination signal")
+	}
+
+	// a second signal terminates the process immediately
+	stop()
+
+	sd := &shutdown{
+		logger:  logger,
+		servers: servers,
+		db:      a,
+		delay:   cfg.ShutdownDelay,
+		timeout: cfg.ShutdownTimeout,
+		workers: workers,
+	}
+
+	if e := sd.run(); e != nil && err == nil {
+		err = e
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+// instrumented are the services of the app wrapped with traces and business metrics.
+type instrumented struct {
+	metrics      *metrics.Metrics
+	accounts     accounts.Service
+	transactions transactions.Service
+	disputes     disputes.Service
+}
+
+func instrument(a *app) instrumented {
+	m := metrics.New()
+
+	return instrumented{
+		metrics:      m,
+		accounts:     metrics.NewAccountsService(tracing.NewAccountsService(a.accounts), m),
+		transactions: metrics.NewTransactionsService(tracing.NewTransactionsService(a.transactions), m),
+		disputes:     metrics.NewDisputesService(a.disputes, m),
+	}
+}
+
+func newServer(a *app, svcs instrumented, tokens auth.TokenVerifier, rateLimit *ratelimit.Policy, migrateOnStart bool) (*server.Server, *tracing.Provider, error) {
+	cfg := a.cfg
+	db := a.db
+
+	uiSub, err := fs.Sub(spec.UI, "ui")
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load embedded ui: %w", err)
+	}
+
+	memoryDriver := cfg.DbDriver == driverMemory
+
+	if migrateOnStart && memoryDriver {
+		return nil, nil, fmt.Errorf("migrations are not supported by the %s database driver", driverMemory)
+	}
+
+	if migrateOnStart {
+		if err := migrateOnStartup(db, a.logger); err != nil {
+			return nil, nil, fmt.Errorf("failed to migrate database: %w", err)
+		}
+	}
+
+	proxies, err := parseNetworks(cfg.TrustedProxies)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to configure trusted proxies: %w", err)
+	}
+
+	checks := []health.Check{
+		{Name: "database", Func: database.Ping(db)},
+	}
+
+	// the in-memory database has no schema
+	if !memoryDriver {
+		schemaVersion, err := database.SchemaVersion()
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read embedded migrations: %w", err)
+		}
+
+		checks = append(checks, health.Check{Name: "schema", Func: database.CheckSchema(db, schemaVersion)})
+	}
+
+	m := svcs.metrics
+
+	if err := m.RegisterDB(db, cfg.DbName); err != nil {
+		return nil, nil, fmt.Errorf("failed to register db metrics: %w", err)
+	}
+
+	if a.router != nil {
+		for name, replica := range a.router.Replicas() {
+			if err := m.RegisterDB(replica, cfg.DbName+"-"+name); err != nil {
+				return nil, nil, fmt.Errorf("failed to register replica metrics: %w", err)
+			}
+		}
+	}
+
+	tracer, err := tracing.New(context.Background(), tracing.Options{
+		ServiceName: "rm-rf-production",
+		Exporter:    cfg.TraceExporter,
+		Endpoint:    cfg.TraceEndpoint,
+		Insecure:    cfg.TraceInsecure,
+		File:        cfg.TraceFile,
+		SampleRatio: cfg.TraceSampleRatio,
+	})
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to configure tracing: %w", err)
+	}
+
+	var deprecation *server.Deprecation
+
+	if !cfg.ApiV1DeprecatedAt.IsZero() || !cfg.ApiV1SunsetAt.IsZero() {
+		deprecation = &server.Deprecation{
+			At:     cfg.ApiV1DeprecatedAt,
+			Sunset: cfg.ApiV1SunsetAt,
+			Link:   cfg.ApiV1DeprecationLink,
+		}
+	}
+
+	handler := api.NewHandler(svcs.accounts, svcs.transactions, a.audit, a.reconciliations, a.scheduler, a.queue, svcs.disputes)
+
+	svr, err := server.NewServer(handler, server.Options{
+		Logger:         a.logger,
+		Spec:           spec.File,
+		UI:             uiSub,
+		Auth:           a.keys,
+		Tokens:         tokens,
+		RateLimit:      rateLimit,
+		TrustedProxies: proxies,
+		OperatorTenant: cfg.OperatorTenant,
+		Audit:          a.audit,
+		Metrics:        m,
+		Readiness:      health.NewChecker(cfg.ReadyzTimeout, checks...),
+		V2: &server.V2Options{
+			Handler: apiv2.NewHandler(handler),
+			Spec:    spec.FileV2,
+		},
+		Deprecation: deprecation,
+	})
+
+	if err != nil {
+		tracer.Shutdown(context.Background())
+
+		return nil, nil, fmt.Errorf("failed to create server: %w", err)
+	}
+
+	return svr, tracer, nil
+}
+
+// newRPCServer creates the gRPC server. It shares the instrumented services, the credentials, the rate limits
+// and the audit log with the HTTP server.
+func newRPCServer(a *app, svcs instrumented, tokens auth.TokenVerifier, rateLimit *ratelimit.Policy) (*rpc.Server, error) {
+	svr, err := rpc.NewServer(svcs.accounts, svcs.transactions, rpc.Options{
+		Logger:    a.logger,
+		Auth:      a.keys,
+		Tokens:    tokens,
+		RateLimit: rateLimit,
+		Audit:     a.audit,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create grpc server: %w", err)
+	}
+
+	return svr, nil
+}
+
+// migrateOnStartup applies pending migrations. Replicas starting at the same time wait for each other on the advisory lock.
+func migrateOnStartup(db *sql.DB, logger zerolog.Logger) error {
+	m, err := database.NewMigrator(context.Background(), db, logger)
+
+	if err != nil {
+		return err
+	}
+
+	defer m.Close()
+
+	return m.Up()
+}
+
+// newTokenVerifier returns nil when bearer tokens are not configured.
+func newTokenVerifier(cfg Config) (auth.TokenVerifier, error) {
+	if cfg.JwksURL == "" && cfg.JwksFile == "" {
+		return nil, nil
+	}
+
+	jwks, err := auth.NewJWKS(context.Background(), auth.JWKSOptions{
+		URL:             cfg.JwksURL,
+		File:            cfg.JwksFile,
+		RefreshInterval: cfg.JwksRefresh,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load jwks: %w", err)
+	}
+
+	tokens, err := auth.NewJWTVerifier(auth.JWTOptions{
+		Keys:     jwks,
+		Issuer:   cfg.JwtIssuer,
+		Audience: cfg.JwtAudience,
+		Leeway:   30 * time.Second,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create jwt verifier: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// parseNetworks parses CIDRs, a single IP is a network of one address.
+func parseNetworks(values []string) ([]*net.IPNet, error) {
+	res := make([]*net.IPNet, 0, len(values))
+
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+
+			if ip == nil {
+				return nil, fmt.Errorf("invalid ip: %s", value)
+			}
+
+			res = append(res, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, network)
+	}
+
+	return res, nil
+}
+
+func newRateLimitPolicy(cfg Config, db dbx.Database) (*ratelimit.Policy, error) {
+	var limiter ratelimit.Limiter
+
+	switch cfg.RateLimitBackend {
+	case "disabled":
+		return nil, nil
+	case "memory":
+		limiter = ratelimit.NewMemoryLimiter(ratelimit.MemoryOptions{})
+	case "postgres":
+		if cfg.DbDriver == driverMemory {
+			return nil, fmt.Errorf("the postgres backend is not supported by the %s database driver", driverMemory)
+		}
+
+		limiter = ratelimit.NewSharedLimiter(db, database.NewRateLimitsRepository(), ratelimit.SharedOptions{})
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend: %s", cfg.RateLimitBackend)
+	}
+
+	def, err := ratelimit.ParseRule(cfg.RateLimitDefault)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ops := make(map[string]ratelimit.Rule, len(cfg.RateLimitOperations))
+
+	for opID, value := range cfg.RateLimitOperations {
+		rule, err := ratelimit.ParseRule(value)
+
+		if err != nil {
+			return nil, fmt.Errorf("operation %s: %w", opID, err)
+		}
+
+		ops[opID] = rule
+	}
+
+	return &ratelimit.Policy{
+		Limiter:    limiter,
+		Default:    def,
+		Operations: ops,
+	}, nil
+}
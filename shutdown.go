@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// drainer is a server that can stop taking new traffic before it shuts down.
+type drainer interface {
+	Drain()
+	Shutdown(ctx context.Context) error
+}
+
+// worker is a background component that is stopped after the server has drained.
+type worker struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+// shutdown stops the service in the order that does not drop in-flight requests:
+// readiness flips to failing on every server, in-flight requests drain, background workers stop and finally the database is closed.
+type shutdown struct {
+	logger  zerolog.Logger
+	servers []drainer
+	workers []worker
+	db      io.Closer
+	// delay gives load balancers time to observe the failing readiness before new connections are refused.
+	delay time.Duration
+	// timeout bounds draining and stopping the workers.
+	timeout time.Duration
+}
+
+func (s *shutdown) run() error {
+	s.logger.Info().Dur("delay", s.delay).Dur("timeout", s.timeout).Msg("shutting down")
+
+	for _, svr := range s.servers {
+		svr.Drain()
+	}
+
+	if s.delay > 0 {
+		time.Sleep(s.delay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	var errs []error
+
+	for _, svr := range s.servers {
+		if err := svr.Shutdown(ctx); err != nil {
+			s.logger.Error().Err(err).Msg("failed to drain in-flight requests")
+			errs = append(errs, err)
+		}
+	}
+
+	for _, w := range s.workers {
+		if err := w.stop(ctx); err != nil {
+			s.logger.Error().Err(err).Str("worker", w.name).Msg("failed to stop worker")
+			errs = append(errs, err)
+		}
+	}
+
+	if err := s.db.Close(); err != nil {
+		s.logger.Error().Err(err).Msg("failed to close database")
+		errs = append(errs, err)
+	}
+
+	s.logger.Info().Msg("shutdown complete")
+
+	return errors.Join(errs...)
+}
@@ -1,11 +1,143 @@
 package spec
 
 import (
+	"bytes"
 	"embed"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
 //go:embed openapi.yaml
 var File []byte
 
+//go:embed v2/openapi.yaml
+var fileV2 []byte
+
+// FileV2 is the spec of the second version. v2/openapi.yaml only lists what changed since the first version,
+// the rest is taken from openapi.yaml.
+var FileV2 = must(Extend(File, fileV2, "../openapi.yaml"))
+
 //go:embed ui/*
 var UI embed.FS
+
+// Extend returns the base spec with the info, the servers, the path items and the components of the extension
+// replaced or added. The references of the extension to the base, prefixed with baseRef, become local.
+func Extend(base, extension []byte, baseRef string) ([]byte, error) {
+	var doc, ext yaml.Node
+
+	if err := yaml.Unmarshal(base, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse the base spec: %w", err)
+	}
+
+	if err := yaml.Unmarshal(extension, &ext); err != nil {
+		return nil, fmt.Errorf("failed to parse the extension: %w", err)
+	}
+
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("the base spec is not a document")
+	}
+
+	if len(ext.Content) == 0 || ext.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("the extension is not a document")
+	}
+
+	localize(ext.Content[0], baseRef)
+
+	root := doc.Content[0]
+	fields := ext.Content[0].Content
+
+	for i := 0; i < len(fields); i += 2 {
+		key, value := fields[i].Value, fields[i+1]
+
+		switch key {
+		case "paths":
+			merge(field(root, key), value, 0)
+		case "components":
+			// components are grouped by kind, each component is replaced on its own
+			merge(field(root, key), value, 1)
+		default:
+			set(root, key, value)
+		}
+	}
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+// merge sets the fields of src in dst, merging the mappings found depth levels below instead of replacing them.
+func merge(dst, src *yaml.Node, depth int) {
+	for i := 0; i < len(src.Content); i += 2 {
+		key, value := src.Content[i].Value, src.Content[i+1]
+
+		if depth > 0 && value.Kind == yaml.MappingNode {
+			merge(field(dst, key), value, depth-1)
+
+			continue
+		}
+
+		set(dst, key, value)
+	}
+}
+
+// field returns the mapping under the key, it is created when missing.
+func field(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+
+	value := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+
+	return value
+}
+
+// set replaces the value under the key, it is appended when missing.
+func set(m *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i+1] = value
+
+			return
+		}
+	}
+
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+// localize rewrites the references prefixed with baseRef to local ones.
+func localize(n *yaml.Node, baseRef string) {
+	if n.Kind == yaml.MappingNode {
+		for i := 0; i < len(n.Content); i += 2 {
+			if n.Content[i].Value == "$ref" && strings.HasPrefix(n.Content[i+1].Value, baseRef+"#") {
+				n.Content[i+1].Value = strings.TrimPrefix(n.Content[i+1].Value, baseRef)
+			}
+		}
+	}
+
+	for _, child := range n.Content {
+		localize(child, baseRef)
+	}
+}
+
+func must(data []byte, err error) []byte {
+	if err != nil {
+		panic(fmt.Sprintf("invalid embedded spec: %v", err))
+	}
+
+	return data
+}
@@ -0,0 +1,72 @@
+package spec_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ziflex/rm-rf-production/spec"
+)
+
+func TestFileV2(t *testing.T) {
+	v1, err := openapi3.NewLoader().LoadFromData(spec.File)
+	require.NoError(t, err)
+
+	v2, err := openapi3.NewLoader().LoadFromData(spec.FileV2)
+	require.NoError(t, err)
+	assert.NoError(t, v2.Validate(context.Background()))
+
+	assert.Equal(t, "2.0.0", v2.Info.Version)
+	assert.Equal(t, "/v2", v2.Servers[0].URL)
+	assert.NotContains(t, string(spec.FileV2), "../openapi.yaml")
+
+	// every operation of the first version is served by the second one
+	assert.ElementsMatch(t, v1.Paths.InMatchingOrder(), v2.Paths.InMatchingOrder())
+
+	body := v2.Paths.Find("/transactions").Post.RequestBody.Value.Content.Get("application/json").Schema.Value
+	assert.Contains(t, body.Properties, "operation_type")
+	assert.NotContains(t, body.Properties, "operation_type_id")
+
+	created := v2.Paths.Find("/transactions").Post.Responses.Status(201).Value.Content.Get("application/json").Schema.Value
+	assert.Contains(t, created.Properties, "operation_type")
+
+	// the schemas left out of v2/openapi.yaml are the ones of the first version
+	assert.Equal(t, v1.Components.Schemas["Account"].Value, v2.Components.Schemas["Account"].Value)
+}
+
+func TestExtend(t *testing.T) {
+	base := `openapi: 3.0.3
+info: { title: base, version: 1.0.0 }
+paths:
+  /a: { get: { responses: { "200": { description: a } } } }
+  /b: { get: { responses: { "200": { description: b } } } }
+components:
+  schemas:
+    A: { type: string }
+    B: { type: integer }
+`
+	ext := `info: { title: ext, version: 2.0.0 }
+paths:
+  /b: { get: { responses: { "200": { description: b2, content: { application/json: { schema: { $ref: "base.yaml#/components/schemas/A" } } } } } } }
+components:
+  schemas:
+    B: { type: number }
+    C: { type: boolean }
+`
+	out, err := spec.Extend([]byte(base), []byte(ext), "base.yaml")
+	require.NoError(t, err)
+
+	doc, err := openapi3.NewLoader().LoadFromData(out)
+	require.NoError(t, err)
+
+	assert.Equal(t, "ext", doc.Info.Title)
+	assert.Equal(t, "a", *doc.Paths.Find("/a").Get.Responses.Status(200).Value.Description)
+	assert.Equal(t, "b2", *doc.Paths.Find("/b").Get.Responses.Status(200).Value.Description)
+	assert.Equal(t, "#/components/schemas/A", doc.Paths.Find("/b").Get.Responses.Status(200).Value.Content.Get("application/json").Schema.Ref)
+	assert.True(t, doc.Components.Schemas["A"].Value.Type.Is("string"))
+	assert.True(t, doc.Components.Schemas["B"].Value.Type.Is("number"))
+	assert.True(t, doc.Components.Schemas["C"].Value.Type.Is("boolean"))
+	assert.NotContains(t, string(out), "base.yaml")
+}